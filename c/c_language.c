@@ -25,11 +25,13 @@
 #include <sys/stat.h>
 #include <tree_sitter/api.h>
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
 #include "../shared/file_opener.h"
 #include "../shared/string_utils.h"
 #include "../shared/parse_result.h"
 #include "../shared/file_utils.h"
 #include "../shared/debug.h"
+#include "../shared/parse_errors.h"
 
 /* External C language function from tree-sitter-c */
 extern const TSLanguage *tree_sitter_c(void);
@@ -250,6 +252,22 @@ static void process_children(TSNode node, const char *source_code, const char *d
     ts_tree_cursor_delete(&cursor);
 }
 
+/* Helper: ExtColumns.exported value for a function or variable declaration
+ * C has external linkage for file-scope names that are not static; names
+ * declared inside a function body are never exported */
+static const char *get_exported_linkage(TSNode node, const char *modifier) {
+    if (strstr(modifier, "static")) {
+        return "0";
+    }
+    for (TSNode parent = ts_node_parent(node); !ts_node_is_null(parent); parent = ts_node_parent(parent)) {
+        TSSymbol parent_sym = ts_node_symbol(parent);
+        if (parent_sym == c_symbols.compound_statement || parent_sym == c_symbols.function_definition) {
+            return "0";
+        }
+    }
+    return "1";
+}
+
 /* Helper: Extract identifier from potentially nested declarator */
 static TSNode find_identifier_in_declarator(TSNode declarator_node) {
     TSSymbol node_sym = ts_node_symbol(declarator_node);
@@ -426,6 +444,7 @@ static void handle_function_definition(TSNode node, const char *source_code, con
         }
     }
 
+    char symbol[SYMBOL_MAX_LENGTH] = "";
     if (!ts_node_is_null(function_declarator_node)) {
         TSNode child = function_declarator_node;
         /* Function declarator has identifier and parameter_list */
@@ -446,7 +465,6 @@ static void handle_function_definition(TSNode node, const char *source_code, con
 
         /* Extract function name and return type */
         if (!ts_node_is_null(func_name_node)) {
-            char symbol[SYMBOL_MAX_LENGTH];
             char type_str[SYMBOL_MAX_LENGTH];
             char modifier_str[SYMBOL_MAX_LENGTH];
             char location[128];
@@ -466,18 +484,22 @@ static void handle_function_definition(TSNode node, const char *source_code, con
                         directory, filename, location, &(ExtColumns){
                             .type = type_str,
                             .modifier = modifier_str,
-                            .definition = "1"
+                            .definition = "1",
+                            .exported = get_exported_linkage(node, modifier_str)
                         });
             }
         }
 
         /* Extract parameters */
+        push_scope(result, symbol);
         if (!ts_node_is_null(param_list_node)) {
             extract_parameters(param_list_node, source_code, directory, filename, result, filter, 1);
         }
+        pop_scope(result);
     }
 
     /* Process function body (compound_statement) to index local variables, strings, calls, etc. */
+    push_scope(result, symbol);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
 
@@ -486,6 +508,7 @@ static void handle_function_definition(TSNode node, const char *source_code, con
             break;
         }
     }
+    pop_scope(result);
 }
 
 /* Extract type and modifier information from a declaration node
@@ -639,7 +662,8 @@ static void handle_declaration(TSNode node, const char *source_code, const char
                                 directory, filename, location, &(ExtColumns){
                                     .type = type_str,
                                     .modifier = modifier_str,
-                                    .definition = "1"
+                                    .definition = "1",
+                                    .exported = get_exported_linkage(node, modifier_str)
                                 });
                     }
                     break;
@@ -684,7 +708,8 @@ static void handle_declaration(TSNode node, const char *source_code, const char
                             directory, filename, location, &(ExtColumns){
                                 .type = type_str,
                                 .modifier = modifier_str,
-                                .definition = "1"
+                                .definition = "1",
+                                .exported = get_exported_linkage(node, modifier_str)
                             });
                 }
             }
@@ -716,7 +741,8 @@ static void handle_declaration(TSNode node, const char *source_code, const char
                                 directory, filename, location, &(ExtColumns){
                                     .type = type_str,
                                     .modifier = modifier_str,
-                                    .definition = "1"
+                                    .definition = "1",
+                                    .exported = get_exported_linkage(node, modifier_str)
                                 });
                     }
                 } else if (array_child_sym == c_symbols.identifier) {
@@ -745,6 +771,7 @@ static void handle_struct_specifier(TSNode node, const char *source_code, const
                                      const char *filename, ParseResult *result, SymbolFilter *filter,
                                      int line) {
     /* Find struct name (type_identifier) and fields */
+    char symbol[SYMBOL_MAX_LENGTH] = "";
     uint32_t child_count = ts_node_child_count(node);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
@@ -752,7 +779,6 @@ static void handle_struct_specifier(TSNode node, const char *source_code, const
 
         if (child_sym == c_symbols.type_identifier) {
             /* Extract struct name */
-            char symbol[SYMBOL_MAX_LENGTH];
             char location[128];
             safe_extract_node_text(source_code, child, symbol, sizeof(symbol), filename);
 
@@ -764,7 +790,8 @@ static void handle_struct_specifier(TSNode node, const char *source_code, const
                   directory, filename, location, &(ExtColumns){.definition = "1"});
             }
         } else if (child_sym == c_symbols.field_declaration_list) {
-            /* Extract field names */
+            /* Extract field names; anonymous structs add no scope */
+            push_scope(result, symbol);
             uint32_t field_list_count = ts_node_child_count(child);
             for (uint32_t j = 0; j < field_list_count; j++) {
                 TSNode field = ts_node_child(child, j);
@@ -877,6 +904,7 @@ static void handle_struct_specifier(TSNode node, const char *source_code, const
                     }
                 }
             }
+            pop_scope(result);
         }
     }
 }
@@ -2056,37 +2084,12 @@ int parser_init(CParser *parser, SymbolFilter *filter) {
 }
 
 int parser_parse_file(CParser *parser, const char *filepath, const char *project_root, ParseResult *result) {
-    FILE *fp = safe_fopen(filepath, "rb", 0);  /* binary mode for accurate byte count */
-    if (!fp) {
-        fprintf(stderr, "Cannot open file: %s\n", filepath);
-        return -1;
-    }
-
-    /* Get file size using fstat */
-    int fd = fileno(fp);
-    struct stat st;
-    if (fstat(fd, &st) != 0) {
-        fclose(fp);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) {
-        fclose(fp);
-        return -1;
-    }
-
-    size_t bytes_read = fread(source_code, 1, file_size, fp);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "Error reading file: %s (expected %zu bytes, got %zu)\n",
-                filepath, file_size, bytes_read);
-        free(source_code);
-        fclose(fp);
-        return -1;
-    }
-    source_code[bytes_read] = '\0';
-    fclose(fp);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     result->count = 0;
 
@@ -2108,8 +2111,8 @@ int parser_parse_file(CParser *parser, const char *filepath, const char *project
     TSParser *ts_parser = ts_parser_new();
     const TSLanguage *language = tree_sitter_c();
     if (!ts_parser_set_language(ts_parser, language)) {
-        fprintf(stderr, "ERROR: Failed to set C language\n");
-        free(source_code);
+        parse_error_set("failed to load the tree-sitter grammar");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
@@ -2117,23 +2120,29 @@ int parser_parse_file(CParser *parser, const char *filepath, const char *project
     /* Initialize symbol lookup table */
     init_c_symbols(language);
 
-    TSTree *tree = ts_parser_parse_string(ts_parser, NULL, source_code, (uint32_t)file_size);
+    TSTree *tree = ts_parser_parse(ts_parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "ERROR: Failed to parse file: %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, ts_parser);
+
     TSNode root_node = ts_tree_root_node(tree);
 
     /* Visit all nodes */
     visit_node(root_node, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_SLASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
 
     /* Cleanup */
     ts_tree_delete(tree);
     ts_parser_delete(ts_parser);
-    free(source_code);
+    source_file_close(&source);
 
     return 0;
 }
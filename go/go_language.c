@@ -25,10 +25,14 @@
 #include <sys/stat.h>
 #include <tree_sitter/api.h>
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
 #include "../shared/file_opener.h"
 #include "../shared/string_utils.h"
 #include "../shared/parse_result.h"
 #include "../shared/file_utils.h"
+#include "../shared/struct_tags.h"
+#include "../shared/signature.h"
+#include "../shared/parse_errors.h"
 
 /* External Go language function from tree-sitter-go */
 extern const TSLanguage *tree_sitter_go(void);
@@ -39,6 +43,9 @@ static int g_debug = 0;
 /* Forward declarations */
 static void visit_node(TSNode node, const char *source_code, const char *directory,
                       const char *filename, ParseResult *result, SymbolFilter *filter);
+static void handle_type_alias(TSNode node, const char *source_code, const char *directory,
+                              const char *filename, ParseResult *result, SymbolFilter *filter,
+                              int line);
 
 /* Node handler function pointer type */
 typedef void (*NodeHandler)(TSNode node, const char *source_code,
@@ -119,6 +126,8 @@ static struct {
     TSSymbol union_type;
     TSSymbol negated_type;
     TSSymbol parenthesized_type;
+    TSSymbol type_constraint;  /* Type parameter constraint (any, ~int | ~string) */
+    TSSymbol type_elem;
 
     /* Expression nodes */
     TSSymbol expression_list;
@@ -142,10 +151,12 @@ static struct {
     TSSymbol func_literal;
     TSSymbol method_declaration;
     TSSymbol method_spec;
+    TSSymbol method_elem;         /* method_spec in newer grammars */
     TSSymbol field_declaration;
     TSSymbol var_declaration;
     TSSymbol const_declaration;
     TSSymbol parameter_declaration;
+    TSSymbol variadic_parameter_declaration;
     TSSymbol short_var_declaration;
     TSSymbol range_clause;
     TSSymbol defer_statement;
@@ -164,6 +175,8 @@ static struct {
 
     /* Function parameter nodes */
     TSSymbol parameter_list;
+    TSSymbol type_parameter_list;
+    TSSymbol type_parameter_declaration;
 
     /* Structural nodes */
     TSSymbol block;
@@ -198,6 +211,8 @@ static void init_go_symbols(const TSLanguage *language) {
     go_symbols.union_type = ts_language_symbol_for_name(language, "union_type", 10, true);
     go_symbols.negated_type = ts_language_symbol_for_name(language, "negated_type", 12, true);
     go_symbols.parenthesized_type = ts_language_symbol_for_name(language, "parenthesized_type", 18, true);
+    go_symbols.type_constraint = ts_language_symbol_for_name(language, "type_constraint", 15, true);
+    go_symbols.type_elem = ts_language_symbol_for_name(language, "type_elem", 9, true);
 
     /* Expression nodes */
     go_symbols.expression_list = ts_language_symbol_for_name(language, "expression_list", 15, true);
@@ -221,10 +236,12 @@ static void init_go_symbols(const TSLanguage *language) {
     go_symbols.func_literal = ts_language_symbol_for_name(language, "func_literal", 12, true);
     go_symbols.method_declaration = ts_language_symbol_for_name(language, "method_declaration", 18, true);
     go_symbols.method_spec = ts_language_symbol_for_name(language, "method_spec", 11, true);
+    go_symbols.method_elem = ts_language_symbol_for_name(language, "method_elem", 11, true);
     go_symbols.field_declaration = ts_language_symbol_for_name(language, "field_declaration", 17, true);
     go_symbols.var_declaration = ts_language_symbol_for_name(language, "var_declaration", 15, true);
     go_symbols.const_declaration = ts_language_symbol_for_name(language, "const_declaration", 17, true);
     go_symbols.parameter_declaration = ts_language_symbol_for_name(language, "parameter_declaration", 21, true);
+    go_symbols.variadic_parameter_declaration = ts_language_symbol_for_name(language, "variadic_parameter_declaration", 30, true);
     go_symbols.short_var_declaration = ts_language_symbol_for_name(language, "short_var_declaration", 21, true);
     go_symbols.range_clause = ts_language_symbol_for_name(language, "range_clause", 12, true);
     go_symbols.defer_statement = ts_language_symbol_for_name(language, "defer_statement", 15, true);
@@ -243,6 +260,8 @@ static void init_go_symbols(const TSLanguage *language) {
 
     /* Function parameter nodes */
     go_symbols.parameter_list = ts_language_symbol_for_name(language, "parameter_list", 14, true);
+    go_symbols.type_parameter_list = ts_language_symbol_for_name(language, "type_parameter_list", 19, true);
+    go_symbols.type_parameter_declaration = ts_language_symbol_for_name(language, "type_parameter_declaration", 26, true);
 
     /* Structural nodes */
     go_symbols.block = ts_language_symbol_for_name(language, "block", 5, true);
@@ -283,7 +302,9 @@ static TypeExtractionStrategy classify_type_node(TSNode node) {
         node_sym == go_symbols.generic_type ||
         node_sym == go_symbols.union_type ||
         node_sym == go_symbols.negated_type ||
-        node_sym == go_symbols.parenthesized_type) {
+        node_sym == go_symbols.parenthesized_type ||
+        node_sym == go_symbols.type_constraint ||
+        node_sym == go_symbols.type_elem) {
         return TYPE_EXTRACT_COMPLEX;
     }
 
@@ -325,6 +346,28 @@ static bool is_go_type_node(TSNode node) {
     return strategy != TYPE_NOT_A_TYPE && strategy != TYPE_EXTRACT_SKIP;
 }
 
+/* Helper: Check if an inline struct/interface type has no fields or methods */
+static bool is_empty_inline_type(TSNode node) {
+    TSSymbol sym = ts_node_symbol(node);
+    if (sym == go_symbols.interface_type) {
+        return ts_node_named_child_count(node) == 0;
+    }
+    if (sym == go_symbols.struct_type) {
+        TSNode fields = ts_node_named_child(node, 0);
+        return ts_node_is_null(fields) || ts_node_named_child_count(fields) == 0;
+    }
+    return false;
+}
+
+/* Helper: Extract the text of a type node with canonical spacing, so
+ * "map[string]interface {}" and "map[string]interface{}" are stored alike */
+static void extract_normalized_type(TSNode type_node, const char *source_code, char *type_buffer,
+                                    size_t type_size, const char *filename) {
+    char raw[SYMBOL_MAX_LENGTH];
+    safe_extract_node_text(source_code, type_node, raw, sizeof(raw), filename);
+    normalize_type_expression(raw, type_buffer, type_size);
+}
+
 /* Helper: Extract type from type node using classification strategy */
 static void extract_type_from_node(TSNode type_node, const char *source_code, char *type_buffer, size_t type_size, const char *filename) {
     if (ts_node_is_null(type_node)) {
@@ -348,7 +391,7 @@ static void extract_type_from_node(TSNode type_node, const char *source_code, ch
 
         case TYPE_EXTRACT_QUALIFIED:
             /* qualified_type (pkg.Type) - extract full text */
-            safe_extract_node_text(source_code, type_node, type_buffer, type_size, filename);
+            extract_normalized_type(type_node, source_code, type_buffer, type_size, filename);
             return;
 
         case TYPE_EXTRACT_POINTER: {
@@ -397,6 +440,10 @@ static void extract_type_from_node(TSNode type_node, const char *source_code, ch
                     TSNode child = ts_node_child(type_node, i);
                     TSSymbol child_sym = ts_node_symbol(child);
 
+                    if (is_empty_inline_type(child)) {
+                        continue;  /* struct{} and interface{} are kept as written */
+                    }
+
                     if (child_sym == go_symbols.struct_type) {
                         if (node_sym == go_symbols.slice_type) {
                             snprintf(type_buffer, type_size, "[]struct");
@@ -431,7 +478,7 @@ static void extract_type_from_node(TSNode type_node, const char *source_code, ch
                 return;
             }
 
-            safe_extract_node_text(source_code, type_node, type_buffer, type_size, filename);
+            extract_normalized_type(type_node, source_code, type_buffer, type_size, filename);
             return;
         }
 
@@ -518,6 +565,10 @@ static void extract_type_from_node(TSNode type_node, const char *source_code, ch
             /* Unknown node type - this is a bug that should be fixed */
             {
                 TSPoint start_point = ts_node_start_point(type_node);
+                if (parse_abort_is_guarded()) {
+                    parse_abort("unhandled type node '%s' at line %u (not classified in classify_type_node())",
+                                ts_node_type(type_node), start_point.row + 1);
+                }
                 fprintf(stderr, "\n========== UNHANDLED NODE TYPE IN extract_type_from_node() ==========\n");
                 fprintf(stderr, "File: %s\n", filename ? filename : "<unknown>");
                 fprintf(stderr, "Location: line %u, column %u\n", start_point.row + 1, start_point.column + 1);
@@ -560,6 +611,25 @@ static const char *get_scope_from_name(const char *name) {
     return NULL;  /* For non-alphabetic names like operators */
 }
 
+/* Helper: ExtColumns.exported value for a declared name
+ * Go exports identifiers that start with an upper-case letter */
+static const char *get_exported_from_name(const char *name) {
+    return (name[0] >= 'A' && name[0] <= 'Z') ? "1" : "0";
+}
+
+/* Helper: Check if a declaration is local to a function body
+ * (such declarations are never exported, whatever their name) */
+static int is_inside_function(TSNode node) {
+    for (TSNode parent = ts_node_parent(node); !ts_node_is_null(parent); parent = ts_node_parent(parent)) {
+        const char *type = ts_node_type(parent);
+        if (strcmp(type, "function_declaration") == 0 || strcmp(type, "method_declaration") == 0 ||
+            strcmp(type, "func_literal") == 0) {
+            return 1;
+        }
+    }
+    return 0;
+}
+
 /* Helper: Extract package name by walking up the AST tree
  * In Go, package_clause is a top-level child of source_file
  */
@@ -754,6 +824,7 @@ static void handle_import_spec(TSNode node, const char *source_code, const char
     if (!path[0]) {
         return;
     }
+    add_import(result, path, actual_line);
 
     /* Determine symbol and metadata */
     char symbol[SYMBOL_MAX_LENGTH];
@@ -824,6 +895,69 @@ static void handle_import_declaration(TSNode node, const char *source_code, cons
 }
 
 /* Handler: function_declaration */
+/* Build a signature list from a parameter_list or type_parameter_list, or
+ * from a bare result type (func f() error). Each name of a shared-type
+ * declaration (a, b int, or [K, V comparable]) gets its own element;
+ * elements that don't fit end the list. */
+static void extract_signature_list(TSNode list_node, const char *source_code, char *list,
+                                   size_t list_size, const char *filename) {
+    list[0] = '\0';
+    if (ts_node_is_null(list_node)) return;
+
+    char type[SYMBOL_MAX_LENGTH];
+    if (ts_node_symbol(list_node) != go_symbols.parameter_list &&
+        ts_node_symbol(list_node) != go_symbols.type_parameter_list) {
+        extract_type_from_node(list_node, source_code, type, sizeof(type), filename);
+        if (type[0]) signature_append(list, list_size, NULL, type, 0);
+        return;
+    }
+
+    uint32_t child_count = ts_node_named_child_count(list_node);
+    for (uint32_t i = 0; i < child_count; i++) {
+        TSNode decl = ts_node_named_child(list_node, i);
+        TSSymbol decl_sym = ts_node_symbol(decl);
+        if (decl_sym != go_symbols.parameter_declaration &&
+            decl_sym != go_symbols.variadic_parameter_declaration &&
+            decl_sym != go_symbols.type_parameter_declaration) {
+            continue;  /* Comments */
+        }
+        int variadic = (decl_sym == go_symbols.variadic_parameter_declaration);
+
+        TSNode type_node = ts_node_child_by_field_name(decl, "type", 4);
+        extract_type_from_node(type_node, source_code, type, sizeof(type), filename);
+        if (!type[0]) continue;
+
+        int named = 0;
+        uint32_t decl_children = ts_node_child_count(decl);
+        for (uint32_t j = 0; j < decl_children; j++) {
+            TSNode name_node = ts_node_child(decl, j);
+            if (ts_node_symbol(name_node) != go_symbols.identifier ||
+                ts_node_eq(name_node, type_node)) {
+                continue;
+            }
+            char name[SYMBOL_MAX_LENGTH];
+            safe_extract_node_text(source_code, name_node, name, sizeof(name), filename);
+            if (signature_append(list, list_size, name, type, variadic) != 0) return;
+            named = 1;
+        }
+        if (!named && signature_append(list, list_size, NULL, type, variadic) != 0) return;
+    }
+}
+
+/* Signature lists of a function_type (func(int) error) */
+static void extract_function_type_signature(TSNode func_type, const char *source_code,
+                                            char *params, char *returns, size_t list_size,
+                                            const char *filename) {
+    params[0] = '\0';
+    returns[0] = '\0';
+    if (ts_node_is_null(func_type) || ts_node_symbol(func_type) != go_symbols.function_type) return;
+
+    extract_signature_list(ts_node_child_by_field_name(func_type, "parameters", 10),
+                           source_code, params, list_size, filename);
+    extract_signature_list(ts_node_child_by_field_name(func_type, "result", 6),
+                           source_code, returns, list_size, filename);
+}
+
 static void handle_function_declaration(TSNode node, const char *source_code, const char *directory,
                                         const char *filename, ParseResult *result, SymbolFilter *filter,
                                         int line) {
@@ -854,48 +988,24 @@ static void handle_function_declaration(TSNode node, const char *source_code, co
         }
     }
 
+    char func_name[SYMBOL_MAX_LENGTH] = "";
     if (!ts_node_is_null(name_node)) {
-        char func_name[SYMBOL_MAX_LENGTH];
         char return_type[SYMBOL_MAX_LENGTH] = "";
         char package_buf[SYMBOL_MAX_LENGTH];
+        char params[SIGNATURE_MAX_LENGTH];
+        char returns[SIGNATURE_MAX_LENGTH];
+        char type_params[SIGNATURE_MAX_LENGTH];
 
         safe_extract_node_text(source_code, name_node, func_name, sizeof(func_name), filename);
         get_package(node, source_code, package_buf, sizeof(package_buf), filename);
+        extract_signature_list(params_node, source_code, params, sizeof(params), filename);
+        extract_signature_list(return_node, source_code, returns, sizeof(returns), filename);
+        /* Generic functions: func Map[T, U any](...) */
+        extract_signature_list(ts_node_child_by_field_name(node, "type_parameters", 15),
+                               source_code, type_params, sizeof(type_params), filename);
 
-        /* Extract return type */
-        if (!ts_node_is_null(return_node)) {
-            TSSymbol return_sym = ts_node_symbol(return_node);
-            if (return_sym == go_symbols.parameter_list) {
-                /* Multiple return types */
-                char temp_types[SYMBOL_MAX_LENGTH * 2] = "(";
-                bool first = true;
-                uint32_t return_children = ts_node_child_count(return_node);
-                for (uint32_t i = 0; i < return_children; i++) {
-                    TSNode return_child = ts_node_child(return_node, i);
-                    TSSymbol return_child_sym = ts_node_symbol(return_child);
-                    if (return_child_sym == go_symbols.parameter_declaration) {
-                        char single_type[SYMBOL_MAX_LENGTH];
-                        /* Extract type from parameter_declaration */
-                        uint32_t decl_children = ts_node_child_count(return_child);
-                        for (uint32_t j = 0; j < decl_children; j++) {
-                            TSNode type_child = ts_node_child(return_child, j);
-                            extract_type_from_node(type_child, source_code, single_type, sizeof(single_type), filename);
-                            if (single_type[0]) {
-                                if (!first) strncat(temp_types, ", ", sizeof(temp_types) - strlen(temp_types) - 1);
-                                strncat(temp_types, single_type, sizeof(temp_types) - strlen(temp_types) - 1);
-                                first = false;
-                                break;
-                            }
-                        }
-                    }
-                }
-                strncat(temp_types, ")", sizeof(temp_types) - strlen(temp_types) - 1);
-                snprintf(return_type, sizeof(return_type), "%s", temp_types);
-            } else {
-                /* Single return type */
-                extract_type_from_node(return_node, source_code, return_type, sizeof(return_type), filename);
-            }
-        }
+        /* Legacy type column: the result types without their names */
+        signature_result_type(returns, return_type, sizeof(return_type));
 
         if (func_name[0] && filter_should_index(filter, func_name)) {
             /* Extract source location for full function definition */
@@ -905,20 +1015,25 @@ static void handle_function_declaration(TSNode node, const char *source_code, co
             ExtColumns ext = {
                 .parent = NULL,
                 .scope = get_scope_from_name(func_name),
+                .exported = get_exported_from_name(func_name),
                 .modifier = NULL,
                 .clue = NULL,
                 .namespace = package_buf[0] ? package_buf : NULL,
                 .type = return_type[0] ? return_type : NULL,
+                .params = params[0] ? params : NULL,
+                .returns = returns[0] ? returns : NULL,
+                .typeparams = type_params[0] ? type_params : NULL,
                 .definition = "1"
             };
             add_entry(result, func_name, line, CONTEXT_FUNCTION,
                      directory, filename, location, &ext);
         }
+    }
+    push_scope(result, func_name);
 
-        /* Process parameters */
-        if (!ts_node_is_null(params_node)) {
-            process_children(params_node, source_code, directory, filename, result, filter);
-        }
+    /* Process parameters */
+    if (!ts_node_is_null(name_node) && !ts_node_is_null(params_node)) {
+        process_children(params_node, source_code, directory, filename, result, filter);
     }
 
     /* Process body */
@@ -926,6 +1041,7 @@ static void handle_function_declaration(TSNode node, const char *source_code, co
     if (!ts_node_is_null(body_node)) {
         process_children(body_node, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
 }
 
 /* Handler: call_expression */
@@ -1102,10 +1218,107 @@ static void handle_type_declaration(TSNode node, const char *source_code, const
     process_children(node, source_code, directory, filename, result, filter);
 }
 
+/* Split an embedded type into the package it comes from and the type name:
+ * "*sync.RWMutex" gives sync, RWMutex and sets *is_pointer; "Base" and
+ * "List[T]" give own_package (the declaring package), Base and List. The
+ * package is the qualifier as written, i.e. the imported package's name. */
+static void split_embedded_type(const char *embedded_type, const char *own_package,
+                                char *package, size_t package_size,
+                                char *type_name, size_t name_size, int *is_pointer) {
+    const char *start = embedded_type;
+    *is_pointer = 0;
+    if (*start == '*') {
+        *is_pointer = 1;
+        start++;
+    }
+    /* The qualifier ends at the last dot before any type arguments */
+    size_t base_len = strcspn(start, "[");
+    const char *dot = NULL;
+    for (const char *p = start; p < start + base_len; p++) {
+        if (*p == '.') dot = p;
+    }
+    if (dot) {
+        snprintf(package, package_size, "%.*s", (int)(dot - start), start);
+        snprintf(type_name, name_size, "%.*s", (int)(start + base_len - dot - 1), dot + 1);
+    } else {
+        snprintf(package, package_size, "%s", own_package);
+        snprintf(type_name, name_size, "%.*s", (int)base_len, start);
+    }
+}
+
+/* Index interfaces embedded in an interface body (e.g. io.Reader in ReadCloser)
+ * as embedded fields whose parent is the embedding interface, mirroring
+ * embedded struct fields. The indexer's --flatten-embeds pass uses these rows
+ * to copy the embedded interface's methods into the embedding interface.
+ *
+ * Embeds appear as type_elem (newer grammars) or as bare type nodes (older
+ * grammars). Constraint elements (unions, ~T) are not embeds and are skipped. */
+static void index_interface_embeds(TSNode interface_node, const char *interface_name,
+                                   const char *package, const char *source_code,
+                                   const char *directory, const char *filename,
+                                   ParseResult *result, SymbolFilter *filter) {
+    uint32_t child_count = ts_node_named_child_count(interface_node);
+    for (uint32_t i = 0; i < child_count; i++) {
+        TSNode child = ts_node_named_child(interface_node, i);
+        TSNode type_node = child;
+
+        if (strcmp(ts_node_type(child), "type_elem") == 0) {
+            if (ts_node_named_child_count(child) != 1) continue;  /* Union: A | B */
+            type_node = ts_node_named_child(child, 0);
+        }
+
+        TSSymbol type_sym = ts_node_symbol(type_node);
+        if (type_sym != go_symbols.type_identifier &&
+            type_sym != go_symbols.qualified_type &&
+            type_sym != go_symbols.generic_type) {
+            continue;
+        }
+
+        char embedded_type[SYMBOL_MAX_LENGTH];
+        extract_type_from_node(type_node, source_code, embedded_type, sizeof(embedded_type), filename);
+
+        /* Field name is the type name without package qualifier or type arguments */
+        char embedded_name[SYMBOL_MAX_LENGTH];
+        char embedded_package[SYMBOL_MAX_LENGTH];
+        int is_pointer;
+        split_embedded_type(embedded_type, package, embedded_package, sizeof(embedded_package),
+                            embedded_name, sizeof(embedded_name), &is_pointer);
+
+        if (embedded_name[0] && filter_should_index(filter, embedded_name)) {
+            char location[128];
+            format_source_location(type_node, location, sizeof(location));
+
+            ExtColumns ext = {
+                .parent = interface_name,
+                .scope = get_scope_from_name(embedded_name),
+                .exported = get_exported_from_name(embedded_name),
+                .modifier = NULL,
+                .clue = "embedded",
+                .namespace = package[0] ? package : NULL,
+                .type = embedded_type,
+                .typepkg = embedded_package[0] ? embedded_package : NULL,
+                .typename = embedded_name
+            };
+            add_entry(result, embedded_name, (int)ts_node_start_point(type_node).row + 1,
+                     CONTEXT_PROPERTY, directory, filename, location, &ext);
+        }
+    }
+}
+
 /* Handler: type_spec */
 static void handle_type_spec(TSNode node, const char *source_code, const char *directory,
                               const char *filename, ParseResult *result, SymbolFilter *filter,
                               int line) {
+    /* Grammars without a separate type_alias node parse "type A = B" as a
+     * type_spec; the "=" token is what makes it an alias */
+    uint32_t spec_children = ts_node_child_count(node);
+    for (uint32_t i = 0; i < spec_children; i++) {
+        if (strcmp(ts_node_type(ts_node_child(node, i)), "=") == 0) {
+            handle_type_alias(node, source_code, directory, filename, result, filter, line);
+            return;
+        }
+    }
+
     /* Get type name (first type_identifier in type_spec) */
     TSNode name_node = ts_node_child(node, 0);
     if (!ts_node_is_null(name_node) && strcmp(ts_node_type(name_node), "type_identifier") == 0) {
@@ -1115,31 +1328,84 @@ static void handle_type_spec(TSNode node, const char *source_code, const char *d
         safe_extract_node_text(source_code, name_node, type_name, sizeof(type_name), filename);
         get_package(node, source_code, package_buf, sizeof(package_buf), filename);
 
+        /* The definition follows the type parameter list of a generic type
+         * (type List[T any] struct{...}) */
+        TSNode type_def = ts_node_child_by_field_name(node, "type", 4);
+        char type_params[SIGNATURE_MAX_LENGTH];
+        extract_signature_list(ts_node_child_by_field_name(node, "type_parameters", 15),
+                               source_code, type_params, sizeof(type_params), filename);
+
+        /* Clue distinguishes struct and interface definitions from other defined types */
+        const char *clue = NULL;
+        if (!ts_node_is_null(type_def)) {
+            if (strcmp(ts_node_type(type_def), "struct_type") == 0) {
+                clue = "struct";
+            } else if (strcmp(ts_node_type(type_def), "interface_type") == 0) {
+                clue = "interface";
+            }
+        }
+
+        /* Function types (type Handler func(int) error) carry a signature */
+        char params[SIGNATURE_MAX_LENGTH];
+        char returns[SIGNATURE_MAX_LENGTH];
+        extract_function_type_signature(type_def, source_code, params, returns, sizeof(params), filename);
+
         if (type_name[0] && filter_should_index(filter, type_name)) {
+            char location[128];
+            format_source_location(node, location, sizeof(location));
+
             ExtColumns ext = {
                 .parent = NULL,
                 .scope = get_scope_from_name(type_name),
+                .exported = is_inside_function(node) ? "0" : get_exported_from_name(type_name),
                 .modifier = NULL,
-                .clue = NULL,
+                .clue = clue,
                 .namespace = package_buf[0] ? package_buf : NULL,
                 .type = NULL,
+                .params = params[0] ? params : NULL,
+                .returns = returns[0] ? returns : NULL,
+                .typeparams = type_params[0] ? type_params : NULL,
                 .definition = "1"
             };
             add_entry(result, type_name, line, CONTEXT_TYPE,
-                     directory, filename, NULL, &ext);
+                     directory, filename, location, &ext);
         }
 
         /* Process the type definition (struct_type, interface_type, etc.) */
-        TSNode type_def = ts_node_child(node, 1);
         if (!ts_node_is_null(type_def)) {
+            push_scope(result, type_name);
             const char *type_def_type = ts_node_type(type_def);
             if (strcmp(type_def_type, "struct_type") == 0) {
                 /* Process struct fields */
+                int first_new = result->count;
                 process_children(type_def, source_code, directory, filename, result, filter);
+
+                /* Fields (including embedded ones) belong to this struct, so its
+                 * fields and methods share a parent */
+                for (int i = first_new; i < result->count; i++) {
+                    IndexEntry *entry = &result->entries[i];
+                    if (entry->context == CONTEXT_PROPERTY && entry->parent_symbol[0] == '\0') {
+                        snprintf(entry->parent_symbol, sizeof(entry->parent_symbol), "%s", type_name);
+                    }
+                }
             } else if (strcmp(type_def_type, "interface_type") == 0) {
                 /* Process interface methods */
+                int first_new = result->count;
                 process_children(type_def, source_code, directory, filename, result, filter);
+
+                /* Method specs belong to this interface */
+                for (int i = first_new; i < result->count; i++) {
+                    IndexEntry *entry = &result->entries[i];
+                    if (entry->context == CONTEXT_FUNCTION && strcmp(entry->clue, "interface") == 0 &&
+                        entry->parent_symbol[0] == '\0') {
+                        snprintf(entry->parent_symbol, sizeof(entry->parent_symbol), "%s", type_name);
+                    }
+                }
+
+                index_interface_embeds(type_def, type_name, package_buf, source_code,
+                                       directory, filename, result, filter);
             }
+            pop_scope(result);
         }
     }
 }
@@ -1186,22 +1452,81 @@ static void handle_type_alias(TSNode node, const char *source_code, const char *
             extract_type_from_node(type_node, source_code, underlying_type, sizeof(underlying_type), filename);
         }
 
+        /* Function type aliases (Handler = func(int) error) carry a signature */
+        char params[SIGNATURE_MAX_LENGTH];
+        char returns[SIGNATURE_MAX_LENGTH];
+        extract_function_type_signature(type_node, source_code, params, returns, sizeof(params), filename);
+
+        /* Generic aliases: type Set[T comparable] = map[T]struct{} */
+        char type_params[SIGNATURE_MAX_LENGTH];
+        extract_signature_list(ts_node_child_by_field_name(node, "type_parameters", 15),
+                               source_code, type_params, sizeof(type_params), filename);
+
         if (alias_name[0] && filter_should_index(filter, alias_name)) {
+            char location[128];
+            format_source_location(node, location, sizeof(location));
+
+            /* Type column holds the alias target */
             ExtColumns ext = {
                 .parent = NULL,
                 .scope = get_scope_from_name(alias_name),
+                .exported = is_inside_function(node) ? "0" : get_exported_from_name(alias_name),
                 .modifier = NULL,
-                .clue = "alias",
+                .clue = NULL,
                 .namespace = package_buf[0] ? package_buf : NULL,
                 .type = underlying_type[0] ? underlying_type : NULL,
+                .params = params[0] ? params : NULL,
+                .returns = returns[0] ? returns : NULL,
+                .typeparams = type_params[0] ? type_params : NULL,
                 .definition = "1"
             };
-            add_entry(result, alias_name, line, CONTEXT_TYPE,
-                     directory, filename, NULL, &ext);
+            add_entry(result, alias_name, line, CONTEXT_ALIAS,
+                     directory, filename, location, &ext);
         }
     }
 }
 
+/* Extract a field's tag as normalized key:"value" pairs (empty if none).
+ * Tags are usually raw strings (`json:"id"`); interpreted strings
+ * ("json:\"id\"") are unescaped first. Malformed tags keep the pairs that
+ * parsed before the error. */
+static void extract_field_tags(TSNode field_node, const char *source_code, const char *filename,
+                               char *tags, size_t tags_size) {
+    tags[0] = '\0';
+
+    TSNode tag_node = ts_node_child_by_field_name(field_node, "tag", 3);
+    if (ts_node_is_null(tag_node)) return;
+
+    uint32_t length = ts_node_end_byte(tag_node) - ts_node_start_byte(tag_node);
+    if (length < 2 || length >= TAGS_MAX_LENGTH) return;  /* Empty or unreasonably long */
+
+    char literal[TAGS_MAX_LENGTH];
+    safe_extract_node_text(source_code, tag_node, literal, sizeof(literal), filename);
+
+    /* Strip delimiters; unescape interpreted strings */
+    char raw[TAGS_MAX_LENGTH];
+    size_t len = strlen(literal);
+    if (literal[0] == '"') {
+        size_t j = 0;
+        for (size_t i = 1; i + 1 < len && j < sizeof(raw) - 1; i++) {
+            if (literal[i] == '\\' && i + 2 < len) i++;
+            raw[j++] = literal[i];
+        }
+        raw[j] = '\0';
+    } else {
+        snprintf(raw, sizeof(raw), "%.*s", (int)(len - 2), literal + 1);
+    }
+
+    StructTag pairs[MAX_STRUCT_TAGS];
+    int malformed = 0;
+    int count = parse_struct_tag(raw, pairs, MAX_STRUCT_TAGS, &malformed);
+    if (malformed && g_debug) {
+        fprintf(stderr, "Warning: malformed struct tag in %s:%u: %s\n",
+                filename, ts_node_start_point(tag_node).row + 1, literal);
+    }
+    format_struct_tags(pairs, count, tags, tags_size);
+}
+
 /* Handler: field_declaration */
 static void handle_field_declaration(TSNode node, const char *source_code, const char *directory,
                                       const char *filename, ParseResult *result, SymbolFilter *filter,
@@ -1240,6 +1565,9 @@ static void handle_field_declaration(TSNode node, const char *source_code, const
     char package_buf[SYMBOL_MAX_LENGTH];
     get_package(node, source_code, package_buf, sizeof(package_buf), filename);
 
+    char tags[TAGS_MAX_LENGTH];
+    extract_field_tags(node, source_code, filename, tags, sizeof(tags));
+
     if (!ts_node_is_null(name_node)) {
         /* Regular field with explicit name */
         char field_name[SYMBOL_MAX_LENGTH];
@@ -1267,53 +1595,97 @@ static void handle_field_declaration(TSNode node, const char *source_code, const
         }
 
         if (field_name[0] && filter_should_index(filter, field_name)) {
+            char location[128];
+            format_source_location(name_node, location, sizeof(location));
+
             ExtColumns ext = {
                 .parent = NULL,
                 .scope = get_scope_from_name(field_name),
+                .exported = get_exported_from_name(field_name),
                 .modifier = NULL,
                 .clue = NULL,
                 .namespace = package_buf[0] ? package_buf : NULL,
-                .type = field_type[0] ? field_type : NULL
+                .type = field_type[0] ? field_type : NULL,
+                .tags = tags[0] ? tags : NULL
             };
             add_entry(result, field_name, line, CONTEXT_PROPERTY,
-                     directory, filename, NULL, &ext);
+                     directory, filename, location, &ext);
         }
     } else if (is_embedded && !ts_node_is_null(type_node)) {
         /* Embedded field - extract type name to use as field name */
         char embedded_type[SYMBOL_MAX_LENGTH];
-        char embedded_name[SYMBOL_MAX_LENGTH] = "";
+        char embedded_name[SYMBOL_MAX_LENGTH];
+        char embedded_package[SYMBOL_MAX_LENGTH];
+        int is_pointer;
 
         extract_type_from_node(type_node, source_code, embedded_type, sizeof(embedded_type), filename);
 
-        /* Extract just the type name (last part after . if qualified) */
-        const char *last_dot = strrchr(embedded_type, '.');
-        if (last_dot) {
-            snprintf(embedded_name, sizeof(embedded_name), "%s", last_dot + 1);
-        } else {
-            /* Remove pointer prefix if present */
-            const char *type_start = embedded_type;
-            if (embedded_type[0] == '*') {
-                type_start = embedded_type + 1;
-            }
-            snprintf(embedded_name, sizeof(embedded_name), "%s", type_start);
-        }
+        /* The field is named after the type, without pointer, package or type arguments */
+        split_embedded_type(embedded_type, package_buf, embedded_package, sizeof(embedded_package),
+                            embedded_name, sizeof(embedded_name), &is_pointer);
 
         if (embedded_name[0] && filter_should_index(filter, embedded_name)) {
+            char location[128];
+            format_source_location(type_node, location, sizeof(location));
+
             ExtColumns ext = {
                 .parent = NULL,
                 .scope = get_scope_from_name(embedded_name),
-                .modifier = NULL,
+                .exported = get_exported_from_name(embedded_name),
+                .modifier = is_pointer ? "pointer" : "value",
                 .clue = "embedded",
                 .namespace = package_buf[0] ? package_buf : NULL,
-                .type = embedded_type
+                .type = embedded_type,
+                .tags = tags[0] ? tags : NULL,
+                .typepkg = embedded_package[0] ? embedded_package : NULL,
+                .typename = embedded_name
             };
             add_entry(result, embedded_name, line, CONTEXT_PROPERTY,
-                     directory, filename, NULL, &ext);
+                     directory, filename, location, &ext);
         }
     }
 }
 
 /* Handler: method_declaration */
+/* Extract the type a method's receiver list names, without pointer or type
+ * arguments: (m *MyStruct), (m MyStruct) and (l *List[T]) give MyStruct,
+ * MyStruct and List. Sets *is_pointer for pointer receivers. */
+static void extract_receiver_type(TSNode receiver_node, const char *source_code, char *type_buffer,
+                                  size_t type_size, int *is_pointer, const char *filename) {
+    type_buffer[0] = '\0';
+    *is_pointer = 0;
+    if (ts_node_is_null(receiver_node)) return;
+
+    TSNode type_node = {0};
+    uint32_t count = ts_node_named_child_count(receiver_node);
+    for (uint32_t i = 0; i < count; i++) {
+        TSNode decl = ts_node_named_child(receiver_node, i);
+        if (ts_node_symbol(decl) == go_symbols.parameter_declaration) {
+            type_node = ts_node_child_by_field_name(decl, "type", 4);
+            break;
+        }
+    }
+
+    /* Peel (T), *T and T[...] down to the type name */
+    while (!ts_node_is_null(type_node)) {
+        TSSymbol sym = ts_node_symbol(type_node);
+        if (sym == go_symbols.parenthesized_type) {
+            type_node = ts_node_named_child(type_node, 0);
+        } else if (sym == go_symbols.pointer_type) {
+            *is_pointer = 1;
+            type_node = ts_node_named_child(type_node, 0);
+        } else if (sym == go_symbols.generic_type) {
+            type_node = ts_node_child_by_field_name(type_node, "type", 4);
+        } else {
+            break;
+        }
+    }
+
+    if (!ts_node_is_null(type_node) && ts_node_symbol(type_node) == go_symbols.type_identifier) {
+        safe_extract_node_text(source_code, type_node, type_buffer, type_size, filename);
+    }
+}
+
 static void handle_method_declaration(TSNode node, const char *source_code, const char *directory,
                                        const char *filename, ParseResult *result, SymbolFilter *filter,
                                        int line) {
@@ -1349,93 +1721,56 @@ static void handle_method_declaration(TSNode node, const char *source_code, cons
     }
 
     /* Extract method name */
+    char method_name[SYMBOL_MAX_LENGTH] = "";
+    char receiver_type[SYMBOL_MAX_LENGTH] = "";
     if (!ts_node_is_null(name_node)) {
-        char method_name[SYMBOL_MAX_LENGTH];
-        char receiver_type[SYMBOL_MAX_LENGTH] = "";
         char return_type[SYMBOL_MAX_LENGTH] = "";
         char package_buf[SYMBOL_MAX_LENGTH];
+        char params[SIGNATURE_MAX_LENGTH];
+        char returns[SIGNATURE_MAX_LENGTH];
 
         safe_extract_node_text(source_code, name_node, method_name, sizeof(method_name), filename);
         get_package(node, source_code, package_buf, sizeof(package_buf), filename);
+        extract_signature_list(params_node, source_code, params, sizeof(params), filename);
+        extract_signature_list(return_node, source_code, returns, sizeof(returns), filename);
 
-        /* Extract receiver type */
-        if (!ts_node_is_null(receiver_node)) {
-            uint32_t receiver_children = ts_node_child_count(receiver_node);
-            for (uint32_t i = 0; i < receiver_children; i++) {
-                TSNode param_decl = ts_node_child(receiver_node, i);
-                if (strcmp(ts_node_type(param_decl), "parameter_declaration") == 0) {
-                    /* Find the type in parameter_declaration */
-                    uint32_t decl_children = ts_node_child_count(param_decl);
-                    for (uint32_t j = 0; j < decl_children; j++) {
-                        TSNode type_child = ts_node_child(param_decl, j);
-                        const char *type_child_type = ts_node_type(type_child);
-                        if (strcmp(type_child_type, "identifier") != 0) {
-                            extract_type_from_node(type_child, source_code, receiver_type, sizeof(receiver_type), filename);
-                            break;
-                        }
-                    }
-                    break;
-                }
-            }
-        }
+        /* Receiver type: value and pointer receivers share the type as parent */
+        int pointer_receiver = 0;
+        extract_receiver_type(receiver_node, source_code, receiver_type, sizeof(receiver_type),
+                              &pointer_receiver, filename);
 
-        /* Extract return type */
-        if (!ts_node_is_null(return_node)) {
-            TSSymbol return_sym = ts_node_symbol(return_node);
-            if (return_sym == go_symbols.parameter_list) {
-                /* Multiple return types */
-                char temp_types[SYMBOL_MAX_LENGTH * 2] = "(";
-                bool first = true;
-                uint32_t return_children = ts_node_child_count(return_node);
-                for (uint32_t i = 0; i < return_children; i++) {
-                    TSNode return_child = ts_node_child(return_node, i);
-                    TSSymbol return_child_sym = ts_node_symbol(return_child);
-                    if (return_child_sym == go_symbols.parameter_declaration) {
-                        char single_type[SYMBOL_MAX_LENGTH];
-                        /* Extract type from parameter_declaration */
-                        uint32_t decl_children = ts_node_child_count(return_child);
-                        for (uint32_t j = 0; j < decl_children; j++) {
-                            TSNode type_child = ts_node_child(return_child, j);
-                            extract_type_from_node(type_child, source_code, single_type, sizeof(single_type), filename);
-                            if (single_type[0]) {
-                                if (!first) strncat(temp_types, ", ", sizeof(temp_types) - strlen(temp_types) - 1);
-                                strncat(temp_types, single_type, sizeof(temp_types) - strlen(temp_types) - 1);
-                                first = false;
-                                break;
-                            }
-                        }
-                    }
-                }
-                strncat(temp_types, ")", sizeof(temp_types) - strlen(temp_types) - 1);
-                snprintf(return_type, sizeof(return_type), "%s", temp_types);
-            } else {
-                /* Single return type */
-                extract_type_from_node(return_node, source_code, return_type, sizeof(return_type), filename);
-            }
-        }
+        /* Legacy type column: the result types without their names */
+        signature_result_type(returns, return_type, sizeof(return_type));
 
         if (method_name[0] && filter_should_index(filter, method_name)) {
             /* Extract source location for full method definition */
             char location[128];
             format_source_location(node, location, sizeof(location));
 
+            /* Declared at top level, but addressed through its receiver */
             ExtColumns ext = {
                 .parent = receiver_type[0] ? receiver_type : NULL,
+                .scopepath = receiver_type[0] ? receiver_type : NULL,
                 .scope = get_scope_from_name(method_name),
-                .modifier = NULL,
+                .exported = get_exported_from_name(method_name),
+                .modifier = receiver_type[0] ? (pointer_receiver ? "pointer" : "value") : NULL,
                 .clue = NULL,
                 .namespace = package_buf[0] ? package_buf : NULL,
                 .type = return_type[0] ? return_type : NULL,
+                .params = params[0] ? params : NULL,
+                .returns = returns[0] ? returns : NULL,
                 .definition = "1"
             };
             add_entry(result, method_name, line, CONTEXT_FUNCTION,
                      directory, filename, location, &ext);
         }
+    }
+    push_scope(result, receiver_type);
+    push_scope(result, method_name);
 
-        /* Process parameters */
-        if (!ts_node_is_null(params_node)) {
-            process_children(params_node, source_code, directory, filename, result, filter);
-        }
+    /* Process parameters */
+    if (!ts_node_is_null(name_node) && !ts_node_is_null(params_node)) {
+        process_children(params_node, source_code, directory, filename, result, filter);
     }
 
     /* Process method body */
@@ -1443,14 +1778,16 @@ static void handle_method_declaration(TSNode node, const char *source_code, cons
     if (!ts_node_is_null(body_node)) {
         process_children(body_node, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
+    pop_scope(result);
 }
 
-/* Handler: method_spec (interface methods) */
+/* Handler: method_spec / method_elem (interface methods) */
 static void handle_method_spec(TSNode node, const char *source_code, const char *directory,
                                 const char *filename, ParseResult *result, SymbolFilter *filter,
                                 int line) {
     /* Interface method: Read(p []byte) (n int, err error)
-     * Structure: method_spec
+     * Structure: method_spec (method_elem in newer grammars)
      *   field_identifier (method name)
      *   parameter_list (parameters)
      *   parameter_list OR type (return types)
@@ -1485,52 +1822,28 @@ static void handle_method_spec(TSNode node, const char *source_code, const char
         char method_name[SYMBOL_MAX_LENGTH];
         char return_type[SYMBOL_MAX_LENGTH] = "";
         char package_buf[SYMBOL_MAX_LENGTH];
+        char params[SIGNATURE_MAX_LENGTH];
+        char returns[SIGNATURE_MAX_LENGTH];
 
         safe_extract_node_text(source_code, name_node, method_name, sizeof(method_name), filename);
         get_package(node, source_code, package_buf, sizeof(package_buf), filename);
+        extract_signature_list(params_node, source_code, params, sizeof(params), filename);
+        extract_signature_list(return_node, source_code, returns, sizeof(returns), filename);
 
-        /* Extract return type (similar to function_declaration logic) */
-        if (!ts_node_is_null(return_node)) {
-            const char *return_node_type = ts_node_type(return_node);
-            if (strcmp(return_node_type, "parameter_list") == 0) {
-                /* Multiple return types */
-                char temp_types[SYMBOL_MAX_LENGTH * 2] = "(";
-                bool first = true;
-                uint32_t return_children = ts_node_child_count(return_node);
-                for (uint32_t i = 0; i < return_children; i++) {
-                    TSNode return_child = ts_node_child(return_node, i);
-                    if (strcmp(ts_node_type(return_child), "parameter_declaration") == 0) {
-                        char single_type[SYMBOL_MAX_LENGTH];
-                        /* Extract type from parameter_declaration */
-                        uint32_t decl_children = ts_node_child_count(return_child);
-                        for (uint32_t j = 0; j < decl_children; j++) {
-                            TSNode type_child = ts_node_child(return_child, j);
-                            extract_type_from_node(type_child, source_code, single_type, sizeof(single_type), filename);
-                            if (single_type[0]) {
-                                if (!first) strncat(temp_types, ", ", sizeof(temp_types) - strlen(temp_types) - 1);
-                                strncat(temp_types, single_type, sizeof(temp_types) - strlen(temp_types) - 1);
-                                first = false;
-                                break;
-                            }
-                        }
-                    }
-                }
-                strncat(temp_types, ")", sizeof(temp_types) - strlen(temp_types) - 1);
-                snprintf(return_type, sizeof(return_type), "%s", temp_types);
-            } else {
-                /* Single return type */
-                extract_type_from_node(return_node, source_code, return_type, sizeof(return_type), filename);
-            }
-        }
+        /* Legacy type column: the result types without their names */
+        signature_result_type(returns, return_type, sizeof(return_type));
 
         if (method_name[0] && filter_should_index(filter, method_name)) {
             ExtColumns ext = {
-                .parent = NULL,  /* TODO: Could extract parent interface name if needed */
+                .parent = NULL,  /* Set to the interface name by handle_type_spec */
                 .scope = get_scope_from_name(method_name),
+                .exported = get_exported_from_name(method_name),
                 .modifier = NULL,
                 .clue = "interface",
                 .namespace = package_buf[0] ? package_buf : NULL,
                 .type = return_type[0] ? return_type : NULL,
+                .params = params[0] ? params : NULL,
+                .returns = returns[0] ? returns : NULL,
                 .definition = "1"
             };
             add_entry(result, method_name, line, CONTEXT_FUNCTION,
@@ -1539,77 +1852,6 @@ static void handle_method_spec(TSNode node, const char *source_code, const char
     }
 }
 
-/* Handler: var_declaration */
-static void handle_var_declaration(TSNode node, const char *source_code, const char *directory,
-                                    const char *filename, ParseResult *result, SymbolFilter *filter,
-                                    int line) {
-    /* Find var_spec children */
-    uint32_t child_count = ts_node_child_count(node);
-    for (uint32_t i = 0; i < child_count; i++) {
-        TSNode child = ts_node_child(node, i);
-        const char *child_type = ts_node_type(child);
-
-        if (strcmp(child_type, "var_spec") == 0) {
-            /* Extract variable name and type */
-            TSNode name_node = {0};
-            TSNode type_node = {0};
-
-            uint32_t spec_children = ts_node_child_count(child);
-            for (uint32_t j = 0; j < spec_children; j++) {
-                TSNode spec_child = ts_node_child(child, j);
-                const char *spec_child_type = ts_node_type(spec_child);
-
-                if (strcmp(spec_child_type, "identifier") == 0 && ts_node_is_null(name_node)) {
-                    name_node = spec_child;
-                } else if (!ts_node_is_null(name_node) && ts_node_is_null(type_node) &&
-                           strcmp(spec_child_type, "=") != 0) {
-                    /* Type comes after name, before = */
-                    type_node = spec_child;
-                }
-            }
-
-            if (!ts_node_is_null(name_node)) {
-                char var_name[SYMBOL_MAX_LENGTH];
-                char var_type[SYMBOL_MAX_LENGTH] = "";
-                char package_buf[SYMBOL_MAX_LENGTH];
-
-                safe_extract_node_text(source_code, name_node, var_name, sizeof(var_name), filename);
-                get_package(node, source_code, package_buf, sizeof(package_buf), filename);
-
-                if (!ts_node_is_null(type_node)) {
-                    const char *type_node_type = ts_node_type(type_node);
-                    /* Only extract actual type nodes, not computed expressions */
-                    if (strcmp(type_node_type, "binary_expression") != 0 &&
-                        strcmp(type_node_type, "expression_list") != 0) {
-                        extract_type_from_node(type_node, source_code, var_type, sizeof(var_type), filename);
-                    }
-                }
-
-                if (var_name[0] && filter_should_index(filter, var_name)) {
-                    /* Extract source location for full variable declaration */
-                    char location[128];
-                    format_source_location(node, location, sizeof(location));
-
-                    ExtColumns ext = {
-                        .parent = NULL,
-                        .scope = get_scope_from_name(var_name),
-                        .modifier = "var",
-                        .clue = NULL,
-                        .namespace = package_buf[0] ? package_buf : NULL,
-                        .type = var_type[0] ? var_type : NULL,
-                        .definition = "1"
-                    };
-                    add_entry(result, var_name, line, CONTEXT_VARIABLE,
-                             directory, filename, location, &ext);
-                }
-            }
-
-            /* Process children to index symbols in computed expressions */
-            process_children(child, source_code, directory, filename, result, filter);
-        }
-    }
-}
-
 /* Helper: Check if iota is used in a node's subtree */
 static int contains_iota(TSNode node, const char *source_code) {
     const char *node_type = ts_node_type(node);
@@ -1630,80 +1872,217 @@ static int contains_iota(TSNode node, const char *source_code) {
     return 0;
 }
 
-/* Handler: const_declaration */
-static void handle_const_declaration(TSNode node, const char *source_code, const char *directory,
-                                      const char *filename, ParseResult *result, SymbolFilter *filter,
-                                      int line) {
-    /* Find const_spec children */
+/* Helper: Operand of a sign or negation (-1, +2.5, !true), or a null node */
+static TSNode sign_operand(TSNode expr) {
+    TSNode none = {0};
+    if (strcmp(ts_node_type(expr), "unary_expression") != 0) return none;
+
+    TSNode op = ts_node_child_by_field_name(expr, "operator", 8);
+    const char *op_type = ts_node_is_null(op) ? "" : ts_node_type(op);
+    if (strcmp(op_type, "-") != 0 && strcmp(op_type, "+") != 0 &&
+        strcmp(op_type, "^") != 0 && strcmp(op_type, "!") != 0) {
+        return none;
+    }
+    return ts_node_child_by_field_name(expr, "operand", 7);
+}
+
+/* Helper: Default type of an untyped literal or iota (5 is an int, "v1" a
+ * string), NULL for anything else */
+static const char *literal_default_type(TSNode expr) {
+    static const struct { const char *node; const char *type; } defaults[] = {
+        { "int_literal", "int" },
+        { "float_literal", "float64" },
+        { "imaginary_literal", "complex128" },
+        { "rune_literal", "rune" },
+        { "interpreted_string_literal", "string" },
+        { "raw_string_literal", "string" },
+        { "true", "bool" },
+        { "false", "bool" },
+        { "iota", "int" },
+    };
+
+    if (ts_node_is_null(expr)) return NULL;
+    const char *expr_type = ts_node_type(expr);
+    if (strcmp(expr_type, "parenthesized_expression") == 0) {
+        return literal_default_type(ts_node_named_child(expr, 0));
+    }
+    TSNode operand = sign_operand(expr);
+    if (!ts_node_is_null(operand)) {
+        return literal_default_type(operand);
+    }
+    for (size_t i = 0; i < sizeof(defaults) / sizeof(defaults[0]); i++) {
+        if (strcmp(expr_type, defaults[i].node) == 0) return defaults[i].type;
+    }
+    return NULL;
+}
+
+/* Helper: Value of a trivially constant expression: a literal, signed or
+ * not, as written (-1, "v1", true), or iota itself as the spec's index in
+ * its block. Empty for anything computed, and for multi-line literals. */
+static void extract_constant_value(TSNode expr, int iota, const char *source_code, char *buffer,
+                                   size_t size, const char *filename) {
+    buffer[0] = '\0';
+    if (ts_node_is_null(expr)) return;
+
+    const char *expr_type = ts_node_type(expr);
+    if (strcmp(expr_type, "parenthesized_expression") == 0) {
+        extract_constant_value(ts_node_named_child(expr, 0), iota, source_code, buffer, size, filename);
+        return;
+    }
+    if (strcmp(expr_type, "iota") == 0) {
+        snprintf(buffer, size, "%d", iota);
+        return;
+    }
+    if (!literal_default_type(expr)) return;  /* Not a literal */
+
+    uint32_t length = ts_node_end_byte(expr) - ts_node_start_byte(expr);
+    if (length >= size || memchr(source_code + ts_node_start_byte(expr), '\n', length)) return;
+    safe_extract_node_text(source_code, expr, buffer, size, filename);
+}
+
+/* Handler: const_declaration and var_declaration
+ * Every name of every spec becomes a symbol, on the spec's own line. In a
+ * const block a spec without type and value repeats the previous one, as
+ * in Go (B and C in "A Kind = iota; B; C" are Kinds with values 1 and 2).
+ * Names of a parenthesized block share the first name as their group. */
+static void handle_value_declaration(TSNode node, const char *source_code, const char *directory,
+                                     const char *filename, ParseResult *result, SymbolFilter *filter,
+                                     int is_const) {
+    const char *spec_type = is_const ? "const_spec" : "var_spec";
+
+    /* Grouped specs are direct children, or inside a var_spec_list */
+    TSNode container = node;
     uint32_t child_count = ts_node_child_count(node);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
-        const char *child_type = ts_node_type(child);
+        if (strcmp(ts_node_type(child), "var_spec_list") == 0) {
+            container = child;
+            break;
+        }
+    }
+    bool grouped = false;
+    child_count = ts_node_child_count(container);
+    for (uint32_t i = 0; i < child_count; i++) {
+        if (strcmp(ts_node_type(ts_node_child(container, i)), "(") == 0) {
+            grouped = true;
+            break;
+        }
+    }
 
-        if (strcmp(child_type, "const_spec") == 0) {
-            /* Extract constant name and type */
-            TSNode name_node = {0};
-            TSNode type_node = {0};
+    char package_buf[SYMBOL_MAX_LENGTH];
+    get_package(node, source_code, package_buf, sizeof(package_buf), filename);
+    bool in_function = is_inside_function(node);
 
-            uint32_t spec_children = ts_node_child_count(child);
-            for (uint32_t j = 0; j < spec_children; j++) {
-                TSNode spec_child = ts_node_child(child, j);
-                const char *spec_child_type = ts_node_type(spec_child);
-
-                if (strcmp(spec_child_type, "identifier") == 0 && ts_node_is_null(name_node)) {
-                    name_node = spec_child;
-                } else if (!ts_node_is_null(name_node) && ts_node_is_null(type_node) &&
-                           strcmp(spec_child_type, "=") != 0) {
-                    /* Type comes after name, before = */
-                    type_node = spec_child;
-                }
+    char group[SYMBOL_MAX_LENGTH] = "";
+    TSNode prev_type = {0};
+    TSNode prev_value = {0};
+    int iota = 0;
+
+    for (uint32_t i = 0; i < child_count; i++) {
+        TSNode spec = ts_node_child(container, i);
+        if (strcmp(ts_node_type(spec), spec_type) != 0) {
+            continue;
+        }
+
+        TSNode type_node = ts_node_child_by_field_name(spec, "type", 4);
+        TSNode value_list = ts_node_child_by_field_name(spec, "value", 5);
+        if (is_const) {
+            if (ts_node_is_null(type_node) && ts_node_is_null(value_list)) {
+                type_node = prev_type;
+                value_list = prev_value;
+            } else {
+                prev_type = type_node;
+                prev_value = value_list;
             }
+        }
 
-            if (!ts_node_is_null(name_node)) {
-                char const_name[SYMBOL_MAX_LENGTH];
-                char const_type[SYMBOL_MAX_LENGTH] = "";
-                char package_buf[SYMBOL_MAX_LENGTH];
+        char declared_type[SYMBOL_MAX_LENGTH] = "";
+        if (!ts_node_is_null(type_node)) {
+            extract_type_from_node(type_node, source_code, declared_type, sizeof(declared_type), filename);
+        }
+        const char *clue = NULL;
+        if (is_const && !ts_node_is_null(value_list) && contains_iota(value_list, source_code)) {
+            clue = "iota";
+        }
 
-                safe_extract_node_text(source_code, name_node, const_name, sizeof(const_name), filename);
-                get_package(node, source_code, package_buf, sizeof(package_buf), filename);
+        /* Values pair up with names, unless one call returns them all (a, b = f()) */
+        uint32_t name_count = 0;
+        uint32_t spec_children = ts_node_child_count(spec);
+        for (uint32_t j = 0; j < spec_children; j++) {
+            if (ts_node_symbol(ts_node_child(spec, j)) == go_symbols.identifier) name_count++;
+        }
+        bool paired = !ts_node_is_null(value_list) && ts_node_named_child_count(value_list) == name_count;
 
-                if (!ts_node_is_null(type_node)) {
-                    const char *type_node_type = ts_node_type(type_node);
-                    /* Only extract actual type nodes, not computed expressions */
-                    if (strcmp(type_node_type, "binary_expression") != 0 &&
-                        strcmp(type_node_type, "expression_list") != 0) {
-                        extract_type_from_node(type_node, source_code, const_type, sizeof(const_type), filename);
-                    }
-                }
+        /* A grouped spec spans its own line(s), a single one the whole declaration */
+        int spec_line = (int)ts_node_start_point(spec).row + 1;
+        char location[128];
+        format_source_location(grouped ? spec : node, location, sizeof(location));
 
-                if (const_name[0] && filter_should_index(filter, const_name)) {
-                    /* Check if iota is used in this const_spec */
-                    const char *clue = NULL;
-                    if (contains_iota(child, source_code)) {
-                        clue = "iota";
-                    }
+        uint32_t name_index = 0;
+        for (uint32_t j = 0; j < spec_children; j++) {
+            TSNode name_node = ts_node_child(spec, j);
+            if (ts_node_symbol(name_node) != go_symbols.identifier) {
+                continue;
+            }
+            uint32_t index = name_index++;
+
+            char name[SYMBOL_MAX_LENGTH];
+            safe_extract_node_text(source_code, name_node, name, sizeof(name), filename);
+            if (!name[0] || strcmp(name, "_") == 0) {
+                continue;
+            }
+            if (grouped && !group[0]) {
+                snprintf(group, sizeof(group), "%s", name);
+            }
+            if (!filter_should_index(filter, name)) {
+                continue;
+            }
 
-                    /* Extract source location for full const declaration */
-                    char location[128];
-                    format_source_location(node, location, sizeof(location));
-
-                    ExtColumns ext = {
-                        .parent = NULL,
-                        .scope = get_scope_from_name(const_name),
-                        .modifier = "const",
-                        .clue = clue,
-                        .namespace = package_buf[0] ? package_buf : NULL,
-                        .type = const_type[0] ? const_type : NULL,
-                        .definition = "1"
-                    };
-                    add_entry(result, const_name, line, CONTEXT_VARIABLE,
-                             directory, filename, location, &ext);
+            TSNode value_node = {0};
+            if (paired) {
+                value_node = ts_node_named_child(value_list, index);
+            }
+
+            char type[SYMBOL_MAX_LENGTH];
+            snprintf(type, sizeof(type), "%s", declared_type);
+            if (!type[0] && paired) {
+                infer_type_from_expression(value_node, source_code, type, sizeof(type), filename);
+                const char *literal_type = literal_default_type(value_node);
+                if (!type[0] && literal_type) {
+                    snprintf(type, sizeof(type), "%s", literal_type);
                 }
             }
 
-            /* Process children to index symbols in computed expressions */
-            process_children(child, source_code, directory, filename, result, filter);
+            char value[SYMBOL_MAX_LENGTH] = "";
+            if (is_const && paired) {
+                extract_constant_value(value_node, iota, source_code, value, sizeof(value), filename);
+            }
+
+            ExtColumns ext = {
+                .parent = NULL,
+                .scope = get_scope_from_name(name),
+                .exported = in_function ? "0" : get_exported_from_name(name),
+                .modifier = is_const ? "const" : "var",
+                .clue = clue,
+                .namespace = package_buf[0] ? package_buf : NULL,
+                .type = type[0] ? type : NULL,
+                .value = value[0] ? value : NULL,
+                .group = grouped ? group : NULL,
+                .definition = "1"
+            };
+            add_entry(result, name, spec_line, CONTEXT_VARIABLE,
+                     directory, filename, location, &ext);
+        }
+
+        /* Index symbols in the type and computed expressions; the names
+         * were indexed above, so they would only be repeated as variables */
+        for (uint32_t j = 0; j < spec_children; j++) {
+            TSNode child = ts_node_child(spec, j);
+            if (ts_node_symbol(child) != go_symbols.identifier) {
+                visit_node(child, source_code, directory, filename, result, filter);
+            }
         }
+        iota++;
     }
 }
 
@@ -1751,7 +2130,12 @@ static void handle_parameter_declaration(TSNode node, const char *source_code, c
     /* Extract the shared type */
     char param_type[SYMBOL_MAX_LENGTH] = "";
     if (!ts_node_is_null(type_node)) {
-        extract_type_from_node(type_node, source_code, param_type, sizeof(param_type), filename);
+        char element_type[SYMBOL_MAX_LENGTH - 3];  /* Room for "..." */
+        extract_type_from_node(type_node, source_code, element_type, sizeof(element_type), filename);
+        /* Variadic parameters keep the ... they are declared with (parts ...string) */
+        snprintf(param_type, sizeof(param_type), "%s%s",
+                 ts_node_symbol(node) == go_symbols.variadic_parameter_declaration && element_type[0] ? "..." : "",
+                 element_type);
     }
 
     /* Add entry for each parameter with the shared type */
@@ -1987,6 +2371,7 @@ static void handle_defer_statement(TSNode node, const char *source_code, const c
     const char *func_type = ts_node_type(function_node);
     char defer_symbol[SYMBOL_MAX_LENGTH] = "";
     char *parent_name = NULL;
+    char parent_buf[SYMBOL_MAX_LENGTH];
 
     if (strcmp(func_type, "identifier") == 0) {
         /* Named function: defer releaseResource(resource) */
@@ -2006,7 +2391,6 @@ static void handle_defer_statement(TSNode node, const char *source_code, const c
             if (!ts_node_is_null(operand_node)) {
                 const char *operand_type = ts_node_type(operand_node);
                 if (strcmp(operand_type, "identifier") == 0) {
-                    static char parent_buf[SYMBOL_MAX_LENGTH];
                     safe_extract_node_text(source_code, operand_node, parent_buf, sizeof(parent_buf), filename);
                     parent_name = parent_buf;
                 }
@@ -2077,6 +2461,7 @@ static void handle_go_statement(TSNode node, const char *source_code, const char
     const char *func_type = ts_node_type(function_node);
     char goroutine_symbol[SYMBOL_MAX_LENGTH] = "";
     char *parent_name = NULL;
+    char parent_buf[SYMBOL_MAX_LENGTH];
 
     if (strcmp(func_type, "identifier") == 0) {
         /* Named function: go worker(1, nil, nil) */
@@ -2096,7 +2481,6 @@ static void handle_go_statement(TSNode node, const char *source_code, const char
             if (!ts_node_is_null(operand_node)) {
                 const char *operand_type = ts_node_type(operand_node);
                 if (strcmp(operand_type, "identifier") == 0) {
-                    static char parent_buf[SYMBOL_MAX_LENGTH];
                     safe_extract_node_text(source_code, operand_node, parent_buf, sizeof(parent_buf), filename);
                     parent_name = parent_buf;
                 }
@@ -2595,7 +2979,7 @@ static void visit_node(TSNode node, const char *source_code, const char *directo
         handle_method_declaration(node, source_code, directory, filename, result, filter, line);
         return;
     }
-    if (node_sym == go_symbols.method_spec) {
+    if (node_sym == go_symbols.method_spec || node_sym == go_symbols.method_elem) {
         handle_method_spec(node, source_code, directory, filename, result, filter, line);
         return;
     }
@@ -2604,14 +2988,15 @@ static void visit_node(TSNode node, const char *source_code, const char *directo
         return;
     }
     if (node_sym == go_symbols.var_declaration) {
-        handle_var_declaration(node, source_code, directory, filename, result, filter, line);
+        handle_value_declaration(node, source_code, directory, filename, result, filter, 0);
         return;
     }
     if (node_sym == go_symbols.const_declaration) {
-        handle_const_declaration(node, source_code, directory, filename, result, filter, line);
+        handle_value_declaration(node, source_code, directory, filename, result, filter, 1);
         return;
     }
-    if (node_sym == go_symbols.parameter_declaration) {
+    if (node_sym == go_symbols.parameter_declaration ||
+        node_sym == go_symbols.variadic_parameter_declaration) {
         handle_parameter_declaration(node, source_code, directory, filename, result, filter, line);
         return;
     }
@@ -2686,46 +3071,19 @@ void parser_free(GoParser *parser) {
 
 int parser_parse_file(GoParser *parser, const char *filepath,
                       const char *project_root, ParseResult *result) {
-    /* Open file in binary mode for accurate byte count */
-    FILE *file = safe_fopen(filepath, "rb", 0);
-    if (!file) {
-        fprintf(stderr, "Failed to open file: %s\n", filepath);
-        return -1;
-    }
-
-    /* Get file size using fstat */
-    int fd = fileno(file);
-    struct stat st;
-    if (fstat(fd, &st) != 0) {
-        fclose(file);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) {
-        fprintf(stderr, "Failed to allocate memory for file: %s\n", filepath);
-        fclose(file);
-        return -1;
-    }
-
-    size_t bytes_read = fread(source_code, 1, file_size, file);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "Error reading file: %s (expected %zu bytes, got %zu)\n",
-                filepath, file_size, bytes_read);
-        free(source_code);
-        fclose(file);
-        return -1;
-    }
-    source_code[bytes_read] = '\0';
-    fclose(file);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     /* Create parser */
     TSParser *ts_parser = ts_parser_new();
     const TSLanguage *language = tree_sitter_go();
     if (!ts_parser_set_language(ts_parser, language)) {
-        fprintf(stderr, "Failed to set Go language\n");
-        free(source_code);
+        parse_error_set("failed to load the tree-sitter grammar");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
@@ -2734,14 +3092,17 @@ int parser_parse_file(GoParser *parser, const char *filepath,
     init_go_symbols(language);
 
     /* Parse */
-    TSTree *tree = ts_parser_parse_string(ts_parser, NULL, source_code, (uint32_t)bytes_read);
+    TSTree *tree = ts_parser_parse(ts_parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "Failed to parse file: %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, ts_parser);
+
     /* Get relative path */
     char directory[DIRECTORY_MAX_LENGTH] = "";
     char filename[FILENAME_MAX_LENGTH] = "";
@@ -2755,6 +3116,7 @@ int parser_parse_file(GoParser *parser, const char *filepath,
     if (dot) *dot = '\0';
 
     result->count = 0;
+    result->import_count = 0;
     if (name_only[0] && filter_should_index(parser->filter, name_only)) {
         add_entry(result, name_only, 1, CONTEXT_FILENAME, directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
     }
@@ -2762,11 +3124,14 @@ int parser_parse_file(GoParser *parser, const char *filepath,
     /* Traverse AST */
     TSNode root = ts_tree_root_node(tree);
     visit_node(root, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_SLASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
 
     /* Cleanup */
     ts_tree_delete(tree);
     ts_parser_delete(ts_parser);
-    free(source_code);
+    source_file_close(&source);
 
     return 0;
 }
@@ -0,0 +1,17 @@
+#include <string.h>
+#include "../shared/indexer_main.h"
+#include "../shared/indexers.h"
+#include "../shared/version.h"
+#include "grammar_version.h"
+
+int main(int argc, char *argv[]) {
+    /* Check for --version flag */
+    for (int i = 1; i < argc; i++) {
+        if (strcmp(argv[i], "--version") == 0) {
+            print_version_with_grammar(GRAMMAR_NAME, GRAMMAR_VERSION);
+            return 0;
+        }
+    }
+
+    return indexer_main(argc, argv, javascript_indexer_config());
+}
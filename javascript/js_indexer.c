@@ -0,0 +1,58 @@
+#include <stdlib.h>
+#include "../shared/indexer_main.h"
+#include "../shared/constants.h"
+#include "../shared/indexers.h"
+#include "../typescript/ts_language.h"
+#include "grammar_version.h"
+
+/* Grammar entry point (checked against the tree-sitter runtime ABI)
+ * JavaScript is parsed with the TSX grammar, so the TypeScript extractor
+ * handles it; JSX comes for free */
+extern const TSLanguage *tree_sitter_tsx(void);
+
+/* Wrapper for parser_init to match IndexerConfig signature */
+static void* parser_init_wrapper(SymbolFilter *filter) {
+    TypeScriptParser *parser = malloc(sizeof(TypeScriptParser));
+    if (!parser) {
+        return NULL;
+    }
+    if (parser_init_javascript(parser, filter, tree_sitter_tsx) != 0) {
+        free(parser);
+        return NULL;
+    }
+    return parser;
+}
+
+/* Wrapper for parser_parse_file to match IndexerConfig signature */
+static int parser_parse_wrapper(void *parser, const char *filepath, const char *project_root, ParseResult *result) {
+    return parser_parse_file((TypeScriptParser*)parser, filepath, project_root, result);
+}
+
+/* Wrapper for parser_free to match IndexerConfig signature */
+static void parser_free_wrapper(void *parser) {
+    if (parser) {
+        parser_free((TypeScriptParser*)parser);
+        free(parser);
+    }
+}
+
+/* Wrapper for parser_set_debug to match IndexerConfig signature */
+static void parser_set_debug_wrapper(void *parser, int debug) {
+    parser_set_debug((TypeScriptParser*)parser, debug);
+}
+
+static const IndexerConfig config = {
+    .name = "index-javascript",
+    .data_dir = "javascript/" CONFIG_DIR,
+    .parser_init = parser_init_wrapper,
+    .parser_parse = parser_parse_wrapper,
+    .parser_free = parser_free_wrapper,
+    .parser_set_debug = parser_set_debug_wrapper,
+    .grammar_name = GRAMMAR_NAME,
+    .grammar_version = GRAMMAR_VERSION,
+    .language = tree_sitter_tsx
+};
+
+const IndexerConfig *javascript_indexer_config(void) {
+    return &config;
+}
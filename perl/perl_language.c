@@ -24,6 +24,8 @@
 #include "../shared/parse_result.h"
 #include "../shared/debug.h"
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
+#include "../shared/parse_errors.h"
 #include <stdbool.h>
 #include <stdio.h>
 #include <stdlib.h>
@@ -37,8 +39,9 @@ const TSLanguage *tree_sitter_perl(void);
 /* Global debug flag */
 static int g_debug = 0;
 
-/* Byte position of a scalar already indexed as filehandle; skipped in visit_node to avoid re-indexing as scalar */
-static uint32_t g_skip_scalar_start = UINT32_MAX;
+/* Byte position of a scalar already indexed as filehandle; skipped in visit_node to avoid re-indexing as scalar.
+ * Per thread: files are parsed concurrently by the indexer's workers. */
+static _Thread_local uint32_t g_skip_scalar_start = UINT32_MAX;
 
 /* Pre-looked-up node type IDs for fast dispatch */
 static struct {
@@ -576,7 +579,7 @@ static void handle_package_statement(TSNode node, const char *source_code,
             safe_extract_node_text(source_code, child, name, sizeof(name), filename);
             if (filter_should_index(filter, name)) {
                 add_entry(result, name, line, CONTEXT_NAMESPACE,
-                          directory, filename, NULL, &(ExtColumns){.definition = "1"});
+                          directory, filename, NULL, &(ExtColumns){.definition = "1", .exported = "1"});
             }
             break;
         }
@@ -591,21 +594,25 @@ static void handle_subroutine_declaration(TSNode node, const char *source_code,
     char location[128];
     format_source_location(node, location, sizeof(location));
 
+    char name[SYMBOL_MAX_LENGTH] = "";
     uint32_t child_count = ts_node_child_count(node);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
         if (ts_node_symbol(child) == perl_symbols.bareword) {
-            char name[SYMBOL_MAX_LENGTH];
             safe_extract_node_text(source_code, child, name, sizeof(name), filename);
             if (filter_should_index(filter, name)) {
+                /* Leading underscore is Perl's convention for private subs */
                 add_entry(result, name, line, CONTEXT_FUNCTION,
-                          directory, filename, location, &(ExtColumns){.definition = "1"});
+                          directory, filename, location,
+                          &(ExtColumns){.definition = "1", .exported = name[0] == '_' ? "0" : "1"});
             }
             break;
         }
     }
     /* Recurse into the block body to index variables, comments, etc. */
+    push_scope(result, name);
     process_children(node, source_code, directory, filename, result, filter);
+    pop_scope(result);
 }
 
 static void handle_anonymous_sub(TSNode node, const char *source_code,
@@ -1543,48 +1550,26 @@ void parser_set_debug(PerlParser *parser, int debug) {
 
 int parser_parse_file(PerlParser *parser, const char *filepath,
                       const char *project_root, ParseResult *result) {
-    /* Read source file */
-    FILE *fp = safe_fopen(filepath, "rb", 0);
-    if (!fp) {
-        fprintf(stderr, "index-perl: cannot open %s\n", filepath);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-
-    int fd = fileno(fp);
-    struct stat st;
-    if (fstat(fd, &st) != 0) {
-        fclose(fp);
-        return -1;
-    }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) {
-        fclose(fp);
-        return -1;
-    }
-
-    size_t bytes_read = fread(source_code, 1, file_size, fp);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "index-perl: error reading %s\n", filepath);
-        free(source_code);
-        fclose(fp);
-        return -1;
-    }
-    source_code[bytes_read] = '\0';
-    fclose(fp);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     result->count = 0;
 
     /* Parse with tree-sitter */
-    TSTree *tree = ts_parser_parse_string(parser->parser, NULL,
-                                          source_code, (uint32_t)bytes_read);
+    TSTree *tree = ts_parser_parse(parser->parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "index-perl: failed to parse %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, NULL);
+
     /* Resolve directory and filename relative to project root */
     char directory[DIRECTORY_MAX_LENGTH];
     char filename[FILENAME_MAX_LENGTH];
@@ -1604,9 +1589,12 @@ int parser_parse_file(PerlParser *parser, const char *filepath,
     /* Walk the AST */
     TSNode root = ts_tree_root_node(tree);
     visit_node(root, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_HASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
 
     ts_tree_delete(tree);
-    free(source_code);
+    source_file_close(&source);
     return 0;
 }
 
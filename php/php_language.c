@@ -25,10 +25,12 @@
 #include <sys/stat.h>
 #include <tree_sitter/api.h>
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
 #include "../shared/file_opener.h"
 #include "../shared/string_utils.h"
 #include "../shared/parse_result.h"
 #include "../shared/file_utils.h"
+#include "../shared/parse_errors.h"
 
 /* External PHP language function from tree-sitter-php */
 extern const TSLanguage *tree_sitter_php(void);
@@ -640,13 +642,13 @@ static void handle_simple_declaration(TSNode node, const char *source_code, cons
                                        int line, ContextType context) {
     char namespace_buf[SYMBOL_MAX_LENGTH];
     char location[128];
+    char symbol[SYMBOL_MAX_LENGTH] = "";
     get_namespace(node, source_code, namespace_buf, sizeof(namespace_buf), filename);
 
     uint32_t child_count = ts_node_child_count(node);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
         if (strcmp(ts_node_type(child), "name") == 0) {
-            char symbol[SYMBOL_MAX_LENGTH];
             safe_extract_node_text(source_code, child, symbol, sizeof(symbol), filename);
             if (filter_should_index(filter, symbol)) {
                 /* Extract source location for full declaration */
@@ -662,7 +664,9 @@ static void handle_simple_declaration(TSNode node, const char *source_code, cons
     /* Process declaration body (methods, properties, etc.) */
     TSNode body = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body)) {
+        push_scope(result, symbol);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
     }
 }
 
@@ -707,6 +711,14 @@ static void extract_visibility(TSNode node, const char *source_code, char *visib
     visibility[0] = '\0';
 }
 
+/**
+ * ExtColumns.exported value for a class member
+ * Members without a visibility modifier are public in PHP
+ */
+static const char *exported_from_visibility(const char *visibility) {
+    return (visibility[0] == '\0' || strcmp(visibility, "public") == 0) ? "1" : "0";
+}
+
 /**
  * Extract property name from property_element node
  * PHP properties have structure: property_element -> variable_name (includes $)
@@ -741,6 +753,7 @@ static void handle_class_declaration(TSNode node, const char *source_code, const
     /* Find class name, check for modifiers, and index implemented interfaces */
     uint32_t child_count = ts_node_child_count(node);
     bool found_class_name = false;
+    char symbol[SYMBOL_MAX_LENGTH] = "";
 
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
@@ -753,7 +766,6 @@ static void handle_class_declaration(TSNode node, const char *source_code, const
         } else if (strcmp(child_type, "readonly_modifier") == 0) {
             has_readonly = true;
         } else if (strcmp(child_type, "name") == 0 && !found_class_name) {
-            char symbol[SYMBOL_MAX_LENGTH];
             safe_extract_node_text(source_code, child, symbol, sizeof(symbol), filename);
 
             /* Build modifier string */
@@ -767,7 +779,7 @@ static void handle_class_declaration(TSNode node, const char *source_code, const
 
             if (filter_should_index(filter, symbol)) {
                 add_entry(result, symbol, line, CONTEXT_CLASS,
-                        directory, filename, NULL, &(ExtColumns){.namespace = namespace_buf, .modifier = modifier, .definition = "1"});
+                        directory, filename, NULL, &(ExtColumns){.namespace = namespace_buf, .modifier = modifier, .definition = "1", .exported = "1"});
             }
             found_class_name = true;
         } else if (strcmp(child_type, "class_interface_clause") == 0) {
@@ -796,7 +808,9 @@ static void handle_class_declaration(TSNode node, const char *source_code, const
     /* Process class body (methods, properties, constants, etc.) */
     TSNode body = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body)) {
+        push_scope(result, symbol);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
     }
 }
 
@@ -899,7 +913,7 @@ static void handle_formal_parameters(TSNode node, const char *source_code, const
                 if (filter_should_index(filter, property_name)) {
                     /* Promoted properties are indexed as PROPERTY_NAME with parent class */
                     add_entry(result, property_name, property_line, CONTEXT_PROPERTY,
-                            directory, filename, NULL, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = modifier, .type = param_type});
+                            directory, filename, NULL, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = modifier, .type = param_type, .exported = exported_from_visibility(visibility)});
                 }
             }
         }
@@ -966,10 +980,11 @@ static void handle_method_declaration(TSNode node, const char *source_code, cons
         format_source_location(node, location, sizeof(location));
 
         add_entry(result, method_name, line, CONTEXT_FUNCTION,
-                directory, filename, location, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = modifier, .type = type_str, .definition = "1"});
+                directory, filename, location, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = modifier, .type = type_str, .definition = "1", .exported = exported_from_visibility(visibility)});
     }
 
     /* Process method body to index local variables, strings, calls, etc. */
+    push_scope(result, has_name ? method_name : "");
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
         const char *child_type = ts_node_type(child);
@@ -979,6 +994,7 @@ static void handle_method_declaration(TSNode node, const char *source_code, cons
             break;
         }
     }
+    pop_scope(result);
 }
 
 static void handle_function_definition(TSNode node, const char *source_code, const char *directory,
@@ -991,11 +1007,11 @@ static void handle_function_definition(TSNode node, const char *source_code, con
     /* Extract return type from union_type node (appears after : token) */
     extract_type_from_union(node, source_code, type_str, sizeof(type_str), filename);
 
+    char symbol[SYMBOL_MAX_LENGTH] = "";
     uint32_t child_count = ts_node_child_count(node);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
         if (strcmp(ts_node_type(child), "name") == 0) {
-            char symbol[SYMBOL_MAX_LENGTH];
             char location[128];
             safe_extract_node_text(source_code, child, symbol, sizeof(symbol), filename);
             if (filter_should_index(filter, symbol)) {
@@ -1003,13 +1019,14 @@ static void handle_function_definition(TSNode node, const char *source_code, con
                 format_source_location(node, location, sizeof(location));
 
                 add_entry(result, symbol, line, CONTEXT_FUNCTION,
-                        directory, filename, location, &(ExtColumns){.namespace = namespace_buf, .type = type_str, .definition = "1"});
+                        directory, filename, location, &(ExtColumns){.namespace = namespace_buf, .type = type_str, .definition = "1", .exported = "1"});
             }
             break;
         }
     }
 
     /* Process function body to index local variables, strings, calls, etc. */
+    push_scope(result, symbol);
     for (uint32_t i = 0; i < child_count; i++) {
         TSNode child = ts_node_child(node, i);
         const char *child_type = ts_node_type(child);
@@ -1019,6 +1036,7 @@ static void handle_function_definition(TSNode node, const char *source_code, con
             break;
         }
     }
+    pop_scope(result);
 }
 
 static void handle_enum_declaration(TSNode node, const char *source_code, const char *directory,
@@ -1056,7 +1074,7 @@ static void handle_enum_case(TSNode node, const char *source_code, const char *d
     if (has_name && filter_should_index(filter, case_name)) {
         /* Enum cases belong to parent enum, no visibility modifier */
         add_entry(result, case_name, line, CONTEXT_ENUM_CASE,
-                directory, filename, NULL, &(ExtColumns){.parent = parent_enum, .namespace = namespace_buf, .definition = "1"});
+                directory, filename, NULL, &(ExtColumns){.parent = parent_enum, .namespace = namespace_buf, .definition = "1", .exported = "1"});
     }
 }
 
@@ -1121,7 +1139,7 @@ static void handle_property_declaration(TSNode node, const char *source_code, co
                 format_source_location(node, location, sizeof(location));
 
                 add_entry(result, property_name, line, CONTEXT_PROPERTY,
-                        directory, filename, location, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = modifier, .type = type_str, .definition = "1"});
+                        directory, filename, location, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = modifier, .type = type_str, .definition = "1", .exported = exported_from_visibility(visibility)});
             }
         }
     }
@@ -1165,7 +1183,7 @@ static void handle_const_declaration(TSNode node, const char *source_code, const
                         format_source_location(node, location, sizeof(location));
 
                         add_entry(result, const_name, line, CONTEXT_VARIABLE,
-                                directory, filename, location, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = "const", .definition = "1"});
+                                directory, filename, location, &(ExtColumns){.parent = parent_name, .scope = visibility, .namespace = namespace_buf, .modifier = "const", .definition = "1", .exported = exported_from_visibility(visibility)});
                     }
                     break;
                 }
@@ -2045,37 +2063,12 @@ int parser_init(PHPParser *parser, SymbolFilter *filter) {
 }
 
 int parser_parse_file(PHPParser *parser, const char *filepath, const char *project_root, ParseResult *result) {
-    FILE *fp = safe_fopen(filepath, "rb", 0);  /* binary mode for accurate byte count */
-    if (!fp) {
-        fprintf(stderr, "Cannot open file: %s\n", filepath);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-
-    /* Get file size using fstat */
-    int fd = fileno(fp);
-    struct stat st;
-    if (fstat(fd, &st) != 0) {
-        fclose(fp);
-        return -1;
-    }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) {
-        fclose(fp);
-        return -1;
-    }
-
-    size_t bytes_read = fread(source_code, 1, file_size, fp);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "Error reading file: %s (expected %zu bytes, got %zu)\n",
-                filepath, file_size, bytes_read);
-        free(source_code);
-        fclose(fp);
-        return -1;
-    }
-    source_code[bytes_read] = '\0';
-    fclose(fp);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     result->count = 0;
 
@@ -2097,8 +2090,8 @@ int parser_parse_file(PHPParser *parser, const char *filepath, const char *proje
     TSParser *ts_parser = ts_parser_new();
     const TSLanguage *language = tree_sitter_php();
     if (!ts_parser_set_language(ts_parser, language)) {
-        fprintf(stderr, "ERROR: Failed to set PHP language\n");
-        free(source_code);
+        parse_error_set("failed to load the tree-sitter grammar");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
@@ -2106,23 +2099,29 @@ int parser_parse_file(PHPParser *parser, const char *filepath, const char *proje
     /* Initialize symbol lookup table */
     init_php_symbols(language);
 
-    TSTree *tree = ts_parser_parse_string(ts_parser, NULL, source_code, (uint32_t)file_size);
+    TSTree *tree = ts_parser_parse(ts_parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "ERROR: Failed to parse file: %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, ts_parser);
+
     TSNode root_node = ts_tree_root_node(tree);
 
     /* Visit all nodes */
     visit_node(root_node, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_SLASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
 
     /* Cleanup */
     ts_tree_delete(tree);
     ts_parser_delete(ts_parser);
-    free(source_code);
+    source_file_close(&source);
 
     return 0;
 }
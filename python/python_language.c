@@ -19,9 +19,11 @@
 #include "../shared/constants.h"
 #include "../shared/string_utils.h"
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
 #include "../shared/file_opener.h"
 #include "../shared/file_utils.h"
 #include "../shared/filter.h"
+#include "../shared/parse_errors.h"
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
@@ -29,7 +31,7 @@
 #include <sys/stat.h>
 
 /* Declare the tree-sitter Python language */
-TSLanguage *tree_sitter_python(void);
+const TSLanguage *tree_sitter_python(void);
 
 /* Global debug flag */
 static int g_debug = 0;
@@ -290,6 +292,30 @@ static void extract_parameters(TSNode parameters_node, const char *source_code,
     }
 }
 
+/* Helper: Check for the _internal naming convention
+ * Python has no visibility keywords: a leading underscore marks a name as
+ * internal, dunder names like __init__ excepted */
+static int is_internal_name(const char *name) {
+    size_t len = strlen(name);
+    int dunder = len > 4 && strncmp(name, "__", 2) == 0 && strcmp(name + len - 2, "__") == 0;
+    return name[0] == '_' && !dunder;
+}
+
+/* Helper: ExtColumns.exported value for a definition
+ * Names bound inside a function body are never exported */
+static const char *get_exported(TSNode node, const char *name) {
+    if (is_internal_name(name)) {
+        return "0";
+    }
+    for (TSNode parent = ts_node_parent(node); !ts_node_is_null(parent); parent = ts_node_parent(parent)) {
+        const char *type = ts_node_type(parent);
+        if (strcmp(type, "function_definition") == 0 || strcmp(type, "lambda") == 0) {
+            return "0";
+        }
+    }
+    return "1";
+}
+
 /* Handle function definition */
 static void handle_function_definition(TSNode node, const char *source_code,
                                       const char *directory, const char *filename,
@@ -335,9 +361,11 @@ static void handle_function_definition(TSNode node, const char *source_code,
     add_entry(result, function_name, line,
                          CONTEXT_FUNCTION, directory, filename, location,
                          &(ExtColumns){.type = return_type, .definition = "1", .modifier = modifier,
-                                      .clue = decorators[0] ? decorators : NULL});
+                                      .clue = decorators[0] ? decorators : NULL,
+                                      .exported = get_exported(node, function_name)});
 
     /* Extract parameters */
+    push_scope(result, function_name);
     TSNode params_node = ts_node_child_by_field_name(node, "parameters", 10);
     if (!ts_node_is_null(params_node)) {
         extract_parameters(params_node, source_code, directory, filename,
@@ -349,6 +377,7 @@ static void handle_function_definition(TSNode node, const char *source_code,
     if (!ts_node_is_null(body_node)) {
         process_children(body_node, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
 }
 
 /* Handle class definition */
@@ -396,13 +425,16 @@ static void handle_class_definition(TSNode node, const char *source_code,
                          &(ExtColumns){
                              .definition = "1",
                              .parent = parent_class[0] ? parent_class : NULL,
-                             .clue = decorators[0] ? decorators : NULL
+                             .clue = decorators[0] ? decorators : NULL,
+                             .exported = get_exported(node, class_name)
                          });
 
     /* Process class body */
     TSNode body_node = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body_node)) {
+        push_scope(result, class_name);
         process_children(body_node, source_code, directory, filename, result, filter);
+        pop_scope(result);
     }
 }
 
@@ -441,7 +473,8 @@ static void handle_assignment(TSNode node, const char *source_code,
 
         add_entry(result, var_name, line,
                              CONTEXT_VARIABLE, directory, filename, location,
-                             &(ExtColumns){.type = type_str, .definition = "1"});
+                             &(ExtColumns){.type = type_str, .definition = "1",
+                                           .exported = get_exported(node, var_name)});
     }
     /* Handle tuple unpacking (a, b = 1, 2) */
     else if (strcmp(left_type, "pattern_list") == 0) {
@@ -465,7 +498,7 @@ static void handle_assignment(TSNode node, const char *source_code,
 
                 add_entry(result, var_name, line,
                                      CONTEXT_VARIABLE, directory, filename, location,
-                                     &(ExtColumns){.definition = "1"});
+                                     &(ExtColumns){.definition = "1", .exported = get_exported(node, var_name)});
             }
         }
     }
@@ -489,7 +522,8 @@ static void handle_assignment(TSNode node, const char *source_code,
 
             add_entry(result, attr_name, line,
                                  CONTEXT_PROPERTY, directory, filename, location,
-                                 &(ExtColumns){.parent = parent_obj, .definition = "1"});
+                                 &(ExtColumns){.parent = parent_obj, .definition = "1",
+                                               .exported = is_internal_name(attr_name) ? "0" : "1"});
         }
     }
 
@@ -515,6 +549,7 @@ static void handle_import_statement(TSNode node, const char *source_code,
         if (child_sym == python_symbols.dotted_name) {
             char import_name[SYMBOL_MAX_LENGTH];
             safe_extract_node_text(source_code, child, import_name, sizeof(import_name), filename);
+            add_import(result, import_name, line);
 
             add_entry(result, import_name, line,
                                  CONTEXT_IMPORT, directory, filename, NULL,
@@ -528,6 +563,7 @@ static void handle_import_statement(TSNode node, const char *source_code,
             if (!ts_node_is_null(dotted_node)) {
                 safe_extract_node_text(source_code, dotted_node, module_name, sizeof(module_name), filename);
             }
+            add_import(result, module_name, line);
 
             /* Extract the alias identifier */
             TSNode alias_node = ts_node_child_by_field_name(child, "alias", 5);
@@ -549,9 +585,13 @@ static void handle_import_from_statement(TSNode node, const char *source_code,
                                         ParseResult *result, SymbolFilter *filter,
                                         int line) {
     (void)filter;  /* Imports are always indexed regardless of filter */
-    /* Get the module being imported from */
+    /* The module imported from ("os.path", or relative: "..models") */
     TSNode module_node = ts_node_child_by_field_name(node, "module_name", 11);
-    (void)module_node;  /* Reserved for future use tracking import sources */
+    if (!ts_node_is_null(module_node)) {
+        char module_name[IMPORT_PATH_MAX_LENGTH];
+        safe_extract_node_text(source_code, module_node, module_name, sizeof(module_name), filename);
+        add_import(result, module_name, line);
+    }
 
     /* Process imported names */
     uint32_t child_count = ts_node_child_count(node);
@@ -1575,7 +1615,7 @@ static void process_children(TSNode node, const char *source_code, const char *d
 }
 
 /* Initialize Python symbol table for fast comparisons */
-static void init_python_symbols(TSLanguage *language) {
+static void init_python_symbols(const TSLanguage *language) {
     static int initialized = 0;
     if (initialized) return;
     initialized = 1;
@@ -1631,7 +1671,7 @@ int parser_init(PythonParser *parser, SymbolFilter *filter) {
         return -1;
     }
 
-    TSLanguage *language = tree_sitter_python();
+    const TSLanguage *language = tree_sitter_python();
     if (!ts_parser_set_language(parser->parser, language)) {
         ts_parser_delete(parser->parser);
         return -1;
@@ -1654,48 +1694,27 @@ void parser_set_debug(PythonParser *parser, int debug) {
 
 /* Parse a Python file */
 int parser_parse_file(PythonParser *parser, const char *filepath, const char *project_root, ParseResult *result) {
-    FILE *fp = safe_fopen(filepath, "rb", 0);  /* binary mode for accurate byte count */
-    if (!fp) {
-        fprintf(stderr, "Cannot open file: %s\n", filepath);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-
-    /* Get file size using fstat */
-    int fd = fileno(fp);
-    struct stat st;
-    if (fstat(fd, &st) != 0) {
-        fclose(fp);
-        return -1;
-    }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) {
-        fclose(fp);
-        return -1;
-    }
-
-    size_t bytes_read = fread(source_code, 1, file_size, fp);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "Error reading file: %s (expected %zu bytes, got %zu)\n",
-                filepath, file_size, bytes_read);
-        free(source_code);
-        fclose(fp);
-        return -1;
-    }
-    source_code[bytes_read] = '\0';
-    fclose(fp);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     result->count = 0;
+    result->import_count = 0;
 
     /* Parse the source code */
-    TSTree *tree = ts_parser_parse_string(parser->parser, NULL, source_code, (uint32_t)bytes_read);
+    TSTree *tree = ts_parser_parse(parser->parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "Failed to parse file: %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, NULL);
+
     TSNode root = ts_tree_root_node(tree);
 
     /* Get directory and filename */
@@ -1715,10 +1734,13 @@ int parser_parse_file(PythonParser *parser, const char *filepath, const char *pr
 
     /* Visit the AST */
     visit_node(root, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_HASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
 
     /* Cleanup */
     ts_tree_delete(tree);
-    free(source_code);
+    source_file_close(&source);
 
     return 0;
 }
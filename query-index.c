@@ -55,6 +55,9 @@ static char *strndup(const char *s, size_t n) {
 #include "shared/toc.h"
 #include "shared/version.h"
 #include "shared/sql_builder.h"
+#if ENABLED(GO)
+#include "shared/signature.h"
+#endif
 
 typedef struct {
     ContextType types[MAX_CONTEXT_TYPES];
@@ -64,6 +67,7 @@ typedef struct {
 typedef struct {
     char *patterns[MAX_PATTERNS];
     int count;
+    int subword;  /* --subword: patterns also match identifier tokens */
 } PatternList;
 
 /* Generic string list for extensible column filters */
@@ -92,6 +96,16 @@ typedef struct {
     /* Line range filter */
     int line_start;  /* -1 = not set */
     int line_end;    /* -1 = not set */
+#if ENABLED(GO)
+    /* Struct tag filters: "key" or "key:value" (--tag), "key" (--no-tag) */
+    StringList tag_present;
+    StringList tag_absent;
+    /* Signature filters: an element type of params (--param-type) or returns (--return-type) */
+    StringList param_types;
+    StringList return_types;
+    /* Generic filter: a type parameter constrained by TYPE (--constraint) */
+    StringList constraints;
+#endif
     /* X-Macro: Extensible filterable column filters */
 #define COLUMN(name, ...) StringList name;
 #define INT_COLUMN(name, ...) StringList name;
@@ -184,8 +198,10 @@ static const char* display_context(const char *context_type, int compact) {
     return context_to_string(type, 0);
 }
 
-/* Flag presence bits */
-typedef enum {
+/* Flag presence bits (a 64-bit mask: one bit per column is more than an int holds) */
+typedef unsigned long long CliFlags;
+
+enum {
     FLAG_COLUMNS = 1 << 0,
     FLAG_VERBOSE = 1 << 1,
     FLAG_LIMIT   = 1 << 2,
@@ -199,20 +215,25 @@ typedef enum {
     FLAG_CONTEXT_BOTH   = 1 << 10,
     FLAG_FILES_ONLY = 1 << 11,
     FLAG_DB_FILE = 1 << 12,
-    /* X-Macro: Generate FLAG bits for extensible columns */
+};
+
+/* X-Macro: Generate bit positions for extensible columns, after the flags above */
+enum {
 #define COLUMN(name, sql_type, c_type, width, full, compact, long_flag, short_flag, ...) \
-    FLAG_##long_flag = 1 << (__COUNTER__ + 13),
+    FLAG_BIT_##long_flag = __COUNTER__ + 13,
 #define INT_COLUMN(name, sql_type, c_type, width, full, compact, long_flag, short_flag, ...) \
-    FLAG_##long_flag = 1 << (__COUNTER__ + 13),
+    FLAG_BIT_##long_flag = __COUNTER__ + 13,
 #include "shared/column_schema.def"
 #undef COLUMN
 #undef INT_COLUMN
-    FLAG_LAST  /* Sentinel */
-} CliFlags;
+    FLAG_BIT_LAST  /* Sentinel */
+};
+_Static_assert(FLAG_BIT_LAST <= 64, "too many columns for the CliFlags mask");
+#define COLUMN_FLAG(long_flag) (1ULL << FLAG_BIT_##long_flag)
 
 /* Check which flags are present in CLI args */
-static int scan_cli_flags(int argc, char *argv[]) {
-    int flags = 0;
+static CliFlags scan_cli_flags(int argc, char *argv[]) {
+    CliFlags flags = 0;
     for (int i = 1; i < argc; i++) {
         if (strcmp(argv[i], "--columns") == 0) flags |= FLAG_COLUMNS;
         else if (strcmp(argv[i], "-v") == 0 || strcmp(argv[i], "--verbose") == 0) flags |= FLAG_VERBOSE;
@@ -229,9 +250,9 @@ static int scan_cli_flags(int argc, char *argv[]) {
         else if (strcmp(argv[i], "-C") == 0) flags |= FLAG_CONTEXT_BOTH;
         /* X-Macro: Check for extensible column flags */
 #define COLUMN(name, sql_type, c_type, width, full, compact, long_flag, short_flag, ...) \
-        else if (strcmp(argv[i], "--" #long_flag) == 0 || strcmp(argv[i], "-" #short_flag) == 0) flags |= FLAG_##long_flag;
+        else if (strcmp(argv[i], "--" #long_flag) == 0 || strcmp(argv[i], "-" #short_flag) == 0) flags |= COLUMN_FLAG(long_flag);
 #define INT_COLUMN(name, sql_type, c_type, width, full, compact, long_flag, short_flag, ...) \
-        else if (strcmp(argv[i], "--" #long_flag) == 0 || strcmp(argv[i], "-" #short_flag) == 0) flags |= FLAG_##long_flag;
+        else if (strcmp(argv[i], "--" #long_flag) == 0 || strcmp(argv[i], "-" #short_flag) == 0) flags |= COLUMN_FLAG(long_flag);
 #include "shared/column_schema.def"
 #undef COLUMN
 #undef INT_COLUMN
@@ -240,7 +261,7 @@ static int scan_cli_flags(int argc, char *argv[]) {
 }
 
 /* Check if config line should be skipped based on CLI flags */
-static int should_skip_config_line(const char *line, int cli_flags) {
+static int should_skip_config_line(const char *line, CliFlags cli_flags) {
     if ((cli_flags & FLAG_COLUMNS) && strstr(line, "--columns") == line) return 1;
     if ((cli_flags & FLAG_VERBOSE) && (strstr(line, "-v") == line || strstr(line, "--verbose") == line)) return 1;
     if ((cli_flags & FLAG_LIMIT) && strstr(line, "--limit") == line) return 1;
@@ -256,9 +277,9 @@ static int should_skip_config_line(const char *line, int cli_flags) {
     if ((cli_flags & FLAG_CONTEXT_BOTH) && strstr(line, "-C") == line) return 1;
     /* X-Macro: Skip config lines for extensible column flags */
 #define COLUMN(name, sql_type, c_type, width, full, compact, long_flag, short_flag, ...) \
-    if ((cli_flags & FLAG_##long_flag) && (strstr(line, "-" #short_flag) == line || strstr(line, "--" #long_flag) == line)) return 1;
+    if ((cli_flags & COLUMN_FLAG(long_flag)) && (strstr(line, "-" #short_flag) == line || strstr(line, "--" #long_flag) == line)) return 1;
 #define INT_COLUMN(name, sql_type, c_type, width, full, compact, long_flag, short_flag, ...) \
-    if ((cli_flags & FLAG_##long_flag) && (strstr(line, "-" #short_flag) == line || strstr(line, "--" #long_flag) == line)) return 1;
+    if ((cli_flags & COLUMN_FLAG(long_flag)) && (strstr(line, "-" #short_flag) == line || strstr(line, "--" #long_flag) == line)) return 1;
 #include "shared/column_schema.def"
 #undef COLUMN
 #undef INT_COLUMN
@@ -266,7 +287,7 @@ static int should_skip_config_line(const char *line, int cli_flags) {
 }
 
 /* Load config file from ~/.smconfig and prepend args to argv */
-static int load_config_file(int *argc_ptr, char ***argv_ptr, int cli_flags) {
+static int load_config_file(int *argc_ptr, char ***argv_ptr, CliFlags cli_flags) {
     /* Security note: We trust HOME environment variable for config file location.
      * If an attacker can set HOME, they can already execute arbitrary code in this
      * process context. Config file is optional and only affects query defaults. */
@@ -560,8 +581,9 @@ static ColumnSpec column_registry[] = {
     {NULL, NULL, NULL, 0, 0, NULL}  /* sentinel */
 };
 
-/* Active columns - what will be displayed */
-static ActiveColumn active_columns[MAX_CONTEXT_TYPES];
+/* Active columns - what will be displayed (at most every registered column) */
+#define MAX_ACTIVE_COLUMNS ((int)(sizeof(column_registry) / sizeof(column_registry[0])))
+static ActiveColumn active_columns[MAX_ACTIVE_COLUMNS];
 static int num_active_columns = 0;
 
 /* Find column by name (supports aliases from compact column names) */
@@ -598,12 +620,12 @@ static ColumnSpec* find_column_by_name(const char *name) {
 static int add_column_by_name(const char *name) {
     ColumnSpec *spec = find_column_by_name(name);
     if (spec) {
-        if (num_active_columns < MAX_CONTEXT_TYPES) {
+        if (num_active_columns < MAX_ACTIVE_COLUMNS) {
             active_columns[num_active_columns++] = (ActiveColumn){spec, 1};
             return 1;
         }
     } else {
-        fprintf(stderr, "Warning: unknown column '%s' (available: line, context, parent, scope, modifier, clue, namespace, type, definition, symbol)\n", name);
+        fprintf(stderr, "Warning: unknown column '%s' (available: line, context, parent, scope, modifier, clue, namespace, type, language, doc, definition, exported, symbol)\n", name);
     }
     return 0;
 }
@@ -697,6 +719,15 @@ static void print_table_row(RowData *data, int compact) {
             if (strcmp(spec->name, "context") == 0 && str) {
                 str = display_context(str, compact);
             }
+            /* Multi-line values (doc comments) stay on one table row */
+            char one_line[DOC_MAX_LENGTH];
+            if (str && strchr(str, '\n')) {
+                snprintf(one_line, sizeof(one_line), "%s", str);
+                for (char *p = one_line; *p; p++) {
+                    if (*p == '\n') *p = ' ';
+                }
+                str = one_line;
+            }
             if (spec->width > 0) {
                 printf("%-*s", spec->width, str ? str : "");
             } else {
@@ -865,6 +896,30 @@ static int process_file_pattern(const char *input, char **dir_out, char **file_o
 /* Helper function to build common filter clauses (file, context type, extensible columns)
  * Returns: 0 on success, -1 on error (buffer overflow or allocation failure)
  */
+#if ENABLED(GO)
+/* SQL function signature_has_type(list, pattern): 1 if an element of a
+ * params/returns list has a type matching the LIKE pattern ('\' escapes) */
+static void sql_signature_has_type(sqlite3_context *ctx, int argc, sqlite3_value **argv) {
+    (void)argc;
+    const char *list = (const char *)sqlite3_value_text(argv[0]);
+    const char *pattern = (const char *)sqlite3_value_text(argv[1]);
+    if (!list || !pattern) {
+        sqlite3_result_int(ctx, 0);
+        return;
+    }
+
+    SignatureParam params[MAX_SIGNATURE_PARAMS];
+    int count = parse_signature_list(list, params, MAX_SIGNATURE_PARAMS);
+    for (int i = 0; i < count; i++) {
+        if (sqlite3_strlike(pattern, params[i].type, '\\') == 0) {
+            sqlite3_result_int(ctx, 1);
+            return;
+        }
+    }
+    sqlite3_result_int(ctx, 0);
+}
+#endif
+
 static int build_common_filters(SqlQueryBuilder *builder,
                                   ContextTypeList *include, ContextTypeList *exclude,
                                   QueryFilters *filters, FileFilterList *file_filter,
@@ -910,6 +965,65 @@ static int build_common_filters(SqlQueryBuilder *builder,
         }
     }
 
+#if ENABLED(GO)
+    /* Struct tag filters (AND semantics). Tags are stored as key:"value"
+     * pairs separated by spaces; padding with spaces lets every pair be
+     * matched as ' key:"' without also matching keys that end in key. */
+    if (filters && (filters->tag_present.count > 0 || filters->tag_absent.count > 0)) {
+        for (int i = 0; i < filters->tag_present.count + filters->tag_absent.count; i++) {
+            int absent = (i >= filters->tag_present.count);
+            const char *arg = absent ? filters->tag_absent.values[i - filters->tag_present.count]
+                                     : filters->tag_present.values[i];
+
+            /* Key is literal (escape LIKE wildcards); value supports * and . wildcards */
+            char like[SYMBOL_MAX_LENGTH];
+            size_t j = 0;
+            like[j++] = '%';
+            like[j++] = ' ';
+            const char *p = arg;
+            for (; *p && *p != ':' && j < sizeof(like) - 4; p++) {
+                if (*p == '%' || *p == '_' || *p == '\\') like[j++] = '\\';
+                like[j++] = *p;
+            }
+            like[j++] = ':';
+            like[j++] = '"';
+            like[j] = '\0';
+            char value[SYMBOL_MAX_LENGTH] = "";
+            int has_value = (*p == ':' && !absent);
+            if (has_value) {
+                convert_wildcards(p + 1, value, sizeof(value));
+            }
+
+            char *escaped = sqlite3_mprintf(has_value ? "%q%q\" %%" : "%q%q%%", like, value);
+            if (!escaped) return -1;
+            int ret = sql_append(builder, " AND (' ' || COALESCE(tags, '') || ' ') %s '%s' ESCAPE '\\'",
+                                 absent ? "NOT LIKE" : "LIKE", escaped);
+            sqlite3_free(escaped);
+            if (ret != 0) return -1;
+        }
+    }
+
+    /* Signature filters (AND semantics): some element of the list has a
+     * matching type, see signature_has_type(). A type parameter's "type" is
+     * its constraint. */
+    if (filters) {
+        const StringList *signature_lists[] = {&filters->param_types, &filters->return_types, &filters->constraints};
+        const char *signature_columns[] = {"params", "returns", "type_params"};
+        for (int l = 0; l < 3; l++) {
+            for (int i = 0; i < signature_lists[l]->count; i++) {
+                char pattern[SYMBOL_MAX_LENGTH];
+                convert_wildcards(signature_lists[l]->values[i], pattern, sizeof(pattern));
+
+                char *escaped = sqlite3_mprintf("%q", pattern);
+                int ret = sql_append(builder, " AND signature_has_type(%s, '%s')",
+                                     signature_columns[l], escaped);
+                sqlite3_free(escaped);
+                if (ret != 0) return -1;
+            }
+        }
+    }
+#endif
+
     /* Add within filter - restrict to specific file/line ranges */
     if (within_ranges && within_ranges->count > 0) {
 
@@ -990,6 +1104,31 @@ static int build_common_filters(SqlQueryBuilder *builder,
     return 0;
 }
 
+/* Match pattern i against the symbol and, with --subword, against each of
+ * its tokens. Bound patterns use parameter i + 1; others are inlined */
+static int append_pattern_match(SqlQueryBuilder *builder, PatternList *patterns, int i, int bound) {
+    if (bound) {
+        if (!patterns->subword) {
+            return sql_append(builder, "symbol LIKE ? ESCAPE '\\'");
+        }
+        return sql_append(builder,
+            "(symbol LIKE ?%d ESCAPE '\\' OR ' ' || tokens || ' ' LIKE '%% ' || ?%d || ' %%' ESCAPE '\\')",
+            i + 1, i + 1);
+    }
+
+    char *escaped_pattern = sqlite3_mprintf("%q", patterns->patterns[i]);
+    int ret;
+    if (!patterns->subword) {
+        ret = sql_append(builder, "symbol LIKE '%s' ESCAPE '\\'", escaped_pattern);
+    } else {
+        ret = sql_append(builder,
+            "(symbol LIKE '%s' ESCAPE '\\' OR ' ' || tokens || ' ' LIKE '%% %s %%' ESCAPE '\\')",
+            escaped_pattern, escaped_pattern);
+    }
+    sqlite3_free(escaped_pattern);
+    return ret;
+}
+
 static int build_query_filters(SqlQueryBuilder *builder, PatternList *patterns,
                                ContextTypeList *include, ContextTypeList *exclude, QueryFilters *filters, FileFilterList *file_filter,
                                WithinRangeList *within_ranges, int line_range, int debug) {
@@ -1003,12 +1142,8 @@ static int build_query_filters(SqlQueryBuilder *builder, PatternList *patterns,
                 if (sql_append(builder, " INTERSECT ") != 0) return -1;
             }
 
-            char *escaped_pattern = sqlite3_mprintf("%q", patterns->patterns[i]);
-            int ret = sql_append(builder,
-                "SELECT directory, filename, line FROM code_index WHERE symbol LIKE '%s' ESCAPE '\\'",
-                escaped_pattern);
-            sqlite3_free(escaped_pattern);
-            if (ret != 0) return -1;
+            if (sql_append(builder, "SELECT directory, filename, line FROM code_index WHERE ") != 0) return -1;
+            if (append_pattern_match(builder, patterns, i, 0) != 0) return -1;
 
             /* Add all filters to each INTERSECT subquery */
             if (build_common_filters(builder, include, exclude, filters, file_filter, within_ranges, debug) != 0) return -1;
@@ -1021,10 +1156,7 @@ static int build_query_filters(SqlQueryBuilder *builder, PatternList *patterns,
             if (i > 0) {
                 if (sql_append(builder, " OR ") != 0) return -1;
             }
-            char *escaped_pattern = sqlite3_mprintf("%q", patterns->patterns[i]);
-            int ret = sql_append(builder, "symbol LIKE '%s' ESCAPE '\\'", escaped_pattern);
-            sqlite3_free(escaped_pattern);
-            if (ret != 0) return -1;
+            if (append_pattern_match(builder, patterns, i, 0) != 0) return -1;
         }
 
         if (sql_append(builder, "))") != 0) return -1;  /* Close symbol filter and WHERE clause */
@@ -1034,7 +1166,9 @@ static int build_query_filters(SqlQueryBuilder *builder, PatternList *patterns,
             if (i > 0) {
                 if (sql_append(builder, " OR ") != 0) return -1;
             }
-            if (sql_append(builder, "(symbol LIKE ? ESCAPE '\\')") != 0) return -1;
+            if (sql_append(builder, "(") != 0) return -1;
+            if (append_pattern_match(builder, patterns, i, 1) != 0) return -1;
+            if (sql_append(builder, ")") != 0) return -1;
         }
         if (sql_append(builder, ")") != 0) return -1;
 
@@ -1054,6 +1188,16 @@ static const char *width_columns =
 #undef COLUMN
 #undef INT_COLUMN
 
+/* Number of extensible columns, for sizing the width array */
+enum {
+#define COLUMN(name, ...) EXT_WIDTH_##name,
+#define INT_COLUMN(name, ...) EXT_WIDTH_##name,
+#include "shared/column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+    EXT_WIDTH_COUNT
+};
+
 static int build_width_query(SqlQueryBuilder *builder, PatternList *patterns,
                               ContextTypeList *include, ContextTypeList *exclude, QueryFilters *filters, FileFilterList *file_filter,
                               WithinRangeList *within_ranges, int limit, int line_range, int debug) {
@@ -1118,7 +1262,7 @@ static void update_column_widths(sqlite3_stmt *stmt, int compact) {
     int max_context = sqlite3_column_int(stmt, col_idx++);
 
     /* X-Macro: Build array of extensible column max widths */
-    int extensible_widths[10] = {0};  /* max extensible columns */
+    int extensible_widths[EXT_WIDTH_COUNT] = {0};
     int ext_idx = 0;
 #define COLUMN(name, ...) \
     extensible_widths[ext_idx++] = sqlite3_column_int(stmt, col_idx++);
@@ -2430,11 +2574,13 @@ static void print_context_types(void) {
     printf("Context Types (use full or abbreviated forms, case insensitive):\n");
     printf("  %-12s %-9s %s\n", "Full Name", "Short", "Description");
     printf("  %-12s %-9s %s\n", "------------", "-----", "-----------");
+    printf("  %-12s %-9s %s\n", "alias", "-", "Type aliases, e.g. type A = B (Go; -t filters the target)");
     printf("  %-12s %-9s %s\n", "argument", "arg", "Function parameters");
     printf("  %-12s %-9s %s\n", "call", "-", "Function/method calls");
     printf("  %-12s %-9s %s\n", "case", "-", "Enum values/cases");
     printf("  %-12s %-9s %s\n", "class", "-", "Class definitions");
     printf("  %-12s %-9s %s\n", "comment", "com", "Words from comments");
+    printf("  %-12s %-9s %s\n", "custom", "-", "Matches of extractors.txt patterns (-c filters the kind)");
     printf("  %-12s %-9s %s\n", "enum", "-", "Enum type definitions");
     printf("  %-12s %-9s %s\n", "exception", "exc", "Exception classes");
     printf("  %-12s %-9s %s\n", "export", "exp", "Export statements");
@@ -2502,7 +2648,7 @@ int main(int argc, char *argv[]) {
 
     /* Load config file only if not showing help (CLI flags override config) */
     if (!show_help) {
-        int cli_flags = scan_cli_flags(argc, argv);
+        CliFlags cli_flags = scan_cli_flags(argc, argv);
         if (load_config_file(&argc, &argv, cli_flags) != 0) {
             retval = 1;
             goto cleanup;
@@ -2576,6 +2722,21 @@ int main(int argc, char *argv[]) {
 #undef INT_COLUMN
         printf("      --def                      show only definitions (alias for -d 1)\n");
         printf("      --usage                    show only usages (alias for -d 0)\n");
+        printf("      --exported-only            show only exported symbols, the public API (alias for -ex 1)\n");
+        printf("      --subword                  patterns also match identifier tokens\n");
+        printf("                                 qi closer --subword  (ReadCloser, closer_test, ...)\n");
+#if ENABLED(GO)
+        printf("      --tag KEY[:VALUE]...       fields whose struct tag has KEY (with VALUE, wildcards allowed)\n");
+        printf("                                 qi '*' -i prop --tag json:-  (fields tagged json:\"-\")\n");
+        printf("      --no-tag KEY...            fields whose struct tag lacks KEY\n");
+        printf("                                 qi '*' -i prop --no-tag db  (fields missing a db tag)\n");
+        printf("      --param-type TYPE...       functions taking a parameter of TYPE (wildcards allowed)\n");
+        printf("                                 qi '*' -i func --param-type io.Reader\n");
+        printf("      --return-type TYPE...      functions returning TYPE among their results\n");
+        printf("                                 qi '*' -i func --return-type error\n");
+        printf("      --constraint TYPE...       generic types and functions with a type parameter constrained by TYPE\n");
+        printf("                                 qi '*' -i type --constraint comparable\n");
+#endif
         printf("      --lines LINE               filter by single line number\n");
         printf("      --lines START-END          filter by line range (inclusive)\n");
         printf("  -w, --within SYMBOL [...]      filter by symbol definition (scoped search)\n");
@@ -2995,6 +3156,71 @@ int main(int argc, char *argv[]) {
                 filters.is_definition.count++;
             }
         }
+        else if (strcmp(argv[i], "--subword") == 0) {
+            patterns.subword = 1;
+        }
+        /* Convenience alias for is_exported filter */
+        else if (strcmp(argv[i], "--exported-only") == 0) {
+            show_columns.is_exported = 1;
+            if (filters.is_exported.count < MAX_CONTEXT_TYPES) {
+                filters.is_exported.values[filters.is_exported.count] = try_strdup_ctx("1", "Failed to allocate memory for exported filter");
+                if (!filters.is_exported.values[filters.is_exported.count]) {
+                    retval = 1;
+                    goto cleanup;
+                }
+                filters.is_exported.count++;
+            }
+        }
+#if ENABLED(GO)
+        else if (strcmp(argv[i], "--tag") == 0 || strcmp(argv[i], "--no-tag") == 0) {
+            int absent = (strcmp(argv[i], "--no-tag") == 0);
+            StringList *list = absent ? &filters.tag_absent : &filters.tag_present;
+            show_columns.tags = 1;
+            while (i + 1 < argc && argv[i + 1][0] != '-') {
+                if (list->count < MAX_CONTEXT_TYPES) {
+                    list->values[list->count] = try_strdup_ctx(argv[i + 1], "Failed to allocate memory for tag filter");
+                    if (!list->values[list->count]) {
+                        retval = 1;
+                        goto cleanup;
+                    }
+                    list->count++;
+                } else {
+                    fprintf(stderr, "Warning: Maximum filter limit (%d) reached for %s. Ignoring: %s\n",
+                            MAX_CONTEXT_TYPES, absent ? "--no-tag" : "--tag", argv[i + 1]);
+                }
+                i++;
+            }
+        }
+        else if (strcmp(argv[i], "--param-type") == 0 || strcmp(argv[i], "--return-type") == 0 ||
+                 strcmp(argv[i], "--constraint") == 0) {
+            const char *flag = argv[i];
+            StringList *list;
+            if (strcmp(flag, "--return-type") == 0) {
+                list = &filters.return_types;
+                show_columns.returns = 1;
+            } else if (strcmp(flag, "--constraint") == 0) {
+                list = &filters.constraints;
+                show_columns.type_params = 1;
+            } else {
+                list = &filters.param_types;
+                show_columns.params = 1;
+            }
+            while (i + 1 < argc && argv[i + 1][0] != '-') {
+                if (list->count < MAX_CONTEXT_TYPES) {
+                    list->values[list->count] = try_strdup_ctx(argv[i + 1], "Failed to allocate memory for signature filter");
+                    if (!list->values[list->count]) {
+                        retval = 1;
+                        goto cleanup;
+                    }
+                    list->count++;
+                } else {
+                    fprintf(stderr, "Warning: Maximum filter limit (%d) reached for %s. Ignoring: %s\n",
+                            MAX_CONTEXT_TYPES, flag, argv[i + 1]);
+                }
+                i++;
+            }
+        }
+#endif
         else if (strcmp(argv[i], "--columns") == 0) {
             has_custom_columns = 1;
             num_active_columns = 0;  /* Reset before adding custom columns */
@@ -3421,6 +3647,15 @@ int main(int argc, char *argv[]) {
         goto cleanup;
     }
 
+#if ENABLED(GO)
+    if (sqlite3_create_function(db.db, "signature_has_type", 2, SQLITE_UTF8 | SQLITE_DETERMINISTIC,
+                                NULL, sql_signature_has_type, NULL, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Failed to register signature_has_type: %s\n", sqlite3_errmsg(db.db));
+        retval = 1;
+        goto cleanup;
+    }
+#endif
+
     /* Load known file extensions for validation */
     FileExtensions known_extensions;
     if (load_all_language_extensions(&known_extensions) != 0) {
@@ -3558,6 +3793,24 @@ cleanup:
         }
     }
 
+#if ENABLED(GO)
+    for (int j = 0; j < filters.tag_present.count; j++) {
+        free(filters.tag_present.values[j]);
+    }
+    for (int j = 0; j < filters.tag_absent.count; j++) {
+        free(filters.tag_absent.values[j]);
+    }
+    for (int j = 0; j < filters.param_types.count; j++) {
+        free(filters.param_types.values[j]);
+    }
+    for (int j = 0; j < filters.return_types.count; j++) {
+        free(filters.return_types.values[j]);
+    }
+    for (int j = 0; j < filters.constraints.count; j++) {
+        free(filters.constraints.values[j]);
+    }
+#endif
+
     /* X-Macro: Free allocated filter values */
 #define COLUMN(name, ...) \
     for (int j = 0; j < filters.name.count; j++) { \
@@ -0,0 +1,33 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ * your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include <string.h>
+#include "../shared/indexer_main.h"
+#include "../shared/indexers.h"
+#include "../shared/version.h"
+#include "grammar_version.h"
+
+int main(int argc, char *argv[]) {
+    for (int i = 1; i < argc; i++) {
+        if (strcmp(argv[i], "--version") == 0) {
+            print_version_with_grammar(GRAMMAR_NAME, GRAMMAR_VERSION);
+            return 0;
+        }
+    }
+
+    return indexer_main(argc, argv, ruby_indexer_config());
+}
@@ -0,0 +1,69 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ * your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include <stdlib.h>
+#include "../shared/indexer_main.h"
+#include "../shared/constants.h"
+#include "../shared/indexers.h"
+#include "ruby_language.h"
+#include "grammar_version.h"
+
+/* Grammar entry point (checked against the tree-sitter runtime ABI) */
+extern const TSLanguage *tree_sitter_ruby(void);
+
+static void* parser_init_wrapper(SymbolFilter *filter) {
+    RubyParser *parser = malloc(sizeof(RubyParser));
+    if (!parser) {
+        return NULL;
+    }
+    if (parser_init(parser, filter) != 0) {
+        free(parser);
+        return NULL;
+    }
+    return parser;
+}
+
+static int parser_parse_wrapper(void *parser, const char *filepath, const char *project_root, ParseResult *result) {
+    return parser_parse_file((RubyParser*)parser, filepath, project_root, result);
+}
+
+static void parser_free_wrapper(void *parser) {
+    if (parser) {
+        parser_free((RubyParser*)parser);
+        free(parser);
+    }
+}
+
+static void parser_set_debug_wrapper(void *parser, int debug) {
+    parser_set_debug((RubyParser*)parser, debug);
+}
+
+static const IndexerConfig config = {
+    .name = "index-ruby",
+    .data_dir = "ruby/" CONFIG_DIR,
+    .parser_init = parser_init_wrapper,
+    .parser_parse = parser_parse_wrapper,
+    .parser_free = parser_free_wrapper,
+    .parser_set_debug = parser_set_debug_wrapper,
+    .grammar_name = GRAMMAR_NAME,
+    .grammar_version = GRAMMAR_VERSION,
+    .language = tree_sitter_ruby
+};
+
+const IndexerConfig *ruby_indexer_config(void) {
+    return &config;
+}
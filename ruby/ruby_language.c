@@ -0,0 +1,836 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "ruby_language.h"
+#include "../shared/constants.h"
+#include "../shared/string_utils.h"
+#include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
+#include "../shared/file_utils.h"
+#include "../shared/filter.h"
+#include "../shared/parse_errors.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <ctype.h>
+
+/* External Ruby language function from tree-sitter-ruby */
+extern const TSLanguage *tree_sitter_ruby(void);
+
+/* Global debug flag */
+static int g_debug = 0;
+
+/* Innermost class or module being parsed ("" at top level) - the parent of
+ * its methods, accessors and mixins. Per thread, like everything below,
+ * since the indexer's workers parse files concurrently. */
+static _Thread_local char g_current_class[SYMBOL_MAX_LENGTH] = "";
+
+/* First entry of the innermost class body, so `private :name` only
+ * revisits methods defined in that body */
+static _Thread_local int g_class_start = 0;
+
+/* Visibility set by a bare `private`/`protected`/`public` line in the
+ * current class body ("" until one appears, meaning public) */
+static _Thread_local const char *g_visibility = "";
+
+/* Visibility of the method passed to `private def name` ("" otherwise) */
+static _Thread_local const char *g_visibility_override = "";
+
+/* Inside `class << self`: methods defined there belong to the class */
+static _Thread_local int g_in_singleton = 0;
+
+/* Forward declarations */
+static void visit_node(TSNode node, const char *source_code, const char *directory,
+                       const char *filename, ParseResult *result, SymbolFilter *filter);
+static void process_children(TSNode node, const char *source_code, const char *directory,
+                             const char *filename, ParseResult *result, SymbolFilter *filter);
+
+static void process_children(TSNode node, const char *source_code, const char *directory,
+                             const char *filename, ParseResult *result, SymbolFilter *filter) {
+    uint32_t child_count = ts_node_child_count(node);
+    for (uint32_t i = 0; i < child_count; i++) {
+        TSNode child = ts_node_child(node, i);
+        visit_node(child, source_code, directory, filename, result, filter);
+    }
+}
+
+/* ---------------- Helpers ---------------- */
+
+/* The visibility a keyword names (private, protected, public), or NULL */
+static const char *visibility_keyword(const char *name) {
+    static const char *const keywords[] = {"private", "protected", "public"};
+    for (size_t i = 0; i < sizeof(keywords) / sizeof(keywords[0]); i++) {
+        if (strcmp(name, keywords[i]) == 0) return keywords[i];
+    }
+    return NULL;
+}
+
+/* Scope of a method defined now: an explicit `private def` wins over the
+ * section it is in. Returns NULL outside classes and modules. */
+static const char *current_scope(void) {
+    if (g_visibility_override[0]) return g_visibility_override;
+    if (!g_current_class[0]) return NULL;
+    return g_visibility[0] ? g_visibility : "public";
+}
+
+/* ExtColumns.exported value for a scope: private and protected methods are
+ * not part of a class's public interface */
+static const char *exported_from_scope(const char *scope) {
+    return (!scope || strcmp(scope, "public") == 0) ? "1" : "0";
+}
+
+/* Last segment of a constant path ("Admin::User" -> "User") */
+static const char *constant_basename(const char *path) {
+    const char *name = path;
+    for (const char *p = path; *p; p++) {
+        if (p[0] == ':' && p[1] == ':') {
+            name = p + 2;
+        }
+    }
+    return name;
+}
+
+/* Method name given as a symbol or string argument (:name, "name"), as
+ * `attr_accessor` and `private` take them. Returns 0 for other arguments. */
+static int extract_name_argument(TSNode arg, const char *source_code,
+                                 char *out, size_t out_size, const char *filename) {
+    out[0] = '\0';
+    const char *t = ts_node_type(arg);
+    if (strcmp(t, "simple_symbol") == 0) {
+        char text[SYMBOL_MAX_LENGTH];
+        safe_extract_node_text(source_code, arg, text, sizeof(text), filename);
+        snprintf(out, out_size, "%s", text[0] == ':' ? text + 1 : text);
+        return out[0] != '\0';
+    }
+    if (strcmp(t, "string") == 0 || strcmp(t, "delimited_symbol") == 0) {
+        /* Only plain contents: "name_#{x}" names nothing we can know */
+        if (ts_node_named_child_count(arg) != 1) return 0;
+        TSNode content = ts_node_named_child(arg, 0);
+        if (strcmp(ts_node_type(content), "string_content") != 0) return 0;
+        safe_extract_node_text(source_code, content, out, out_size, filename);
+        return out[0] != '\0';
+    }
+    return 0;
+}
+
+/* Give the methods already defined as `name` in the current class body a
+ * new visibility, for `private :name` after the method */
+static void set_method_scope(ParseResult *result, const char *name, const char *scope) {
+    for (int i = g_class_start; i < result->count; i++) {
+        IndexEntry *entry = &result->entries[i];
+        if (entry->context != CONTEXT_FUNCTION || entry->is_definition != 1 ||
+            strcmp(entry->full_symbol, name) != 0 ||
+            strcmp(entry->parent_symbol, g_current_class) != 0) {
+            continue;
+        }
+        snprintf(entry->scope, sizeof(entry->scope), "%s", scope);
+        entry->is_exported = strcmp(scope, "public") == 0;
+    }
+}
+
+/* Index the words of a comment or string as context entries */
+static void index_words(char *text, int line, ContextType context,
+                        const char *directory, const char *filename,
+                        ParseResult *result, SymbolFilter *filter) {
+    char word[CLEANED_WORD_BUFFER];
+    char cleaned[CLEANED_WORD_BUFFER];
+    char *word_start = text;
+    for (char *p = text; ; p++) {
+        if (*p == '\0' || isspace((unsigned char)*p)) {
+            if (p > word_start) {
+                size_t wlen = (size_t)(p - word_start);
+                if (wlen < sizeof(word)) {
+                    snprintf(word, sizeof(word), "%.*s", (int)wlen, word_start);
+                    filter_clean_string_symbol(word, cleaned, sizeof(cleaned));
+                    if (cleaned[0] && filter_should_index(filter, cleaned)) {
+                        add_entry(result, cleaned, line, context,
+                                  directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+                    }
+                }
+            }
+            word_start = p + 1;
+            if (*p == '\0') break;
+        }
+    }
+}
+
+/* ---------------- Classes & modules ---------------- */
+
+/* Process a class or module body with `class_name` as the enclosing class:
+ * visibility sections start over in every body */
+static void process_class_body(TSNode node, TSNode skip1, TSNode skip2, const char *class_name,
+                               const char *source_code, const char *directory,
+                               const char *filename, ParseResult *result, SymbolFilter *filter) {
+    char saved_class[SYMBOL_MAX_LENGTH];
+    const char *saved_visibility = g_visibility;
+    int saved_start = g_class_start;
+    int saved_singleton = g_in_singleton;
+    snprintf(saved_class, sizeof(saved_class), "%s", g_current_class);
+
+    snprintf(g_current_class, sizeof(g_current_class), "%s", class_name);
+    g_visibility = "";
+    g_class_start = result->count;
+    g_in_singleton = 0;
+
+    /* The body is the children after the name and superclass (grammar
+     * versions differ on whether they wrap it in a body field) */
+    push_scope(result, class_name);
+    uint32_t n = ts_node_child_count(node);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode child = ts_node_child(node, i);
+        if (ts_node_eq(child, skip1) || ts_node_eq(child, skip2)) continue;
+        visit_node(child, source_code, directory, filename, result, filter);
+    }
+    pop_scope(result);
+
+    snprintf(g_current_class, sizeof(g_current_class), "%s", saved_class);
+    g_visibility = saved_visibility;
+    g_class_start = saved_start;
+    g_in_singleton = saved_singleton;
+}
+
+static void handle_class(TSNode node, const char *source_code,
+                         const char *directory, const char *filename,
+                         ParseResult *result, SymbolFilter *filter, int line) {
+    TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
+    if (ts_node_is_null(name_node)) return;
+
+    char path[SYMBOL_MAX_LENGTH];
+    safe_extract_node_text(source_code, name_node, path, sizeof(path), filename);
+    const char *name = constant_basename(path);
+
+    /* `class Admin::User` lives in the namespace Admin */
+    char ns[SYMBOL_MAX_LENGTH] = "";
+    if (name != path) {
+        snprintf(ns, sizeof(ns), "%.*s", (int)(name - path - 2), path);
+    }
+
+    /* Superclass (`class User < ApplicationRecord`) is the parent */
+    char superclass[SYMBOL_MAX_LENGTH] = "";
+    TSNode super_node = ts_node_child_by_field_name(node, "superclass", 10);
+    if (!ts_node_is_null(super_node) && ts_node_named_child_count(super_node) > 0) {
+        safe_extract_node_text(source_code, ts_node_named_child(super_node, 0),
+                               superclass, sizeof(superclass), filename);
+    }
+
+    char location[128];
+    format_source_location(node, location, sizeof(location));
+
+    if (name[0] && filter_should_index(filter, name)) {
+        add_entry(result, name, line, CONTEXT_CLASS,
+                  directory, filename, location,
+                  &(ExtColumns){
+                      .definition = "1",
+                      .exported = "1",
+                      .namespace = ns[0] ? ns : NULL,
+                      .parent = superclass[0] ? superclass : NULL
+                  });
+    }
+
+    if (!ts_node_is_null(super_node)) {
+        process_children(super_node, source_code, directory, filename, result, filter);
+    }
+    process_class_body(node, name_node, super_node, name, source_code, directory,
+                       filename, result, filter);
+}
+
+static void handle_module(TSNode node, const char *source_code,
+                          const char *directory, const char *filename,
+                          ParseResult *result, SymbolFilter *filter, int line) {
+    TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
+    if (ts_node_is_null(name_node)) return;
+
+    char path[SYMBOL_MAX_LENGTH];
+    safe_extract_node_text(source_code, name_node, path, sizeof(path), filename);
+    const char *name = constant_basename(path);
+
+    char ns[SYMBOL_MAX_LENGTH] = "";
+    if (name != path) {
+        snprintf(ns, sizeof(ns), "%.*s", (int)(name - path - 2), path);
+    }
+
+    char location[128];
+    format_source_location(node, location, sizeof(location));
+
+    if (name[0] && filter_should_index(filter, name)) {
+        add_entry(result, name, line, CONTEXT_NAMESPACE,
+                  directory, filename, location,
+                  &(ExtColumns){
+                      .definition = "1",
+                      .exported = "1",
+                      .namespace = ns[0] ? ns : NULL,
+                      .clue = "module"
+                  });
+    }
+
+    process_class_body(node, name_node, name_node, name, source_code, directory,
+                       filename, result, filter);
+}
+
+/* `class << self`: its methods are class methods of the enclosing class */
+static void handle_singleton_class(TSNode node, const char *source_code,
+                                   const char *directory, const char *filename,
+                                   ParseResult *result, SymbolFilter *filter, int line) {
+    (void)line;
+    const char *saved_visibility = g_visibility;
+    int saved_singleton = g_in_singleton;
+    g_visibility = "";
+    g_in_singleton = 1;
+
+    TSNode value = ts_node_child_by_field_name(node, "value", 5);
+    uint32_t n = ts_node_child_count(node);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode child = ts_node_child(node, i);
+        if (ts_node_eq(child, value)) continue;
+        visit_node(child, source_code, directory, filename, result, filter);
+    }
+
+    g_visibility = saved_visibility;
+    g_in_singleton = saved_singleton;
+}
+
+/* ---------------- Methods ---------------- */
+
+/* Index a method's parameters: plain, optional (a = 1), keyword (a:),
+ * splat (*a), double splat (**a), block (&a) and destructured ((a, b)) */
+static void handle_parameters(TSNode node, const char *source_code,
+                              const char *directory, const char *filename,
+                              ParseResult *result, SymbolFilter *filter) {
+    uint32_t n = ts_node_named_child_count(node);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode param = ts_node_named_child(node, i);
+        const char *t = ts_node_type(param);
+        int line = (int)ts_node_start_point(param).row + 1;
+
+        if (strcmp(t, "destructured_parameter") == 0) {
+            handle_parameters(param, source_code, directory, filename, result, filter);
+            continue;
+        }
+
+        TSNode name_node = strcmp(t, "identifier") == 0
+                               ? param
+                               : ts_node_child_by_field_name(param, "name", 4);
+        if (!ts_node_is_null(name_node)) {
+            char name[SYMBOL_MAX_LENGTH];
+            safe_extract_node_text(source_code, name_node, name, sizeof(name), filename);
+            if (name[0] && filter_should_index(filter, name)) {
+                add_entry(result, name, line, CONTEXT_ARGUMENT,
+                          directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+            }
+        }
+
+        /* Default values can call methods */
+        TSNode value = ts_node_child_by_field_name(param, "value", 5);
+        if (!ts_node_is_null(value)) {
+            visit_node(value, source_code, directory, filename, result, filter);
+        }
+    }
+}
+
+/* `def name` and `def self.name` (singleton) */
+static void handle_method(TSNode node, const char *source_code,
+                          const char *directory, const char *filename,
+                          ParseResult *result, SymbolFilter *filter, int line,
+                          int singleton) {
+    TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
+    if (ts_node_is_null(name_node)) return;
+
+    char name[SYMBOL_MAX_LENGTH];
+    safe_extract_node_text(source_code, name_node, name, sizeof(name), filename);
+
+    /* `def self.name` belongs to the enclosing class, `def obj.name` to obj */
+    char parent[SYMBOL_MAX_LENGTH];
+    snprintf(parent, sizeof(parent), "%s", g_current_class);
+    TSNode object = ts_node_child_by_field_name(node, "object", 6);
+    if (!ts_node_is_null(object) && strcmp(ts_node_type(object), "self") != 0) {
+        safe_extract_node_text(source_code, object, parent, sizeof(parent), filename);
+    }
+
+    const char *scope = current_scope();
+    char location[128];
+    format_source_location(node, location, sizeof(location));
+
+    if (name[0] && filter_should_index(filter, name)) {
+        add_entry(result, name, line, CONTEXT_FUNCTION,
+                  directory, filename, location,
+                  &(ExtColumns){
+                      .definition = "1",
+                      .scope = scope,
+                      .exported = exported_from_scope(scope),
+                      .modifier = (singleton || g_in_singleton) ? "static" : NULL,
+                      .parent = parent[0] ? parent : NULL
+                  });
+    }
+
+    /* Methods nested in the body are not covered by `private def` */
+    const char *saved_override = g_visibility_override;
+    g_visibility_override = "";
+    push_scope(result, name);
+    uint32_t n = ts_node_child_count(node);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode child = ts_node_child(node, i);
+        if (ts_node_eq(child, name_node) || (!ts_node_is_null(object) && ts_node_eq(child, object))) {
+            continue;
+        }
+        const char *t = ts_node_type(child);
+        if (strcmp(t, "method_parameters") == 0 || strcmp(t, "parameters") == 0) {
+            handle_parameters(child, source_code, directory, filename, result, filter);
+        } else {
+            visit_node(child, source_code, directory, filename, result, filter);
+        }
+    }
+    pop_scope(result);
+    g_visibility_override = saved_override;
+}
+
+/* ---------------- Calls ---------------- */
+
+/* attr_reader/attr_writer/attr_accessor :name - the methods they define */
+static void handle_attr_call(TSNode args, const char *kind, const char *source_code,
+                             const char *directory, const char *filename,
+                             ParseResult *result, SymbolFilter *filter) {
+    int reader = strcmp(kind, "attr_writer") != 0;
+    int writer = strcmp(kind, "attr_reader") != 0;
+    const char *scope = current_scope();
+
+    uint32_t n = ts_node_named_child_count(args);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode arg = ts_node_named_child(args, i);
+        char name[SYMBOL_MAX_LENGTH];
+        if (!extract_name_argument(arg, source_code, name, sizeof(name), filename)) continue;
+        if (!filter_should_index(filter, name)) continue;
+
+        int line = (int)ts_node_start_point(arg).row + 1;
+        char location[128];
+        format_source_location(arg, location, sizeof(location));
+        ExtColumns ext = {
+            .definition = "1",
+            .scope = scope,
+            .exported = exported_from_scope(scope),
+            .clue = kind,
+            .parent = g_current_class[0] ? g_current_class : NULL
+        };
+        if (reader) {
+            add_entry(result, name, line, CONTEXT_FUNCTION, directory, filename, location, &ext);
+        }
+        if (writer) {
+            char setter[SYMBOL_MAX_LENGTH + 1];
+            snprintf(setter, sizeof(setter), "%s=", name);
+            add_entry(result, setter, line, CONTEXT_FUNCTION, directory, filename, location, &ext);
+        }
+    }
+}
+
+/* include/extend/prepend Mod - recorded like Go's embedded types: a
+ * property named after the module, clue "embedded", parent the class */
+static void handle_mixin_call(TSNode args, const char *kind, const char *source_code,
+                              const char *directory, const char *filename,
+                              ParseResult *result, SymbolFilter *filter) {
+    uint32_t n = ts_node_named_child_count(args);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode arg = ts_node_named_child(args, i);
+        const char *t = ts_node_type(arg);
+        if (strcmp(t, "constant") != 0 && strcmp(t, "scope_resolution") != 0) {
+            visit_node(arg, source_code, directory, filename, result, filter);
+            continue;
+        }
+
+        char path[SYMBOL_MAX_LENGTH];
+        safe_extract_node_text(source_code, arg, path, sizeof(path), filename);
+        const char *name = constant_basename(path);
+        if (!name[0] || !filter_should_index(filter, name)) continue;
+
+        char location[128];
+        format_source_location(arg, location, sizeof(location));
+        add_entry(result, name, (int)ts_node_start_point(arg).row + 1,
+                  CONTEXT_PROPERTY, directory, filename, location,
+                  &(ExtColumns){
+                      .parent = g_current_class[0] ? g_current_class : NULL,
+                      .modifier = kind,
+                      .clue = "embedded",
+                      .type = path
+                  });
+    }
+}
+
+/* private/protected/public, with or without arguments */
+static void handle_visibility_call(TSNode args, const char *kind, const char *source_code,
+                                   const char *directory, const char *filename,
+                                   ParseResult *result, SymbolFilter *filter) {
+    /* A bare `private` starts a section */
+    if (ts_node_is_null(args) || ts_node_named_child_count(args) == 0) {
+        g_visibility = kind;
+        return;
+    }
+
+    uint32_t n = ts_node_named_child_count(args);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode arg = ts_node_named_child(args, i);
+        char name[SYMBOL_MAX_LENGTH];
+        if (extract_name_argument(arg, source_code, name, sizeof(name), filename)) {
+            /* `private :name` after the method */
+            set_method_scope(result, name, kind);
+        } else {
+            /* `private def name` or `private attr_reader :name` */
+            const char *saved_override = g_visibility_override;
+            g_visibility_override = kind;
+            visit_node(arg, source_code, directory, filename, result, filter);
+            g_visibility_override = saved_override;
+        }
+    }
+}
+
+/* require "json", require_relative "models/user" */
+static void handle_require_call(TSNode args, int line, const char *source_code,
+                                const char *directory, const char *filename,
+                                ParseResult *result, SymbolFilter *filter) {
+    (void)filter;  /* Imports are always indexed regardless of filter */
+    if (ts_node_named_child_count(args) == 0) return;
+    char path[IMPORT_PATH_MAX_LENGTH];
+    if (!extract_name_argument(ts_node_named_child(args, 0), source_code,
+                               path, sizeof(path), filename)) {
+        return;
+    }
+    add_import(result, path, line);
+    add_entry(result, path, line, CONTEXT_IMPORT,
+              directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+}
+
+static void handle_call(TSNode node, const char *source_code,
+                        const char *directory, const char *filename,
+                        ParseResult *result, SymbolFilter *filter, int line) {
+    TSNode receiver = ts_node_child_by_field_name(node, "receiver", 8);
+    TSNode method = ts_node_child_by_field_name(node, "method", 6);
+    TSNode args = ts_node_child_by_field_name(node, "arguments", 9);
+    TSNode block = ts_node_child_by_field_name(node, "block", 5);
+
+    char name[SYMBOL_MAX_LENGTH] = "";
+    if (!ts_node_is_null(method)) {
+        safe_extract_node_text(source_code, method, name, sizeof(name), filename);
+    }
+
+    /* Class-body macros: only without a receiver */
+    if (ts_node_is_null(receiver) && name[0]) {
+        const char *visibility = visibility_keyword(name);
+        if (visibility) {
+            handle_visibility_call(args, visibility, source_code, directory, filename, result, filter);
+            return;
+        }
+        if (!ts_node_is_null(args)) {
+            if (strcmp(name, "attr_accessor") == 0 || strcmp(name, "attr_reader") == 0 ||
+                strcmp(name, "attr_writer") == 0) {
+                handle_attr_call(args, name, source_code, directory, filename, result, filter);
+                return;
+            }
+            if (strcmp(name, "include") == 0 || strcmp(name, "extend") == 0 ||
+                strcmp(name, "prepend") == 0) {
+                handle_mixin_call(args, name, source_code, directory, filename, result, filter);
+                return;
+            }
+            if (strcmp(name, "require") == 0 || strcmp(name, "require_relative") == 0) {
+                handle_require_call(args, line, source_code, directory, filename, result, filter);
+                return;
+            }
+        }
+    }
+
+    /* obj.method / Const.method: the receiver is the parent */
+    char parent[SYMBOL_MAX_LENGTH] = "";
+    if (!ts_node_is_null(receiver)) {
+        const char *rt = ts_node_type(receiver);
+        if (strcmp(rt, "identifier") == 0 || strcmp(rt, "constant") == 0 ||
+            strcmp(rt, "scope_resolution") == 0 || strcmp(rt, "instance_variable") == 0 ||
+            strcmp(rt, "self") == 0) {
+            safe_extract_node_text(source_code, receiver, parent, sizeof(parent), filename);
+        } else {
+            visit_node(receiver, source_code, directory, filename, result, filter);
+        }
+    }
+
+    if (name[0] && filter_should_index(filter, name)) {
+        add_entry(result, name, line, CONTEXT_CALL,
+                  directory, filename, NULL,
+                  &(ExtColumns){.parent = parent[0] ? parent : NULL});
+    }
+
+    if (!ts_node_is_null(args)) {
+        process_children(args, source_code, directory, filename, result, filter);
+    }
+    if (!ts_node_is_null(block)) {
+        visit_node(block, source_code, directory, filename, result, filter);
+    }
+}
+
+/* Block parameters (|a, b|) are arguments of the block */
+static void handle_block(TSNode node, const char *source_code,
+                         const char *directory, const char *filename,
+                         ParseResult *result, SymbolFilter *filter) {
+    uint32_t n = ts_node_child_count(node);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode child = ts_node_child(node, i);
+        if (strcmp(ts_node_type(child), "block_parameters") == 0 ||
+            strcmp(ts_node_type(child), "lambda_parameters") == 0) {
+            handle_parameters(child, source_code, directory, filename, result, filter);
+        } else {
+            visit_node(child, source_code, directory, filename, result, filter);
+        }
+    }
+}
+
+/* ---------------- Assignments ---------------- */
+
+/* NAME = value (constants), name = value (locals), @name = value */
+static void handle_assignment(TSNode node, const char *source_code,
+                              const char *directory, const char *filename,
+                              ParseResult *result, SymbolFilter *filter, int line) {
+    TSNode left = ts_node_child_by_field_name(node, "left", 4);
+    TSNode right = ts_node_child_by_field_name(node, "right", 5);
+
+    if (!ts_node_is_null(left)) {
+        const char *lt = ts_node_type(left);
+        char name[SYMBOL_MAX_LENGTH];
+        safe_extract_node_text(source_code, left, name, sizeof(name), filename);
+        if (strcmp(lt, "constant") == 0) {
+            if (filter_should_index(filter, name)) {
+                char location[128];
+                format_source_location(node, location, sizeof(location));
+                add_entry(result, name, line, CONTEXT_VARIABLE,
+                          directory, filename, location,
+                          &(ExtColumns){
+                              .definition = "1",
+                              .exported = "1",
+                              .modifier = "const",
+                              .parent = g_current_class[0] ? g_current_class : NULL
+                          });
+            }
+        } else if (strcmp(lt, "identifier") == 0) {
+            if (filter_should_index(filter, name)) {
+                add_entry(result, name, line, CONTEXT_VARIABLE,
+                          directory, filename, NULL,
+                          &(ExtColumns){.definition = "1"});
+            }
+        } else if (strcmp(lt, "instance_variable") == 0 || strcmp(lt, "class_variable") == 0) {
+            /* Assigned again in every method, so none of them is the definition */
+            if (filter_should_index(filter, name)) {
+                add_entry(result, name, line, CONTEXT_PROPERTY,
+                          directory, filename, NULL,
+                          &(ExtColumns){.parent = g_current_class[0] ? g_current_class : NULL});
+            }
+        } else {
+            visit_node(left, source_code, directory, filename, result, filter);
+        }
+    }
+
+    if (!ts_node_is_null(right)) {
+        visit_node(right, source_code, directory, filename, result, filter);
+    }
+}
+
+/* ---------------- Strings & comments ---------------- */
+
+static void handle_comment(TSNode node, const char *source_code,
+                           const char *directory, const char *filename,
+                           ParseResult *result, SymbolFilter *filter, int line) {
+    char text[COMMENT_TEXT_BUFFER];
+    safe_extract_node_text(source_code, node, text, sizeof(text), filename);
+
+    /* =begin/=end blocks: drop the markers, keep the lines between */
+    char *start = text;
+    if (strncmp(start, "=begin", 6) == 0) {
+        start += 6;
+        char *end = strstr(start, "\n=end");
+        if (end) *end = '\0';
+    } else {
+        start = strip_comment_delimiters(text);
+    }
+    index_words(start, line, CONTEXT_COMMENT, directory, filename, result, filter);
+}
+
+static void handle_string(TSNode node, const char *source_code,
+                          const char *directory, const char *filename,
+                          ParseResult *result, SymbolFilter *filter) {
+    /* Index words from string_content nodes; interpolations are code */
+    uint32_t n = ts_node_child_count(node);
+    for (uint32_t i = 0; i < n; i++) {
+        TSNode c = ts_node_child(node, i);
+        const char *ct = ts_node_type(c);
+        if (strcmp(ct, "string_content") == 0) {
+            char content[CLEANED_WORD_BUFFER];
+            safe_extract_node_text(source_code, c, content, sizeof(content), filename);
+            index_words(content, (int)ts_node_start_point(c).row + 1, CONTEXT_STRING,
+                        directory, filename, result, filter);
+        } else if (strcmp(ct, "interpolation") == 0) {
+            process_children(c, source_code, directory, filename, result, filter);
+        }
+    }
+}
+
+/* ---------------- Dispatcher ---------------- */
+
+static void visit_node(TSNode node, const char *source_code, const char *directory,
+                       const char *filename, ParseResult *result, SymbolFilter *filter) {
+    if (ts_node_is_null(node)) return;
+    const char *t = ts_node_type(node);
+    TSPoint sp = ts_node_start_point(node);
+    int line = (int)(sp.row + 1);
+
+    if (g_debug) fprintf(stderr, "[ruby] visit %s line=%d\n", t, line);
+
+    if (strcmp(t, "class") == 0) {
+        handle_class(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
+    if (strcmp(t, "module") == 0) {
+        handle_module(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
+    if (strcmp(t, "singleton_class") == 0) {
+        handle_singleton_class(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
+    if (strcmp(t, "method") == 0) {
+        handle_method(node, source_code, directory, filename, result, filter, line, 0);
+        return;
+    }
+    if (strcmp(t, "singleton_method") == 0) {
+        handle_method(node, source_code, directory, filename, result, filter, line, 1);
+        return;
+    }
+    if (strcmp(t, "call") == 0 || strcmp(t, "method_call") == 0) {
+        handle_call(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
+    if (strcmp(t, "block") == 0 || strcmp(t, "do_block") == 0 || strcmp(t, "lambda") == 0) {
+        handle_block(node, source_code, directory, filename, result, filter);
+        return;
+    }
+    if (strcmp(t, "assignment") == 0) {
+        handle_assignment(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
+    /* A bare `private` line in a class body is an identifier, not a call */
+    if (strcmp(t, "identifier") == 0) {
+        TSNode parent = ts_node_parent(node);
+        const char *pt = ts_node_is_null(parent) ? "" : ts_node_type(parent);
+        if (g_current_class[0] && (strcmp(pt, "body_statement") == 0 || strcmp(pt, "class") == 0 ||
+                                   strcmp(pt, "module") == 0 || strcmp(pt, "singleton_class") == 0)) {
+            char name[SYMBOL_MAX_LENGTH];
+            safe_extract_node_text(source_code, node, name, sizeof(name), filename);
+            const char *visibility = visibility_keyword(name);
+            if (visibility) {
+                g_visibility = visibility;
+            }
+        }
+        return;
+    }
+    if (strcmp(t, "comment") == 0) {
+        handle_comment(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
+    if (strcmp(t, "string") == 0 || strcmp(t, "heredoc_body") == 0) {
+        handle_string(node, source_code, directory, filename, result, filter);
+        return;
+    }
+
+    /* Default: recurse into children */
+    process_children(node, source_code, directory, filename, result, filter);
+}
+
+/* ---------------- Lifecycle ---------------- */
+
+static void reset_state(void) {
+    g_current_class[0] = '\0';
+    g_class_start = 0;
+    g_visibility = "";
+    g_visibility_override = "";
+    g_in_singleton = 0;
+}
+
+int parser_init(RubyParser *parser, SymbolFilter *filter) {
+    parser->parser = ts_parser_new();
+    if (!parser->parser) return -1;
+
+    const TSLanguage *language = tree_sitter_ruby();
+    if (!ts_parser_set_language(parser->parser, (TSLanguage*)language)) {
+        ts_parser_delete(parser->parser);
+        return -1;
+    }
+    parser->filter = filter;
+    parser->debug = 0;
+    g_debug = 0;
+    reset_state();
+    return 0;
+}
+
+void parser_set_debug(RubyParser *parser, int debug) {
+    parser->debug = debug;
+    g_debug = debug;
+}
+
+int parser_parse_file(RubyParser *parser, const char *filepath,
+                      const char *project_root, ParseResult *result) {
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
+        return -1;
+    }
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
+
+    result->count = 0;
+    TSTree *tree = ts_parser_parse(parser->parser, NULL, source_file_input(&source));
+    if (!tree) {
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
+        return -1;
+    }
+
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, NULL);
+
+    TSNode root = ts_tree_root_node(tree);
+
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    get_relative_path(filepath, project_root, directory, filename);
+
+    /* Filename without extension as CONTEXT_FILENAME */
+    char fname_noext[FILENAME_MAX_LENGTH];
+    snprintf(fname_noext, sizeof(fname_noext), "%s", filename);
+    char *dot = strrchr(fname_noext, '.');
+    if (dot) *dot = '\0';
+    if (filter_should_index(parser->filter, fname_noext)) {
+        add_entry(result, fname_noext, 1, CONTEXT_FILENAME,
+                  directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+    }
+
+    reset_state();
+    visit_node(root, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_HASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
+
+    ts_tree_delete(tree);
+    source_file_close(&source);
+    return 0;
+}
+
+void parser_free(RubyParser *parser) {
+    if (parser->parser) {
+        ts_parser_delete(parser->parser);
+        parser->parser = NULL;
+    }
+}
@@ -15,42 +15,12 @@
  * You should have received a copy of the GNU General Public License
  * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
  */
-#include <stdio.h>
-#include <stdlib.h>
 #include <string.h>
 #include "../shared/indexer_main.h"
-#include "../shared/constants.h"
+#include "../shared/indexers.h"
 #include "../shared/version.h"
-#include "rust_language.h"
 #include "grammar_version.h"
 
-static void* parser_init_wrapper(SymbolFilter *filter) {
-    RustParser *parser = malloc(sizeof(RustParser));
-    if (!parser) {
-        return NULL;
-    }
-    if (parser_init(parser, filter) != 0) {
-        free(parser);
-        return NULL;
-    }
-    return parser;
-}
-
-static int parser_parse_wrapper(void *parser, const char *filepath, const char *project_root, ParseResult *result) {
-    return parser_parse_file((RustParser*)parser, filepath, project_root, result);
-}
-
-static void parser_free_wrapper(void *parser) {
-    if (parser) {
-        parser_free((RustParser*)parser);
-        free(parser);
-    }
-}
-
-static void parser_set_debug_wrapper(void *parser, int debug) {
-    parser_set_debug((RustParser*)parser, debug);
-}
-
 int main(int argc, char *argv[]) {
     for (int i = 1; i < argc; i++) {
         if (strcmp(argv[i], "--version") == 0) {
@@ -59,14 +29,5 @@ int main(int argc, char *argv[]) {
         }
     }
 
-    IndexerConfig config = {
-        .name = "index-rust",
-        .data_dir = "rust/" CONFIG_DIR,
-        .parser_init = parser_init_wrapper,
-        .parser_parse = parser_parse_wrapper,
-        .parser_free = parser_free_wrapper,
-        .parser_set_debug = parser_set_debug_wrapper
-    };
-
-    return indexer_main(argc, argv, &config);
+    return indexer_main(argc, argv, rust_indexer_config());
 }
@@ -19,9 +19,11 @@
 #include "../shared/constants.h"
 #include "../shared/string_utils.h"
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
 #include "../shared/file_opener.h"
 #include "../shared/file_utils.h"
 #include "../shared/filter.h"
+#include "../shared/parse_errors.h"
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
@@ -36,8 +38,13 @@ static int g_debug = 0;
 
 /* Current impl target type - acts as parent for methods inside an impl block.
  * Rust's tree-sitter parser doesn't expose this directly, so we track it as
- * we descend into impl_item nodes and restore it on exit. */
-static char g_current_impl[SYMBOL_MAX_LENGTH] = "";
+ * we descend into impl_item nodes and restore it on exit. Per thread, since
+ * the indexer's workers parse files concurrently. */
+static _Thread_local char g_current_impl[SYMBOL_MAX_LENGTH] = "";
+
+/* Trait of the enclosing `impl Trait for Type` block ("" for inherent impls
+ * and outside impls). Methods record it in their clue, like the impl row. */
+static _Thread_local char g_current_trait[SYMBOL_MAX_LENGTH] = "";
 
 /* Forward declarations */
 static void visit_node(TSNode node, const char *source_code, const char *directory,
@@ -71,6 +78,12 @@ static void extract_visibility(TSNode node, const char *source_code,
     }
 }
 
+/* ExtColumns.exported value for a visibility: only plain `pub` items are
+ * part of the crate's public API (pub(crate), pub(super) are not) */
+static const char *exported_from_visibility(const char *vis) {
+    return strcmp(vis, "pub") == 0 ? "1" : "0";
+}
+
 /* Extract function modifier keywords (async, unsafe, const, extern) from a
  * function_item or function_signature_item. Visibility (`pub`) is handled
  * separately via extract_visibility(). Returns "" if none. */
@@ -210,6 +223,21 @@ static void extract_base_type_name(TSNode type_node, const char *source_code,
     safe_extract_node_text(source_code, type_node, out, out_size, filename);
 }
 
+/* Clue for an item inside an impl block: its attributes, followed by
+ * "impl Trait" when the block implements a trait. Returns "" if neither. */
+static void build_item_clue(const char *attrs, char *out, size_t out_size) {
+    int written;
+    if (g_current_trait[0]) {
+        written = snprintf(out, out_size, "%s%simpl %s", attrs, attrs[0] ? "," : "", g_current_trait);
+    } else {
+        written = snprintf(out, out_size, "%s", attrs);
+    }
+    if (g_current_trait[0] && (written < 0 || (size_t)written >= out_size)) {
+        /* No room for the attributes; keep the trait, which is what gets queried */
+        snprintf(out, out_size, "impl %.*s", (int)(out_size - 6), g_current_trait);
+    }
+}
+
 /* Walk a pattern node and emit each binding identifier as a CONTEXT_VARIABLE.
  * Skips `_`, constructor names in tuple_struct_pattern/struct_pattern, and
  * descends through wrappers like mut_pattern, reference_pattern, etc. */
@@ -307,6 +335,8 @@ static void handle_function_item(TSNode node, const char *source_code,
 
     char attrs[SYMBOL_MAX_LENGTH];
     extract_attributes(node, source_code, attrs, sizeof(attrs), filename);
+    char clue[CLUE_MAX_LENGTH];
+    build_item_clue(attrs, clue, sizeof(clue));
 
     char return_type[SYMBOL_MAX_LENGTH] = "";
     TSNode ret_node = ts_node_child_by_field_name(node, "return_type", 11);
@@ -323,8 +353,9 @@ static void handle_function_item(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
+                      .exported = exported_from_visibility(vis),
                       .modifier = modifiers[0] ? modifiers : NULL,
-                      .clue = attrs[0] ? attrs : NULL,
+                      .clue = clue[0] ? clue : NULL,
                       .type = return_type[0] ? return_type : NULL,
                       .parent = g_current_impl[0] ? g_current_impl : NULL
                   });
@@ -332,6 +363,7 @@ static void handle_function_item(TSNode node, const char *source_code,
 
     /* Process type parameters, parameters, return type, body so their inner
      * identifiers/types/calls get indexed too. */
+    push_scope(result, fn_name);
     TSNode type_params = ts_node_child_by_field_name(node, "type_parameters", 15);
     if (!ts_node_is_null(type_params)) {
         process_children(type_params, source_code, directory, filename, result, filter);
@@ -347,6 +379,7 @@ static void handle_function_item(TSNode node, const char *source_code,
     if (!ts_node_is_null(body)) {
         process_children(body, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
 }
 
 static void handle_parameter(TSNode node, const char *source_code,
@@ -409,14 +442,21 @@ static void handle_struct_item(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
+                      .exported = exported_from_visibility(vis),
                       .clue = attrs[0] ? attrs : NULL
                   });
     }
 
-    /* Process body fields */
+    /* Process body fields, with the struct as their parent */
     TSNode body = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body)) {
+        char saved[SYMBOL_MAX_LENGTH];
+        snprintf(saved, sizeof(saved), "%s", g_current_impl);
+        snprintf(g_current_impl, sizeof(g_current_impl), "%s", name);
+        push_scope(result, name);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
+        snprintf(g_current_impl, sizeof(g_current_impl), "%s", saved);
     }
 }
 
@@ -447,7 +487,9 @@ static void handle_field_declaration(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
-                      .type = type_str[0] ? type_str : NULL
+                      .exported = exported_from_visibility(vis),
+                      .type = type_str[0] ? type_str : NULL,
+                      .parent = g_current_impl[0] ? g_current_impl : NULL
                   });
     }
 }
@@ -475,6 +517,7 @@ static void handle_enum_item(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
+                      .exported = exported_from_visibility(vis),
                       .clue = attrs[0] ? attrs : NULL
                   });
     }
@@ -485,7 +528,9 @@ static void handle_enum_item(TSNode node, const char *source_code,
         char saved[SYMBOL_MAX_LENGTH];
         snprintf(saved, sizeof(saved), "%s", g_current_impl);
         snprintf(g_current_impl, sizeof(g_current_impl), "%s", name);
+        push_scope(result, name);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
         snprintf(g_current_impl, sizeof(g_current_impl), "%s", saved);
     }
 }
@@ -511,10 +556,16 @@ static void handle_enum_variant(TSNode node, const char *source_code,
                   });
     }
 
-    /* Process variant body for nested types/fields */
+    /* Process variant body; struct-like variant fields belong to the variant */
     TSNode body = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body)) {
+        char saved[SYMBOL_MAX_LENGTH];
+        snprintf(saved, sizeof(saved), "%s", g_current_impl);
+        snprintf(g_current_impl, sizeof(g_current_impl), "%s", name);
+        push_scope(result, name);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
+        snprintf(g_current_impl, sizeof(g_current_impl), "%s", saved);
     }
 }
 
@@ -541,6 +592,7 @@ static void handle_trait_item(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
+                      .exported = exported_from_visibility(vis),
                       .clue = attrs[0] ? attrs : NULL
                   });
     }
@@ -549,10 +601,16 @@ static void handle_trait_item(TSNode node, const char *source_code,
     if (!ts_node_is_null(body)) {
         /* Methods inside a trait belong to the trait */
         char saved[SYMBOL_MAX_LENGTH];
+        char saved_trait[SYMBOL_MAX_LENGTH];
         snprintf(saved, sizeof(saved), "%s", g_current_impl);
+        snprintf(saved_trait, sizeof(saved_trait), "%s", g_current_trait);
         snprintf(g_current_impl, sizeof(g_current_impl), "%s", name);
+        g_current_trait[0] = '\0';
+        push_scope(result, name);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
         snprintf(g_current_impl, sizeof(g_current_impl), "%s", saved);
+        snprintf(g_current_trait, sizeof(g_current_trait), "%s", saved_trait);
     }
 }
 
@@ -590,14 +648,21 @@ static void handle_impl_item(TSNode node, const char *source_code,
                   });
     }
 
-    /* Descend into body with g_current_impl set to target */
+    /* Descend into body with g_current_impl set to target and
+     * g_current_trait to the implemented trait (if any) */
     TSNode body = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body)) {
         char saved[SYMBOL_MAX_LENGTH];
+        char saved_trait[SYMBOL_MAX_LENGTH];
         snprintf(saved, sizeof(saved), "%s", g_current_impl);
+        snprintf(saved_trait, sizeof(saved_trait), "%s", g_current_trait);
         snprintf(g_current_impl, sizeof(g_current_impl), "%s", target);
+        snprintf(g_current_trait, sizeof(g_current_trait), "%s", trait_name);
+        push_scope(result, target);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
         snprintf(g_current_impl, sizeof(g_current_impl), "%s", saved);
+        snprintf(g_current_trait, sizeof(g_current_trait), "%s", saved_trait);
     }
 }
 
@@ -627,7 +692,9 @@ static void handle_mod_item(TSNode node, const char *source_code,
 
     TSNode body = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body)) {
+        push_scope(result, name);
         process_children(body, source_code, directory, filename, result, filter);
+        pop_scope(result);
     }
 }
 
@@ -854,6 +921,8 @@ static void handle_function_signature_item(TSNode node, const char *source_code,
 
     char attrs[SYMBOL_MAX_LENGTH];
     extract_attributes(node, source_code, attrs, sizeof(attrs), filename);
+    char clue[CLUE_MAX_LENGTH];
+    build_item_clue(attrs, clue, sizeof(clue));
 
     char return_type[SYMBOL_MAX_LENGTH] = "";
     TSNode ret_node = ts_node_child_by_field_name(node, "return_type", 11);
@@ -873,8 +942,9 @@ static void handle_function_signature_item(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
+                      .exported = exported_from_visibility(vis),
                       .modifier = modifiers[0] ? modifiers : NULL,
-                      .clue = attrs[0] ? attrs : NULL,
+                      .clue = clue[0] ? clue : NULL,
                       .type = return_type[0] ? return_type : NULL,
                       .parent = g_current_impl[0] ? g_current_impl : NULL
                   });
@@ -921,7 +991,9 @@ static void handle_const_or_static(TSNode node, const char *source_code,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
-                      .type = type_str[0] ? type_str : NULL
+                      .exported = exported_from_visibility(vis),
+                      .type = type_str[0] ? type_str : NULL,
+                      .parent = g_current_impl[0] ? g_current_impl : NULL
                   });
     }
 
@@ -948,15 +1020,65 @@ static void handle_type_item(TSNode node, const char *source_code,
         safe_extract_node_text(source_code, type_node, type_str, sizeof(type_str), filename);
     }
 
+    char location[128];
+    format_source_location(node, location, sizeof(location));
+
+    /* `type Alias = T;` is an alias; inside an impl it is an associated type */
     if (filter_should_index(filter, name)) {
         add_entry(result, name, line, CONTEXT_TYPE,
-                  directory, filename, NULL,
+                  directory, filename, location,
                   &(ExtColumns){
                       .definition = "1",
                       .scope = vis[0] ? vis : NULL,
-                      .type = type_str[0] ? type_str : NULL
+                      .exported = exported_from_visibility(vis),
+                      .type = type_str[0] ? type_str : NULL,
+                      .parent = g_current_impl[0] ? g_current_impl : NULL
+                  });
+    }
+
+    /* Index the types named on the right-hand side */
+    if (!ts_node_is_null(type_node)) {
+        visit_node(type_node, source_code, directory, filename, result, filter);
+    }
+}
+
+/* Associated type declared in a trait: `type Item;` or `type Item: Bound;`.
+ * The bounds go in the type column. */
+static void handle_associated_type(TSNode node, const char *source_code,
+                                   const char *directory, const char *filename,
+                                   ParseResult *result, SymbolFilter *filter, int line) {
+    TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
+    TSNode bounds_node = ts_node_child_by_field_name(node, "bounds", 6);
+    if (ts_node_is_null(name_node)) return;
+
+    char name[SYMBOL_MAX_LENGTH];
+    safe_extract_node_text(source_code, name_node, name, sizeof(name), filename);
+
+    char bounds[SYMBOL_MAX_LENGTH] = "";
+    if (!ts_node_is_null(bounds_node)) {
+        safe_extract_node_text(source_code, bounds_node, bounds, sizeof(bounds), filename);
+    }
+    /* trait_bounds text starts with the colon */
+    const char *bounds_text = bounds;
+    if (*bounds_text == ':') bounds_text++;
+    while (*bounds_text == ' ') bounds_text++;
+
+    char location[128];
+    format_source_location(node, location, sizeof(location));
+
+    if (filter_should_index(filter, name)) {
+        add_entry(result, name, line, CONTEXT_TYPE,
+                  directory, filename, location,
+                  &(ExtColumns){
+                      .definition = "1",
+                      .type = bounds_text[0] ? bounds_text : NULL,
+                      .parent = g_current_impl[0] ? g_current_impl : NULL
                   });
     }
+
+    if (!ts_node_is_null(bounds_node)) {
+        process_children(bounds_node, source_code, directory, filename, result, filter);
+    }
 }
 
 static void handle_macro_definition(TSNode node, const char *source_code,
@@ -1282,6 +1404,10 @@ static void visit_node(TSNode node, const char *source_code, const char *directo
         handle_type_item(node, source_code, directory, filename, result, filter, line);
         return;
     }
+    if (strcmp(t, "associated_type") == 0) {
+        handle_associated_type(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
     if (strcmp(t, "macro_definition") == 0) {
         handle_macro_definition(node, source_code, directory, filename, result, filter, line);
         return;
@@ -1334,6 +1460,7 @@ int parser_init(RustParser *parser, SymbolFilter *filter) {
     parser->debug = 0;
     g_debug = 0;
     g_current_impl[0] = '\0';
+    g_current_trait[0] = '\0';
     return 0;
 }
 
@@ -1344,36 +1471,24 @@ void parser_set_debug(RustParser *parser, int debug) {
 
 int parser_parse_file(RustParser *parser, const char *filepath,
                       const char *project_root, ParseResult *result) {
-    FILE *fp = safe_fopen(filepath, "rb", 0);
-    if (!fp) {
-        fprintf(stderr, "Cannot open file: %s\n", filepath);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-    int fd = fileno(fp);
-    struct stat st;
-    if (fstat(fd, &st) != 0) { fclose(fp); return -1; }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) { fclose(fp); return -1; }
-    size_t bytes_read = fread(source_code, 1, file_size, fp);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "Error reading %s: expected %zu got %zu\n",
-                filepath, file_size, bytes_read);
-        free(source_code); fclose(fp); return -1;
-    }
-    source_code[bytes_read] = '\0';
-    fclose(fp);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     result->count = 0;
-    TSTree *tree = ts_parser_parse_string(parser->parser, NULL,
-                                          source_code, (uint32_t)bytes_read);
+    TSTree *tree = ts_parser_parse(parser->parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "Failed to parse: %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, NULL);
+
     TSNode root = ts_tree_root_node(tree);
 
     char directory[DIRECTORY_MAX_LENGTH];
@@ -1391,10 +1506,14 @@ int parser_parse_file(RustParser *parser, const char *filepath,
     }
 
     g_current_impl[0] = '\0';
+    g_current_trait[0] = '\0';
     visit_node(root, source_code, directory, filename, result, parser->filter);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_SLASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
 
     ts_tree_delete(tree);
-    free(source_code);
+    source_file_close(&source);
     return 0;
 }
 
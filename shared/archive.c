@@ -0,0 +1,502 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "archive.h"
+#include "constants.h"
+#include "file_opener.h"
+#include <ctype.h>
+#include <limits.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <zlib.h>
+
+#define TAR_BLOCK 512
+
+/* Largest zlib read or inflate step (their lengths are unsigned int) */
+#define ARCHIVE_IO_CHUNK (1u << 30)
+
+static int ends_with_nocase(const char *s, const char *suffix) {
+    size_t len = strlen(s);
+    size_t suffix_len = strlen(suffix);
+    if (len < suffix_len) {
+        return 0;
+    }
+    for (size_t i = 0; i < suffix_len; i++) {
+        if (tolower((unsigned char)s[len - suffix_len + i]) != suffix[i]) {
+            return 0;
+        }
+    }
+    return 1;
+}
+
+static int is_zip_path(const char *path) {
+    return ends_with_nocase(path, ".zip");
+}
+
+int is_archive_path(const char *path) {
+    return ends_with_nocase(path, ".tar") || ends_with_nocase(path, ".tar.gz") ||
+           ends_with_nocase(path, ".tgz") || is_zip_path(path);
+}
+
+/* Name an entry is recorded under: without leading "/" and "./"
+ * Returns: 0 if it is a file name worth visiting, -1 to pass it over
+ * (a directory, empty, too long, or with a ".." component) */
+static int clean_entry_name(const char *raw, size_t raw_len, char *out, size_t size) {
+    size_t len = strnlen(raw, raw_len);
+    for (;;) {
+        if (len > 0 && raw[0] == '/') {
+            raw++;
+            len--;
+        } else if (len > 1 && raw[0] == '.' && raw[1] == '/') {
+            raw += 2;
+            len -= 2;
+        } else {
+            break;
+        }
+    }
+    if (len == 0 || raw[len - 1] == '/' || len >= size) {
+        return -1;
+    }
+    memcpy(out, raw, len);
+    out[len] = '\0';
+
+    for (const char *p = out; *p; ) {
+        const char *slash = strchr(p, '/');
+        size_t part = slash ? (size_t)(slash - p) : strlen(p);
+        if (part == 2 && p[0] == '.' && p[1] == '.') {
+            return -1;
+        }
+        p += part + (slash ? 1 : 0);
+    }
+    return 0;
+}
+
+static int archive_error(char *error, size_t error_size, const char *message) {
+    snprintf(error, error_size, "%s", message);
+    return -1;
+}
+
+/* ---- tar (plain or gzip-compressed, both read through gzread) ---- */
+
+/* Read exactly length bytes
+ * Returns: 0 on success, -1 at a short read or error */
+static int gz_read_all(gzFile gz, void *buffer, size_t length) {
+    unsigned char *p = buffer;
+    while (length > 0) {
+        unsigned int step = length > ARCHIVE_IO_CHUNK ? ARCHIVE_IO_CHUNK : (unsigned int)length;
+        int got = gzread(gz, p, step);
+        if (got <= 0) {
+            return -1;
+        }
+        p += got;
+        length -= (size_t)got;
+    }
+    return 0;
+}
+
+static int gz_skip(gzFile gz, unsigned long long length) {
+    unsigned char buffer[TAR_BLOCK * 16];
+    while (length > 0) {
+        size_t step = length > sizeof(buffer) ? sizeof(buffer) : (size_t)length;
+        if (gz_read_all(gz, buffer, step) != 0) {
+            return -1;
+        }
+        length -= step;
+    }
+    return 0;
+}
+
+/* Octal field, or the base-256 form GNU tar uses for large sizes
+ * Returns: 0 on success, -1 if the field is not a number */
+static int tar_number(const unsigned char *field, size_t width, unsigned long long *value) {
+    *value = 0;
+    if (field[0] & 0x80) {
+        for (size_t i = 1; i < width; i++) {
+            if (*value >> 56) {
+                return -1;
+            }
+            *value = (*value << 8) | field[i];
+        }
+        return (field[0] & 0x7f) == 0 ? 0 : -1;
+    }
+    size_t i = 0;
+    while (i < width && (field[i] == ' ' || field[i] == '\0')) i++;
+    if (i == width) {
+        return 0;
+    }
+    for (; i < width && field[i] >= '0' && field[i] <= '7'; i++) {
+        *value = (*value << 3) | (unsigned long long)(field[i] - '0');
+    }
+    return (i == width || field[i] == ' ' || field[i] == '\0') ? 0 : -1;
+}
+
+static int tar_checksum_ok(const unsigned char *header) {
+    unsigned long long stored;
+    if (tar_number(header + 148, 8, &stored) != 0) {
+        return 0;
+    }
+    unsigned long long sum = 0;
+    for (int i = 0; i < TAR_BLOCK; i++) {
+        sum += (i >= 148 && i < 156) ? ' ' : header[i];
+    }
+    return sum == stored;
+}
+
+/* The "path" record of a pax extended header, if it has one */
+static void pax_path(const char *data, size_t length, char *path, size_t size) {
+    const char *p = data;
+    const char *end = data + length;
+    while (p < end) {
+        char *after;
+        unsigned long record = strtoul(p, &after, 10);
+        if (after == p || *after != ' ' || record == 0 || record > (size_t)(end - p)) {
+            return;
+        }
+        const char *key = after + 1;
+        const char *record_end = p + record;
+        if ((size_t)(record_end - key) > 5 && strncmp(key, "path=", 5) == 0) {
+            size_t value_len = (size_t)(record_end - key - 5);
+            if (value_len > 0 && key[5 + value_len - 1] == '\n') value_len--;
+            snprintf(path, size, "%.*s", (int)value_len, key + 5);
+        }
+        p = record_end;
+    }
+}
+
+static int tar_for_each(const char *path, const ArchiveVisitor *visitor, char *error, size_t error_size) {
+    /* Plain tar is read through gzread too: zlib passes it through */
+    gzFile gz = gzopen(path, "rb");
+    if (!gz) {
+        return archive_error(error, error_size, "cannot open archive");
+    }
+
+    unsigned char header[TAR_BLOCK];
+    char long_name[PATH_MAX_LENGTH] = "";   /* From a GNU 'L' or pax header */
+    char name[PATH_MAX_LENGTH];
+    int status = 0;
+
+    for (;;) {
+        int got = gzread(gz, header, TAR_BLOCK);
+        if (got == 0) {
+            break;  /* End without the terminating zero blocks */
+        }
+        if (got != TAR_BLOCK) {
+            status = archive_error(error, error_size, "truncated tar header");
+            break;
+        }
+        int zero = 1;
+        for (int i = 0; i < TAR_BLOCK && zero; i++) {
+            zero = header[i] == 0;
+        }
+        if (zero) {
+            break;
+        }
+        unsigned long long size;
+        if (!tar_checksum_ok(header) || tar_number(header + 124, 12, &size) != 0) {
+            status = archive_error(error, error_size, "not a tar archive, or a damaged header");
+            break;
+        }
+        unsigned long long padded = (size + TAR_BLOCK - 1) / TAR_BLOCK * TAR_BLOCK;
+        char type = (char)header[156];
+
+        if (type == 'L' || type == 'x') {
+            /* Name of the next entry */
+            if (size >= PATH_MAX_LENGTH * 4) {
+                status = archive_error(error, error_size, "oversized tar name header");
+                break;
+            }
+            char data[PATH_MAX_LENGTH * 4];
+            if (gz_read_all(gz, data, (size_t)size) != 0 || gz_skip(gz, padded - size) != 0) {
+                status = archive_error(error, error_size, "truncated tar entry");
+                break;
+            }
+            data[size] = '\0';
+            if (type == 'L') {
+                snprintf(long_name, sizeof(long_name), "%.*s", (int)sizeof(long_name) - 1, data);
+            } else {
+                pax_path(data, (size_t)size, long_name, sizeof(long_name));
+            }
+            continue;
+        }
+
+        /* ustar splits long names into a prefix and the name */
+        char raw[PATH_MAX_LENGTH];
+        if (long_name[0]) {
+            snprintf(raw, sizeof(raw), "%s", long_name);
+        } else if (memcmp(header + 257, "ustar", 5) == 0 && header[345]) {
+            snprintf(raw, sizeof(raw), "%.*s/%.*s", (int)strnlen((const char *)header + 345, 155),
+                     (const char *)header + 345, (int)strnlen((const char *)header, 100),
+                     (const char *)header);
+        } else {
+            snprintf(raw, sizeof(raw), "%.*s", (int)strnlen((const char *)header, 100),
+                     (const char *)header);
+        }
+        long_name[0] = '\0';
+
+        int regular = type == '0' || type == '\0' || type == '7';
+        int wanted = regular && clean_entry_name(raw, strlen(raw), name, sizeof(name)) == 0 &&
+                     size < SIZE_MAX &&
+                     (!visitor->select || visitor->select(name, (long long)size, visitor->ctx));
+        if (!wanted) {
+            if (gz_skip(gz, padded) != 0) {
+                status = archive_error(error, error_size, "truncated tar entry");
+                break;
+            }
+            continue;
+        }
+
+        char *content = malloc((size_t)size + 1);
+        if (!content) {
+            status = archive_error(error, error_size, "out of memory reading an entry");
+            break;
+        }
+        if (gz_read_all(gz, content, (size_t)size) != 0 || gz_skip(gz, padded - size) != 0) {
+            free(content);
+            status = archive_error(error, error_size, "truncated tar entry");
+            break;
+        }
+        content[size] = '\0';
+        int stop = visitor->entry(name, content, (size_t)size, visitor->ctx);
+        free(content);
+        if (stop) {
+            status = 1;
+            break;
+        }
+    }
+
+    if (status == 0) {
+        int errnum;
+        const char *message = gzerror(gz, &errnum);
+        if (errnum != Z_OK) {
+            /* zlib prefixes the path: "vendor.tgz: unexpected end of file" */
+            size_t path_len = strlen(path);
+            if (strncmp(message, path, path_len) == 0 && strncmp(message + path_len, ": ", 2) == 0) {
+                message += path_len + 2;
+            }
+            status = archive_error(error, error_size, message);
+        }
+    }
+    gzclose(gz);
+    return status;
+}
+
+/* ---- zip ---- */
+
+#define ZIP_END_SIGNATURE 0x06054b50u
+#define ZIP_CENTRAL_SIGNATURE 0x02014b50u
+#define ZIP_LOCAL_SIGNATURE 0x04034b50u
+#define ZIP_END_SIZE 22
+#define ZIP_CENTRAL_SIZE 46
+#define ZIP_LOCAL_SIZE 30
+#define ZIP_MAX_COMMENT 0xffff
+
+static unsigned int le16(const unsigned char *p) {
+    return (unsigned int)p[0] | ((unsigned int)p[1] << 8);
+}
+
+static unsigned long le32(const unsigned char *p) {
+    return (unsigned long)p[0] | ((unsigned long)p[1] << 8) |
+           ((unsigned long)p[2] << 16) | ((unsigned long)p[3] << 24);
+}
+
+static int read_at(FILE *fp, unsigned long offset, void *buffer, size_t length) {
+    if (offset > LONG_MAX || fseek(fp, (long)offset, SEEK_SET) != 0) {
+        return -1;
+    }
+    return fread(buffer, 1, length, fp) == length ? 0 : -1;
+}
+
+/* Find the end of central directory record, which ends the file up to
+ * its comment
+ * Returns: 0 on success, -1 if there is none */
+static int zip_find_end(FILE *fp, unsigned char *record) {
+    if (fseek(fp, 0, SEEK_END) != 0) {
+        return -1;
+    }
+    long file_size = ftell(fp);
+    if (file_size < ZIP_END_SIZE) {
+        return -1;
+    }
+    size_t tail = (size_t)(file_size < ZIP_END_SIZE + ZIP_MAX_COMMENT ? file_size : ZIP_END_SIZE + ZIP_MAX_COMMENT);
+    unsigned char *buffer = malloc(tail);
+    if (!buffer) {
+        return -1;
+    }
+    int found = -1;
+    if (read_at(fp, (unsigned long)(file_size - (long)tail), buffer, tail) == 0) {
+        for (size_t i = tail - ZIP_END_SIZE + 1; i-- > 0; ) {
+            if (le32(buffer + i) == ZIP_END_SIGNATURE) {
+                memcpy(record, buffer + i, ZIP_END_SIZE);
+                found = 0;
+                break;
+            }
+        }
+    }
+    free(buffer);
+    return found;
+}
+
+/* Read a stored or deflated entry's data into content (length bytes)
+ * Returns: 0 on success, -1 with why in error */
+static int zip_read_entry(FILE *fp, unsigned long local_offset, unsigned int method,
+                          unsigned long compressed, unsigned long crc, char *content, size_t length,
+                          char *error, size_t error_size) {
+    unsigned char local[ZIP_LOCAL_SIZE];
+    if (read_at(fp, local_offset, local, sizeof(local)) != 0 || le32(local) != ZIP_LOCAL_SIGNATURE) {
+        return archive_error(error, error_size, "damaged zip entry header");
+    }
+    unsigned long data_offset = local_offset + ZIP_LOCAL_SIZE + le16(local + 26) + le16(local + 28);
+    if (data_offset > LONG_MAX || fseek(fp, (long)data_offset, SEEK_SET) != 0) {
+        return archive_error(error, error_size, "damaged zip entry header");
+    }
+
+    if (method == 0) {
+        if (compressed != length || fread(content, 1, length, fp) != length) {
+            return archive_error(error, error_size, "truncated zip entry");
+        }
+    } else {
+        z_stream stream;
+        memset(&stream, 0, sizeof(stream));
+        if (inflateInit2(&stream, -MAX_WBITS) != Z_OK) {
+            return archive_error(error, error_size, "cannot start inflating a zip entry");
+        }
+        unsigned char input[LINE_BUFFER_LARGE * 8];
+        unsigned long remaining = compressed;
+        size_t produced = 0;
+        int rc = Z_OK;
+        while (rc == Z_OK) {
+            if (stream.avail_in == 0 && remaining > 0) {
+                size_t step = remaining > sizeof(input) ? sizeof(input) : (size_t)remaining;
+                if (fread(input, 1, step, fp) != step) {
+                    break;
+                }
+                stream.next_in = input;
+                stream.avail_in = (unsigned int)step;
+                remaining -= step;
+            }
+            /* One spare byte, so data beyond length shows as Z_OK */
+            size_t room = length + 1 - produced;
+            stream.next_out = (unsigned char *)content + produced;
+            stream.avail_out = room > ARCHIVE_IO_CHUNK ? ARCHIVE_IO_CHUNK : (unsigned int)room;
+            unsigned int before = stream.avail_out;
+            rc = inflate(&stream, Z_NO_FLUSH);
+            produced += before - stream.avail_out;
+            if (rc == Z_BUF_ERROR && remaining == 0 && stream.avail_in == 0) {
+                break;
+            }
+            if (rc == Z_BUF_ERROR) {
+                rc = Z_OK;
+            }
+            if (produced > length) {
+                break;
+            }
+        }
+        inflateEnd(&stream);
+        if (rc != Z_STREAM_END || produced != length) {
+            return archive_error(error, error_size, "damaged deflated zip entry");
+        }
+    }
+
+    unsigned long actual = crc32(0L, Z_NULL, 0);
+    for (size_t done = 0; done < length; ) {
+        unsigned int step = length - done > ARCHIVE_IO_CHUNK ? ARCHIVE_IO_CHUNK : (unsigned int)(length - done);
+        actual = crc32(actual, (const unsigned char *)content + done, step);
+        done += step;
+    }
+    if (actual != crc) {
+        return archive_error(error, error_size, "zip entry fails its CRC check");
+    }
+    return 0;
+}
+
+static int zip_for_each(const char *path, const ArchiveVisitor *visitor, char *error, size_t error_size) {
+    FILE *fp = safe_fopen(path, "rb", 0);
+    if (!fp) {
+        return archive_error(error, error_size, "cannot open archive");
+    }
+
+    unsigned char end[ZIP_END_SIZE];
+    if (zip_find_end(fp, end) != 0) {
+        fclose(fp);
+        return archive_error(error, error_size, "not a zip archive");
+    }
+    unsigned int entries = le16(end + 10);
+    unsigned long offset = le32(end + 16);
+    if (entries == 0xffff || offset == 0xffffffffUL) {
+        fclose(fp);
+        return archive_error(error, error_size, "ZIP64 archives are not supported");
+    }
+
+    char name[PATH_MAX_LENGTH];
+    int status = 0;
+    for (unsigned int i = 0; i < entries && status == 0; i++) {
+        unsigned char record[ZIP_CENTRAL_SIZE];
+        if (read_at(fp, offset, record, sizeof(record)) != 0 || le32(record) != ZIP_CENTRAL_SIGNATURE) {
+            status = archive_error(error, error_size, "damaged zip central directory");
+            break;
+        }
+        unsigned int flags = le16(record + 8);
+        unsigned int method = le16(record + 10);
+        unsigned long crc = le32(record + 16);
+        unsigned long compressed = le32(record + 20);
+        unsigned long size = le32(record + 24);
+        unsigned int name_len = le16(record + 28);
+        unsigned long local_offset = le32(record + 42);
+        unsigned long next = offset + ZIP_CENTRAL_SIZE + name_len + le16(record + 30) + le16(record + 32);
+
+        char raw[PATH_MAX_LENGTH];
+        int named = name_len < sizeof(raw) &&
+                    fread(raw, 1, name_len, fp) == name_len &&
+                    clean_entry_name(raw, name_len, name, sizeof(name)) == 0;
+        offset = next;
+
+        /* Encrypted (bit 0) or compressed some other way: passed over */
+        if (!named || (flags & 1) || (method != 0 && method != 8) ||
+            (visitor->select && !visitor->select(name, (long long)size, visitor->ctx))) {
+            continue;
+        }
+
+        char *content = malloc((size_t)size + 1);
+        if (!content) {
+            status = archive_error(error, error_size, "out of memory reading an entry");
+            break;
+        }
+        if (zip_read_entry(fp, local_offset, method, compressed, crc, content, (size_t)size,
+                           error, error_size) != 0) {
+            free(content);
+            status = -1;
+            break;
+        }
+        content[size] = '\0';
+        int stop = visitor->entry(name, content, (size_t)size, visitor->ctx);
+        free(content);
+        if (stop) {
+            status = 1;
+        }
+    }
+
+    fclose(fp);
+    return status;
+}
+
+int archive_for_each(const char *path, const ArchiveVisitor *visitor, char *error, size_t error_size) {
+    error[0] = '\0';
+    return is_zip_path(path) ? zip_for_each(path, visitor, error, error_size)
+                             : tar_for_each(path, visitor, error, error_size);
+}
@@ -0,0 +1,51 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef ARCHIVE_H
+#define ARCHIVE_H
+
+#include <stddef.h>
+
+/*
+ * Reading source files out of archives without extracting them
+ *
+ * Supported, by file name: .tar, .tar.gz / .tgz (zlib) and .zip (stored or
+ * deflated entries). Only regular files are visited; directories, links
+ * and encrypted zip entries are passed over, as are entries whose name is
+ * unsafe to record (containing a ".." component). Names are given
+ * relative, without a leading "/" or "./".
+ */
+
+typedef struct {
+    /* Whether to read an entry of size bytes (NULL: read every entry) */
+    int (*select)(const char *name, long long size, void *ctx);
+    /* An entry's content, NUL-terminated; nonzero stops the walk */
+    int (*entry)(const char *name, const char *content, size_t length, void *ctx);
+    void *ctx;
+} ArchiveVisitor;
+
+/* Whether path names an archive that archive_for_each() can read (by its
+ * extension, case-insensitively) */
+int is_archive_path(const char *path);
+
+/* Visit the files of the archive at path in archive order
+ * Returns: 0 when every entry was visited, 1 if the visitor stopped,
+ *          -1 if the archive cannot be read (why in error; entries
+ *          before the damage were visited) */
+int archive_for_each(const char *path, const ArchiveVisitor *visitor, char *error, size_t error_size);
+
+#endif /* ARCHIVE_H */
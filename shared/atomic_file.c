@@ -0,0 +1,99 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "atomic_file.h"
+#include <string.h>
+#include <sys/stat.h>
+#include <unistd.h>
+
+#if defined(_WIN32) || defined(__MINGW32__) || defined(__MINGW64__)
+#include <io.h>
+#include <windows.h>
+
+static int sync_file(FILE *fp) {
+    return _commit(_fileno(fp));
+}
+
+/* rename() on Windows fails when the destination exists */
+static int replace_file(const char *from, const char *to) {
+    return MoveFileExA(from, to, MOVEFILE_REPLACE_EXISTING | MOVEFILE_WRITE_THROUGH) ? 0 : -1;
+}
+#else
+static int sync_file(FILE *fp) {
+    return fsync(fileno(fp));
+}
+
+static int replace_file(const char *from, const char *to) {
+    return rename(from, to);
+}
+#endif
+
+FILE *atomic_file_open(AtomicFile *file, const char *path) {
+    memset(file, 0, sizeof(*file));
+    snprintf(file->path, sizeof(file->path), "%s", path);
+
+    struct stat st;
+    if (stat(path, &st) == 0 && !S_ISREG(st.st_mode)) {
+        file->fp = fopen(path, "w");
+        return file->fp;
+    }
+
+    int written = snprintf(file->temp_path, sizeof(file->temp_path), "%s.%ld.tmp", path, (long)getpid());
+    if (written < 0 || (size_t)written >= sizeof(file->temp_path)) {
+        file->temp_path[0] = '\0';
+        return NULL;
+    }
+    file->fp = fopen(file->temp_path, "w");
+    if (!file->fp) {
+        file->temp_path[0] = '\0';
+    }
+    return file->fp;
+}
+
+int atomic_file_commit(AtomicFile *file) {
+    if (!file->fp) {
+        return -1;
+    }
+    int failed = fflush(file->fp) != 0 || ferror(file->fp);
+    if (file->temp_path[0] && !failed) {
+        failed = sync_file(file->fp) != 0;
+    }
+    failed |= fclose(file->fp) != 0;
+    file->fp = NULL;
+
+    if (file->temp_path[0]) {
+        if (!failed && replace_file(file->temp_path, file->path) != 0) {
+            failed = 1;
+        }
+        if (failed) {
+            remove(file->temp_path);
+        }
+        file->temp_path[0] = '\0';
+    }
+    return failed ? -1 : 0;
+}
+
+void atomic_file_abort(AtomicFile *file) {
+    if (file->fp) {
+        fclose(file->fp);
+        file->fp = NULL;
+    }
+    if (file->temp_path[0]) {
+        remove(file->temp_path);
+        file->temp_path[0] = '\0';
+    }
+}
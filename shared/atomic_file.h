@@ -0,0 +1,54 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef ATOMIC_FILE_H
+#define ATOMIC_FILE_H
+
+#include <stdio.h>
+#include "constants.h"
+
+/*
+ * Files replaced only once they are complete
+ *
+ * Output goes to a temporary file next to the destination ("out.ndjson"
+ * is written as "out.ndjson.<pid>.tmp"), which is flushed to disk and
+ * renamed over the destination on commit. A run killed before that leaves
+ * the previous file as it was, plus at most a stray .tmp file.
+ *
+ * A destination that exists and is not a regular file (/dev/stdout, a
+ * FIFO such as >(jq ...)) cannot be replaced and is written directly.
+ */
+
+typedef struct {
+    FILE *fp;                           /* Write here */
+    char path[PATH_MAX_LENGTH];         /* Destination */
+    char temp_path[PATH_MAX_LENGTH];    /* "" when writing path directly */
+} AtomicFile;
+
+/* Start writing path
+ * Returns: file->fp, or NULL if it cannot be created */
+FILE *atomic_file_open(AtomicFile *file, const char *path);
+
+/* Flush, close and move the file into place
+ * Returns: 0 on success, -1 if it could not be written (the destination
+ * is left as it was and the temporary file removed) */
+int atomic_file_commit(AtomicFile *file);
+
+/* Close and remove the temporary file, leaving the destination alone */
+void atomic_file_abort(AtomicFile *file);
+
+#endif /* ATOMIC_FILE_H */
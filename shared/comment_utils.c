@@ -20,6 +20,7 @@
 #include "constants.h"
 #include <string.h>
 #include <ctype.h>
+#include <stdlib.h>
 
 char* strip_comment_delimiters(char *comment_text) {
     if (!comment_text || !comment_text[0]) {
@@ -45,3 +46,195 @@ char* strip_comment_delimiters(char *comment_text) {
 
     return text_start;
 }
+
+/* One source line, surrounding whitespace trimmed */
+typedef struct {
+    const char *start;
+    const char *end;
+} SourceLine;
+
+/* Doc comment being assembled; text is cut when DOC_MAX_LENGTH is reached */
+typedef struct {
+    char text[DOC_MAX_LENGTH];
+    size_t len;
+    int blank_lines;  /* Blank lines seen since the last text line */
+    int full;
+} DocText;
+
+static SourceLine get_source_line(const char *source, size_t length,
+                                  const size_t *line_starts, int line_count, int line) {
+    SourceLine l;
+    l.start = source + line_starts[line - 1];
+    l.end = line < line_count ? source + line_starts[line] - 1 : source + length;
+    while (l.start < l.end && isspace((unsigned char)*l.start)) l.start++;
+    while (l.end > l.start && isspace((unsigned char)l.end[-1])) l.end--;
+    return l;
+}
+
+static int line_starts_with(SourceLine l, const char *prefix) {
+    size_t n = strlen(prefix);
+    return (size_t)(l.end - l.start) >= n && memcmp(l.start, prefix, n) == 0;
+}
+
+static int line_ends_with(SourceLine l, const char *suffix) {
+    size_t n = strlen(suffix);
+    return (size_t)(l.end - l.start) >= n && memcmp(l.end - n, suffix, n) == 0;
+}
+
+static void doc_append(DocText *doc, const char *text, size_t n) {
+    if (doc->full) return;
+    size_t room = sizeof(doc->text) - 1 - doc->len;
+    if (n > room) {
+        /* Cut at a UTF-8 character boundary */
+        n = room;
+        while (n > 0 && ((unsigned char)text[n] & 0xC0) == 0x80) n--;
+        doc->full = 1;
+    }
+    memcpy(doc->text + doc->len, text, n);
+    doc->len += n;
+    doc->text[doc->len] = '\0';
+}
+
+/* Add one line of comment text, markers already removed. Leading and
+ * trailing blank lines are dropped, blank lines in between are kept. */
+static void doc_add_line(DocText *doc, const char *start, const char *end) {
+    if (start < end && *start == ' ') start++;
+    while (end > start && isspace((unsigned char)end[-1])) end--;
+    if (start == end) {
+        if (doc->len > 0) doc->blank_lines++;
+        return;
+    }
+    if (doc->len > 0) {
+        for (int i = 0; i <= doc->blank_lines; i++) {
+            doc_append(doc, "\n", 1);
+        }
+    }
+    doc->blank_lines = 0;
+    doc_append(doc, start, (size_t)(end - start));
+}
+
+/* Declarations that can carry a doc comment */
+static int is_documentable(const IndexEntry *entry) {
+    if (!entry->is_definition) return 0;
+    switch (entry->context) {
+        case CONTEXT_CLASS:
+        case CONTEXT_INTERFACE:
+        case CONTEXT_FUNCTION:
+        case CONTEXT_VARIABLE:
+        case CONTEXT_TYPE:
+        case CONTEXT_PROPERTY:
+        case CONTEXT_NAMESPACE:
+        case CONTEXT_ENUM:
+        case CONTEXT_ENUM_CASE:
+        case CONTEXT_TRAIT:
+        case CONTEXT_ALIAS:
+            return 1;
+        default:
+            return 0;
+    }
+}
+
+/* Collect the comment ending right above a declaration line into doc */
+static void read_doc_comment(const char *source, size_t length, const size_t *line_starts,
+                             int line_count, int decl_line, DocCommentStyle style, DocText *doc) {
+    const char *line_prefix = style == DOC_COMMENT_HASH ? "#" : "//";
+    int last = decl_line - 1;
+
+    /* Decorators and attributes sit between a doc comment and its declaration */
+    while (last >= 1) {
+        SourceLine l = get_source_line(source, length, line_starts, line_count, last);
+        if (!line_starts_with(l, "@") && !(style == DOC_COMMENT_SLASH && line_starts_with(l, "#["))) {
+            break;
+        }
+        last--;
+    }
+    if (last < 1) return;
+
+    SourceLine end_line = get_source_line(source, length, line_starts, line_count, last);
+    if (style == DOC_COMMENT_SLASH && line_ends_with(end_line, "*/")) {
+        /* Block comment: find its opening slash-star, which must start a line */
+        const char *p = end_line.end - 2;
+        while (p > source && !(p[-1] == '/' && p[0] == '*')) p--;
+        if (p == source) return;
+        const char *open = p - 1;
+        int first = last;
+        while (first > 1 && source + line_starts[first - 1] > open) first--;
+        SourceLine first_line = get_source_line(source, length, line_starts, line_count, first);
+        if (first_line.start != open) return;
+
+        for (int line = first; line <= last; line++) {
+            SourceLine l = get_source_line(source, length, line_starts, line_count, line);
+            const char *start = l.start;
+            const char *end = l.end;
+            if (line == last) {
+                end -= 2;
+                while (end > start && end[-1] == '*') end--;
+            }
+            if (line == first) {
+                start += 2;
+                while (start < end && (*start == '*' || *start == '!')) start++;
+            } else if (start < end && *start == '*') {
+                start++;
+            }
+            doc_add_line(doc, start, end);
+        }
+        return;
+    }
+
+    /* Run of line comments */
+    int first = last;
+    while (first >= 1) {
+        SourceLine l = get_source_line(source, length, line_starts, line_count, first);
+        if (!line_starts_with(l, line_prefix) || line_starts_with(l, "#!")) break;
+        first--;
+    }
+    for (int line = first + 1; line <= last; line++) {
+        SourceLine l = get_source_line(source, length, line_starts, line_count, line);
+        const char *start = l.start + strlen(line_prefix);
+        /* Doc markers: triple slash, //! and ## */
+        while (start < l.end && (*start == line_prefix[0] || (style == DOC_COMMENT_SLASH && *start == '!'))) {
+            start++;
+        }
+        doc_add_line(doc, start, l.end);
+    }
+}
+
+int attach_doc_comments(ParseResult *result, const char *source, size_t length,
+                        DocCommentStyle style) {
+    if (result->count == 0) return 0;
+
+    int line_count = 1;
+    for (size_t i = 0; i < length; i++) {
+        if (source[i] == '\n') line_count++;
+    }
+    size_t *line_starts = malloc((size_t)line_count * sizeof(size_t));
+    unsigned char *documented = calloc((size_t)line_count + 1, 1);
+    if (!line_starts || !documented) {
+        free(line_starts);
+        free(documented);
+        return -1;
+    }
+    int line = 0;
+    line_starts[line++] = 0;
+    for (size_t i = 0; i < length; i++) {
+        if (source[i] == '\n') line_starts[line++] = i + 1;
+    }
+
+    for (int i = 0; i < result->count; i++) {
+        IndexEntry *entry = &result->entries[i];
+        if (entry->line < 2 || entry->line > line_count || documented[entry->line] ||
+            !is_documentable(entry)) {
+            continue;
+        }
+        documented[entry->line] = 1;
+
+        DocText doc = {.len = 0};
+        doc.text[0] = '\0';
+        read_doc_comment(source, length, line_starts, line_count, entry->line, style, &doc);
+        memcpy(entry->doc, doc.text, doc.len + 1);
+    }
+
+    free(line_starts);
+    free(documented);
+    return 0;
+}
@@ -19,6 +19,13 @@
 #define COMMENT_UTILS_H
 
 #include <stddef.h>
+#include "parse_result.h"
+
+/* Comment syntax of a language, for attach_doc_comments() */
+typedef enum {
+    DOC_COMMENT_SLASH,   /* double-slash lines and slash-star blocks (C, Go, TypeScript, PHP, Rust) */
+    DOC_COMMENT_HASH     /* hash lines (Python, Perl, Ruby) */
+} DocCommentStyle;
 
 /**
  * Strip comment delimiters from comment text
@@ -31,4 +38,23 @@
  */
 char* strip_comment_delimiters(char *comment_text);
 
+/**
+ * Store the comment directly above each declaration in its doc column
+ *
+ * The comment is a run of line comments or one block comment ending on the
+ * line before the declaration (decorator/attribute lines in between are
+ * skipped); a blank line in between means it documents nothing. Markers and
+ * the leading stars of block comment lines are stripped, lines are joined
+ * with newlines. Only the first definition on a line gets the doc, so
+ * `class Box<T>` documents Box, not T.
+ *
+ * @param result Entries of one file, as parsed from source
+ * @param source File contents
+ * @param length Length of source in bytes
+ * @param style Comment syntax of the file's language
+ * @return 0 on success, -1 if out of memory (no docs are stored then)
+ */
+int attach_doc_comments(ParseResult *result, const char *source, size_t length,
+                        DocCommentStyle style);
+
 #endif
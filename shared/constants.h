@@ -75,15 +75,44 @@
 /* Maximum length for source location strings (e.g., "10:5-12:20") */
 #define SOURCE_LOCATION_MAX_LENGTH 32
 
+/* Maximum length of a file content hash (64-bit FNV-1a as hex + null terminator) */
+#define FILE_HASH_LENGTH 17
+
 /* Maximum length for access modifiers (e.g., "protected", "private") */
 #define MODIFIER_MAX_LENGTH 16
 
 /* Maximum length for scope identifiers (e.g., "instance", "static") */
 #define SCOPE_MAX_LENGTH 16
 
+/* Maximum length for a scope path ("User.save.Local") and the number of
+ * nested scopes tracked while parsing; deeper scopes keep the outer path */
+#define SCOPE_PATH_MAX_LENGTH 256
+#define SCOPE_PATH_MAX_DEPTH 32
+
+/* Maximum length for a language name (the config directory, e.g., "typescript") */
+#define LANGUAGE_MAX_LENGTH 16
+
 /* Maximum length for clue/context hint strings */
 #define CLUE_MAX_LENGTH 64
 
+/* Maximum length for normalized struct field tags (e.g., json:"id,omitempty" db:"id") */
+#define TAGS_MAX_LENGTH 256
+
+/* Maximum number of key/value pairs parsed from one struct field tag */
+#define MAX_STRUCT_TAGS 16
+
+/* Maximum length for a function's parameter or return list (e.g., "r io.Reader, opts ...Option") */
+#define SIGNATURE_MAX_LENGTH 512
+
+/* Maximum length for a doc comment, markers stripped (longer ones are cut) */
+#define DOC_MAX_LENGTH 1024
+
+/* Maximum length for an imported path ("github.com/org/repo/pkg", "./utils") */
+#define IMPORT_PATH_MAX_LENGTH 512
+
+/* Maximum number of parameters or returns parsed from one signature list */
+#define MAX_SIGNATURE_PARAMS 32
+
 /* Maximum length for file extension strings (e.g., ".ts", ".tsx") */
 #define FILE_EXTENSION_MAX_LENGTH 16
 
@@ -98,11 +127,24 @@
 #define FILE_EXTENSIONS_FILENAME "file_extensions.txt"
 #define IGNORE_FILES_FILENAME "ignore_files.txt"
 #define KEYWORDS_FILENAME "keywords.txt"
+#define SYMBOL_LIMITS_FILENAME "symbol_limits.txt"
+#define EXTRACTORS_FILENAME "extractors.txt"
+#define IDENTIFIER_SPLIT_FILENAME "identifier_split.txt"
+
+/* All language word lists in one file (in <language>/config/, optional) */
+#define UNIFIED_CONFIG_FILENAME "sourceminder.toml"
+#define UNIFIED_CONFIG_MAX_SIZE (1024 * 1024)
+
+/* Lines read from the top of a file when looking for a generated code marker */
+#define GENERATED_HEADER_LINES 10
 
 /* Shared configuration filenames (in shared/config/) */
 #define STOPWORDS_FILENAME "stopwords.txt"
 #define REGEX_PATTERNS_FILENAME "regex-patterns.txt"
 
+/* Per-project ignore patterns (gitignore syntax, in the root of an indexed directory) */
+#define IGNORE_RULES_FILENAME ".sourceminderignore"
+
 
 /* ============================================================================
  * Array Capacity Limits
@@ -116,7 +158,7 @@
  */
 
 /* Legacy: Maximum number of index entries from parsing a single file
- * ParseResult now uses dynamic allocation starting at 1000 entries and
+ * ParseResult now uses dynamic allocation starting at 64 entries and
  * growing by 2x as needed. No hard limit. */
 #define MAX_PARSE_ENTRIES 100000
 
@@ -131,9 +173,16 @@
 /* Maximum number of regex patterns in filter pattern list */
 #define MAX_REGEX_PATTERNS 128
 
+/* Maximum number of custom extractors per language (extractors.txt) */
+#define MAX_CUSTOM_EXTRACTORS 32
+
 /* Maximum number of file extensions to track per language */
 #define MAX_FILE_EXTENSIONS 16
 
+/* Default --max-file-size: larger files are skipped rather than parsed
+ * (typically generated code, whose parse tree dwarfs the file itself) */
+#define DEFAULT_MAX_FILE_SIZE (10LL * 1024 * 1024)
+
 /* Maximum number of directories that can be excluded via --exclude-dir */
 #define MAX_EXCLUDE_DIRS 32
 
@@ -0,0 +1,237 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "custom_extractors.h"
+#include "file_opener.h"
+#include "file_utils.h"
+#include "source_file.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <ctype.h>
+
+/* Capture groups a regex may have; enough for any sensible extractor */
+#define CUSTOM_EXTRACTOR_MAX_GROUPS 10
+
+int custom_extractor_parse(const char *line, CustomExtractor *extractor,
+                           char *error, size_t error_size) {
+    while (*line == ' ' || *line == '\t') line++;
+    size_t len = strcspn(line, "\r\n");
+    if (len == 0 || line[0] == '#') {
+        return 0;
+    }
+
+    /* KIND */
+    size_t kind_len = 0;
+    if (!islower((unsigned char)line[0])) {
+        snprintf(error, error_size, "kind must start with a lowercase letter");
+        return -1;
+    }
+    while (islower((unsigned char)line[kind_len]) || isdigit((unsigned char)line[kind_len]) ||
+           line[kind_len] == '_') {
+        kind_len++;
+    }
+    if (kind_len >= sizeof(extractor->kind)) {
+        snprintf(error, error_size, "kind longer than %zu characters", sizeof(extractor->kind) - 1);
+        return -1;
+    }
+    memcpy(extractor->kind, line, kind_len);
+    extractor->kind[kind_len] = '\0';
+    const char *p = line + kind_len;
+
+    /* [:GROUP] */
+    int group = -1;
+    if (*p == ':') {
+        p++;
+        if (!isdigit((unsigned char)*p)) {
+            snprintf(error, error_size, "expected a group number after ':'");
+            return -1;
+        }
+        group = 0;
+        while (isdigit((unsigned char)*p)) {
+            group = group * 10 + (*p - '0');
+            if (group >= CUSTOM_EXTRACTOR_MAX_GROUPS) {
+                snprintf(error, error_size, "group must be less than %d", CUSTOM_EXTRACTOR_MAX_GROUPS);
+                return -1;
+            }
+            p++;
+        }
+    }
+    if (*p != ' ' && *p != '\t') {
+        snprintf(error, error_size, "expected KIND[:GROUP] followed by a regex");
+        return -1;
+    }
+    while (*p == ' ' || *p == '\t') p++;
+
+    /* REGEX (rest of the line) */
+    char pattern[LINE_BUFFER_LARGE];
+    size_t pattern_len = strcspn(p, "\r\n");
+    if (pattern_len == 0) {
+        snprintf(error, error_size, "missing regex");
+        return -1;
+    }
+    if (pattern_len >= sizeof(pattern)) {
+        snprintf(error, error_size, "regex too long");
+        return -1;
+    }
+    memcpy(pattern, p, pattern_len);
+    pattern[pattern_len] = '\0';
+
+    int ret = regcomp(&extractor->regex, pattern, REG_EXTENDED);
+    if (ret != 0) {
+        char reason[ERROR_MESSAGE_BUFFER];
+        regerror(ret, &extractor->regex, reason, sizeof(reason));
+        snprintf(error, error_size, "invalid regex: %s", reason);
+        return -1;
+    }
+
+    size_t groups = extractor->regex.re_nsub;
+    if (group < 0) {
+        group = groups > 0 ? 1 : 0;
+    }
+    if ((size_t)group > groups || groups >= CUSTOM_EXTRACTOR_MAX_GROUPS) {
+        regfree(&extractor->regex);
+        if (groups >= CUSTOM_EXTRACTOR_MAX_GROUPS) {
+            snprintf(error, error_size, "regex has more than %d groups", CUSTOM_EXTRACTOR_MAX_GROUPS - 1);
+        } else {
+            snprintf(error, error_size, "group %d does not exist (regex has %zu)", group, groups);
+        }
+        return -1;
+    }
+    extractor->group = group;
+    return 1;
+}
+
+int custom_extractors_load(CustomExtractorSet *set, const char *filepath) {
+    set->count = 0;
+    FILE *fp = safe_fopen(filepath, "r", 0);
+    if (!fp) {
+        return -1;
+    }
+
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    while (fgets(line, sizeof(line), fp) && set->count < MAX_CUSTOM_EXTRACTORS) {
+        line_num++;
+        char error[ERROR_MESSAGE_BUFFER];
+        int parsed = custom_extractor_parse(line, &set->extractors[set->count], error, sizeof(error));
+        if (parsed < 0) {
+            fprintf(stderr, "Warning: %s:%d: %s; extractor skipped\n", filepath, line_num, error);
+        } else if (parsed == 1) {
+            set->count++;
+        }
+    }
+
+    fclose(fp);
+    return set->count;
+}
+
+void custom_extractors_free(CustomExtractorSet *set) {
+    for (int i = 0; i < set->count; i++) {
+        regfree(&set->extractors[i].regex);
+    }
+    set->count = 0;
+}
+
+/* Index every match of extractor in one line */
+static int extract_line(const CustomExtractor *extractor, const char *text, int line,
+                        const char *directory, const char *filename, ParseResult *result) {
+    regmatch_t match[CUSTOM_EXTRACTOR_MAX_GROUPS];
+    const char *p = text;
+    int flags = 0;
+    int added = 0;
+
+    while (*p && regexec(&extractor->regex, p, CUSTOM_EXTRACTOR_MAX_GROUPS, match, flags) == 0) {
+        regmatch_t *group = &match[extractor->group];
+        if (group->rm_so >= 0 && group->rm_eo > group->rm_so) {
+            char symbol[SYMBOL_MAX_LENGTH];
+            size_t len = (size_t)(group->rm_eo - group->rm_so);
+            if (len >= sizeof(symbol)) len = sizeof(symbol) - 1;
+            memcpy(symbol, p + group->rm_so, len);
+            symbol[len] = '\0';
+
+            unsigned start = (unsigned)(p - text) + (unsigned)group->rm_so;
+            unsigned end = (unsigned)(p - text) + (unsigned)group->rm_eo;
+            char location[SOURCE_LOCATION_MAX_LENGTH];
+            if (snprintf(location, sizeof(location), "%d:%u - %d:%u", line, start, line, end) >=
+                (int)sizeof(location)) {
+                location[0] = '\0';  /* Absurdly long line: no location */
+            }
+
+            add_entry(result, symbol, line, CONTEXT_CUSTOM, directory, filename, location,
+                      &(ExtColumns){.clue = extractor->kind});
+            added++;
+        }
+
+        /* Continue after the match; step over empty matches */
+        p += match[0].rm_eo > 0 ? match[0].rm_eo : 1;
+        flags = REG_NOTBOL;
+    }
+    return added;
+}
+
+int custom_extract_file(const CustomExtractorSet *set, const char *filepath,
+                        const char *project_root, ParseResult *result) {
+    if (set->count == 0) {
+        return 0;
+    }
+
+    /* Through source_file_open(), so a substituted buffer is matched too */
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
+        return -1;
+    }
+
+    char directory[DIRECTORY_MAX_LENGTH] = "";
+    char filename[FILENAME_MAX_LENGTH] = "";
+    get_relative_path(filepath, project_root, directory, filename);
+
+    /* regexec() needs each line NUL-terminated; the source is read-only */
+    char *text = NULL;
+    size_t capacity = 0;
+    int line = 0;
+    int added = 0;
+    const char *start = source.text;
+    const char *end = source.text + source.length;
+    while (start < end) {
+        const char *newline = memchr(start, '\n', (size_t)(end - start));
+        size_t len = newline ? (size_t)(newline - start) : (size_t)(end - start);
+        if (len + 1 > capacity) {
+            char *grown = realloc(text, len + 1);
+            if (!grown) {
+                fprintf(stderr, "Warning: out of memory matching extractors in %s\n", filepath);
+                break;
+            }
+            text = grown;
+            capacity = len + 1;
+        }
+        memcpy(text, start, len);
+        while (len > 0 && text[len - 1] == '\r') {
+            len--;
+        }
+        text[len] = '\0';
+        line++;
+        for (int i = 0; i < set->count; i++) {
+            added += extract_line(&set->extractors[i], text, line, directory, filename, result);
+        }
+        start = newline ? newline + 1 : end;
+    }
+
+    free(text);
+    source_file_close(&source);
+    return added;
+}
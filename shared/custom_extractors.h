@@ -0,0 +1,85 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef CUSTOM_EXTRACTORS_H
+#define CUSTOM_EXTRACTORS_H
+
+#include <stddef.h>
+#include <regex.h>
+#include "constants.h"
+#include "parse_result.h"
+
+/*
+ * Custom symbol extractors (<language>/config/extractors.txt)
+ *
+ * Index things the grammar does not model, such as SQL table names inside
+ * string literals. Each line names a kind, optionally a capture group, and
+ * a POSIX extended regex:
+ *
+ *   # KIND[:GROUP] REGEX
+ *   sql_table:2 (FROM|JOIN|INTO|UPDATE)[[:space:]]+([A-Za-z_][A-Za-z0-9_]*)
+ *   todo_owner TODO\(([a-z]+)\)
+ *
+ * GROUP defaults to 1 (the whole match if the regex has no groups). The
+ * regexes run over every line of each file of the language, after the
+ * parser; every match of the group is indexed as a CONTEXT_CUSTOM entry
+ * whose clue is the kind. Matches do not span lines.
+ */
+
+typedef struct {
+    char kind[CLUE_MAX_LENGTH];     /* Lowercase name, stored in the clue column */
+    int group;                      /* Capture group that becomes the symbol */
+    regex_t regex;
+} CustomExtractor;
+
+typedef struct {
+    CustomExtractor extractors[MAX_CUSTOM_EXTRACTORS];
+    int count;
+} CustomExtractorSet;
+
+/* Parse and compile one line of extractors.txt
+ *
+ * Returns: 1 if *extractor was filled in (free it with regfree), 0 for
+ *          blank lines and comments, -1 if the line is invalid (error
+ *          describes why)
+ */
+int custom_extractor_parse(const char *line, CustomExtractor *extractor,
+                           char *error, size_t error_size);
+
+/* Load filepath into set (emptied first)
+ *
+ * Invalid lines are skipped with a warning; preflight validation rejects
+ * them. Lines past MAX_CUSTOM_EXTRACTORS are ignored.
+ *
+ * Returns: number of extractors loaded, -1 if the file cannot be opened
+ */
+int custom_extractors_load(CustomExtractorSet *set, const char *filepath);
+
+/* Free the compiled regexes (safe on an empty set) */
+void custom_extractors_free(CustomExtractorSet *set);
+
+/* Run every extractor over filepath, adding matches to result
+ *
+ * Directory and filename are made relative to project_root, as the
+ * parsers do. Safe to call from several threads on one set.
+ *
+ * Returns: number of entries added, -1 if the file cannot be read
+ */
+int custom_extract_file(const CustomExtractorSet *set, const char *filepath,
+                        const char *project_root, ParseResult *result);
+
+#endif /* CUSTOM_EXTRACTORS_H */
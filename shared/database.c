@@ -17,9 +17,12 @@
  */
 #include "database.h"
 #include "string_utils.h"
+#include "archive.h"
 #include <stdio.h>
 #include <string.h>
 #include <ctype.h>
+#include <stdlib.h>
+#include <sys/stat.h>
 
 const char *context_to_string(ContextType type, int compact) {
     switch (type) {
@@ -44,6 +47,8 @@ const char *context_to_string(ContextType type, int compact) {
         case CONTEXT_LAMBDA: return compact ? "LAM" : "LAMBDA";
         case CONTEXT_LABEL: return compact ? "LABEL" : "LABEL";
         case CONTEXT_GOTO: return compact ? "GOTO" : "GOTO";
+        case CONTEXT_ALIAS: return compact ? "ALIAS" : "ALIAS";
+        case CONTEXT_CUSTOM: return compact ? "CUSTOM" : "CUSTOM";
         default: return compact ? "UNKNOWN" : "UNKNOWN";
     }
 }
@@ -70,11 +75,14 @@ ContextType string_to_context(const char *str) {
     if (strcmp(str, "LAMBDA") == 0 || strcmp(str, "LAM") == 0) return CONTEXT_LAMBDA;
     if (strcmp(str, "LABEL") == 0) return CONTEXT_LABEL;
     if (strcmp(str, "GOTO") == 0) return CONTEXT_GOTO;
+    if (strcmp(str, "ALIAS") == 0) return CONTEXT_ALIAS;
+    if (strcmp(str, "CUSTOM") == 0) return CONTEXT_CUSTOM;
     return CONTEXT_CLASS; /* default */
 }
 
-int db_init(CodeIndexDatabase *db, const char *db_path) {
+static int db_open(CodeIndexDatabase *db, const char *db_path) {
     db->insert_stmt = NULL;  /* Initialize to NULL */
+    db->import_stmt = NULL;
 
     int rc = sqlite3_open(db_path, &db->db);
     if (rc != SQLITE_OK) {
@@ -84,7 +92,12 @@ int db_init(CodeIndexDatabase *db, const char *db_path) {
 
     /* Set busy timeout BEFORE any SQL operations to handle concurrent access */
     sqlite3_busy_timeout(db->db, 5000);
+    return SQLITE_OK;
+}
 
+/* Create tables and indexes if missing and prepare the INSERT statement */
+static int db_create_schema(CodeIndexDatabase *db) {
+    int rc;
     const char *schema =
         "CREATE TABLE IF NOT EXISTS code_index ("
         /* Infrastructure columns (traditional) */
@@ -119,6 +132,43 @@ int db_init(CodeIndexDatabase *db, const char *db_path) {
 #include "column_schema.def"
 #undef COLUMN
 #undef INT_COLUMN
+        /* Content hash of each indexed file, for skipping unchanged files */
+        "CREATE TABLE IF NOT EXISTS file_hashes ("
+        "  directory TEXT NOT NULL,"
+        "  filename TEXT NOT NULL,"
+        "  hash TEXT NOT NULL,"
+        "  PRIMARY KEY (directory, filename)"
+        ");"
+        /* Import edges: which file imports which package or module */
+        "CREATE TABLE IF NOT EXISTS imports ("
+        "  directory TEXT NOT NULL,"
+        "  filename TEXT NOT NULL,"
+        "  path TEXT NOT NULL,"
+        "  line INTEGER NOT NULL,"
+        "  language TEXT"
+        ");"
+        "CREATE INDEX IF NOT EXISTS idx_imports_path ON imports(path);"
+        "CREATE INDEX IF NOT EXISTS idx_imports_file ON imports(directory, filename);"
+        /* Interface satisfaction: which type implements which interface
+         * (recomputed by the Go indexer after each run and change, see implements.h) */
+        "CREATE TABLE IF NOT EXISTS implements ("
+        "  type TEXT NOT NULL,"
+        "  type_package TEXT,"
+        "  directory TEXT NOT NULL,"
+        "  filename TEXT NOT NULL,"
+        "  line INTEGER NOT NULL,"
+        "  pointer INTEGER NOT NULL,"
+        "  interface TEXT NOT NULL,"
+        "  interface_package TEXT,"
+        "  interface_directory TEXT NOT NULL,"
+        "  interface_filename TEXT NOT NULL,"
+        "  interface_line INTEGER NOT NULL,"
+        "  certainty TEXT NOT NULL,"
+        "  unresolved TEXT"
+        ");"
+        "CREATE INDEX IF NOT EXISTS idx_implements_interface ON implements(interface);"
+        "CREATE INDEX IF NOT EXISTS idx_implements_file ON implements(directory, filename);"
+        "CREATE INDEX IF NOT EXISTS idx_implements_interface_file ON implements(interface_directory, interface_filename);"
         ;
 
     char *err_msg = NULL;
@@ -154,9 +204,92 @@ int db_init(CodeIndexDatabase *db, const char *db_path) {
         return rc;
     }
 
+    rc = sqlite3_prepare_v2(db->db,
+        "INSERT INTO imports (directory, filename, path, line, language) VALUES (?, ?, ?, ?, ?)",
+        -1, &db->import_stmt, NULL);
+    if (rc != SQLITE_OK) {
+        fprintf(stderr, "Failed to prepare import INSERT statement: %s\n", sqlite3_errmsg(db->db));
+        return rc;
+    }
+
     return SQLITE_OK;
 }
 
+int db_init(CodeIndexDatabase *db, const char *db_path) {
+    int rc = db_open(db, db_path);
+    if (rc != SQLITE_OK) return rc;
+    return db_create_schema(db);
+}
+
+/* PRAGMA user_version, or -1 on error */
+static int db_user_version(CodeIndexDatabase *db) {
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, "PRAGMA user_version", -1, &stmt, NULL) != SQLITE_OK) {
+        return -1;
+    }
+    int version = sqlite3_step(stmt) == SQLITE_ROW ? sqlite3_column_int(stmt, 0) : -1;
+    sqlite3_finalize(stmt);
+    return version;
+}
+
+/* Whether the code_index table exists (an index was built here before) */
+static int db_has_index_table(CodeIndexDatabase *db) {
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db,
+            "SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'code_index'",
+            -1, &stmt, NULL) != SQLITE_OK) {
+        return 0;
+    }
+    int found = sqlite3_step(stmt) == SQLITE_ROW;
+    sqlite3_finalize(stmt);
+    return found;
+}
+
+int db_init_store(CodeIndexDatabase *db, const char *db_path, int *rebuilt) {
+    *rebuilt = 0;
+    int rc = db_open(db, db_path);
+    if (rc != SQLITE_OK) return rc;
+
+    /* Checking the version and creating the schema is one write transaction,
+     * so indexers started together on a new database (index-code) cannot see
+     * each other's half-built schema as an outdated one and drop it */
+    rc = sqlite3_exec(db->db, "BEGIN IMMEDIATE", NULL, NULL, NULL);
+    if (rc != SQLITE_OK) {
+        fprintf(stderr, "Failed to lock the index for setup: %s\n", sqlite3_errmsg(db->db));
+        return rc;
+    }
+
+    int version = db_user_version(db);
+    if (version != DB_SCHEMA_VERSION && db_has_index_table(db)) {
+        char *err_msg = NULL;
+        rc = sqlite3_exec(db->db,
+                          "DROP TABLE IF EXISTS code_index;"
+                          "DROP TABLE IF EXISTS file_hashes;"
+                          "DROP TABLE IF EXISTS imports;"
+                          "DROP TABLE IF EXISTS implements;",
+                          NULL, NULL, &err_msg);
+        if (rc != SQLITE_OK) {
+            fprintf(stderr, "Failed to drop outdated index tables: %s\n", err_msg);
+            sqlite3_free(err_msg);
+            sqlite3_exec(db->db, "ROLLBACK", NULL, NULL, NULL);
+            return rc;
+        }
+        *rebuilt = 1;
+    }
+
+    rc = db_create_schema(db);
+    if (rc == SQLITE_OK && version != DB_SCHEMA_VERSION) {
+        char pragma[64];
+        snprintf(pragma, sizeof(pragma), "PRAGMA user_version = %d", DB_SCHEMA_VERSION);
+        rc = sqlite3_exec(db->db, pragma, NULL, NULL, NULL);
+    }
+    if (rc != SQLITE_OK) {
+        sqlite3_exec(db->db, "ROLLBACK", NULL, NULL, NULL);
+        return rc;
+    }
+    return sqlite3_exec(db->db, "COMMIT", NULL, NULL, NULL);
+}
+
 int db_enable_concurrent_writes(CodeIndexDatabase *db) {
     char *err_msg = NULL;
     int rc;
@@ -183,6 +316,10 @@ void db_close(CodeIndexDatabase *db) {
         sqlite3_finalize(db->insert_stmt);
         db->insert_stmt = NULL;
     }
+    if (db->import_stmt) {
+        sqlite3_finalize(db->import_stmt);
+        db->import_stmt = NULL;
+    }
     if (db->db) {
         sqlite3_close(db->db);
         db->db = NULL;
@@ -191,7 +328,9 @@ void db_close(CodeIndexDatabase *db) {
 
 int db_begin_transaction(CodeIndexDatabase *db) {
     char *err_msg = NULL;
-    int rc = sqlite3_exec(db->db, "BEGIN TRANSACTION", NULL, NULL, &err_msg);
+    /* IMMEDIATE takes the write lock now, so a second indexer waits for it
+     * (busy timeout) instead of failing when its first INSERT upgrades */
+    int rc = sqlite3_exec(db->db, "BEGIN IMMEDIATE", NULL, NULL, &err_msg);
     if (rc != SQLITE_OK) {
         fprintf(stderr, "Failed to begin transaction: %s\n", err_msg);
         sqlite3_free(err_msg);
@@ -251,9 +390,75 @@ int db_insert(CodeIndexDatabase *db, const IndexEntry *entry) {
     return (rc == SQLITE_DONE) ? SQLITE_OK : rc;
 }
 
-int db_delete_by_file(CodeIndexDatabase *db, const char *directory, const char *filename) {
-    const char *sql = "DELETE FROM code_index WHERE directory = ? AND filename = ?";
+int db_insert_import(CodeIndexDatabase *db, const char *directory, const char *filename,
+                     const char *language, const ImportEdge *edge) {
+    sqlite3_reset(db->import_stmt);
+    sqlite3_clear_bindings(db->import_stmt);
+    sqlite3_bind_text(db->import_stmt, 1, directory, -1, SQLITE_TRANSIENT);
+    sqlite3_bind_text(db->import_stmt, 2, filename, -1, SQLITE_TRANSIENT);
+    sqlite3_bind_text(db->import_stmt, 3, edge->path, -1, SQLITE_TRANSIENT);
+    sqlite3_bind_int(db->import_stmt, 4, edge->line);
+    if (language && language[0]) {
+        sqlite3_bind_text(db->import_stmt, 5, language, -1, SQLITE_TRANSIENT);
+    }
 
+    int rc = sqlite3_step(db->import_stmt);
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Execution failed: %s\n", sqlite3_errmsg(db->db));
+    }
+    return (rc == SQLITE_DONE) ? SQLITE_OK : rc;
+}
+
+const char *db_entry_columns(void) {
+    return "symbol, directory, filename, line, context, full_symbol, source_location"
+        /* X-Macro: extensible columns, TEXT first then INTEGER (same as IndexEntry) */
+#define COLUMN(name, ...) ", " #name
+#define INT_COLUMN(name, ...)  /* below */
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+#define COLUMN(name, ...)  /* above */
+#define INT_COLUMN(name, ...) ", " #name
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+        ;
+}
+
+/* Copy a TEXT column into a fixed buffer (NULL becomes "") */
+static void read_text_column(sqlite3_stmt *stmt, int col, char *dst, size_t size) {
+    const char *text = (const char *)sqlite3_column_text(stmt, col);
+    snprintf(dst, size, "%s", text ? text : "");
+}
+
+void db_read_entry(sqlite3_stmt *stmt, IndexEntry *entry) {
+    read_text_column(stmt, 0, entry->symbol, sizeof(entry->symbol));
+    read_text_column(stmt, 1, entry->directory, sizeof(entry->directory));
+    read_text_column(stmt, 2, entry->filename, sizeof(entry->filename));
+    entry->line = sqlite3_column_int(stmt, 3);
+    const char *context = (const char *)sqlite3_column_text(stmt, 4);
+    entry->context = string_to_context(context ? context : "");
+    read_text_column(stmt, 5, entry->full_symbol, sizeof(entry->full_symbol));
+    read_text_column(stmt, 6, entry->source_location, sizeof(entry->source_location));
+
+    int col = 7;
+#define COLUMN(name, ...) \
+    read_text_column(stmt, col++, entry->name, sizeof(entry->name));
+#define INT_COLUMN(name, ...)  /* skip */
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+#define COLUMN(name, ...)  /* skip */
+#define INT_COLUMN(name, ...) \
+    entry->name = sqlite3_column_int(stmt, col++);
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+}
+
+/* Run a statement taking (directory, filename) as ?1 and ?2 */
+static int exec_file_statement(CodeIndexDatabase *db, const char *sql,
+                               const char *directory, const char *filename) {
     sqlite3_stmt *stmt;
     int rc = sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL);
     if (rc != SQLITE_OK) {
@@ -269,3 +474,145 @@ int db_delete_by_file(CodeIndexDatabase *db, const char *directory, const char *
 
     return (rc == SQLITE_DONE) ? SQLITE_OK : rc;
 }
+
+int db_delete_by_file(CodeIndexDatabase *db, const char *directory, const char *filename) {
+    int rc = exec_file_statement(db, "DELETE FROM code_index WHERE directory = ? AND filename = ?",
+                                 directory, filename);
+    if (rc != SQLITE_OK) return rc;
+    rc = exec_file_statement(db, "DELETE FROM imports WHERE directory = ? AND filename = ?",
+                             directory, filename);
+    if (rc != SQLITE_OK) return rc;
+    /* Edges of its types and interfaces; the next implements pass redoes the rest */
+    rc = exec_file_statement(db, "DELETE FROM implements WHERE (directory = ?1 AND filename = ?2) "
+                                 "OR (interface_directory = ?1 AND interface_filename = ?2)",
+                             directory, filename);
+    if (rc != SQLITE_OK) return rc;
+    return exec_file_statement(db, "DELETE FROM file_hashes WHERE directory = ? AND filename = ?",
+                               directory, filename);
+}
+
+int db_get_file_hash(CodeIndexDatabase *db, const char *directory, const char *filename,
+                     char *hash, size_t size) {
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, "SELECT hash FROM file_hashes WHERE directory = ? AND filename = ?",
+                           -1, &stmt, NULL) != SQLITE_OK) {
+        return 0;
+    }
+    sqlite3_bind_text(stmt, 1, directory, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 2, filename, -1, SQLITE_STATIC);
+
+    int found = 0;
+    if (sqlite3_step(stmt) == SQLITE_ROW) {
+        read_text_column(stmt, 0, hash, size);
+        found = 1;
+    }
+    sqlite3_finalize(stmt);
+    return found;
+}
+
+int db_set_file_hash(CodeIndexDatabase *db, const char *directory, const char *filename,
+                     const char *hash) {
+    sqlite3_stmt *stmt;
+    int rc = sqlite3_prepare_v2(db->db,
+        "INSERT OR REPLACE INTO file_hashes (directory, filename, hash) VALUES (?, ?, ?)",
+        -1, &stmt, NULL);
+    if (rc != SQLITE_OK) {
+        fprintf(stderr, "Failed to prepare statement: %s\n", sqlite3_errmsg(db->db));
+        return rc;
+    }
+
+    sqlite3_bind_text(stmt, 1, directory, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 2, filename, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 3, hash, -1, SQLITE_STATIC);
+
+    rc = sqlite3_step(stmt);
+    sqlite3_finalize(stmt);
+
+    return (rc == SQLITE_DONE) ? SQLITE_OK : rc;
+}
+
+int db_stored_file_exists(const char *directory, const char *filename) {
+    char path[PATH_MAX_LENGTH];
+    int written = snprintf(path, sizeof(path), "%s%s", directory, filename);
+    if (written < 0 || (size_t)written >= sizeof(path)) {
+        return 1;  /* Cannot be checked */
+    }
+
+    struct stat st;
+    if (stat(path, &st) == 0) {
+        return 1;
+    }
+    for (char *slash = strchr(path, '/'); slash; slash = strchr(slash + 1, '/')) {
+        *slash = '\0';
+        int archive = is_archive_path(path) && stat(path, &st) == 0 && S_ISREG(st.st_mode);
+        *slash = '/';
+        if (archive) {
+            return 1;
+        }
+    }
+    return 0;
+}
+
+static int stored_path_exists(const char *directory, const char *filename, void *ctx) {
+    (void)ctx;
+    return db_stored_file_exists(directory, filename);
+}
+
+int db_delete_missing_files(CodeIndexDatabase *db, const char *directory_prefix) {
+    return db_delete_files_unless(db, directory_prefix, stored_path_exists, NULL);
+}
+
+int db_delete_files_unless(CodeIndexDatabase *db, const char *directory_prefix,
+                           int (*keep)(const char *directory, const char *filename, void *ctx),
+                           void *ctx) {
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db,
+            "SELECT directory, filename FROM file_hashes"
+            " WHERE substr(directory, 1, length(?1)) = ?1",
+            -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Failed to prepare statement: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    sqlite3_bind_text(stmt, 1, directory_prefix, -1, SQLITE_STATIC);
+
+    /* Collect first: rows are deleted from the table being read */
+    typedef struct {
+        char directory[DIRECTORY_MAX_LENGTH];
+        char filename[FILENAME_MAX_LENGTH];
+    } MissingFile;
+    MissingFile *missing = NULL;
+    int count = 0;
+    int capacity = 0;
+    int failed = 0;
+    while (sqlite3_step(stmt) == SQLITE_ROW) {
+        char directory[DIRECTORY_MAX_LENGTH];
+        char filename[FILENAME_MAX_LENGTH];
+        read_text_column(stmt, 0, directory, sizeof(directory));
+        read_text_column(stmt, 1, filename, sizeof(filename));
+        if (keep(directory, filename, ctx)) {
+            continue;
+        }
+        if (count == capacity) {
+            int new_capacity = capacity ? capacity * 2 : 16;
+            MissingFile *grown = realloc(missing, (size_t)new_capacity * sizeof(MissingFile));
+            if (!grown) {
+                failed = 1;
+                break;
+            }
+            missing = grown;
+            capacity = new_capacity;
+        }
+        snprintf(missing[count].directory, sizeof(missing[count].directory), "%s", directory);
+        snprintf(missing[count].filename, sizeof(missing[count].filename), "%s", filename);
+        count++;
+    }
+    sqlite3_finalize(stmt);
+
+    for (int i = 0; i < count && !failed; i++) {
+        if (db_delete_by_file(db, missing[i].directory, missing[i].filename) != SQLITE_OK) {
+            failed = 1;
+        }
+    }
+    free(missing);
+    return failed ? -1 : count;
+}
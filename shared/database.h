@@ -19,6 +19,7 @@
 #define DATABASE_H
 
 #include "../config.h"
+#include <stddef.h>
 #include <sqlite3.h>
 #include "constants.h"
 
@@ -43,7 +44,9 @@ typedef enum {
     CONTEXT_TRAIT,
     CONTEXT_LAMBDA,
     CONTEXT_LABEL,
-    CONTEXT_GOTO
+    CONTEXT_GOTO,
+    CONTEXT_ALIAS,
+    CONTEXT_CUSTOM      /* Match of an extractors.txt pattern; clue holds its kind */
 } ContextType;
 
 /* Extensible columns for add_entry() - supports OOP and language-specific features
@@ -58,6 +61,7 @@ typedef enum {
  * To add a new extensible column:
  * 1. column_schema.def - add COLUMN() line
  * 2. Language indexers - implement extraction
+ * 3. DB_SCHEMA_VERSION (below) - bump, so existing indexes are rebuilt
  *
  * Everything else auto-generates from the X-Macro!
  *
@@ -112,19 +116,66 @@ typedef struct {
 #undef INT_COLUMN
 } IndexEntry;
 
+/* One import of a file: the package or module path as written in the
+ * source, quotes removed ("fmt", "./utils", "os.path") */
+typedef struct {
+    char path[IMPORT_PATH_MAX_LENGTH];
+    int line;
+} ImportEdge;
+
 typedef struct {
     sqlite3 *db;
     sqlite3_stmt *insert_stmt;  /* Prepared INSERT statement for reuse */
+    sqlite3_stmt *import_stmt;  /* Prepared INSERT into imports */
 } CodeIndexDatabase;
 
+/* Layout version of the index tables, stored as PRAGMA user_version.
+ * Bump it whenever code_index, file_hashes, imports or implements change
+ * (column_schema.def included), and whenever a parser stores different
+ * values for the same source (such as the spacing of type strings), since
+ * unchanged files are not parsed again: indexers then rebuild older
+ * indexes instead of mixing rows. */
+#define DB_SCHEMA_VERSION 15
+
 /* Database operations */
 int db_init(CodeIndexDatabase *db, const char *db_path);
+/* Open the index for writing, like db_init, after dropping the tables of
+ * an index with another DB_SCHEMA_VERSION (*rebuilt is set to 1 then) */
+int db_init_store(CodeIndexDatabase *db, const char *db_path, int *rebuilt);
 int db_enable_concurrent_writes(CodeIndexDatabase *db);
 void db_close(CodeIndexDatabase *db);
 int db_begin_transaction(CodeIndexDatabase *db);
 int db_commit_transaction(CodeIndexDatabase *db);
 int db_insert(CodeIndexDatabase *db, const IndexEntry *entry);
+/* Record that a file imports edge->path (language as in the language column) */
+int db_insert_import(CodeIndexDatabase *db, const char *directory, const char *filename,
+                     const char *language, const ImportEdge *edge);
+/* Delete a file's rows, imports and content hash */
 int db_delete_by_file(CodeIndexDatabase *db, const char *directory, const char *filename);
+/* Content hash recorded when a file was last indexed
+ * Returns: 1 if one is recorded (copied to hash), 0 if not */
+int db_get_file_hash(CodeIndexDatabase *db, const char *directory, const char *filename,
+                     char *hash, size_t size);
+int db_set_file_hash(CodeIndexDatabase *db, const char *directory, const char *filename,
+                     const char *hash);
+/* A stored file (directory + filename, relative to the current directory)
+ * exists, or is an entry of an archive that does ("vendor.tar.gz/pkg/a.go",
+ * indexed without extracting it) */
+int db_stored_file_exists(const char *directory, const char *filename);
+/* Delete rows of hashed files under directory_prefix that no longer exist
+ * (directory + filename, relative to the current directory); entries of
+ * an indexed archive are kept while the archive exists
+ * Returns: number of files deleted, -1 on database error */
+int db_delete_missing_files(CodeIndexDatabase *db, const char *directory_prefix);
+/* Delete rows of hashed files under directory_prefix that keep() rejects
+ * Returns: number of files deleted, -1 on database error */
+int db_delete_files_unless(CodeIndexDatabase *db, const char *directory_prefix,
+                           int (*keep)(const char *directory, const char *filename, void *ctx),
+                           void *ctx);
+/* Reading entries back (for post-index passes and exports):
+ * db_entry_columns() is the SELECT column list db_read_entry() expects */
+const char *db_entry_columns(void);
+void db_read_entry(sqlite3_stmt *stmt, IndexEntry *entry);
 const char *context_to_string(ContextType type, int compact);
 ContextType string_to_context(const char *str);
 
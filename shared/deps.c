@@ -0,0 +1,142 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "deps.h"
+#include "constants.h"
+#include "ndjson.h"
+#include <sqlite3.h>
+#include <string.h>
+
+/* As in search.c: paths without the leading "./" they may be stored with */
+#define DISPLAY_PATH \
+    "(CASE WHEN substr(directory, 1, 2) = './' THEN substr(directory, 3) " \
+    "ELSE directory END || filename)"
+
+/* Write str as a double-quoted DOT identifier */
+static void dot_write_string(FILE *out, const char *str) {
+    fputc('"', out);
+    for (const char *p = str; *p; p++) {
+        if (*p == '"' || *p == '\\') {
+            fputc('\\', out);
+        }
+        fputc(*p, out);
+    }
+    fputc('"', out);
+}
+
+int deps_print(const char *db_path, const DepsOptions *opts, FILE *out) {
+    sqlite3 *db = NULL;
+    if (sqlite3_open_v2(db_path, &db, SQLITE_OPEN_READONLY, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", db_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+
+    char sql[LINE_BUFFER_LARGE];
+    snprintf(sql, sizeof(sql),
+             "SELECT " DISPLAY_PATH ", path, line, language FROM imports WHERE 1%s%s"
+             " ORDER BY 1, line, path",
+             opts->package ? " AND (path = ?1 OR path GLOB ?2)" : "",
+             opts->file_pattern ? " AND " DISPLAY_PATH " GLOB ?3" : "");
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot read imports: %s (re-index to record them)\n", sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+
+    /* "pkg/..." is pkg and everything below it; a glob is used as is */
+    char exact[IMPORT_PATH_MAX_LENGTH];
+    char glob[IMPORT_PATH_MAX_LENGTH + 2];
+    if (opts->package) {
+        size_t len = strlen(opts->package);
+        if (len > 4 && strcmp(opts->package + len - 4, "/...") == 0) {
+            snprintf(exact, sizeof(exact), "%.*s", (int)(len - 4), opts->package);
+            snprintf(glob, sizeof(glob), "%s/*", exact);
+            sqlite3_bind_text(stmt, 1, exact, -1, SQLITE_STATIC);
+            sqlite3_bind_text(stmt, 2, glob, -1, SQLITE_STATIC);
+        } else if (strpbrk(opts->package, "*?[")) {
+            sqlite3_bind_text(stmt, 2, opts->package, -1, SQLITE_STATIC);
+        } else {
+            sqlite3_bind_text(stmt, 1, opts->package, -1, SQLITE_STATIC);
+        }
+    }
+    char file_glob[PATH_MAX_LENGTH];
+    if (opts->file_pattern) {
+        /* A plain word matches anywhere in the path */
+        if (strpbrk(opts->file_pattern, "*?[")) {
+            snprintf(file_glob, sizeof(file_glob), "%s", opts->file_pattern);
+        } else {
+            snprintf(file_glob, sizeof(file_glob), "*%s*", opts->file_pattern);
+        }
+        sqlite3_bind_text(stmt, 3, file_glob, -1, SQLITE_STATIC);
+    }
+
+    if (opts->format == DEPS_FORMAT_DOT) {
+        fprintf(out, "digraph imports {\n");
+    }
+    char previous[PATH_MAX_LENGTH] = "";
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        const char *file = (const char *)sqlite3_column_text(stmt, 0);
+        const char *path = (const char *)sqlite3_column_text(stmt, 1);
+        int line = sqlite3_column_int(stmt, 2);
+        const char *language = (const char *)sqlite3_column_text(stmt, 3);
+        if (!file || !path) {
+            continue;
+        }
+
+        if (opts->format == DEPS_FORMAT_NDJSON) {
+            fputs("{\"file\":", out);
+            json_write_string(out, file);
+            fprintf(out, ",\"line\":%d,\"import\":", line);
+            json_write_string(out, path);
+            fputs(",\"language\":", out);
+            json_write_string(out, language);
+            fputs("}\n", out);
+        } else if (opts->format == DEPS_FORMAT_DOT) {
+            fputs("  ", out);
+            dot_write_string(out, file);
+            fputs(" -> ", out);
+            dot_write_string(out, path);
+            fputs(";\n", out);
+        } else if (opts->package) {
+            /* Who imports it: one importing line per row */
+            fprintf(out, "%s:%d  %s\n", file, line, path);
+        } else {
+            /* The graph: each file followed by what it imports */
+            if (strcmp(file, previous) != 0) {
+                fprintf(out, "%s\n", file);
+                snprintf(previous, sizeof(previous), "%s", file);
+            }
+            fprintf(out, "  %s\n", path);
+        }
+    }
+    if (opts->format == DEPS_FORMAT_DOT) {
+        fprintf(out, "}\n");
+    }
+    fflush(out);
+
+    int result = 0;
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: cannot read imports: %s\n", sqlite3_errmsg(db));
+        result = -1;
+    }
+    sqlite3_finalize(stmt);
+    sqlite3_close(db);
+    return result;
+}
@@ -0,0 +1,55 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef DEPS_H
+#define DEPS_H
+
+#include <stdio.h>
+
+/*
+ * Import graph of an existing index (the "deps" subcommand)
+ *
+ * Indexers record, for every file, the packages and modules it imports as
+ * written in the source: Go import paths, TypeScript module specifiers
+ * ("./utils", "react"; re-exports with from count too) and Python modules
+ * ("os.path", relative "..models"). Paths are not resolved to files.
+ *
+ * Without a package, the whole graph is printed, file by file. With one,
+ * the files importing it are listed instead (reverse dependencies). The
+ * package matches exactly; a glob (*, ?, [) matches as such, and a trailing
+ * "/..." matches the package and everything below it, as in the go tool.
+ */
+
+typedef enum {
+    DEPS_FORMAT_TABLE,
+    DEPS_FORMAT_NDJSON,     /* {"file":...,"line":N,"import":...,"language":...} per edge */
+    DEPS_FORMAT_DOT         /* Graphviz digraph, file -> import */
+} DepsFormat;
+
+typedef struct {
+    const char *package;       /* Only edges to this package (NULL = all) */
+    const char *file_pattern;  /* Only importing files matching this glob (NULL = any) */
+    DepsFormat format;
+} DepsOptions;
+
+/* Print the import edges of the index at db_path to out
+ *
+ * Returns: 0 on success (including no edges), -1 on error
+ */
+int deps_print(const char *db_path, const DepsOptions *opts, FILE *out);
+
+#endif /* DEPS_H */
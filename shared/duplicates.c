@@ -0,0 +1,103 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "duplicates.h"
+#include "ndjson.h"
+#include "symbol_at.h"
+#include <stdlib.h>
+#include <string.h>
+
+/* Definitions sharing a key with another one, grouped by key and then in
+ * file order; "unit" is the directory or file the name is scoped to */
+static const char *DUPLICATES_SQL =
+    "WITH defs AS ("
+    "  SELECT full_symbol, context, clue, directory, filename, line,"
+    "         COALESCE(namespace, '') AS ns, COALESCE(scope_path, '') AS sp,"
+    "         COALESCE(language, '') AS lang,"
+    "         CASE WHEN COALESCE(namespace, '') = '' THEN filename ELSE '' END AS unit"
+    "  FROM code_index"
+    "  WHERE is_definition = 1"
+    "    AND context IN ('CLASS', 'IFACE', 'FUNC', 'TYPE', 'PROP', 'ENUM', 'CASE', 'TRAIT', 'ALIAS')"
+    "    AND NOT (language = 'go' AND context = 'FUNC' AND full_symbol = 'init' AND COALESCE(scope_path, '') = '')"
+    "), counted AS ("
+    "  SELECT *, COUNT(*) OVER (PARTITION BY lang, directory, unit, ns, sp, full_symbol, context) AS copies"
+    "  FROM defs"
+    ")"
+    " SELECT full_symbol, context, clue, directory, filename, line, ns, sp, copies"
+    " FROM counted WHERE copies > 1"
+    " ORDER BY directory, unit, ns, sp, full_symbol, context, filename, line";
+
+int duplicates_report(CodeIndexDatabase *db, FILE *out) {
+    /* Only the columns symbol_kind() and symbol_qualified_name() read */
+    IndexEntry *entry = calloc(1, sizeof(IndexEntry));
+    if (!entry) {
+        fprintf(stderr, "Failed to allocate memory for duplicate check\n");
+        return -1;
+    }
+
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, DUPLICATES_SQL, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Failed to check for duplicate definitions: %s\n", sqlite3_errmsg(db->db));
+        free(entry);
+        return -1;
+    }
+
+    int conflicts = 0;
+    int remaining = 0;  /* Definitions of the current conflict still to print */
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        const char *directory = (const char *)sqlite3_column_text(stmt, 3);
+        const char *filename = (const char *)sqlite3_column_text(stmt, 4);
+        int line = sqlite3_column_int(stmt, 5);
+
+        if (remaining == 0) {
+            const char *symbol = (const char *)sqlite3_column_text(stmt, 0);
+            const char *context = (const char *)sqlite3_column_text(stmt, 1);
+            const char *clue = (const char *)sqlite3_column_text(stmt, 2);
+            const char *ns = (const char *)sqlite3_column_text(stmt, 6);
+            const char *sp = (const char *)sqlite3_column_text(stmt, 7);
+            remaining = sqlite3_column_int(stmt, 8);
+
+            snprintf(entry->full_symbol, sizeof(entry->full_symbol), "%s", symbol ? symbol : "");
+            snprintf(entry->clue, sizeof(entry->clue), "%s", clue ? clue : "");
+            snprintf(entry->scope_path, sizeof(entry->scope_path), "%s", sp ? sp : "");
+            entry->context = string_to_context(context ? context : "");
+
+            char name[SCOPE_PATH_MAX_LENGTH + SYMBOL_MAX_LENGTH];
+            symbol_qualified_name(entry, name, sizeof(name));
+            if (conflicts == 0) {
+                fprintf(out, "Duplicate definitions:\n");
+            }
+            fprintf(out, "  %s %s%s%s defined %d times:\n", symbol_kind(entry),
+                    (ns && ns[0]) ? ns : "", (ns && ns[0]) ? "." : "", name, remaining);
+            conflicts++;
+        }
+        if (!directory) directory = "";
+        if (strncmp(directory, "./", 2) == 0) directory += 2;
+        fprintf(out, "    %s%s:%d\n", directory, filename ? filename : "", line);
+        remaining--;
+    }
+    sqlite3_finalize(stmt);
+    free(entry);
+
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Failed to check for duplicate definitions: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    fflush(out);
+    return conflicts;
+}
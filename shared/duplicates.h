@@ -0,0 +1,44 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef DUPLICATES_H
+#define DUPLICATES_H
+
+#include <stdio.h>
+#include "database.h"
+
+/*
+ * Conflicting definitions (--warn-duplicates)
+ *
+ * Run over the merged index once a pass has committed. Two definitions
+ * conflict when they have the same name, kind, scope path, namespace and
+ * language and live in the same unit: the same directory for symbols with
+ * a namespace (a Go package, a PHP namespace), the same file otherwise,
+ * since those languages scope names by module. Only declarations count
+ * (types, classes, interfaces, traits, enums and their cases, aliases,
+ * functions and properties); variables are left out because rebinding
+ * one is ordinary code. Go's init functions may be declared repeatedly
+ * and are not reported.
+ */
+
+/* Print each conflict with the file and line of every definition
+ *
+ * Returns: number of conflicts, or -1 on database error
+ */
+int duplicates_report(CodeIndexDatabase *db, FILE *out);
+
+#endif /* DUPLICATES_H */
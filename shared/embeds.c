@@ -0,0 +1,309 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "embeds.h"
+#include "ndjson.h"
+#include <stdlib.h>
+#include <string.h>
+
+/* Embedded interfaces nest rarely more than a few levels; the limit only
+ * guards against pathological input */
+#define MAX_EMBED_DEPTH 32
+
+#if ENABLED(GO)
+
+/* Package an embed resolves in: the qualifier of "pkg.Type", else the
+ * package of the embedding interface */
+#define EMBED_PACKAGE \
+    "CASE WHEN instr(e.type, '.') > 0 THEN substr(e.type, 1, instr(e.type, '.') - 1) " \
+    "ELSE e.namespace END"
+
+/* Interface definition an embed row e refers to */
+#define RESOLVED_INTERFACE \
+    "t.context = 'TYPE' AND t.clue = 'interface' AND t.full_symbol = e.full_symbol " \
+    "AND t.namespace = " EMBED_PACKAGE
+
+/* Embeds that belong to an interface; struct embeds also have their type as
+ * parent, so check the parent is an interface declared in the same file */
+#define INTERFACE_EMBED \
+    "e.context = 'PROP' AND e.clue = 'embedded' AND EXISTS (SELECT 1 FROM code_index i " \
+    "WHERE i.context = 'TYPE' AND i.clue = 'interface' AND i.full_symbol = e.parent_symbol " \
+    "AND i.directory = e.directory AND i.filename = e.filename)"
+
+/* Rows of alias (embeds and promoted methods) that belong to an interface
+ * the scoped pass recomputes */
+#define DIRTY_INTERFACE(alias) \
+    "EXISTS (SELECT 1 FROM temp.embeds_dirty d WHERE d.package = " alias ".namespace " \
+    "AND d.name = " alias ".parent_symbol)"
+
+static int exec_sql(CodeIndexDatabase *db, const char *sql) {
+    char *err_msg = NULL;
+    int rc = sqlite3_exec(db->db, sql, NULL, NULL, &err_msg);
+    if (rc != SQLITE_OK) {
+        fprintf(stderr, "Error: embed flattening failed: %s\n", err_msg);
+        sqlite3_free(err_msg);
+        return -1;
+    }
+    return 0;
+}
+
+/* Repeat an INSERT OR IGNORE until it adds nothing (each round follows one
+ * more level of embedding) */
+static int exec_until_stable(CodeIndexDatabase *db, const char *sql) {
+    for (int round = 0; round < MAX_EMBED_DEPTH; round++) {
+        if (exec_sql(db, sql) != 0) {
+            return -1;
+        }
+        if (sqlite3_changes(db->db) == 0) {
+            break;
+        }
+    }
+    return 0;
+}
+
+/* Fill temp.embeds_dirty with the interfaces whose promoted methods may have
+ * changed: those declared in the changed directories, those with an embed
+ * that does not resolve (its interface may just have been removed) and,
+ * transitively, those embedding any of them */
+static int select_dirty_interfaces(CodeIndexDatabase *db, const char *const *directories,
+                                   int directory_count) {
+    if (exec_sql(db,
+            "CREATE TEMP TABLE IF NOT EXISTS embeds_scope (directory TEXT PRIMARY KEY);"
+            "CREATE TEMP TABLE IF NOT EXISTS embeds_dirty ("
+            "  package TEXT, name TEXT, PRIMARY KEY (package, name));"
+            "DELETE FROM temp.embeds_scope;"
+            "DELETE FROM temp.embeds_dirty;") != 0) {
+        return -1;
+    }
+
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, "INSERT OR IGNORE INTO temp.embeds_scope (directory) VALUES (?)",
+                           -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: embed flattening failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    for (int i = 0; i < directory_count; i++) {
+        sqlite3_reset(stmt);
+        sqlite3_bind_text(stmt, 1, directories[i], -1, SQLITE_STATIC);
+        if (sqlite3_step(stmt) != SQLITE_DONE) {
+            fprintf(stderr, "Error: embed flattening failed: %s\n", sqlite3_errmsg(db->db));
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+    }
+    sqlite3_finalize(stmt);
+
+    if (exec_sql(db,
+            "INSERT OR IGNORE INTO temp.embeds_dirty "
+            "SELECT c.namespace, c.full_symbol "
+            "FROM temp.embeds_scope s CROSS JOIN code_index c INDEXED BY idx_directory "
+            "  ON c.directory = s.directory "
+            "WHERE c.context = 'TYPE' AND c.clue = 'interface' AND c.namespace IS NOT NULL;"
+            "INSERT OR IGNORE INTO temp.embeds_dirty "
+            "SELECT e.namespace, e.parent_symbol FROM code_index e INDEXED BY idx_clue "
+            "WHERE " INTERFACE_EMBED " AND e.namespace IS NOT NULL "
+            "  AND NOT EXISTS (SELECT 1 FROM code_index t WHERE " RESOLVED_INTERFACE ")") != 0) {
+        return -1;
+    }
+    return exec_until_stable(db,
+            "INSERT OR IGNORE INTO temp.embeds_dirty "
+            "SELECT e.namespace, e.parent_symbol "
+            "FROM temp.embeds_dirty d CROSS JOIN code_index e INDEXED BY idx_type_name "
+            "  ON e.type_name = d.name AND e.type_package = d.package "
+            "WHERE " INTERFACE_EMBED " AND e.namespace IS NOT NULL");
+}
+
+static int report_unresolved(CodeIndexDatabase *db, int scoped, int verbose, EmbedStats *stats) {
+    char sql[SQL_QUERY_BUFFER];
+    snprintf(sql, sizeof(sql),
+             "SELECT parent_symbol, type, directory, filename, line FROM code_index e "
+             "WHERE context = 'PROP' AND clue = 'embedded' AND modifier = 'unresolved'%s "
+             "ORDER BY directory, filename, line",
+             scoped ? " AND " DIRTY_INTERFACE("e") : "");
+
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: embed flattening failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+
+    while (sqlite3_step(stmt) == SQLITE_ROW) {
+        stats->unresolved++;
+        if (verbose) {
+            printf("Unresolved embed: %s embeds %s (%s%s:%d)\n",
+                   (const char *)sqlite3_column_text(stmt, 0),
+                   (const char *)sqlite3_column_text(stmt, 1),
+                   (const char *)sqlite3_column_text(stmt, 2),
+                   (const char *)sqlite3_column_text(stmt, 3),
+                   sqlite3_column_int(stmt, 4));
+        }
+    }
+    sqlite3_finalize(stmt);
+    return 0;
+}
+
+/* Write promoted rows as NDJSON; a scoped pass writes only those of the
+ * recomputed interfaces that differ from temp.embeds_previous */
+static int write_promoted(CodeIndexDatabase *db, int scoped, FILE *out) {
+    char sql[SQL_QUERY_BUFFER];
+    snprintf(sql, sizeof(sql),
+             "SELECT %s FROM code_index c WHERE clue = 'promoted'%s "
+             "ORDER BY directory, filename, line, symbol", db_entry_columns(),
+             scoped ? " AND " DIRTY_INTERFACE("c") " AND NOT EXISTS (SELECT 1 FROM temp.embeds_previous p "
+                      "WHERE p.directory = c.directory AND p.filename = c.filename AND p.line = c.line "
+                      "AND p.full_symbol = c.full_symbol AND p.parent_symbol IS c.parent_symbol "
+                      "AND p.namespace IS c.namespace AND p.source_location IS c.source_location "
+                      "AND p.scope IS c.scope AND p.type IS c.type AND p.params IS c.params "
+                      "AND p.returns IS c.returns AND p.doc IS c.doc AND p.is_exported IS c.is_exported)"
+                    : "");
+
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: embed flattening failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+
+    IndexEntry *entry = malloc(sizeof(IndexEntry));
+    if (!entry) {
+        fprintf(stderr, "Error: Failed to allocate memory for index entry\n");
+        sqlite3_finalize(stmt);
+        return -1;
+    }
+
+    while (sqlite3_step(stmt) == SQLITE_ROW) {
+        db_read_entry(stmt, entry);
+        ndjson_write_entry(out, entry);
+    }
+    fflush(out);
+
+    free(entry);
+    sqlite3_finalize(stmt);
+    return 0;
+}
+
+int clear_interface_embeds(CodeIndexDatabase *db) {
+    return exec_sql(db, "DELETE FROM code_index WHERE clue = 'promoted';"
+                        "UPDATE code_index SET modifier = '' "
+                        "WHERE context = 'PROP' AND clue = 'embedded' AND modifier = 'unresolved';");
+}
+
+int flatten_interface_embeds(CodeIndexDatabase *db, const char *const *directories, int directory_count,
+                             int verbose, FILE *ndjson_out, EmbedStats *stats) {
+    EmbedStats local = { 0, 0 };
+    int scoped = (directories != NULL);
+
+    /* Drop results of the previous pass: all of them, or those of the
+     * interfaces recomputed (kept aside to tell which rows changed) */
+    if (!scoped) {
+        if (clear_interface_embeds(db) != 0) {
+            return -1;
+        }
+    } else if (select_dirty_interfaces(db, directories, directory_count) != 0 ||
+               exec_sql(db,
+                   "DROP TABLE IF EXISTS temp.embeds_previous;"
+                   "CREATE TEMP TABLE embeds_previous AS "
+                   "SELECT * FROM code_index c WHERE clue = 'promoted' AND " DIRTY_INTERFACE("c") ";"
+                   "DELETE FROM code_index AS c WHERE clue = 'promoted' AND " DIRTY_INTERFACE("c") ";"
+                   "UPDATE code_index AS e SET modifier = '' "
+                   "WHERE context = 'PROP' AND clue = 'embedded' AND modifier = 'unresolved' "
+                   "  AND " DIRTY_INTERFACE("e") ";") != 0) {
+        return -1;
+    }
+
+    /* Each round promotes one more level of nesting. GROUP BY collapses a
+     * method reachable through several embeds (diamonds) into one row, and
+     * NOT EXISTS stops methods the interface already has (declared, or
+     * promoted in an earlier round), which also terminates cycles. */
+    char promote_sql[SQL_QUERY_BUFFER];
+    snprintf(promote_sql, sizeof(promote_sql),
+        "INSERT INTO code_index (symbol, directory, filename, line, context, full_symbol, "
+        "source_location, parent_symbol, scope, namespace, modifier, clue, type, language, params, returns, "
+        "doc, is_definition, is_exported) "
+        "SELECT m.symbol, e.directory, e.filename, e.line, 'FUNC', m.full_symbol, "
+        "       e.source_location, e.parent_symbol, m.scope, e.namespace, '', 'promoted', m.type, "
+        "       e.language, m.params, m.returns, m.doc, 0, m.is_exported "
+        "FROM code_index e "
+        "JOIN code_index t ON " RESOLVED_INTERFACE " "
+        "JOIN code_index m ON m.context = 'FUNC' AND m.parent_symbol = t.full_symbol "
+        "     AND m.namespace = t.namespace AND m.clue IN ('interface', 'promoted') "
+        "WHERE " INTERFACE_EMBED " "
+        "  AND NOT EXISTS (SELECT 1 FROM code_index x WHERE x.context = 'FUNC' "
+        "      AND x.parent_symbol = e.parent_symbol AND x.namespace = e.namespace "
+        "      AND x.full_symbol = m.full_symbol AND x.clue IN ('interface', 'promoted'))%s "
+        "GROUP BY e.parent_symbol, e.namespace, m.full_symbol",
+        scoped ? " AND " DIRTY_INTERFACE("e") : "");
+
+    for (int depth = 0; depth < MAX_EMBED_DEPTH; depth++) {
+        if (exec_sql(db, promote_sql) != 0) {
+            return -1;
+        }
+        int added = sqlite3_changes(db->db);
+        if (added == 0) break;
+        local.promoted += added;
+    }
+
+    /* Embeds naming an interface outside the indexed set */
+    char unresolved_sql[SQL_QUERY_BUFFER];
+    snprintf(unresolved_sql, sizeof(unresolved_sql),
+             "UPDATE code_index AS e SET modifier = 'unresolved' "
+             "WHERE " INTERFACE_EMBED " "
+             "  AND NOT EXISTS (SELECT 1 FROM code_index t WHERE " RESOLVED_INTERFACE ")%s",
+             scoped ? " AND " DIRTY_INTERFACE("e") : "");
+    if (exec_sql(db, unresolved_sql) != 0) {
+        return -1;
+    }
+
+    if (report_unresolved(db, scoped, verbose, &local) != 0) {
+        return -1;
+    }
+
+    if (ndjson_out && write_promoted(db, scoped, ndjson_out) != 0) {
+        return -1;
+    }
+    if (scoped && exec_sql(db, "DROP TABLE IF EXISTS temp.embeds_previous;") != 0) {
+        return -1;
+    }
+
+    if (stats) {
+        *stats = local;
+    }
+    return 0;
+}
+
+#else /* !ENABLED(GO) */
+
+/* Only Go indexes interface embeds; nothing to flatten or clear */
+int clear_interface_embeds(CodeIndexDatabase *db) {
+    (void)db;
+    return 0;
+}
+
+int flatten_interface_embeds(CodeIndexDatabase *db, const char *const *directories, int directory_count,
+                             int verbose, FILE *ndjson_out, EmbedStats *stats) {
+    (void)db;
+    (void)directories;
+    (void)directory_count;
+    (void)verbose;
+    (void)ndjson_out;
+    if (stats) {
+        stats->promoted = 0;
+        stats->unresolved = 0;
+    }
+    return 0;
+}
+
+#endif /* ENABLED(GO) */
@@ -0,0 +1,82 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef EMBEDS_H
+#define EMBEDS_H
+
+#include <stdio.h>
+#include "database.h"
+
+/*
+ * Interface embed flattening (--flatten-embeds)
+ *
+ * Language parsers record an interface embedded in another interface as a
+ * PROPERTY row with clue "embedded", parent = embedding interface and type =
+ * the (possibly qualified) embedded type, e.g. ReadCloser embeds io.Reader:
+ *
+ *   Reader  PROP  parent=ReadCloser  clue=embedded  type=io.Reader
+ *
+ * Interface method specs are FUNCTION rows with clue "interface" and
+ * parent = interface. Flattening copies the methods of each resolvable
+ * embedded interface into the embedding interface as FUNCTION rows with
+ * clue "promoted", recursively, so Read shows up under ReadCloser. The
+ * promoted row points at the embed line.
+ *
+ * Embeds whose interface is not in the index (e.g. io.Reader when the
+ * standard library is not indexed) keep their row and get
+ * modifier = "unresolved".
+ *
+ * The pass is idempotent: it removes previous results before recomputing.
+ * Watch mode reruns it after each batch of changes, scoped to the
+ * directories (packages) whose files changed: it recomputes the interfaces
+ * declared there, those with an embed that does not resolve and those
+ * embedding any of them, and keeps the promoted rows of the others.
+ *
+ * Runs without the flag remove the promoted rows of earlier ones, since
+ * nothing keeps them up to date.
+ */
+
+typedef struct {
+    int promoted;      /* Method rows added */
+    int unresolved;    /* Embeds that could not be resolved */
+} EmbedStats;
+
+/* Recompute promoted methods and unresolved embeds
+ *
+ * Parameters:
+ *   db              - Open database (caller manages the transaction)
+ *   directories     - Directories of the changed files, as stored in the
+ *                     index (NULL = the whole index)
+ *   directory_count - Number of directories
+ *   verbose         - If non-zero, list unresolved embeds on stdout
+ *   ndjson_out      - If non-NULL, write promoted rows as NDJSON: all of
+ *                     them, or for a scoped pass the new and changed ones
+ *   stats           - Output counts of the interfaces recomputed (may be NULL)
+ *
+ * Returns: 0 on success, -1 on database error
+ */
+int flatten_interface_embeds(CodeIndexDatabase *db, const char *const *directories, int directory_count,
+                             int verbose, FILE *ndjson_out, EmbedStats *stats);
+
+/* Remove promoted methods and unresolved marks left by earlier passes
+ * (caller manages the transaction)
+ *
+ * Returns: 0 on success, -1 on database error
+ */
+int clear_interface_embeds(CodeIndexDatabase *db);
+
+#endif /* EMBEDS_H */
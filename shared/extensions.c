@@ -19,6 +19,7 @@
 #include "constants.h"
 #include "file_opener.h"
 #include "string_utils.h"
+#include "unified_config.h"
 #include <stdio.h>
 #include <string.h>
 #include <strings.h>  /* For strcasecmp */
@@ -27,16 +28,11 @@
 #include <sys/stat.h>
 
 /**
- * Load extensions from a single file-extensions.txt file.
+ * Add the extensions of one language's file_extensions list.
  */
-static int load_extensions_from_file(const char *filepath, FileExtensions *exts) {
-    FILE *f = safe_fopen(filepath, "r", 0);
-    if (!f) {
-        return -1;
-    }
-
+static void load_extensions(ConfigLineReader *reader, FileExtensions *exts) {
     char line[LINE_BUFFER_SMALL];
-    while (fgets(line, sizeof(line), f) && exts->count < MAX_FILE_EXTENSIONS) {
+    while (config_reader_gets(reader, line, sizeof(line)) && exts->count < MAX_FILE_EXTENSIONS) {
         /* Remove trailing newline and whitespace */
         size_t len = strnlength(line, sizeof(line));
         while (len > 0 && isspace(line[len - 1])) {
@@ -65,9 +61,6 @@ static int load_extensions_from_file(const char *filepath, FileExtensions *exts)
             }
         }
     }
-
-    fclose(f);
-    return 0;
 }
 
 /**
@@ -82,18 +75,29 @@ int load_all_language_extensions(FileExtensions *all_exts) {
     all_exts->count = 0;
 
     /* List of language directories to check */
-    const char *lang_dirs[] = {"c", "typescript", "php", "go", "python", NULL};
+    const char *lang_dirs[] = {"c", "typescript", "javascript", "php", "go", "python", NULL};
 
     /* Try to load from each language's config directory */
     for (int i = 0; lang_dirs[i] != NULL; i++) {
+        /* Check if language directory exists first */
+        if (!dir_exists(lang_dirs[i])) {
+            continue;
+        }
+
+        /* Try to load extensions (silently skip if there are none) */
         char path[PATH_MAX_LENGTH];
-        snprintf(path, sizeof(path), "%s/%s/%s", lang_dirs[i], CONFIG_DIR, FILE_EXTENSIONS_FILENAME);
+        snprintf(path, sizeof(path), "%s/%s/%s", lang_dirs[i], CONFIG_DIR, UNIFIED_CONFIG_FILENAME);
+        UnifiedConfig unified;
+        int loaded = unified_config_parse(&unified, path);
 
-        /* Check if language directory exists first */
-        if (dir_exists(lang_dirs[i])) {
-            /* Try to load extensions (silently skip if file doesn't exist) */
-            load_extensions_from_file(path, all_exts);
+        ConfigLineReader reader;
+        snprintf(path, sizeof(path), "%s/%s/%s", lang_dirs[i], CONFIG_DIR, FILE_EXTENSIONS_FILENAME);
+        if (config_reader_open(&reader, loaded == 1 ? &unified : NULL,
+                               CONFIG_LIST_FILE_EXTENSIONS, path) == 0) {
+            load_extensions(&reader, all_exts);
+            config_reader_close(&reader);
         }
+        unified_config_free(&unified);
     }
 
     return (all_exts->count > 0) ? 0 : -1;
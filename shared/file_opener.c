@@ -48,3 +48,34 @@ FILE *safe_fopen(const char *filepath, const char *mode, int silent) {
     /* File is verified as regular, safe to open */
     return fopen(filepath, mode);
 }
+
+FILE *safe_fopen_follow(const char *filepath, const char *mode, int silent) {
+    if (!filepath || !mode) {
+        return NULL;
+    }
+
+    struct stat st;
+    if (stat(filepath, &st) != 0) {
+        return NULL;
+    }
+    if (!S_ISREG(st.st_mode)) {
+        if (!silent) {
+            fprintf(stderr, "Warning: '%s' is not a regular file, skipping\n", filepath);
+        }
+        return NULL;
+    }
+
+    FILE *fp = fopen(filepath, mode);
+    if (!fp) {
+        return NULL;
+    }
+    /* Check what was opened too: the link may have changed since stat() */
+    if (fstat(fileno(fp), &st) != 0 || !S_ISREG(st.st_mode)) {
+        if (!silent) {
+            fprintf(stderr, "Warning: '%s' is not a regular file, skipping\n", filepath);
+        }
+        fclose(fp);
+        return NULL;
+    }
+    return fp;
+}
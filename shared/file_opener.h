@@ -44,4 +44,15 @@
  */
 FILE *safe_fopen(const char *filepath, const char *mode, int silent);
 
+/* Open a source file found by the walk, following a symlink to a regular
+ * file (symlinked files are indexed under the link's path)
+ *
+ * Returns: FILE pointer on success, NULL on failure
+ *
+ * The same checks as safe_fopen() apply to the file the path resolves to,
+ * before and after opening it. Configuration files are opened with
+ * safe_fopen() instead.
+ */
+FILE *safe_fopen_follow(const char *filepath, const char *mode, int silent);
+
 #endif /* FILE_OPENER_H */
@@ -20,7 +20,9 @@
 #include "constants.h"
 #include "file_opener.h"
 #include <string.h>
+#include <ctype.h>
 #include <stdio.h>
+#include <stdint.h>
 
 void get_relative_path(const char *filepath, const char *project_root,
                       char *directory, char *filename) {
@@ -66,7 +68,7 @@ int print_lines_range(const char *filepath, int start_line, int end_line,
     }
 
     /* Open file */
-    FILE *fp = safe_fopen(filepath, "r", 1);  /* silent=1 */
+    FILE *fp = safe_fopen_follow(filepath, "r", 1);  /* silent=1 */
     if (!fp) {
         fprintf(stderr, "Warning: Could not read file '%s' for full definition\n", filepath);
         return -1;
@@ -120,3 +122,99 @@ int print_lines_range(const char *filepath, int start_line, int end_line,
     fclose(fp);
     return 0;
 }
+
+/* 64-bit FNV-1a over bytes, continuing from value */
+static uint64_t fnv1a(uint64_t value, const unsigned char *bytes, size_t length) {
+    for (size_t i = 0; i < length; i++) {
+        value ^= bytes[i];
+        value *= 1099511628211ULL;
+    }
+    return value;
+}
+
+#define FNV1A_OFFSET 14695981039346656037ULL
+
+int hash_file_contents(const char *filepath, char *hash, size_t size) {
+    FILE *fp = safe_fopen_follow(filepath, "rb", 1);
+    if (!fp) {
+        return -1;
+    }
+
+    uint64_t value = FNV1A_OFFSET;
+    unsigned char buffer[LINE_BUFFER_LARGE * 8];
+    size_t bytes;
+    while ((bytes = fread(buffer, 1, sizeof(buffer), fp)) > 0) {
+        value = fnv1a(value, buffer, bytes);
+    }
+    int failed = ferror(fp);
+    fclose(fp);
+    if (failed) {
+        return -1;
+    }
+
+    snprintf(hash, size, "%016llx", (unsigned long long)value);
+    return 0;
+}
+
+void hash_contents(const char *content, size_t length, char *hash, size_t size) {
+    uint64_t value = fnv1a(FNV1A_OFFSET, (const unsigned char *)content, length);
+    snprintf(hash, size, "%016llx", (unsigned long long)value);
+}
+
+/* A line of a comment, by its first characters after indentation */
+static int is_comment_line(const char *line) {
+    static const char *prefixes[] = { "//", "#", "/*", "*", "--", ";", "<!--", NULL };
+
+    while (*line == ' ' || *line == '\t') line++;
+    for (int i = 0; prefixes[i]; i++) {
+        if (strncmp(line, prefixes[i], strlen(prefixes[i])) == 0) {
+            return 1;
+        }
+    }
+    return 0;
+}
+
+/* A generated code marker on one header line (lowercased in place) */
+static int is_generated_line(char *line) {
+    if (!is_comment_line(line)) {
+        return 0;
+    }
+    for (char *p = line; *p; p++) {
+        *p = (char)tolower((unsigned char)*p);
+    }
+    return (strstr(line, "generated") && strstr(line, "do not edit")) ||
+           strstr(line, "@generated") || strstr(line, "<auto-generated");
+}
+
+int is_generated_file(const char *filepath) {
+    FILE *fp = safe_fopen_follow(filepath, "r", 1);
+    if (!fp) {
+        return 0;
+    }
+
+    char line[LINE_BUFFER_LARGE];
+    int generated = 0;
+    for (int n = 0; n < GENERATED_HEADER_LINES && !generated && fgets(line, sizeof(line), fp); n++) {
+        generated = is_generated_line(line);
+    }
+
+    fclose(fp);
+    return generated;
+}
+
+int is_generated_content(const char *content, size_t length) {
+    char line[LINE_BUFFER_LARGE];
+    const char *p = content;
+    const char *end = content + length;
+    for (int n = 0; n < GENERATED_HEADER_LINES && p < end; n++) {
+        const char *newline = memchr(p, '\n', (size_t)(end - p));
+        size_t len = newline ? (size_t)(newline - p) : (size_t)(end - p);
+        /* Long lines are checked up to the buffer size */
+        snprintf(line, sizeof(line), "%.*s", (int)(len < sizeof(line) ? len : sizeof(line) - 1), p);
+        if (is_generated_line(line)) {
+            return 1;
+        }
+        p += len + 1;
+    }
+    return 0;
+}
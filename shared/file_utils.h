@@ -18,6 +18,8 @@
 #ifndef FILE_UTILS_H
 #define FILE_UTILS_H
 
+#include <stddef.h>
+
 /* Convert absolute filepath to relative path with directory/filename split
  * Parameters:
  *   filepath     - Absolute or relative file path
@@ -52,4 +54,32 @@ int parse_source_location(const char *source_location, int *start_line, int *sta
 int print_lines_range(const char *filepath, int start_line, int end_line,
                      int start_column, int end_column, int raw);
 
+/* Hash the contents of a file (64-bit FNV-1a, as FILE_HASH_LENGTH - 1 hex digits)
+ * Parameters:
+ *   filepath - File to read
+ *   hash     - Output buffer, at least FILE_HASH_LENGTH bytes
+ *   size     - Size of hash
+ * Returns: 0 on success, -1 if the file cannot be read
+ */
+int hash_file_contents(const char *filepath, char *hash, size_t size);
+
+/* Hash content in memory, as hash_file_contents() hashes a file */
+void hash_contents(const char *content, size_t length, char *hash, size_t size);
+
+/* Check the first GENERATED_HEADER_LINES lines of a file for a generated
+ * code marker on a comment line (a line of a C block comment, or one
+ * starting with //, #, --, ; or <!--):
+ *   "Code generated by protoc-gen-go. DO NOT EDIT."    (Go convention)
+ *   "Generated by the protocol buffer compiler.  DO NOT EDIT!"
+ *   "@generated"                                      (Hack, Rust, Buck)
+ *   "<auto-generated>"                                (.NET)
+ * Markers are matched case-insensitively: "generated" together with
+ * "do not edit" on the same line, or either of the last two alone.
+ * Returns: 1 if the file is generated, 0 if not or it cannot be read
+ */
+int is_generated_file(const char *filepath);
+
+/* is_generated_file() for content in memory (an archive entry) */
+int is_generated_content(const char *content, size_t length);
+
 #endif /* FILE_UTILS_H */
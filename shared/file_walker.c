@@ -21,6 +21,7 @@
 #include <sys/types.h>
 #include <sys/stat.h>
 #include <dirent.h>
+#include <stdint.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
@@ -294,7 +295,107 @@ static int is_file_ignored(const char *full_path, const char *filename, const Wo
     return 0;
 }
 
-static void walk_directory(const char *dir_path, FileList *files, const ExcludeDirs *exclude_dirs, const FileExtensions *extensions, const WordSet *ignore_dirs) {
+int is_entry_ignored(const char *full_path, const char *name, int is_dir,
+                     const WordSet *ignore_dirs, const IgnoreRules *ignore_rules) {
+    int ignored = is_dir ? is_path_ignored(full_path, name, ignore_dirs)
+                         : is_file_ignored(full_path, name, ignore_dirs);
+
+    /* .sourceminderignore is layered on top: its last matching pattern wins */
+    IgnoreVerdict verdict = ignore_rules_match(ignore_rules, full_path, is_dir);
+    if (verdict != IGNORE_NO_MATCH) {
+        ignored = (verdict == IGNORE_EXCLUDE);
+    }
+    return ignored;
+}
+
+#if defined(_WIN32) || defined(__MINGW32__) || defined(__MINGW64__)
+/* Windows: no lstat, and st_ino is always 0, so directories cannot be told
+ * apart by inode; symlinks and junctions are followed as plain directories */
+#define WALK_TRACKS_INODES 0
+#define walk_lstat stat
+#define S_ISLNK(mode) 0
+#else
+#define WALK_TRACKS_INODES 1
+#define walk_lstat lstat
+#endif
+
+/* A walked directory, by device and inode; used is 0 for an empty slot */
+typedef struct {
+    dev_t dev;
+    ino_t ino;
+    int used;
+} VisitedDir;
+
+/* Walk state shared by the recursion */
+typedef struct {
+    FileList *files;
+    const ExcludeDirs *exclude_dirs;
+    const FileExtensions *extensions;
+    const WordSet *ignore_dirs;
+    const IgnoreRules *ignore_rules;
+    int follow_symlinks;
+    int verbose;
+    /* Every directory walked so far: an open-addressing hash set whose
+     * capacity is a power of two, kept at most half full */
+    VisitedDir *visited;
+    size_t visited_count;
+    size_t visited_capacity;
+} Walk;
+
+#define VISITED_INITIAL_CAPACITY 64
+
+#if WALK_TRACKS_INODES
+/* Find the slot for a directory: either the slot holding it or the empty slot where it belongs */
+static VisitedDir *visited_slot(VisitedDir *slots, size_t capacity, dev_t dev, ino_t ino) {
+    size_t mask = capacity - 1;
+    uint64_t hash = ((uint64_t)ino * 0x9E3779B97F4A7C15ull) ^ (uint64_t)dev;
+    size_t i = (size_t)(hash ^ (hash >> 29)) & mask;
+    while (slots[i].used && (slots[i].dev != dev || slots[i].ino != ino)) {
+        i = (i + 1) & mask;
+    }
+    return &slots[i];
+}
+
+static int visited_grow(Walk *walk) {
+    size_t new_capacity = walk->visited_capacity > 0 ? walk->visited_capacity * 2 : VISITED_INITIAL_CAPACITY;
+    VisitedDir *new_slots = calloc(new_capacity, sizeof(VisitedDir));
+    if (!new_slots) {
+        return -1;
+    }
+    for (size_t i = 0; i < walk->visited_capacity; i++) {
+        if (walk->visited[i].used) {
+            *visited_slot(new_slots, new_capacity, walk->visited[i].dev, walk->visited[i].ino) = walk->visited[i];
+        }
+    }
+    free(walk->visited);
+    walk->visited = new_slots;
+    walk->visited_capacity = new_capacity;
+    return 0;
+}
+#endif
+
+/* Record a directory as walked; returns 1 if it already was, -1 on allocation failure */
+static int mark_visited(Walk *walk, const struct stat *st) {
+#if WALK_TRACKS_INODES
+    if ((walk->visited_count + 1) * 2 > walk->visited_capacity && visited_grow(walk) != 0) {
+        return -1;
+    }
+    VisitedDir *slot = visited_slot(walk->visited, walk->visited_capacity, st->st_dev, st->st_ino);
+    if (slot->used) {
+        return 1;
+    }
+    slot->dev = st->st_dev;
+    slot->ino = st->st_ino;
+    slot->used = 1;
+    walk->visited_count++;
+#else
+    (void)walk;
+    (void)st;
+#endif
+    return 0;
+}
+
+static void walk_directory(Walk *walk, const char *dir_path) {
     DIR *dir;
     struct dirent *entry;
 
@@ -315,32 +416,58 @@ static void walk_directory(const char *dir_path, FileList *files, const ExcludeD
         int has_trailing_slash = (dir_len > 0 && dir_path[dir_len - 1] == '/');
         snprintf(path, sizeof(path), "%s%s%s", dir_path, has_trailing_slash ? "" : "/", entry->d_name);
 
-        /* Use stat to determine file type */
+        /* Use lstat to see symlinks, then stat for what they point to */
         struct stat st;
-        if (stat(path, &st) != 0) {
+        if (walk_lstat(path, &st) != 0) {
             continue;
         }
+        /* Symlinked files are indexed like the files they point to (they
+         * cannot loop); symlinked directories only with --follow-symlinks */
+        if (S_ISLNK(st.st_mode)) {
+            if (stat(path, &st) != 0) {
+                if (walk->verbose) {
+                    fprintf(stderr, "Skipping broken symlink %s\n", path);
+                }
+                continue;
+            }
+            if (S_ISDIR(st.st_mode) && !walk->follow_symlinks) {
+                if (walk->verbose) {
+                    fprintf(stderr, "Skipping symlinked directory %s (use --follow-symlinks to follow)\n", path);
+                }
+                continue;
+            }
+        }
 
         if (S_ISDIR(st.st_mode)) {
             /* Skip configured ignore directories */
-            if (is_path_ignored(path, entry->d_name, ignore_dirs)) {
+            if (is_entry_ignored(path, entry->d_name, 1, walk->ignore_dirs, walk->ignore_rules)) {
                 continue;
             }
             /* Skip user-specified exclude directories */
-            if (is_excluded(path, entry->d_name, exclude_dirs)) {
+            if (is_excluded(path, entry->d_name, walk->exclude_dirs)) {
+                continue;
+            }
+            /* Skip directories already walked under another path (symlink cycles) */
+            int seen = mark_visited(walk, &st);
+            if (seen != 0) {
+                if (seen < 0) {
+                    fprintf(stderr, "Warning: out of memory tracking directories, skipping %s\n", path);
+                } else if (walk->verbose) {
+                    fprintf(stderr, "Skipping %s (directory already indexed)\n", path);
+                }
                 continue;
             }
             /* Recursively walk subdirectory */
-            walk_directory(path, files, exclude_dirs, extensions, ignore_dirs);
+            walk_directory(walk, path);
         }
         else if (S_ISREG(st.st_mode)) {
             /* Skip ignored files (e.g., *.o, test_*.tmp, *.c) */
-            if (is_file_ignored(path, entry->d_name, ignore_dirs)) {
+            if (is_entry_ignored(path, entry->d_name, 0, walk->ignore_dirs, walk->ignore_rules)) {
                 continue;
             }
             /* Check if file has valid extension */
-            if (path_matches_extensions(path, extensions)) {
-                add_file_to_list(files, path);
+            if (path_matches_extensions(path, walk->extensions)) {
+                add_file_to_list(walk->files, path);
             }
         }
     }
@@ -348,8 +475,45 @@ static void walk_directory(const char *dir_path, FileList *files, const ExcludeD
     closedir(dir);
 }
 
-int find_files(const char *dir_path, FileList *files, const ExcludeDirs *exclude_dirs, const FileExtensions *extensions, const WordSet *ignore_dirs) {
+int find_files(const char *dir_path, FileList *files, const ExcludeDirs *exclude_dirs, const FileExtensions *extensions, const WordSet *ignore_dirs, const IgnoreRules *ignore_rules,
+               const WalkOptions *options) {
+    Walk walk = {
+        .files = files,
+        .exclude_dirs = exclude_dirs,
+        .extensions = extensions,
+        .ignore_dirs = ignore_dirs,
+        .ignore_rules = ignore_rules,
+        .follow_symlinks = options ? options->follow_symlinks : 0,
+        .verbose = options ? options->verbose : 0,
+    };
+
     files->count = 0;
-    walk_directory(dir_path, files, exclude_dirs, extensions, ignore_dirs);
+    /* The root itself may be a symlink; it was named explicitly, so follow it */
+    struct stat st;
+    if (stat(dir_path, &st) == 0 && mark_visited(&walk, &st) >= 0) {
+        walk_directory(&walk, dir_path);
+    }
+    free(walk.visited);
     return 0;
 }
+
+int is_archive_entry_indexed(const char *name, const ExcludeDirs *exclude_dirs,
+                             const FileExtensions *extensions, const WordSet *ignore_dirs) {
+    char path[PATH_MAX_LENGTH];
+    snprintf(path, sizeof(path), "%s", name);
+
+    /* Each directory on the way, as the walk would reach it */
+    char *component = path;
+    char *slash;
+    while ((slash = strchr(component, '/')) != NULL) {
+        *slash = '\0';
+        if (is_entry_ignored(path, component, 1, ignore_dirs, NULL) ||
+            is_excluded(path, component, exclude_dirs)) {
+            return 0;
+        }
+        *slash = '/';
+        component = slash + 1;
+    }
+    return !is_entry_ignored(path, component, 0, ignore_dirs, NULL) &&
+           path_matches_extensions(path, extensions);
+}
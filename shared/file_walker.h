@@ -20,6 +20,7 @@
 
 #include "filter.h"
 #include "constants.h"
+#include "ignore_rules.h"
 
 typedef struct {
     char **files;       /* Dynamic array of string pointers */
@@ -32,6 +33,12 @@ typedef struct {
     int count;
 } ExcludeDirs;
 
+/* Symlink handling while walking */
+typedef struct {
+    int follow_symlinks;  /* Descend into symlinked directories (--follow-symlinks) */
+    int verbose;          /* Report skipped symlinks and cycles on stderr */
+} WalkOptions;
+
 /* Initialize FileList with dynamic allocation */
 void init_file_list(FileList *list);
 
@@ -41,10 +48,30 @@ void free_file_list(FileList *list);
 /* Add a file path to the FileList (grows capacity as needed) */
 void add_file_to_list(FileList *list, const char *path);
 
-/* Find all files matching configured extensions in a directory recursively */
-int find_files(const char *dir_path, FileList *files, const ExcludeDirs *exclude_dirs, const FileExtensions *extensions, const WordSet *ignore_dirs);
+/* Find all files matching configured extensions in a directory recursively
+ * (ignore_rules: the directory's .sourceminderignore, may be NULL)
+ *
+ * Symlinks below dir_path are skipped unless options->follow_symlinks is
+ * set; then symlinked directories are walked too. Symlinked files are always
+ * skipped, since only regular files are opened for indexing. Each directory
+ * is walked once, by device and inode, so links back up the tree cannot
+ * loop. options may be NULL for the defaults */
+int find_files(const char *dir_path, FileList *files, const ExcludeDirs *exclude_dirs, const FileExtensions *extensions, const WordSet *ignore_dirs, const IgnoreRules *ignore_rules,
+               const WalkOptions *options);
 
 /* Check if a path should be ignored based on ignore_dirs patterns */
 int is_path_ignored(const char *full_path, const char *dirname, const WordSet *ignore_dirs);
 
+/* Check if a directory or file found while walking should be skipped:
+ * ignore_files.txt patterns, overridden by any matching .sourceminderignore
+ * pattern (ignore_rules may be NULL) */
+int is_entry_ignored(const char *full_path, const char *name, int is_dir,
+                     const WordSet *ignore_dirs, const IgnoreRules *ignore_rules);
+
+/* Check if a file inside an archive (name relative to its root) would be
+ * indexed by find_files() in the extracted tree: no directory on its path
+ * ignored or excluded, the file itself not ignored, a configured extension */
+int is_archive_entry_indexed(const char *name, const ExcludeDirs *exclude_dirs,
+                             const FileExtensions *extensions, const WordSet *ignore_dirs);
+
 #endif
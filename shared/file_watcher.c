@@ -429,9 +429,10 @@ static int add_directory_recursive(FileWatcher *watcher, const char *directory)
         char path[4096];
         snprintf(path, sizeof(path), "%s/%s", directory, entry->d_name);
 
-        /* Get file type */
+        /* Get file type (lstat: symlinks are not watched, so links back up
+         * the tree cannot loop) */
         struct stat st;
-        if (stat(path, &st) == -1) {
+        if (lstat(path, &st) == -1) {
             continue;
         }
 
@@ -632,6 +633,14 @@ static int has_valid_extension_array(const char *filename, char extensions[][FIL
     return 0;
 }
 
+/* Join directory and name without doubling the separator, so event paths
+ * match the paths produced by the file walker (e.g. "src/" + "a.go") */
+static void join_path(char *out, size_t size, const char *dir, const char *name) {
+    size_t dir_len = strlen(dir);
+    int has_trailing_slash = (dir_len > 0 && dir[dir_len - 1] == '/');
+    snprintf(out, size, "%s%s%s", dir, has_trailing_slash ? "" : "/", name);
+}
+
 /* Add watch for a single directory */
 static int add_watch(FileWatcher *watcher, const char *path) {
     if (watcher->watch_count >= MAX_WATCH_DIRS) {
@@ -639,12 +648,23 @@ static int add_watch(FileWatcher *watcher, const char *path) {
         return -1;
     }
 
-    int wd = inotify_add_watch(watcher->fd, path, IN_MODIFY | IN_CREATE | IN_DELETE | IN_MOVED_TO);
+    /* IN_MOVED_FROM is needed so the old name of a renamed file is reported
+     * and its symbols can be purged from the index */
+    int wd = inotify_add_watch(watcher->fd, path,
+                               IN_MODIFY | IN_CREATE | IN_DELETE | IN_MOVED_FROM | IN_MOVED_TO);
     if (wd == -1) {
         /* Silently skip directories we can't watch (permissions, etc.) */
         return 0;
     }
 
+    /* A directory watched already (moved back in) keeps its descriptor */
+    for (int i = 0; i < watcher->watch_count; i++) {
+        if (watcher->watches[i].wd == wd) {
+            snprintf(watcher->watches[i].path, sizeof(watcher->watches[i].path), "%s", path);
+            return 0;
+        }
+    }
+
     watcher->watches[watcher->watch_count].wd = wd;
     strncpy(watcher->watches[watcher->watch_count].path, path, sizeof(watcher->watches[0].path) - 1);
     watcher->watch_count++;
@@ -672,7 +692,7 @@ static int add_watch_recursive(FileWatcher *watcher, const char *directory) {
 
         /* Build full path */
         char path[4096];
-        snprintf(path, sizeof(path), "%s/%s", directory, entry->d_name);
+        join_path(path, sizeof(path), directory, entry->d_name);
 
         /* Check if directory */
         if (entry->d_type == DT_DIR) {
@@ -684,6 +704,22 @@ static int add_watch_recursive(FileWatcher *watcher, const char *directory) {
     return 0;
 }
 
+/* Stop watching a directory that left the tree, and every directory below it */
+static void remove_watches_under(FileWatcher *watcher, const char *directory) {
+    size_t len = strlen(directory);
+    int i = 0;
+    while (i < watcher->watch_count) {
+        const char *path = watcher->watches[i].path;
+        if (strncmp(path, directory, len) == 0 && (path[len] == '\0' || path[len] == '/')) {
+            /* Fails harmlessly for a deleted directory, whose watch is gone */
+            inotify_rm_watch(watcher->fd, watcher->watches[i].wd);
+            watcher->watches[i] = watcher->watches[--watcher->watch_count];
+        } else {
+            i++;
+        }
+    }
+}
+
 /* Find directory path for watch descriptor */
 static const char* find_watch_path(FileWatcher *watcher, int wd) {
     for (int i = 0; i < watcher->watch_count; i++) {
@@ -694,6 +730,24 @@ static const char* find_watch_path(FileWatcher *watcher, int wd) {
     return NULL;
 }
 
+/* Add an event to the batch. The latest event for a path wins, so a delete
+ * followed by a re-create within the debounce window is reported as a
+ * change, not a deletion. */
+static void queue_event(FileEvent *events, int *event_count, int max_events,
+                        const char *filepath, FileEventType type) {
+    for (int i = 0; i < *event_count; i++) {
+        if (strcmp(events[i].filepath, filepath) == 0) {
+            events[i].type = type;
+            return;
+        }
+    }
+    if (*event_count < max_events) {
+        snprintf(events[*event_count].filepath, sizeof(events[0].filepath), "%s", filepath);
+        events[*event_count].type = type;
+        (*event_count)++;
+    }
+}
+
 /* Get current time in milliseconds */
 static long long current_time_ms(void) {
     struct timespec ts;
@@ -787,12 +841,29 @@ int file_watcher_wait(FileWatcher *watcher, FileEvent *events, int max_events) {
         for (char *ptr = buf; ptr < buf + len; ptr += sizeof(struct inotify_event) + event->len) {
             event = (const struct inotify_event *)ptr;
 
-            /* Skip directory events */
-            if (event->mask & IN_ISDIR) continue;
-
             /* Skip if no name */
             if (event->len == 0) continue;
 
+            /* Watch directories created or moved into the tree, drop the
+             * watches of those deleted or moved out, and report either:
+             * their files get no events of their own */
+            if (event->mask & IN_ISDIR) {
+                const char *parent = find_watch_path(watcher, event->wd);
+                if (!parent) continue;
+                char dirpath[4096];
+                join_path(dirpath, sizeof(dirpath), parent, event->name);
+                if (event->mask & (IN_CREATE | IN_MOVED_TO)) {
+                    add_watch_recursive(watcher, dirpath);
+                } else if (event->mask & (IN_DELETE | IN_MOVED_FROM)) {
+                    remove_watches_under(watcher, dirpath);
+                } else {
+                    continue;
+                }
+                queue_event(events, &event_count, max_events, dirpath, FILE_EVENT_DIRECTORY);
+                last_event_time = current_time_ms();
+                continue;
+            }
+
             /* Check extension */
             if (!has_valid_extension_array(event->name, watcher->extensions, watcher->extension_count)) {
                 continue;
@@ -804,33 +875,19 @@ int file_watcher_wait(FileWatcher *watcher, FileEvent *events, int max_events) {
 
             /* Build full path */
             char filepath[4096];
-            snprintf(filepath, sizeof(filepath), "%s/%s", dir, event->name);
-
-            /* Check if we already have this file in the events array (deduplicate) */
-            int already_exists = 0;
-            for (int i = 0; i < event_count; i++) {
-                if (strcmp(events[i].filepath, filepath) == 0) {
-                    already_exists = 1;
-                    break;
-                }
-            }
-
-            if (!already_exists && event_count < max_events) {
-                strncpy(events[event_count].filepath, filepath, sizeof(events[0].filepath) - 1);
-                events[event_count].filepath[sizeof(events[0].filepath) - 1] = '\0';
-
-                /* Determine event type */
-                if (event->mask & (IN_MODIFY | IN_MOVED_TO)) {
-                    events[event_count].type = FILE_EVENT_MODIFIED;
-                } else if (event->mask & IN_CREATE) {
-                    events[event_count].type = FILE_EVENT_CREATED;
-                } else if (event->mask & IN_DELETE) {
-                    events[event_count].type = FILE_EVENT_DELETED;
-                }
-
-                event_count++;
+            join_path(filepath, sizeof(filepath), dir, event->name);
+
+            /* Determine event type */
+            FileEventType type;
+            if (event->mask & (IN_MODIFY | IN_MOVED_TO)) {
+                type = FILE_EVENT_MODIFIED;
+            } else if (event->mask & IN_CREATE) {
+                type = FILE_EVENT_CREATED;
+            } else {
+                type = FILE_EVENT_DELETED;  /* IN_DELETE or IN_MOVED_FROM */
             }
 
+            queue_event(events, &event_count, max_events, filepath, type);
             last_event_time = current_time_ms();
         }
     }
@@ -32,11 +32,16 @@
 /* File watcher handle */
 typedef struct FileWatcher FileWatcher;
 
-/* File event types */
+/* File event types
+ * FILE_EVENT_DIRECTORY: a directory was created, deleted or renamed, or moved
+ * into or out of the tree (filepath is the directory). The files under it get
+ * no events of their own, so the caller rescans it. Reported by the inotify
+ * watcher (Linux). */
 typedef enum {
     FILE_EVENT_MODIFIED,
     FILE_EVENT_CREATED,
-    FILE_EVENT_DELETED
+    FILE_EVENT_DELETED,
+    FILE_EVENT_DIRECTORY
 } FileEventType;
 
 /* File event information */
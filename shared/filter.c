@@ -20,20 +20,62 @@
 #include "string_utils.h"
 #include "paths.h"
 #include "constants.h"
+#include "identifier_tokens.h"
+#include "unified_config.h"
+#include "file_utils.h"
 #include <stdio.h>
 #include <string.h>
 #include <ctype.h>
 
-static int load_file_extensions(FileExtensions *exts, const char *config_path) {
-    exts->count = 0;
+static int is_in_set(WordSet *set, const char *word) {
+    for (int i = 0; i < set->count; i++) {
+        if (strcmp(set->words[i], word) == 0) {
+            return 1;
+        }
+    }
+    return 0;
+}
 
-    FILE *f = safe_fopen(config_path, "r", 0);
-    if (!f) {
+/* Fold a setting into a fingerprint (FILE_HASH_LENGTH bytes) */
+static void fingerprint_add(char *fingerprint, const char *text) {
+    char buffer[FILE_HASH_LENGTH + LINE_BUFFER_LARGE];
+    int written = snprintf(buffer, sizeof(buffer), "%s\n%s", fingerprint, text);
+    if (written < 0) {
+        return;
+    }
+    size_t length = (size_t)written < sizeof(buffer) ? (size_t)written : sizeof(buffer) - 1;
+    hash_contents(buffer, length, fingerprint, FILE_HASH_LENGTH);
+}
+
+static void fingerprint_add_words(char *fingerprint, const WordSet *set) {
+    for (int i = 0; i < set->count; i++) {
+        fingerprint_add(fingerprint, set->words[i]);
+    }
+    fingerprint_add(fingerprint, "");  /* Ends the list */
+}
+
+/* Open list id of the unified config, or else <dir>/<filename> */
+static int open_list(ConfigLineReader *reader, const UnifiedConfig *unified, ConfigListId id,
+                     const char *dir, const char *filename) {
+    char path[LINE_BUFFER_LARGE];
+    char resolved_path[PATH_MAX_LENGTH];
+
+    snprintf(path, sizeof(path), "%s/%s", dir, filename);
+    int found = resolve_data_file(path, resolved_path, sizeof(resolved_path)) == 0;
+    if (config_reader_open(reader, unified, id, found ? resolved_path : NULL) != 0) {
+        if (found) {
+            fprintf(stderr, "Warning: Could not open %s\n", resolved_path);
+        }
         return -1;
     }
+    return 0;
+}
+
+static void load_file_extensions(FileExtensions *exts, ConfigLineReader *reader) {
+    exts->count = 0;
 
     char line[LINE_BUFFER_SMALL];
-    while (fgets(line, sizeof(line), f) && exts->count < MAX_FILE_EXTENSIONS) {
+    while (config_reader_gets(reader, line, sizeof(line)) && exts->count < MAX_FILE_EXTENSIONS) {
         /* Remove trailing newline and whitespace */
         size_t len = strnlength(line, sizeof(line));
         while (len > 0 && isspace(line[len - 1])) {
@@ -52,22 +94,13 @@ static int load_file_extensions(FileExtensions *exts, const char *config_path) {
             exts->count++;
         }
     }
-
-    fclose(f);
-    return 0;
 }
 
-static int load_word_list(WordSet *set, const char *filepath) {
-    FILE *fp = safe_fopen(filepath, "r", 0);
-    if (!fp) {
-        fprintf(stderr, "Warning: Could not open %s\n", filepath);
-        return -1;
-    }
-
+static void load_word_list(WordSet *set, ConfigLineReader *reader) {
     set->count = 0;
     char line[WORD_MAX_LENGTH];
 
-    while (fgets(line, sizeof(line), fp) && set->count < MAX_FILTER_WORDS) {
+    while (config_reader_gets(reader, line, sizeof(line)) && set->count < MAX_FILTER_WORDS) {
         /* Remove trailing newline */
         line[strcspn(line, "\n")] = '\0';
 
@@ -80,22 +113,42 @@ static int load_word_list(WordSet *set, const char *filepath) {
         snprintf(set->words[set->count], WORD_MAX_LENGTH, "%s", line);
         set->count++;
     }
-
-    fclose(fp);
-    return 0;
 }
 
-static int load_regex_patterns(RegexSet *set, const char *filepath) {
-    FILE *fp = safe_fopen(filepath, "r", 0);
-    if (!fp) {
-        fprintf(stderr, "Warning: Could not open %s\n", filepath);
-        return -1;
+/* Layer a language stopwords.txt over the words already in set: each word
+ * is added, "!word" drops one and "!*" drops all of them (replacing the
+ * shared list with the lines that follow). Blank and '#' lines are skipped. */
+static void merge_stopwords(WordSet *set, ConfigLineReader *reader) {
+    char line[WORD_MAX_LENGTH];
+    while (config_reader_gets(reader, line, sizeof(line))) {
+        line[strcspn(line, "\n")] = '\0';
+        if (line[0] == '\0' || line[0] == '#') {
+            continue;
+        }
+
+        if (strcmp(line, "!*") == 0) {
+            set->count = 0;
+        } else if (line[0] == '!') {
+            for (int i = 0; i < set->count; i++) {
+                if (strcmp(set->words[i], line + 1) == 0) {
+                    /* Order does not matter; move the last word into the gap */
+                    set->count--;
+                    memcpy(set->words[i], set->words[set->count], WORD_MAX_LENGTH);
+                    break;
+                }
+            }
+        } else if (!is_in_set(set, line) && set->count < MAX_FILTER_WORDS) {
+            snprintf(set->words[set->count], WORD_MAX_LENGTH, "%s", line);
+            set->count++;
+        }
     }
+}
 
+static void load_regex_patterns(RegexSet *set, ConfigLineReader *reader, char *fingerprint) {
     set->count = 0;
     char line[LINE_BUFFER_LARGE];
 
-    while (fgets(line, sizeof(line), fp) && set->count < MAX_REGEX_PATTERNS) {
+    while (config_reader_gets(reader, line, sizeof(line)) && set->count < MAX_REGEX_PATTERNS) {
         /* Remove trailing newline */
         line[strcspn(line, "\n")] = '\0';
 
@@ -113,20 +166,111 @@ static int load_regex_patterns(RegexSet *set, const char *filepath) {
             continue;
         }
 
+        fingerprint_add(fingerprint, line);
         set->count++;
     }
+}
+
+int filter_parse_symbol_limit(const char *line, int *min_length, int *max_length, const char **error) {
+    char key[WORD_MAX_LENGTH];
+    char value[WORD_MAX_LENGTH];
+    char extra;
+
+    const char *p = line;
+    while (isspace((unsigned char)*p)) p++;
+    if (*p == '\0' || *p == '#') {
+        return 0;
+    }
+
+    /* "key = value" or "key=value" */
+    char buf[LINE_BUFFER_LARGE];
+    snprintf(buf, sizeof(buf), "%s", p);
+    char *eq = strchr(buf, '=');
+    if (!eq) {
+        *error = "expected 'name = value'";
+        return -1;
+    }
+    *eq = '\0';
+    if (sscanf(buf, "%63s %c", key, &extra) != 1 || sscanf(eq + 1, "%63s %c", value, &extra) != 1) {
+        *error = "expected 'name = value'";
+        return -1;
+    }
 
+    char *end;
+    long n = strtol(value, &end, 10);
+    if (*end != '\0') {
+        *error = "value is not a number";
+        return -1;
+    }
+
+    if (strcmp(key, "min_length") == 0) {
+        if (n < 1 || n >= SYMBOL_MAX_LENGTH) {
+            *error = "min_length must be between 1 and the compiled SYMBOL_MAX_LENGTH - 1";
+            return -1;
+        }
+        *min_length = (int)n;
+    } else if (strcmp(key, "max_length") == 0) {
+        if (n < 1 || n >= SYMBOL_MAX_LENGTH) {
+            *error = "max_length must be between 1 and the compiled SYMBOL_MAX_LENGTH - 1";
+            return -1;
+        }
+        *max_length = (int)n;
+    } else {
+        *error = "unknown setting (expected min_length or max_length)";
+        return -1;
+    }
+    return 1;
+}
+
+/* Load symbol_limits.txt over the compile-time defaults; invalid lines are
+ * skipped with a warning (preflight validation reports them as errors) */
+static void load_symbol_limits(SymbolFilter *filter, const char *filepath) {
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        return;
+    }
+
+    int min_length = filter->min_symbol_length;
+    int max_length = filter->max_symbol_length;
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        const char *error;
+        if (filter_parse_symbol_limit(line, &min_length, &max_length, &error) < 0) {
+            fprintf(stderr, "Warning: %s:%d: %s\n", filepath, line_num, error);
+        }
+    }
     fclose(fp);
-    return 0;
+
+    if (min_length > max_length) {
+        fprintf(stderr, "Warning: %s: min_length (%d) > max_length (%d); using defaults\n",
+                filepath, min_length, max_length);
+        return;
+    }
+    filter->min_symbol_length = min_length;
+    filter->max_symbol_length = max_length;
 }
 
-static int is_in_set(WordSet *set, const char *word) {
-    for (int i = 0; i < set->count; i++) {
-        if (strcmp(set->words[i], word) == 0) {
-            return 1;
+/* The rules listed replace the defaults; invalid lines are skipped */
+static void load_split_rules(SymbolFilter *filter, const char *filepath) {
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        return;
+    }
+
+    int rules = 0;
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        const char *error;
+        if (identifier_parse_split_rule(line, &rules, &error) < 0) {
+            fprintf(stderr, "Warning: %s:%d: %s\n", filepath, line_num, error);
         }
     }
-    return 0;
+    fclose(fp);
+    filter->split_rules = rules;
 }
 
 static int matches_regex_pattern(RegexSet *set, const char *word) {
@@ -138,63 +282,207 @@ static int matches_regex_pattern(RegexSet *set, const char *word) {
     return 0;
 }
 
+static int load_stopwords(WordSet *stopwords, const char *lang_data_dir, const UnifiedConfig *unified) {
+    ConfigLineReader reader;
+    int status = 0;
+
+    stopwords->count = 0;
+    if (open_list(&reader, NULL, CONFIG_LIST_STOPWORDS, SHARED_CONFIG_DIR, STOPWORDS_FILENAME) == 0) {
+        load_word_list(stopwords, &reader);
+        config_reader_close(&reader);
+    } else {
+        status = -1;
+    }
+
+    if (open_list(&reader, unified, CONFIG_LIST_STOPWORDS, lang_data_dir, STOPWORDS_FILENAME) == 0) {
+        merge_stopwords(stopwords, &reader);
+        config_reader_close(&reader);
+    }
+    return status;
+}
+
+int filter_load_stopwords(WordSet *stopwords, const char *lang_data_dir) {
+    UnifiedConfig unified;
+    int loaded = unified_config_load(&unified, lang_data_dir);
+    int status = load_stopwords(stopwords, lang_data_dir, loaded == 1 ? &unified : NULL);
+    unified_config_free(&unified);
+    return status;
+}
+
+int filter_load_extensions(FileExtensions *exts, const char *lang_data_dir) {
+    UnifiedConfig unified;
+    int loaded = unified_config_load(&unified, lang_data_dir);
+    ConfigLineReader reader;
+    int status = -1;
+    exts->count = 0;
+    if (open_list(&reader, loaded == 1 ? &unified : NULL, CONFIG_LIST_FILE_EXTENSIONS,
+                  lang_data_dir, FILE_EXTENSIONS_FILENAME) == 0) {
+        load_file_extensions(exts, &reader);
+        config_reader_close(&reader);
+        status = 0;
+    }
+    unified_config_free(&unified);
+    return status;
+}
+
+/* Add each extension of a comma- or space-separated list to exts, once */
+static int add_extension_list(FileExtensions *exts, const char *list, const char *option,
+                              char *error, size_t error_size) {
+    int added = 0;
+    const char *p = list;
+    while (*p) {
+        p += strspn(p, ", \t");
+        size_t len = strcspn(p, ", \t");
+        if (len == 0) break;
+
+        /* ".cgi" or "cgi" */
+        const char *entry = p;
+        p += len;
+        const char *name = entry[0] == '.' ? entry + 1 : entry;
+        size_t name_len = len - (size_t)(name - entry);
+        if (name_len == 0 || memchr(name, '/', name_len) || memchr(name, '\\', name_len)) {
+            snprintf(error, error_size, "%s: '%.*s' is not a file extension", option, (int)len, entry);
+            return -1;
+        }
+        if (name_len + 1 >= FILE_EXTENSION_MAX_LENGTH) {
+            snprintf(error, error_size, "%s: '%.*s' is longer than %d characters", option, (int)len, entry,
+                     FILE_EXTENSION_MAX_LENGTH - 1);
+            return -1;
+        }
+        added++;
+
+        char extension[FILE_EXTENSION_MAX_LENGTH];
+        snprintf(extension, sizeof(extension), ".%.*s", (int)name_len, name);
+        int known = 0;
+        for (int i = 0; i < exts->count; i++) {
+            if (strcmp(exts->extensions[i], extension) == 0) {
+                known = 1;
+                break;
+            }
+        }
+        if (known) continue;
+        if (exts->count == MAX_FILE_EXTENSIONS) {
+            snprintf(error, error_size, "%s: too many extensions (maximum %d)", option, MAX_FILE_EXTENSIONS);
+            return -1;
+        }
+        snprintf(exts->extensions[exts->count], sizeof(exts->extensions[exts->count]), "%s", extension);
+        exts->count++;
+    }
+    if (added == 0) {
+        snprintf(error, error_size, "%s requires at least one extension (e.g. .py,.pyw)", option);
+        return -1;
+    }
+    return 0;
+}
+
+int filter_override_extensions(FileExtensions *exts, const char *replace, const char *add,
+                               char *error, size_t error_size) {
+    if (replace) {
+        exts->count = 0;
+        if (add_extension_list(exts, replace, "--extensions", error, error_size) != 0) {
+            return -1;
+        }
+    }
+    if (add && add_extension_list(exts, add, "--extensions-add", error, error_size) != 0) {
+        return -1;
+    }
+    return 0;
+}
+
 int filter_init(SymbolFilter *filter, const char *lang_data_dir) {
     char path[LINE_BUFFER_LARGE];
     char resolved_path[PATH_MAX_LENGTH];
+    ConfigLineReader reader;
+
+    hash_contents("", 0, filter->fingerprint, sizeof(filter->fingerprint));
+
+    /* Lists in sourceminder.toml take the place of their .txt files */
+    UnifiedConfig unified;
+    int loaded = unified_config_load(&unified, lang_data_dir);
+    if (loaded < 0) {
+        fprintf(stderr, "Warning: %s line %d: %s (using the .txt files)\n",
+                unified.path, unified.error_line, unified.error);
+    }
+    const UnifiedConfig *lists = loaded == 1 ? &unified : NULL;
 
     /* Load file extensions (language-specific) */
-    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, FILE_EXTENSIONS_FILENAME);
-    if (resolve_data_file(path, resolved_path, sizeof(resolved_path)) == 0) {
-        if (load_file_extensions(&filter->file_extensions, resolved_path) != 0) {
-            filter->file_extensions.count = 0;
-        }
-    } else {
-        filter->file_extensions.count = 0;
+    filter->file_extensions.count = 0;
+    if (open_list(&reader, lists, CONFIG_LIST_FILE_EXTENSIONS, lang_data_dir, FILE_EXTENSIONS_FILENAME) == 0) {
+        load_file_extensions(&filter->file_extensions, &reader);
+        config_reader_close(&reader);
     }
 
     /* Load ignore directories (language-specific) */
-    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, IGNORE_FILES_FILENAME);
-    if (resolve_data_file(path, resolved_path, sizeof(resolved_path)) == 0) {
-        if (load_word_list(&filter->ignore_dirs, resolved_path) != 0) {
-            filter->ignore_dirs.count = 0;
-        }
-    } else {
-        filter->ignore_dirs.count = 0;
+    filter->ignore_dirs.count = 0;
+    if (open_list(&reader, lists, CONFIG_LIST_IGNORE_FILES, lang_data_dir, IGNORE_FILES_FILENAME) == 0) {
+        load_word_list(&filter->ignore_dirs, &reader);
+        config_reader_close(&reader);
     }
 
-    /* Load stopwords (shared) */
-    snprintf(path, sizeof(path), "%s/%s", SHARED_CONFIG_DIR, STOPWORDS_FILENAME);
+    /* Load stopwords (shared, with an optional language layer) */
+    load_stopwords(&filter->stopwords, lang_data_dir, lists);
+
+    /* Load language keywords (language-specific) */
+    filter->ts_keywords.count = 0;
+    if (open_list(&reader, lists, CONFIG_LIST_KEYWORDS, lang_data_dir, KEYWORDS_FILENAME) == 0) {
+        load_word_list(&filter->ts_keywords, &reader);
+        config_reader_close(&reader);
+    }
+
+    /* Load regex patterns (shared, unless the language has its own) */
+    filter->regex_patterns.count = 0;
+    if (open_list(&reader, lists, CONFIG_LIST_REGEX_PATTERNS, SHARED_CONFIG_DIR, REGEX_PATTERNS_FILENAME) == 0) {
+        load_regex_patterns(&filter->regex_patterns, &reader, filter->fingerprint);
+        config_reader_close(&reader);
+    }
+    unified_config_free(&unified);
+
+    /* Load symbol length limits (language-specific, optional) */
+    filter->min_symbol_length = MIN_SYMBOL_LENGTH;
+    filter->max_symbol_length = SYMBOL_MAX_LENGTH - 1;
+    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, SYMBOL_LIMITS_FILENAME);
     if (resolve_data_file(path, resolved_path, sizeof(resolved_path)) == 0) {
-        if (load_word_list(&filter->stopwords, resolved_path) != 0) {
-            filter->stopwords.count = 0;
-        }
-    } else {
-        filter->stopwords.count = 0;
+        load_symbol_limits(filter, resolved_path);
     }
 
-    /* Load language keywords (language-specific) */
-    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, KEYWORDS_FILENAME);
+    /* Load identifier split rules (language-specific, optional) */
+    filter->split_rules = SPLIT_DEFAULT;
+    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, IDENTIFIER_SPLIT_FILENAME);
     if (resolve_data_file(path, resolved_path, sizeof(resolved_path)) == 0) {
-        if (load_word_list(&filter->ts_keywords, resolved_path) != 0) {
-            filter->ts_keywords.count = 0;
-        }
-    } else {
-        filter->ts_keywords.count = 0;
+        load_split_rules(filter, resolved_path);
     }
 
-    /* Load regex patterns (shared) */
-    snprintf(path, sizeof(path), "%s/%s", SHARED_CONFIG_DIR, REGEX_PATTERNS_FILENAME);
+    /* Load custom extractors (language-specific, optional) */
+    filter->extractors.count = 0;
+    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, EXTRACTORS_FILENAME);
     if (resolve_data_file(path, resolved_path, sizeof(resolved_path)) == 0) {
-        if (load_regex_patterns(&filter->regex_patterns, resolved_path) != 0) {
-            filter->regex_patterns.count = 0;
+        custom_extractors_load(&filter->extractors, resolved_path);
+        char extractors_hash[FILE_HASH_LENGTH];
+        if (hash_file_contents(resolved_path, extractors_hash, sizeof(extractors_hash)) == 0) {
+            fingerprint_add(filter->fingerprint, extractors_hash);
         }
-    } else {
-        filter->regex_patterns.count = 0;
     }
 
+    fingerprint_add_words(filter->fingerprint, &filter->stopwords);
+    fingerprint_add_words(filter->fingerprint, &filter->ts_keywords);
+    char settings[64];
+    snprintf(settings, sizeof(settings), "min_length=%d max_length=%d split=%d",
+             filter->min_symbol_length, filter->max_symbol_length, filter->split_rules);
+    fingerprint_add(filter->fingerprint, settings);
+
     return 0;
 }
 
+void filter_mix_config_hash(const SymbolFilter *filter, char *hash) {
+    char mixed[FILE_HASH_LENGTH];
+    snprintf(mixed, sizeof(mixed), "%s", hash);
+    fingerprint_add(mixed, filter->fingerprint);
+    for (int i = 0; i < filter->file_extensions.count; i++) {
+        fingerprint_add(mixed, filter->file_extensions.extensions[i]);
+    }
+    memcpy(hash, mixed, sizeof(mixed));
+}
+
 int filter_should_index(SymbolFilter *filter, const char *symbol) {
     if (!symbol || !symbol[0]) {
         return 0;
@@ -216,8 +504,8 @@ int filter_should_index(SymbolFilter *filter, const char *symbol) {
     }
     lower[i] = '\0';
 
-    /* Skip very short symbols (< 2 chars) */
-    if (strnlength(lower, sizeof(lower)) < MIN_SYMBOL_LENGTH) {
+    /* Skip very short symbols (< 2 chars unless symbol_limits.txt says otherwise) */
+    if (strnlength(lower, sizeof(lower)) < (size_t)filter->min_symbol_length) {
         return 0;
     }
 
@@ -251,6 +539,10 @@ int filter_should_index(SymbolFilter *filter, const char *symbol) {
     return 1;
 }
 
+int filter_is_stopword(SymbolFilter *filter, const char *word) {
+    return is_in_set(&filter->stopwords, word);
+}
+
 void filter_clean_string_symbol(const char *src, char *dst, size_t dst_size) {
     /* For strings/comments: preserve most special characters except parens/braces/quotes */
     size_t j = 0;
@@ -280,5 +572,6 @@ void filter_free_regex(SymbolFilter *filter) {
     for (int i = 0; i < filter->regex_patterns.count; i++) {
         regfree(&filter->regex_patterns.patterns[i]);
     }
+    custom_extractors_free(&filter->extractors);
 }
 /* test */
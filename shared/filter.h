@@ -20,6 +20,7 @@
 
 #include <regex.h>
 #include "constants.h"
+#include "custom_extractors.h"
 
 typedef struct {
     char words[MAX_FILTER_WORDS][WORD_MAX_LENGTH];
@@ -42,14 +43,59 @@ typedef struct {
     WordSet ignore_dirs;
     RegexSet regex_patterns;
     FileExtensions file_extensions;
+    int min_symbol_length;      /* Shorter symbols are not indexed */
+    int max_symbol_length;      /* Longer symbols are truncated (< SYMBOL_MAX_LENGTH) */
+    int split_rules;            /* SPLIT_* rules for identifier tokens (identifier_split.txt) */
+    CustomExtractorSet extractors;  /* extractors.txt (language-specific, optional) */
+    char fingerprint[FILE_HASH_LENGTH];  /* Hash of the settings above but extensions */
 } SymbolFilter;
 
-/* Initialize filter by loading word lists from files */
+/* Load shared/config/stopwords.txt, then layer <lang_data_dir>/stopwords.txt
+ * (optional, or the stopwords list of sourceminder.toml) over it: its words
+ * are added, "!word" removes a shared word and "!*" removes all of them.
+ * Returns -1 if the shared list could not be read. */
+int filter_load_stopwords(WordSet *stopwords, const char *lang_data_dir);
+
+/* Load the configured file extensions of <lang_data_dir> (file-extensions.txt,
+ * or the file_extensions list of sourceminder.toml) into exts
+ * Returns -1 if there is none to read. */
+int filter_load_extensions(FileExtensions *exts, const char *lang_data_dir);
+
+/* Change the extensions of one run (--extensions, --extensions-add): replace,
+ * if non-NULL, is used instead of the configured ones, then add's are
+ * appended. Both are comma- or space-separated lists; the leading dot is
+ * optional ("py,.pyw") and extensions already present are not repeated.
+ * Returns: 0 on success, -1 if an entry is invalid or there are more than
+ *          MAX_FILE_EXTENSIONS (*error describes why; exts is then partly
+ *          changed) */
+int filter_override_extensions(FileExtensions *exts, const char *replace, const char *add,
+                               char *error, size_t error_size);
+
+/* Initialize filter by loading word lists from files
+ * Lists set in <data_dir>/sourceminder.toml are used instead of their .txt
+ * files; its regex_patterns replace shared/config/regex-patterns.txt. */
 int filter_init(SymbolFilter *filter, const char *data_dir);
 
+/* Parse one line of symbol_limits.txt ("min_length = 1", "max_length = 256")
+ * Updates *min_length or *max_length; ranges are checked, but not min <= max.
+ * Returns: 1 if a limit was set, 0 for blank and comment lines,
+ *          -1 if the line is invalid (*error describes why) */
+int filter_parse_symbol_limit(const char *line, int *min_length, int *max_length, const char **error);
+
+/* Mix the effective configuration into the content hash of a file (hash,
+ * of FILE_HASH_LENGTH bytes, is replaced): stopwords, keywords, regex
+ * patterns, symbol limits, identifier split rules, extractors and the file
+ * extensions of the run, from their .txt files or sourceminder.toml. A file
+ * stored under another configuration then no longer matches, and is indexed
+ * again although its contents did not change. */
+void filter_mix_config_hash(const SymbolFilter *filter, char *hash);
+
 /* Check if symbol should be indexed (returns 1 if yes, 0 if no) */
 int filter_should_index(SymbolFilter *filter, const char *symbol);
 
+/* Check if a lowercase word is a stopword (returns 1 if yes, 0 if no) */
+int filter_is_stopword(SymbolFilter *filter, const char *word);
+
 /* Clean symbol for strings/comments: preserve path characters (dots, slashes, hyphens, colons) */
 void filter_clean_string_symbol(const char *src, char *dst, size_t dst_size);
 
@@ -59,7 +105,7 @@ const FileExtensions* filter_get_extensions(const SymbolFilter *filter);
 /* Get pointer to ignore directories (for use with file_walker) */
 const WordSet* filter_get_ignore_dirs(const SymbolFilter *filter);
 
-/* Free internal filter resources (compiled regex patterns) */
+/* Free internal filter resources (compiled regex patterns and extractors) */
 void filter_free_regex(SymbolFilter *filter);
 
 #endif
@@ -0,0 +1,201 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "git_changes.h"
+#include "string_utils.h"
+#include "constants.h"
+#include <ctype.h>
+#include <limits.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+/* The ref is pasted into a shell command, so only characters git allows in
+ * ref names and revision suffixes (HEAD~2, main^, @{u}) are accepted */
+static int is_plain_ref(const char *ref) {
+    if (ref[0] == '\0' || ref[0] == '-') return 0;
+    for (const char *p = ref; *p; p++) {
+        if (!isalnum((unsigned char)*p) && !strchr("._/~^@{}-", *p)) return 0;
+    }
+    return 1;
+}
+
+/* Run a git command and collect its output
+ * Returns: the command's exit status (-1 if it could not be run); *out is
+ *          NUL-terminated, to be freed by the caller */
+static int run_git(const char *command, char **out, size_t *out_len) {
+    *out = NULL;
+    *out_len = 0;
+    FILE *fp = popen(command, "r");
+    if (!fp) return -1;
+
+    size_t capacity = 4096;
+    char *buffer = malloc(capacity);
+    size_t len = 0;
+    while (buffer) {
+        if (len + 1 >= capacity) {
+            char *grown = realloc(buffer, capacity * 2);
+            if (!grown) {
+                free(buffer);
+                buffer = NULL;
+                break;
+            }
+            buffer = grown;
+            capacity *= 2;
+        }
+        size_t n = fread(buffer + len, 1, capacity - len - 1, fp);
+        if (n == 0) break;
+        len += n;
+    }
+    int status = pclose(fp);
+    if (!buffer) return -1;
+    buffer[len] = '\0';
+    *out = buffer;
+    *out_len = len;
+    return status;
+}
+
+static const char *skip_dot_slash(const char *path) {
+    while (path[0] == '.' && path[1] == '/') {
+        path += 2;
+        while (*path == '/') path++;
+    }
+    return path;
+}
+
+static int compare_path_ptrs(const void *a, const void *b) {
+    return strcmp(*(const char * const *)a, *(const char * const *)b);
+}
+
+int git_changes_load(GitChanges *changes, const char *ref) {
+    memset(changes, 0, sizeof(*changes));
+    char command[LINE_BUFFER_LARGE];
+    char *out = NULL;
+    size_t len = 0;
+
+    if (run_git("git rev-parse --is-inside-work-tree 2>/dev/null", &out, &len) != 0 ||
+        strncmp(out, "true", 4) != 0) {
+        char cwd[PATH_MAX_LENGTH];
+        if (getcwd(cwd, sizeof(cwd)) == NULL) {
+            snprintf(cwd, sizeof(cwd), ".");
+        }
+        fprintf(stderr, "Error: --since needs a git repository, and %s is not inside one\n", cwd);
+        free(out);
+        return -1;
+    }
+    free(out);
+
+    /* Both sides are compared relative to the top of the work tree */
+    if (run_git("git rev-parse --show-toplevel 2>/dev/null", &out, &len) != 0) {
+        fprintf(stderr, "Error: --since: cannot find the top of the git work tree\n");
+        free(out);
+        return -1;
+    }
+    out[strcspn(out, "\n")] = '\0';
+    char resolved[PATH_MAX];
+    changes->root = try_strdup_ctx(realpath(out, resolved) ? resolved : out,
+                                   "Failed to allocate git work tree path");
+    free(out);
+    if (!changes->root) {
+        return -1;
+    }
+
+    if (!is_plain_ref(ref)) {
+        fprintf(stderr, "Error: --since: invalid git ref '%s'\n", ref);
+        git_changes_free(changes);
+        return -1;
+    }
+    snprintf(command, sizeof(command), "git rev-parse --verify --quiet %s^{commit} 2>/dev/null", ref);
+    if (run_git(command, &out, &len) != 0) {
+        fprintf(stderr, "Error: --since: unknown git ref '%s'\n", ref);
+        free(out);
+        git_changes_free(changes);
+        return -1;
+    }
+    free(out);
+
+    /* -z: NUL-separated "status, path" pairs, paths unquoted; the ":/"
+     * pathspec covers the whole work tree, and without diff.relative the
+     * paths are relative to its top wherever git runs */
+    snprintf(command, sizeof(command),
+             "git -c diff.relative=false diff --name-status --no-renames -z %s -- :/", ref);
+    if (run_git(command, &out, &len) != 0) {
+        fprintf(stderr, "Error: --since: git diff against '%s' failed\n", ref);
+        free(out);
+        git_changes_free(changes);
+        return -1;
+    }
+
+    int capacity = 0;
+    for (size_t i = 0; i < len; i++) {
+        if (out[i] == '\0') capacity++;
+    }
+    changes->paths = malloc((size_t)(capacity / 2 + 1) * sizeof(char *));
+    if (!changes->paths) {
+        fprintf(stderr, "Error: out of memory reading the files changed since '%s'\n", ref);
+        free(out);
+        git_changes_free(changes);
+        return -1;
+    }
+
+    size_t pos = 0;
+    while (pos < len) {
+        const char *status = out + pos;
+        pos += strlen(status) + 1;
+        if (pos >= len) break;
+        const char *path = out + pos;
+        pos += strlen(path) + 1;
+
+        char *copy = try_strdup_ctx(skip_dot_slash(path), "Failed to allocate changed file path");
+        if (!copy) {
+            free(out);
+            git_changes_free(changes);
+            return -1;
+        }
+        changes->paths[changes->count++] = copy;
+        if (status[0] == 'D') {
+            changes->deleted++;
+        }
+    }
+    free(out);
+
+    qsort(changes->paths, (size_t)changes->count, sizeof(char *), compare_path_ptrs);
+    return 0;
+}
+
+int git_changes_contains(const GitChanges *changes, const char *path) {
+    char resolved[PATH_MAX];
+    if (changes->count == 0 || !changes->root || realpath(path, resolved) == NULL) {
+        return 0;
+    }
+    size_t root_len = strlen(changes->root);
+    if (strncmp(resolved, changes->root, root_len) != 0 || resolved[root_len] != '/') {
+        return 0;  /* Outside the work tree, so never in the diff */
+    }
+    const char *key = resolved + root_len + 1;
+    return bsearch(&key, changes->paths, (size_t)changes->count, sizeof(char *), compare_path_ptrs) != NULL;
+}
+
+void git_changes_free(GitChanges *changes) {
+    for (int i = 0; i < changes->count; i++) {
+        free(changes->paths[i]);
+    }
+    free(changes->paths);
+    free(changes->root);
+    memset(changes, 0, sizeof(*changes));
+}
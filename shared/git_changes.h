@@ -0,0 +1,49 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef GIT_CHANGES_H
+#define GIT_CHANGES_H
+
+/*
+ * Files changed since a git ref (--since=<ref>)
+ *
+ * git is asked for the files that differ between the ref and the working
+ * tree (git diff --name-status), across the whole work tree and relative to
+ * its top. Files found by the walker are resolved to the same form before
+ * they are looked up, so targets outside the current directory (../lib) and
+ * absolute ones match too. Untracked files are not part of the diff.
+ */
+typedef struct {
+    char **paths;   /* Changed files, relative to root, sorted */
+    int count;
+    int deleted;    /* How many of them the diff deletes */
+    char *root;     /* Top of the work tree, symlinks resolved */
+} GitChanges;
+
+/* Load the files changed since ref
+ * Returns: 0 on success, -1 (with a message on stderr) if the current
+ *          directory is not in a git repository, the ref is unknown, git
+ *          cannot be run, or out of memory */
+int git_changes_load(GitChanges *changes, const char *ref);
+
+/* Whether a file found by the walker (e.g. "./src/a.go") is one of them;
+ * files outside the work tree never are */
+int git_changes_contains(const GitChanges *changes, const char *path);
+
+void git_changes_free(GitChanges *changes);
+
+#endif
@@ -0,0 +1,114 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "identifier_tokens.h"
+#include "constants.h"
+#include <ctype.h>
+#include <stdio.h>
+#include <string.h>
+
+int identifier_parse_split_rule(const char *line, int *rules, const char **error) {
+    char word[WORD_MAX_LENGTH];
+    char extra;
+
+    const char *p = line;
+    while (isspace((unsigned char)*p)) p++;
+    if (*p == '\0' || *p == '#') {
+        return 0;
+    }
+    if (sscanf(p, "%63s %c", word, &extra) != 1) {
+        *error = "expected one rule per line";
+        return -1;
+    }
+
+    if (strcmp(word, "camel") == 0) {
+        *rules |= SPLIT_CAMEL;
+    } else if (strcmp(word, "snake") == 0) {
+        *rules |= SPLIT_SNAKE;
+    } else if (strcmp(word, "digits") == 0) {
+        *rules |= SPLIT_DIGITS;
+    } else if (strcmp(word, "none") != 0) {
+        *error = "unknown rule (expected camel, snake, digits or none)";
+        return -1;
+    }
+    return 1;
+}
+
+/* Part of a token: letters, digits, multibyte characters and, unless
+ * underscores split, underscores */
+static int is_token_char(unsigned char c, int rules) {
+    return isalnum(c) || c >= 0x80 || (c == '_' && !(rules & SPLIT_SNAKE));
+}
+
+/* Does a new token start at s[i] (both s[i - 1] and s[i] token chars)? */
+static int starts_token(const unsigned char *s, size_t i, int rules) {
+    unsigned char prev = s[i - 1], c = s[i];
+    if (rules & SPLIT_CAMEL) {
+        /* readCloser, utf8Decode */
+        if (isupper(c) && (islower(prev) || isdigit(prev))) return 1;
+        /* HTTPServer: the last capital of a run starts the next word */
+        if (isupper(c) && isupper(prev) && islower(s[i + 1])) return 1;
+    }
+    if (rules & SPLIT_DIGITS) {
+        if (isdigit(c) != isdigit(prev) && (isalpha(c) || isalpha(prev))) return 1;
+    }
+    return 0;
+}
+
+int identifier_tokens(const char *symbol, int rules, char *out, size_t size) {
+    const unsigned char *s = (const unsigned char *)symbol;
+    size_t pos = 0;
+    int count = 0;
+    int in_token = 0;
+
+    if (size == 0) return 0;
+    out[0] = '\0';
+    for (size_t i = 0; s[i]; i++) {
+        if (!is_token_char(s[i], rules)) {
+            in_token = 0;
+            continue;
+        }
+        if (!in_token || starts_token(s, i, rules)) {
+            /* Room for a separator, the character and the terminator */
+            if (pos + (count > 0 ? 1 : 0) + 1 >= size) break;
+            if (count > 0) out[pos++] = ' ';
+            count++;
+            in_token = 1;
+        } else if (pos + 1 >= size) {
+            break;
+        }
+        out[pos++] = (char)tolower(s[i]);
+    }
+    out[pos] = '\0';
+
+    if (count < 2) {
+        out[0] = '\0';
+    }
+    return count;
+}
+
+void tokenize_result(ParseResult *result, int rules) {
+    for (int i = 0; i < result->count; i++) {
+        IndexEntry *entry = &result->entries[i];
+        entry->tokens[0] = '\0';
+        if (entry->context == CONTEXT_COMMENT || entry->context == CONTEXT_STRING ||
+            entry->context == CONTEXT_FILENAME || entry->context == CONTEXT_IMPORT) {
+            continue;
+        }
+        identifier_tokens(entry->full_symbol, rules, entry->tokens, sizeof(entry->tokens));
+    }
+}
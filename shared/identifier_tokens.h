@@ -0,0 +1,64 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef IDENTIFIER_TOKENS_H
+#define IDENTIFIER_TOKENS_H
+
+#include <stddef.h>
+#include "parse_result.h"
+
+/*
+ * Identifier tokens (the tokens column)
+ *
+ * Symbols are split into lowercase words at index time, so a search for
+ * "closer" can find ReadCloser. The words are stored space-separated next
+ * to the symbol, which is left as it is:
+ *
+ *   ReadCloser  -> "read closer"
+ *   my_struct   -> "my struct"
+ *   HTTPServer  -> "http server"
+ *
+ * Which boundaries split is set per language in
+ * <language>/config/identifier_split.txt, one rule per line.
+ */
+
+/* Split rules (bit flags) */
+#define SPLIT_CAMEL  0x1  /* readCloser, HTTPServer: before a capital starting a word */
+#define SPLIT_SNAKE  0x2  /* my_struct: at underscores */
+#define SPLIT_DIGITS 0x4  /* utf8: between letters and digits */
+
+/* Rules without an identifier_split.txt */
+#define SPLIT_DEFAULT (SPLIT_CAMEL | SPLIT_SNAKE)
+
+/* Parse one line of identifier_split.txt ("camel", "snake", "digits" or
+ * "none") and add its rule to *rules ("none" adds nothing)
+ * Returns: 1 if a rule was read, 0 for blank and comment lines,
+ *          -1 if the line is invalid (*error describes why) */
+int identifier_parse_split_rule(const char *line, int *rules, const char **error);
+
+/* Space-separated lowercase tokens of symbol under rules. Characters other
+ * than letters, digits and (unless SPLIT_SNAKE is off) underscores always
+ * separate tokens; bytes of multibyte characters count as letters.
+ * Returns: the number of tokens; out is "" unless there are two or more,
+ *          since a single token is the symbol itself */
+int identifier_tokens(const char *symbol, int rules, char *out, size_t size);
+
+/* Fill in the tokens of every code symbol in result (comments, strings,
+ * filenames and imports are left alone) */
+void tokenize_result(ParseResult *result, int rules);
+
+#endif
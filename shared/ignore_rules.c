@@ -0,0 +1,278 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "ignore_rules.h"
+#include "file_opener.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define IGNORE_RULES_INITIAL_CAPACITY 16
+
+/* Match a bracket expression at p ("[...]") against c
+ * Returns: pointer past the closing "]", or NULL if it is unterminated */
+static const char *match_class(const char *p, char c, int *matched) {
+    int negate = 0;
+    *matched = 0;
+    p++;  /* "[" */
+    if (*p == '!' || *p == '^') {
+        negate = 1;
+        p++;
+    }
+    /* A "]" right after the opening bracket is literal */
+    const char *start = p;
+    while (*p && (*p != ']' || p == start)) {
+        char lo = *p;
+        if (lo == '\\' && p[1]) {
+            lo = *++p;
+        }
+        char hi = lo;
+        if (p[1] == '-' && p[2] && p[2] != ']') {
+            hi = p[2];
+            p += 2;
+        }
+        if (c >= lo && c <= hi) {
+            *matched = 1;
+        }
+        p++;
+    }
+    if (*p != ']') {
+        return NULL;
+    }
+    if (negate) {
+        *matched = !*matched;
+    }
+    if (c == '/') {
+        *matched = 0;  /* Never matches a separator */
+    }
+    return p + 1;
+}
+
+/* gitignore glob: "*" and "?" stay within one path component, "**" crosses
+ * components, and "**" followed by "/" also matches no directory at all */
+static int glob_match(const char *p, const char *s) {
+    while (*p) {
+        if (p[0] == '*' && p[1] == '*') {
+            const char *rest = p + 2;
+            while (*rest == '*') rest++;
+            if (*rest == '/') {
+                rest++;
+                if (glob_match(rest, s)) return 1;
+                for (const char *q = s; *q; q++) {
+                    if (*q == '/' && glob_match(rest, q + 1)) return 1;
+                }
+                return 0;
+            }
+            for (const char *q = s; ; q++) {
+                if (glob_match(rest, q)) return 1;
+                if (*q == '\0') return 0;
+            }
+        }
+        if (*p == '*') {
+            p++;
+            for (const char *q = s; ; q++) {
+                if (glob_match(p, q)) return 1;
+                if (*q == '\0' || *q == '/') return 0;
+            }
+        }
+        if (*s == '\0') {
+            return 0;
+        }
+        if (*p == '?') {
+            if (*s == '/') return 0;
+        } else if (*p == '[') {
+            int matched;
+            const char *next = match_class(p, *s, &matched);
+            if (!next || !matched) return 0;
+            p = next;
+            s++;
+            continue;
+        } else {
+            if (*p == '\\' && p[1]) p++;
+            if (*p != *s) return 0;
+        }
+        p++;
+        s++;
+    }
+    return *s == '\0';
+}
+
+/* Syntax problems that would make a pattern silently match nothing */
+static const char *check_pattern(const char *pattern) {
+    for (const char *p = pattern; *p; p++) {
+        if (*p == '\\') {
+            if (p[1] == '\0') return "trailing backslash";
+            p++;
+        } else if (*p == '[') {
+            int matched;
+            const char *next = match_class(p, 'a', &matched);
+            if (!next) return "unterminated character class '['";
+            p = next - 1;
+        }
+    }
+    return NULL;
+}
+
+int ignore_rule_parse(const char *line, IgnoreRule *rule, const char **error) {
+    char buf[PATH_MAX_LENGTH];
+    size_t len = strcspn(line, "\r\n");
+    if (len >= sizeof(buf)) {
+        *error = "pattern too long";
+        return -1;
+    }
+    memcpy(buf, line, len);
+    buf[len] = '\0';
+
+    /* Trailing spaces are dropped unless escaped with a backslash */
+    while (len > 0 && buf[len - 1] == ' ' && !(len >= 2 && buf[len - 2] == '\\')) {
+        buf[--len] = '\0';
+    }
+    if (len == 0 || buf[0] == '#') {
+        return 0;
+    }
+
+    memset(rule, 0, sizeof(*rule));
+    const char *p = buf;
+    if (*p == '!') {
+        rule->negate = 1;
+        p++;
+        len--;
+    }
+    if (len > 0 && p[len - 1] == '/') {
+        rule->dir_only = 1;
+        len--;
+    }
+    if (len > 0 && *p == '/') {
+        rule->anchored = 1;
+        p++;
+        len--;
+    }
+    if (len == 0) {
+        *error = "empty pattern";
+        return -1;
+    }
+
+    memcpy(rule->pattern, p, len);
+    rule->pattern[len] = '\0';
+    if (strchr(rule->pattern, '/')) {
+        rule->anchored = 1;
+    }
+
+    *error = check_pattern(rule->pattern);
+    return *error ? -1 : 1;
+}
+
+void ignore_rules_init(IgnoreRules *rules, const char *dir) {
+    snprintf(rules->base, sizeof(rules->base), "%s", dir);
+    size_t len = strlen(rules->base);
+    while (len > 1 && rules->base[len - 1] == '/') {
+        rules->base[--len] = '\0';
+    }
+    rules->rules = NULL;
+    rules->count = 0;
+    rules->capacity = 0;
+}
+
+int ignore_rules_load(IgnoreRules *rules, const char *dir) {
+    ignore_rules_init(rules, dir);
+
+    char path[PATH_MAX_LENGTH];
+    int written = snprintf(path, sizeof(path), "%s/%s", rules->base, IGNORE_RULES_FILENAME);
+    if (written < 0 || (size_t)written >= sizeof(path)) {
+        return 0;
+    }
+    FILE *fp = safe_fopen(path, "r", 1);
+    if (!fp) {
+        return 0;
+    }
+
+    char line[LINE_BUFFER_LARGE];
+    while (fgets(line, sizeof(line), fp)) {
+        IgnoreRule rule;
+        const char *error;
+        if (ignore_rule_parse(line, &rule, &error) != 1) {
+            continue;
+        }
+
+        if (rules->count == rules->capacity) {
+            int new_capacity = rules->capacity ? rules->capacity * 2 : IGNORE_RULES_INITIAL_CAPACITY;
+            IgnoreRule *grown = realloc(rules->rules, (size_t)new_capacity * sizeof(IgnoreRule));
+            if (!grown) {
+                fclose(fp);
+                return -1;
+            }
+            rules->rules = grown;
+            rules->capacity = new_capacity;
+        }
+        rules->rules[rules->count] = rule;
+        rules->count++;
+    }
+
+    fclose(fp);
+    return rules->count;
+}
+
+/* Path relative to the rule set's base directory, NULL if not under it */
+static const char *relative_path(const IgnoreRules *rules, const char *path) {
+    size_t base_len = strlen(rules->base);
+    if (strncmp(path, rules->base, base_len) != 0) {
+        return NULL;
+    }
+    const char *relative = path + base_len;
+    if (strcmp(rules->base, "/") != 0) {
+        if (*relative != '/') return NULL;
+        relative++;
+    }
+    return relative;
+}
+
+int ignore_rules_covers(const IgnoreRules *rules, const char *path) {
+    return relative_path(rules, path) != NULL;
+}
+
+IgnoreVerdict ignore_rules_match(const IgnoreRules *rules, const char *path, int is_dir) {
+    if (!rules || rules->count == 0) {
+        return IGNORE_NO_MATCH;
+    }
+
+    /* Path relative to the directory holding the ignore file */
+    const char *relative = relative_path(rules, path);
+    if (!relative) {
+        return IGNORE_NO_MATCH;
+    }
+    const char *name = strrchr(relative, '/');
+    name = name ? name + 1 : relative;
+
+    IgnoreVerdict verdict = IGNORE_NO_MATCH;
+    for (int i = 0; i < rules->count; i++) {
+        const IgnoreRule *rule = &rules->rules[i];
+        if (rule->dir_only && !is_dir) {
+            continue;
+        }
+        if (glob_match(rule->pattern, rule->anchored ? relative : name)) {
+            verdict = rule->negate ? IGNORE_INCLUDE : IGNORE_EXCLUDE;
+        }
+    }
+    return verdict;
+}
+
+void ignore_rules_free(IgnoreRules *rules) {
+    free(rules->rules);
+    rules->rules = NULL;
+    rules->count = 0;
+    rules->capacity = 0;
+}
@@ -0,0 +1,98 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef IGNORE_RULES_H
+#define IGNORE_RULES_H
+
+#include "constants.h"
+
+/*
+ * .sourceminderignore support
+ *
+ * A .sourceminderignore file at the root of an indexed directory uses
+ * gitignore syntax:
+ *
+ *   # comment            blank lines and comments are skipped
+ *   *_test.go            no slash: matches a name at any depth
+ *   /generated.go        leading or inner slash: relative to the root
+ *   build/               trailing slash: directories only
+ *   !keep_test.go        negation: re-include a previously ignored path
+ *   \#literal            backslash escapes a special character
+ *
+ * A double star (**) matches across directories; as a whole path
+ * component it matches zero or more of them. The last matching pattern wins. A file inside an ignored directory cannot
+ * be re-included, because the directory is never entered.
+ *
+ * The rules are layered on top of <language>/config/ignore_files.txt: when
+ * a pattern matches, its verdict replaces the ignore_files.txt one, so
+ * "!vendor/" re-includes a directory the language config ignores.
+ */
+
+typedef struct {
+    char pattern[PATH_MAX_LENGTH];  /* Glob without "!", leading and trailing "/" */
+    int negate;                     /* "!pattern" re-includes */
+    int dir_only;                   /* "pattern/" matches directories only */
+    int anchored;                   /* Contains a slash: match the whole relative path */
+} IgnoreRule;
+
+typedef struct {
+    char base[PATH_MAX_LENGTH];     /* Directory holding the file, without trailing "/" */
+    IgnoreRule *rules;
+    int count;
+    int capacity;
+} IgnoreRules;
+
+typedef enum {
+    IGNORE_NO_MATCH = -1,           /* No pattern matched */
+    IGNORE_INCLUDE = 0,             /* Last match was a negation */
+    IGNORE_EXCLUDE = 1              /* Last match ignores the path */
+} IgnoreVerdict;
+
+/* Parse one line of a .sourceminderignore file
+ *
+ * Returns: 1 if rule was filled in, 0 for blank lines and comments,
+ *          -1 for an invalid pattern (*error gets a static description)
+ */
+int ignore_rule_parse(const char *line, IgnoreRule *rule, const char **error);
+
+/* Initialize an empty rule set for the index root dir */
+void ignore_rules_init(IgnoreRules *rules, const char *dir);
+
+/* Load dir/.sourceminderignore into rules (initializes rules first)
+ *
+ * A missing file is not an error. Invalid patterns are skipped; preflight
+ * validation reports them.
+ *
+ * Returns: number of patterns loaded, -1 on allocation failure
+ */
+int ignore_rules_load(IgnoreRules *rules, const char *dir);
+
+/* Check if path is under the rule set's base directory
+ * Returns: 1 if it is, 0 if not */
+int ignore_rules_covers(const IgnoreRules *rules, const char *path);
+
+/* Match a path found under the rule set's base directory
+ *
+ * path must start with the base directory as passed to ignore_rules_load
+ * (as file walker paths do); other paths never match.
+ */
+IgnoreVerdict ignore_rules_match(const IgnoreRules *rules, const char *path, int is_dir);
+
+/* Free rules (safe on an initialized, empty set) */
+void ignore_rules_free(IgnoreRules *rules);
+
+#endif /* IGNORE_RULES_H */
@@ -0,0 +1,1276 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "implements.h"
+#include "constants.h"
+#include "ndjson.h"
+#include "signature.h"
+#include <ctype.h>
+#include <sqlite3.h>
+#include <stdlib.h>
+#include <string.h>
+
+/* As in search.c: paths without the leading "./" they may be stored with */
+#define DISPLAY_PATH(directory, filename) \
+    "(CASE WHEN substr(" directory ", 1, 2) = './' THEN substr(" directory ", 3) " \
+    "ELSE " directory " END || " filename ")"
+
+#if ENABLED(GO)
+
+/* Embedded types nest rarely more than a few levels; the limit only
+ * guards against pathological input */
+#define MAX_EMBED_DEPTH 32
+
+/* Declared method, or method spec of an interface */
+typedef struct {
+    char *name;
+    char *signature;        /* Qualified types: "([]byte) (int, error)" */
+    const char *directory;  /* Package it is declared in (the owner's) */
+    int pointer;            /* Pointer receiver */
+} Method;
+
+/* Member of a method set */
+typedef struct {
+    const Method *method;
+    int depth;              /* 0 = declared, else embedding levels */
+    int pointer;            /* Only in the method set of *T */
+} SetMethod;
+
+typedef struct {
+    char *package;          /* As recorded: qualifier, or the embedding package */
+    char *name;
+    int pointer;
+    int target;             /* Index of the embedded type, -1 if not indexed */
+} Embed;
+
+typedef struct {
+    char *name;
+    char *package;
+    char *directory;
+    char *filename;
+    int line;
+    int is_interface;
+    int generic;            /* Has type parameters, or reaches a type that has */
+    int constraint;         /* Interface embedding comparable */
+    Method *methods;
+    int method_count;
+    int method_capacity;
+    Embed *embeds;
+    int embed_count;
+    int embed_capacity;
+    SetMethod *set;         /* Method set by name, built by build_method_set */
+    int set_count;
+    char *unresolved;       /* Embeds not in the index, ", "-separated (NULL = none) */
+    int seen;               /* Last interface this type was compared with */
+    int dirty;              /* Its edges are recomputed (all types, on a full pass) */
+} GoType;
+
+typedef struct {
+    GoType *types;
+    int count;
+    int capacity;
+    int *by_name;           /* Type indexes ordered by package, name, directory */
+} TypeTable;
+
+typedef struct {
+    SetMethod *items;
+    int count;
+    int capacity;
+} MethodList;
+
+/* Method name of a type's method set, for finding candidate types */
+typedef struct {
+    const char *name;
+    int type;
+} NameRef;
+
+/* Predeclared identifiers and keywords that are never package-qualified */
+static const char *const UNQUALIFIED[] = {
+    "bool", "byte", "chan", "complex64", "complex128", "error", "float32", "float64",
+    "func", "int", "int8", "int16", "int32", "int64", "interface", "map", "rune",
+    "string", "struct", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+    "comparable", NULL
+};
+
+/* The predeclared error interface, for interfaces embedding it */
+static char error_name[] = "Error";
+static char error_signature[] = "() (string)";
+static const Method ERROR_METHOD = { error_name, error_signature, "", 0 };
+
+static int grow_array(void **items, int *capacity, size_t item_size, int needed) {
+    if (needed <= *capacity) {
+        return 0;
+    }
+    int new_capacity = *capacity ? *capacity * 2 : 8;
+    while (new_capacity < needed) {
+        new_capacity *= 2;
+    }
+    void *grown = realloc(*items, (size_t)new_capacity * item_size);
+    if (!grown) {
+        fprintf(stderr, "Error: Failed to allocate memory for interface satisfaction\n");
+        return -1;
+    }
+    *items = grown;
+    *capacity = new_capacity;
+    return 0;
+}
+
+static char *copy_string(const char *str) {
+    char *copy = strdup(str ? str : "");
+    if (!copy) {
+        fprintf(stderr, "Error: Failed to allocate memory for interface satisfaction\n");
+    }
+    return copy;
+}
+
+static const char *column_text(sqlite3_stmt *stmt, int column) {
+    const char *text = (const char *)sqlite3_column_text(stmt, column);
+    return text ? text : "";
+}
+
+static int is_identifier_char(char c) {
+    return isalnum((unsigned char)c) || c == '_';
+}
+
+static int is_exported_name(const char *name) {
+    return isupper((unsigned char)name[0]);
+}
+
+/* Append the type expression to out with each type name the package
+ * declares qualified ("[]Item" in package store gives "[]store.Item"), so
+ * types written in different packages compare equal */
+static void qualify_type(const char *type, const char *package, char *out, size_t size) {
+    size_t used = strlen(out);
+    const char *p = type;
+    while (*p && used + 1 < size) {
+        if (*p == '"' || *p == '`') {
+            /* Struct tags are copied as they are */
+            char quote = *p;
+            out[used++] = *p++;
+            while (*p && *p != quote && used + 1 < size) {
+                if (quote == '"' && *p == '\\' && p[1]) {
+                    out[used++] = *p++;
+                }
+                out[used++] = *p++;
+            }
+            if (*p == quote && used + 1 < size) {
+                out[used++] = *p++;
+            }
+            continue;
+        }
+        if (!isalpha((unsigned char)*p) && *p != '_') {
+            out[used++] = *p++;
+            continue;
+        }
+
+        const char *start = p;
+        while (is_identifier_char(*p)) {
+            p++;
+        }
+        int length = (int)(p - start);
+        /* "pkg.Name": the qualifier, and the name after it, stay as they are */
+        int selected = start - type >= 2 && start[-1] == '.' && is_identifier_char(start[-2]);
+        int qualifier = *p == '.';
+        int predeclared = 0;
+        for (int i = 0; UNQUALIFIED[i]; i++) {
+            if ((int)strlen(UNQUALIFIED[i]) == length && strncmp(start, UNQUALIFIED[i], (size_t)length) == 0) {
+                predeclared = 1;
+                break;
+            }
+        }
+
+        int written;
+        if (length == 3 && strncmp(start, "any", 3) == 0 && !selected && !qualifier) {
+            written = snprintf(out + used, size - used, "interface{}");
+        } else if (selected || qualifier || predeclared || !package[0]) {
+            written = snprintf(out + used, size - used, "%.*s", length, start);
+        } else {
+            written = snprintf(out + used, size - used, "%s.%.*s", package, length, start);
+        }
+        if (written < 0 || (size_t)written >= size - used) {
+            used = size - 1;
+            break;
+        }
+        used += (size_t)written;
+    }
+    out[used] = '\0';
+}
+
+/* Append the types of a params or returns list, without their names */
+static void append_signature_types(const char *list, const char *package, char *out, size_t size) {
+    SignatureParam params[MAX_SIGNATURE_PARAMS];
+    int count = parse_signature_list(list, params, MAX_SIGNATURE_PARAMS);
+    for (int i = 0; i < count; i++) {
+        size_t used = strlen(out);
+        snprintf(out + used, size - used, "%s%s", i > 0 ? ", " : "", params[i].variadic ? "..." : "");
+        qualify_type(params[i].type, package, out, size);
+    }
+}
+
+/* Parameter and result types of a method, qualified with its package */
+static char *method_signature(const char *params, const char *returns, const char *package) {
+    char signature[SIGNATURE_MAX_LENGTH * 2] = "(";
+    append_signature_types(params, package, signature, sizeof(signature));
+    size_t used = strlen(signature);
+    snprintf(signature + used, sizeof(signature) - used, ") (");
+    append_signature_types(returns, package, signature, sizeof(signature));
+    used = strlen(signature);
+    snprintf(signature + used, sizeof(signature) - used, ")");
+    return copy_string(signature);
+}
+
+static int compare_type_keys(const char *directory, const char *package, const char *name, const GoType *type) {
+    int cmp = strcmp(directory, type->directory);
+    if (cmp == 0) cmp = strcmp(package, type->package);
+    if (cmp == 0) cmp = strcmp(name, type->name);
+    return cmp;
+}
+
+static int compare_types(const void *a, const void *b) {
+    const GoType *left = a;
+    return compare_type_keys(left->directory, left->package, left->name, b);
+}
+
+/* Type declared as package.name in directory, or -1 */
+static int find_type(const TypeTable *table, const char *directory, const char *package, const char *name) {
+    int low = 0;
+    int high = table->count - 1;
+    while (low <= high) {
+        int mid = low + (high - low) / 2;
+        int cmp = compare_type_keys(directory, package, name, &table->types[mid]);
+        if (cmp == 0) return mid;
+        if (cmp < 0) {
+            high = mid - 1;
+        } else {
+            low = mid + 1;
+        }
+    }
+    return -1;
+}
+
+static const TypeTable *sort_table;  /* For compare_by_name (qsort has no context) */
+
+static int compare_by_name(const void *a, const void *b) {
+    const GoType *left = &sort_table->types[*(const int *)a];
+    const GoType *right = &sort_table->types[*(const int *)b];
+    int cmp = strcmp(left->package, right->package);
+    if (cmp == 0) cmp = strcmp(left->name, right->name);
+    if (cmp == 0) cmp = strcmp(left->directory, right->directory);
+    return cmp;
+}
+
+/* Type an embed of a type declared in directory refers to: the one of the
+ * same package, else the only package of that name in the index; -1 when
+ * it is not indexed or several packages share the name */
+static int resolve_embed(const TypeTable *table, const char *directory, const char *own_package,
+                         const Embed *embed) {
+    if (strcmp(embed->package, own_package) == 0) {
+        return find_type(table, directory, embed->package, embed->name);
+    }
+
+    int low = 0;
+    int high = table->count;
+    while (low < high) {
+        int mid = low + (high - low) / 2;
+        const GoType *type = &table->types[table->by_name[mid]];
+        int cmp = strcmp(type->package, embed->package);
+        if (cmp == 0) cmp = strcmp(type->name, embed->name);
+        if (cmp < 0) {
+            low = mid + 1;
+        } else {
+            high = mid;
+        }
+    }
+    if (low >= table->count) return -1;
+    const GoType *found = &table->types[table->by_name[low]];
+    if (strcmp(found->package, embed->package) != 0 || strcmp(found->name, embed->name) != 0) {
+        return -1;
+    }
+    if (low + 1 < table->count) {
+        const GoType *next = &table->types[table->by_name[low + 1]];
+        if (strcmp(next->package, embed->package) == 0 && strcmp(next->name, embed->name) == 0) {
+            return -1;
+        }
+    }
+    return table->by_name[low];
+}
+
+static void free_table(TypeTable *table) {
+    for (int i = 0; i < table->count; i++) {
+        GoType *type = &table->types[i];
+        for (int j = 0; j < type->method_count; j++) {
+            free(type->methods[j].name);
+            free(type->methods[j].signature);
+        }
+        for (int j = 0; j < type->embed_count; j++) {
+            free(type->embeds[j].package);
+            free(type->embeds[j].name);
+        }
+        free(type->methods);
+        free(type->embeds);
+        free(type->set);
+        free(type->unresolved);
+        free(type->name);
+        free(type->package);
+        free(type->directory);
+        free(type->filename);
+    }
+    free(table->types);
+    free(table->by_name);
+    memset(table, 0, sizeof(*table));
+}
+
+/* What the loaders read: the rows of every type, or on a scoped pass only
+ * those of the types in temp.implements_load (the type rows by name, their
+ * methods and embeds by owner). The scoped queries name their index, as
+ * SQLite would otherwise pick idx_context_definition and read every row of
+ * the context for each type. */
+#define LOADED_TYPES \
+    "FROM temp.implements_load l CROSS JOIN code_index c INDEXED BY idx_symbol " \
+    "  ON c.symbol = lower(l.name) AND c.full_symbol = l.name AND c.directory = l.directory " \
+    "  AND COALESCE(c.namespace, '') = l.package "
+#define LOADED_MEMBERS \
+    "FROM temp.implements_load l CROSS JOIN code_index c INDEXED BY idx_parent_symbol " \
+    "  ON c.parent_symbol = l.name AND c.directory = l.directory " \
+    "  AND COALESCE(c.namespace, '') = l.package "
+
+#define TYPE_ROWS \
+    "SELECT c.full_symbol, COALESCE(c.namespace, ''), c.directory, c.filename, c.line, " \
+    "       COALESCE(c.clue, ''), COALESCE(c.type_params, '') "
+#define TYPE_FILTER \
+    "WHERE c.language = 'go' AND c.context = 'TYPE' AND c.is_definition = 1 " \
+    "  AND COALESCE(c.scope_path, '') = ''"
+
+#define METHOD_ROWS \
+    "SELECT c.full_symbol, c.parent_symbol, COALESCE(c.namespace, ''), c.directory, " \
+    "       COALESCE(c.modifier, ''), COALESCE(c.clue, ''), COALESCE(c.params, ''), " \
+    "       COALESCE(c.returns, '') "
+#define METHOD_FILTER \
+    "WHERE c.language = 'go' AND c.context = 'FUNC' AND c.is_definition = 1 " \
+    "  AND COALESCE(c.parent_symbol, '') != '' " \
+    "  AND (c.clue = 'interface' OR c.modifier IN ('pointer', 'value'))"
+
+#define EMBED_ROWS \
+    "SELECT c.parent_symbol, COALESCE(c.namespace, ''), c.directory, COALESCE(c.type_package, ''), " \
+    "       COALESCE(c.type_name, ''), COALESCE(c.modifier, '') "
+#define EMBED_FILTER \
+    "WHERE c.language = 'go' AND c.context = 'PROP' AND c.clue = 'embedded' " \
+    "  AND COALESCE(c.parent_symbol, '') != ''"
+
+static int load_types(CodeIndexDatabase *db, TypeTable *table, int scoped) {
+    /* Package-level types only: local ones cannot have methods */
+    const char *sql = scoped ? TYPE_ROWS LOADED_TYPES TYPE_FILTER
+                             : TYPE_ROWS "FROM code_index c " TYPE_FILTER;
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        if (grow_array((void **)&table->types, &table->capacity, sizeof(GoType), table->count + 1) != 0) {
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+        GoType *type = &table->types[table->count];
+        memset(type, 0, sizeof(*type));
+        type->seen = -1;
+        type->name = copy_string(column_text(stmt, 0));
+        type->package = copy_string(column_text(stmt, 1));
+        type->directory = copy_string(column_text(stmt, 2));
+        type->filename = copy_string(column_text(stmt, 3));
+        table->count++;
+        if (!type->name || !type->package || !type->directory || !type->filename) {
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+        type->line = sqlite3_column_int(stmt, 4);
+        type->is_interface = strcmp(column_text(stmt, 5), "interface") == 0;
+        type->generic = column_text(stmt, 6)[0] != '\0';
+    }
+    sqlite3_finalize(stmt);
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+
+    /* Declared twice (a conflict the compiler reports): keep one */
+    qsort(table->types, (size_t)table->count, sizeof(GoType), compare_types);
+    int kept = 0;
+    for (int i = 0; i < table->count; i++) {
+        if (kept > 0 && compare_types(&table->types[kept - 1], &table->types[i]) == 0) {
+            free(table->types[i].name);
+            free(table->types[i].package);
+            free(table->types[i].directory);
+            free(table->types[i].filename);
+            continue;
+        }
+        table->types[kept++] = table->types[i];
+    }
+    table->count = kept;
+
+    table->by_name = malloc((size_t)(table->count > 0 ? table->count : 1) * sizeof(int));
+    if (!table->by_name) {
+        fprintf(stderr, "Error: Failed to allocate memory for interface satisfaction\n");
+        return -1;
+    }
+    for (int i = 0; i < table->count; i++) {
+        table->by_name[i] = i;
+    }
+    sort_table = table;
+    qsort(table->by_name, (size_t)table->count, sizeof(int), compare_by_name);
+    sort_table = NULL;
+    return 0;
+}
+
+static int load_methods(CodeIndexDatabase *db, TypeTable *table, int scoped) {
+    const char *sql = scoped ? METHOD_ROWS LOADED_MEMBERS METHOD_FILTER
+                             : METHOD_ROWS "FROM code_index c " METHOD_FILTER;
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        const char *package = column_text(stmt, 2);
+        int owner = find_type(table, column_text(stmt, 3), package, column_text(stmt, 1));
+        if (owner < 0) continue;
+        GoType *type = &table->types[owner];
+        int spec = strcmp(column_text(stmt, 5), "interface") == 0;
+        if (spec != type->is_interface) continue;
+
+        if (grow_array((void **)&type->methods, &type->method_capacity, sizeof(Method),
+                       type->method_count + 1) != 0) {
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+        Method *method = &type->methods[type->method_count];
+        method->name = copy_string(column_text(stmt, 0));
+        method->signature = method_signature(column_text(stmt, 6), column_text(stmt, 7), package);
+        method->directory = type->directory;
+        method->pointer = strcmp(column_text(stmt, 4), "pointer") == 0;
+        type->method_count++;
+        if (!method->name || !method->signature) {
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+    }
+    sqlite3_finalize(stmt);
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    return 0;
+}
+
+static int load_embeds(CodeIndexDatabase *db, TypeTable *table, int scoped) {
+    const char *sql = scoped ? EMBED_ROWS LOADED_MEMBERS EMBED_FILTER
+                             : EMBED_ROWS "FROM code_index c " EMBED_FILTER;
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        int owner = find_type(table, column_text(stmt, 2), column_text(stmt, 1), column_text(stmt, 0));
+        const char *name = column_text(stmt, 4);
+        if (owner < 0 || !name[0]) continue;
+        GoType *type = &table->types[owner];
+
+        if (grow_array((void **)&type->embeds, &type->embed_capacity, sizeof(Embed),
+                       type->embed_count + 1) != 0) {
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+        Embed *embed = &type->embeds[type->embed_count];
+        embed->package = copy_string(column_text(stmt, 3));
+        embed->name = copy_string(name);
+        embed->pointer = strcmp(column_text(stmt, 5), "pointer") == 0;
+        embed->target = -1;
+        type->embed_count++;
+        if (!embed->package || !embed->name) {
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+    }
+    sqlite3_finalize(stmt);
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    return 0;
+}
+
+/* Add name to a ", "-separated list, once */
+static int add_unresolved(char **list, const char *name) {
+    size_t name_length = strlen(name);
+    if (*list) {
+        for (const char *p = *list; (p = strstr(p, name)) != NULL; p += name_length) {
+            int starts = p == *list || (p - *list >= 2 && p[-2] == ',');
+            if (starts && (p[name_length] == '\0' || p[name_length] == ',')) {
+                return 0;
+            }
+        }
+    }
+    size_t used = *list ? strlen(*list) : 0;
+    char *grown = realloc(*list, used + name_length + 3);
+    if (!grown) {
+        fprintf(stderr, "Error: Failed to allocate memory for interface satisfaction\n");
+        return -1;
+    }
+    snprintf(grown + used, name_length + 3, "%s%s", used > 0 ? ", " : "", name);
+    *list = grown;
+    return 0;
+}
+
+static int add_to_list(MethodList *list, const Method *method, int depth, int pointer) {
+    if (grow_array((void **)&list->items, &list->capacity, sizeof(SetMethod), list->count + 1) != 0) {
+        return -1;
+    }
+    list->items[list->count].method = method;
+    list->items[list->count].depth = depth;
+    list->items[list->count].pointer = pointer;
+    list->count++;
+    return 0;
+}
+
+/* Add the methods type t contributes at depth to list: its own, then those
+ * of its embeds one level deeper. through_pointer is set once an embed on
+ * the way is a pointer, which makes pointer-receiver methods reachable
+ * from a value. path holds the types being expanded, to stop cycles. */
+static int collect_methods(TypeTable *table, GoType *root, int t, int depth, int through_pointer,
+                           MethodList *list, int *path) {
+    GoType *type = &table->types[t];
+    path[depth] = t;
+    if (type->generic) {
+        root->generic = 1;
+    }
+
+    for (int i = 0; i < type->method_count; i++) {
+        int pointer = type->is_interface ? 0 : type->methods[i].pointer && !through_pointer;
+        if (add_to_list(list, &type->methods[i], depth, pointer) != 0) {
+            return -1;
+        }
+    }
+
+    for (int i = 0; i < type->embed_count; i++) {
+        Embed *embed = &type->embeds[i];
+        if (embed->target < 0) {
+            if (type->is_interface && strcmp(embed->name, "comparable") == 0) {
+                root->constraint = 1;
+                continue;
+            }
+            if (type->is_interface && strcmp(embed->name, "any") == 0) {
+                continue;
+            }
+            if (type->is_interface && strcmp(embed->name, "error") == 0) {
+                if (add_to_list(list, &ERROR_METHOD, depth + 1, 0) != 0) {
+                    return -1;
+                }
+                continue;
+            }
+            char name[SYMBOL_MAX_LENGTH * 2 + 2];
+            if (strcmp(embed->package, type->package) == 0) {
+                snprintf(name, sizeof(name), "%s", embed->name);
+            } else {
+                snprintf(name, sizeof(name), "%s.%s", embed->package, embed->name);
+            }
+            if (add_unresolved(&root->unresolved, name) != 0) {
+                return -1;
+            }
+            continue;
+        }
+
+        int on_path = 0;
+        for (int d = 0; d <= depth; d++) {
+            if (path[d] == embed->target) on_path = 1;
+        }
+        if (on_path || depth + 1 >= MAX_EMBED_DEPTH) continue;
+
+        /* An interface's methods are promoted into a struct as they are */
+        int pointer = through_pointer || embed->pointer || table->types[embed->target].is_interface;
+        if (collect_methods(table, root, embed->target, depth + 1, pointer, list, path) != 0) {
+            return -1;
+        }
+    }
+    return 0;
+}
+
+static int compare_set_methods(const void *a, const void *b) {
+    const SetMethod *left = a;
+    const SetMethod *right = b;
+    int cmp = strcmp(left->method->name, right->method->name);
+    if (cmp == 0) cmp = left->depth - right->depth;
+    return cmp;
+}
+
+/* Build the method set of a type, by name. For a struct, a method reached
+ * at the same (shallowest) depth through two embeds is ambiguous and
+ * belongs to neither; an interface has each name once. */
+static int build_method_set(TypeTable *table, int t) {
+    GoType *type = &table->types[t];
+    MethodList list = { NULL, 0, 0 };
+    int path[MAX_EMBED_DEPTH];
+    if (collect_methods(table, type, t, 0, 0, &list, path) != 0) {
+        free(list.items);
+        return -1;
+    }
+    if (list.count == 0) {
+        return 0;
+    }
+
+    qsort(list.items, (size_t)list.count, sizeof(SetMethod), compare_set_methods);
+    int kept = 0;
+    for (int i = 0; i < list.count; ) {
+        int j = i + 1;
+        while (j < list.count && strcmp(list.items[j].method->name, list.items[i].method->name) == 0) {
+            j++;
+        }
+        int ambiguous = !type->is_interface && i + 1 < j && list.items[i + 1].depth == list.items[i].depth;
+        if (!ambiguous) {
+            list.items[kept++] = list.items[i];
+        }
+        i = j;
+    }
+    type->set = list.items;
+    type->set_count = kept;
+    return 0;
+}
+
+static const SetMethod *find_set_method(const GoType *type, const char *name) {
+    int low = 0;
+    int high = type->set_count - 1;
+    while (low <= high) {
+        int mid = low + (high - low) / 2;
+        int cmp = strcmp(name, type->set[mid].method->name);
+        if (cmp == 0) return &type->set[mid];
+        if (cmp < 0) {
+            high = mid - 1;
+        } else {
+            low = mid + 1;
+        }
+    }
+    return NULL;
+}
+
+static int compare_name_refs(const void *a, const void *b) {
+    const NameRef *left = a;
+    const NameRef *right = b;
+    int cmp = strcmp(left->name, right->name);
+    if (cmp == 0) cmp = left->type - right->type;
+    return cmp;
+}
+
+typedef struct {
+    sqlite3_stmt *insert;
+    int verbose;
+    ImplementsStats stats;
+} EdgeWriter;
+
+static int write_edge(EdgeWriter *writer, const GoType *type, const GoType *iface, int pointer,
+                      int possibly, const char *unresolved) {
+    sqlite3_stmt *stmt = writer->insert;
+    sqlite3_reset(stmt);
+    sqlite3_bind_text(stmt, 1, type->name, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 2, type->package, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 3, type->directory, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 4, type->filename, -1, SQLITE_STATIC);
+    sqlite3_bind_int(stmt, 5, type->line);
+    sqlite3_bind_int(stmt, 6, pointer);
+    sqlite3_bind_text(stmt, 7, iface->name, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 8, iface->package, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 9, iface->directory, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 10, iface->filename, -1, SQLITE_STATIC);
+    sqlite3_bind_int(stmt, 11, iface->line);
+    sqlite3_bind_text(stmt, 12, possibly ? "possibly" : "implements", -1, SQLITE_STATIC);
+    if (unresolved) {
+        sqlite3_bind_text(stmt, 13, unresolved, -1, SQLITE_STATIC);
+    } else {
+        sqlite3_bind_null(stmt, 13);
+    }
+    if (sqlite3_step(stmt) != SQLITE_DONE) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(sqlite3_db_handle(stmt)));
+        return -1;
+    }
+
+    if (possibly) {
+        writer->stats.possibly++;
+        if (writer->verbose) {
+            printf("Possibly implements: %s%s.%s implements %s.%s (unresolved: %s)\n",
+                   pointer ? "*" : "", type->package, type->name, iface->package, iface->name, unresolved);
+        }
+    } else {
+        writer->stats.implements++;
+    }
+    return 0;
+}
+
+/* Compare one type with one interface and record the edge, if any */
+static int check_type(EdgeWriter *writer, const GoType *type, const GoType *iface) {
+    int names_only = type->generic || iface->generic;
+    int matched = 0;
+    int missing = 0;
+    int pointer = 0;
+    for (int i = 0; i < iface->set_count; i++) {
+        const Method *wanted = iface->set[i].method;
+        const SetMethod *found = find_set_method(type, wanted->name);
+        /* An unexported method is only the interface's if from its package */
+        if (!found || (!is_exported_name(wanted->name) &&
+                       strcmp(found->method->directory, wanted->directory) != 0)) {
+            missing++;
+            continue;
+        }
+        if (!names_only && strcmp(found->method->signature, wanted->signature) != 0) {
+            return 0;  /* Same name, other signature: cannot satisfy it */
+        }
+        matched++;
+        pointer |= found->pointer;
+    }
+
+    if (missing == 0) {
+        if (iface->unresolved || names_only) {
+            return write_edge(writer, type, iface, pointer, 1,
+                              iface->unresolved ? iface->unresolved : "type parameters");
+        }
+        return write_edge(writer, type, iface, pointer, 0, NULL);
+    }
+    if (type->unresolved && matched > 0) {
+        return write_edge(writer, type, iface, pointer, 1, type->unresolved);
+    }
+    return 0;
+}
+
+static int find_edges(TypeTable *table, EdgeWriter *writer) {
+    /* Method names of the concrete types, to find the candidates of an
+     * interface by one of its methods */
+    NameRef *refs = NULL;
+    int ref_count = 0;
+    int ref_capacity = 0;
+    int *incomplete = NULL;  /* Types with embeds outside the index */
+    int incomplete_count = 0;
+    int incomplete_capacity = 0;
+    int status = -1;
+
+    for (int t = 0; t < table->count; t++) {
+        GoType *type = &table->types[t];
+        if (type->is_interface) continue;
+        for (int i = 0; i < type->set_count; i++) {
+            if (grow_array((void **)&refs, &ref_capacity, sizeof(NameRef), ref_count + 1) != 0) {
+                goto cleanup;
+            }
+            refs[ref_count].name = type->set[i].method->name;
+            refs[ref_count].type = t;
+            ref_count++;
+        }
+        if (type->unresolved) {
+            if (grow_array((void **)&incomplete, &incomplete_capacity, sizeof(int), incomplete_count + 1) != 0) {
+                goto cleanup;
+            }
+            incomplete[incomplete_count++] = t;
+        }
+    }
+    if (ref_count > 0) {
+        qsort(refs, (size_t)ref_count, sizeof(NameRef), compare_name_refs);
+    }
+
+    for (int f = 0; f < table->count; f++) {
+        GoType *iface = &table->types[f];
+        /* Every type satisfies an empty interface; constraints are not
+         * implemented, only satisfied by type arguments */
+        if (!iface->is_interface || iface->set_count == 0 || iface->constraint) continue;
+        writer->stats.interfaces++;
+
+        /* A type satisfying it has its first method */
+        const char *first = iface->set[0].method->name;
+        int low = 0;
+        int high = ref_count;
+        while (low < high) {
+            int mid = low + (high - low) / 2;
+            if (strcmp(refs[mid].name, first) < 0) {
+                low = mid + 1;
+            } else {
+                high = mid;
+            }
+        }
+        for (int r = low; r < ref_count && strcmp(refs[r].name, first) == 0; r++) {
+            GoType *type = &table->types[refs[r].type];
+            type->seen = f;
+            if (!type->dirty && !iface->dirty) continue;  /* Edge kept from the last pass */
+            if (check_type(writer, type, iface) != 0) {
+                goto cleanup;
+            }
+        }
+        /* Those whose embeds may have it */
+        for (int i = 0; i < incomplete_count; i++) {
+            GoType *type = &table->types[incomplete[i]];
+            if (type->seen == f || (!type->dirty && !iface->dirty)) continue;
+            type->seen = f;
+            if (check_type(writer, type, iface) != 0) {
+                goto cleanup;
+            }
+        }
+    }
+    status = 0;
+
+cleanup:
+    free(refs);
+    free(incomplete);
+    return status;
+}
+
+static int exec_sql(CodeIndexDatabase *db, const char *sql) {
+    char *err_msg = NULL;
+    if (sqlite3_exec(db->db, sql, NULL, NULL, &err_msg) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", err_msg);
+        sqlite3_free(err_msg);
+        return -1;
+    }
+    return 0;
+}
+
+/* Run an INSERT OR IGNORE until it adds no rows (embed chains are short;
+ * MAX_EMBED_DEPTH rounds cover every one collect_methods follows) */
+static int exec_until_stable(CodeIndexDatabase *db, const char *sql) {
+    for (int round = 0; round < MAX_EMBED_DEPTH; round++) {
+        if (exec_sql(db, sql) != 0) {
+            return -1;
+        }
+        if (sqlite3_changes(db->db) == 0) {
+            break;
+        }
+    }
+    return 0;
+}
+
+/* Types embedding one in the table, as (directory, package, name) keys */
+#define ADD_EMBEDDERS(table) \
+    "INSERT OR IGNORE INTO temp." table " " \
+    "SELECT e.directory, COALESCE(e.namespace, ''), e.parent_symbol " \
+    "FROM temp." table " d CROSS JOIN code_index e INDEXED BY idx_type_name " \
+    "  ON e.type_name = d.name AND COALESCE(e.type_package, '') = d.package " \
+    "WHERE e.language = 'go' AND e.context = 'PROP' AND e.clue = 'embedded' " \
+    "  AND COALESCE(e.parent_symbol, '') != ''"
+
+/* Types embedded by one in temp.implements_load, every package of that
+ * name (resolve_embed needs to know when several declare it) */
+#define ADD_EMBEDDED \
+    "INSERT OR IGNORE INTO temp.implements_load " \
+    "SELECT t.directory, COALESCE(t.namespace, ''), t.full_symbol " \
+    "FROM temp.implements_load l CROSS JOIN code_index e INDEXED BY idx_parent_symbol " \
+    "  ON e.parent_symbol = l.name AND e.directory = l.directory " \
+    "  AND COALESCE(e.namespace, '') = l.package " \
+    "CROSS JOIN code_index t INDEXED BY idx_symbol " \
+    "  ON t.symbol = lower(e.type_name) AND t.full_symbol = e.type_name " \
+    "  AND COALESCE(t.namespace, '') = COALESCE(e.type_package, '') " \
+    "WHERE e.language = 'go' AND e.context = 'PROP' AND e.clue = 'embedded' " \
+    "  AND t.language = 'go' AND t.context = 'TYPE' AND t.is_definition = 1 " \
+    "  AND COALESCE(t.scope_path, '') = ''"
+
+/* Fill temp.implements_dirty with the types whose edges may have changed:
+ * those of the changed packages, those embedding a type that is not
+ * indexed (it may just have been removed) and, transitively, those
+ * embedding any of them. Then fill temp.implements_load with what their
+ * edges are computed from: them, every interface, and what they embed. */
+static int select_dirty_types(CodeIndexDatabase *db, const char *const *directories, int directory_count) {
+    if (exec_sql(db,
+            "CREATE TEMP TABLE IF NOT EXISTS implements_scope (directory TEXT PRIMARY KEY);"
+            "CREATE TEMP TABLE IF NOT EXISTS implements_dirty ("
+            "  directory TEXT, package TEXT, name TEXT, PRIMARY KEY (directory, package, name));"
+            "CREATE TEMP TABLE IF NOT EXISTS implements_load ("
+            "  directory TEXT, package TEXT, name TEXT, PRIMARY KEY (directory, package, name));"
+            "DELETE FROM temp.implements_scope;"
+            "DELETE FROM temp.implements_dirty;"
+            "DELETE FROM temp.implements_load;") != 0) {
+        return -1;
+    }
+
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, "INSERT OR IGNORE INTO temp.implements_scope (directory) VALUES (?)",
+                           -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    for (int i = 0; i < directory_count; i++) {
+        sqlite3_reset(stmt);
+        sqlite3_bind_text(stmt, 1, directories[i], -1, SQLITE_STATIC);
+        if (sqlite3_step(stmt) != SQLITE_DONE) {
+            fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+    }
+    sqlite3_finalize(stmt);
+
+    if (exec_sql(db,
+            "INSERT OR IGNORE INTO temp.implements_dirty "
+            "SELECT c.directory, COALESCE(c.namespace, ''), c.full_symbol "
+            "FROM temp.implements_scope s CROSS JOIN code_index c INDEXED BY idx_directory "
+            "  ON c.directory = s.directory "
+            "WHERE c.language = 'go' AND c.context = 'TYPE' AND c.is_definition = 1 "
+            "  AND COALESCE(c.scope_path, '') = '';"
+            "INSERT OR IGNORE INTO temp.implements_dirty "
+            "SELECT e.directory, COALESCE(e.namespace, ''), e.parent_symbol "
+            "FROM code_index e INDEXED BY idx_clue "
+            "WHERE e.language = 'go' AND e.context = 'PROP' AND e.clue = 'embedded' "
+            "  AND COALESCE(e.parent_symbol, '') != '' AND NOT EXISTS ("
+            "    SELECT 1 FROM code_index t INDEXED BY idx_symbol WHERE t.symbol = lower(e.type_name) "
+            "      AND t.full_symbol = e.type_name "
+            "      AND COALESCE(t.namespace, '') = COALESCE(e.type_package, '') "
+            "      AND t.language = 'go' AND t.context = 'TYPE' AND t.is_definition = 1)") != 0 ||
+        exec_until_stable(db, ADD_EMBEDDERS("implements_dirty")) != 0) {
+        return -1;
+    }
+
+    if (exec_sql(db,
+            "INSERT OR IGNORE INTO temp.implements_load SELECT * FROM temp.implements_dirty;"
+            "INSERT OR IGNORE INTO temp.implements_load "
+            "SELECT directory, COALESCE(namespace, ''), full_symbol FROM code_index INDEXED BY idx_clue "
+            "WHERE clue = 'interface' AND language = 'go' AND context = 'TYPE' AND is_definition = 1 "
+            "  AND COALESCE(scope_path, '') = ''") != 0) {
+        return -1;
+    }
+    return exec_until_stable(db, ADD_EMBEDDED);
+}
+
+/* Add to temp.implements_load the types a changed interface is compared
+ * with: those with its first method, declared or promoted, and those with
+ * embeds (find_edges also checks the ones whose embeds are not indexed) */
+static int select_candidates(CodeIndexDatabase *db, const TypeTable *table) {
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db,
+            "INSERT OR IGNORE INTO temp.implements_load "
+            "SELECT directory, COALESCE(namespace, ''), parent_symbol FROM code_index INDEXED BY idx_symbol "
+            "WHERE symbol = lower(?1) AND full_symbol = ?1 "
+            "  AND language = 'go' AND context = 'FUNC' AND is_definition = 1 "
+            "  AND COALESCE(parent_symbol, '') != '' "
+            "  AND (clue = 'interface' OR modifier IN ('pointer', 'value'))",
+            -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    for (int f = 0; f < table->count; f++) {
+        const GoType *iface = &table->types[f];
+        if (!iface->dirty || !iface->is_interface || iface->set_count == 0 || iface->constraint) continue;
+        sqlite3_reset(stmt);
+        sqlite3_bind_text(stmt, 1, iface->set[0].method->name, -1, SQLITE_STATIC);
+        if (sqlite3_step(stmt) != SQLITE_DONE) {
+            fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+            sqlite3_finalize(stmt);
+            return -1;
+        }
+    }
+    sqlite3_finalize(stmt);
+
+    if (exec_sql(db,
+            "INSERT OR IGNORE INTO temp.implements_load "
+            "SELECT DISTINCT directory, COALESCE(namespace, ''), parent_symbol FROM code_index INDEXED BY idx_clue "
+            "WHERE clue = 'embedded' AND language = 'go' AND context = 'PROP' "
+            "  AND COALESCE(parent_symbol, '') != ''") != 0 ||
+        exec_until_stable(db, ADD_EMBEDDERS("implements_load")) != 0) {
+        return -1;
+    }
+    return exec_until_stable(db, ADD_EMBEDDED);
+}
+
+/* Load the types (of temp.implements_load when scoped) and build their
+ * method sets */
+static int load_table(CodeIndexDatabase *db, TypeTable *table, int scoped) {
+    if (load_types(db, table, scoped) != 0 || load_methods(db, table, scoped) != 0 ||
+        load_embeds(db, table, scoped) != 0) {
+        return -1;
+    }
+    for (int t = 0; t < table->count; t++) {
+        GoType *type = &table->types[t];
+        for (int i = 0; i < type->embed_count; i++) {
+            type->embeds[i].target = resolve_embed(table, type->directory, type->package, &type->embeds[i]);
+        }
+    }
+    for (int t = 0; t < table->count; t++) {
+        if (build_method_set(table, t) != 0) {
+            return -1;
+        }
+    }
+    return 0;
+}
+
+/* Flag the types of temp.implements_dirty (every type when not scoped)
+ * Returns: number of changed interfaces with methods, -1 on error */
+static int mark_dirty(CodeIndexDatabase *db, TypeTable *table, int scoped) {
+    int interfaces = 0;
+    if (!scoped) {
+        for (int t = 0; t < table->count; t++) {
+            table->types[t].dirty = 1;
+        }
+        return 0;
+    }
+
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, "SELECT directory, package, name FROM temp.implements_dirty",
+                           -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        int t = find_type(table, column_text(stmt, 0), column_text(stmt, 1), column_text(stmt, 2));
+        if (t < 0) continue;
+        GoType *type = &table->types[t];
+        type->dirty = 1;
+        if (type->is_interface && type->set_count > 0 && !type->constraint) {
+            interfaces++;
+        }
+    }
+    sqlite3_finalize(stmt);
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    return interfaces;
+}
+
+/* Edge totals of the table, for a scoped pass that wrote only some */
+static int count_edges(CodeIndexDatabase *db, ImplementsStats *stats) {
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db,
+            "SELECT COALESCE(SUM(certainty = 'implements'), 0), COALESCE(SUM(certainty = 'possibly'), 0) "
+            "FROM implements", -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    if (sqlite3_step(stmt) == SQLITE_ROW) {
+        stats->implements = sqlite3_column_int(stmt, 0);
+        stats->possibly = sqlite3_column_int(stmt, 1);
+    }
+    sqlite3_finalize(stmt);
+    return 0;
+}
+
+int implements_update(CodeIndexDatabase *db, const char *language, const char *const *directories,
+                      int directory_count, int verbose, ImplementsStats *stats) {
+    if (stats) {
+        memset(stats, 0, sizeof(*stats));
+    }
+    /* Other languages record no receivers; the Go indexer owns the table */
+    if (strcmp(language, "go") != 0) {
+        return 0;
+    }
+
+    TypeTable table;
+    memset(&table, 0, sizeof(table));
+    EdgeWriter writer;
+    memset(&writer, 0, sizeof(writer));
+    writer.verbose = verbose;
+    int scoped = directories != NULL;
+    int changed_interfaces;
+    int status = -1;
+
+    if (!scoped) {
+        if (exec_sql(db, "DELETE FROM implements") != 0) {
+            goto cleanup;
+        }
+    } else if (select_dirty_types(db, directories, directory_count) != 0 ||
+               exec_sql(db,
+                   "DELETE FROM implements WHERE rowid IN ("
+                   "  SELECT i.rowid FROM temp.implements_dirty d CROSS JOIN implements i"
+                   "    ON i.directory = d.directory AND i.type_package = d.package AND i.type = d.name"
+                   "  UNION ALL"
+                   "  SELECT i.rowid FROM temp.implements_dirty d CROSS JOIN implements i"
+                   "    ON i.interface = d.name AND i.interface_directory = d.directory"
+                   "    AND i.interface_package = d.package)") != 0) {
+        goto cleanup;
+    }
+
+    if (load_table(db, &table, scoped) != 0) {
+        goto cleanup;
+    }
+    changed_interfaces = mark_dirty(db, &table, scoped);
+    if (changed_interfaces < 0) {
+        goto cleanup;
+    }
+    if (changed_interfaces > 0) {
+        /* Their candidates are anywhere in the index: load those too */
+        if (select_candidates(db, &table) != 0) {
+            goto cleanup;
+        }
+        free_table(&table);
+        if (load_table(db, &table, scoped) != 0 || mark_dirty(db, &table, scoped) < 0) {
+            goto cleanup;
+        }
+    }
+
+    if (sqlite3_prepare_v2(db->db,
+        "INSERT INTO implements (type, type_package, directory, filename, line, pointer, "
+        "interface, interface_package, interface_directory, interface_filename, interface_line, "
+        "certainty, unresolved) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+        -1, &writer.insert, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: interface satisfaction failed: %s\n", sqlite3_errmsg(db->db));
+        goto cleanup;
+    }
+    if (find_edges(&table, &writer) != 0) {
+        goto cleanup;
+    }
+    if (scoped && count_edges(db, &writer.stats) != 0) {
+        goto cleanup;
+    }
+    if (stats) {
+        *stats = writer.stats;
+    }
+    status = 0;
+
+cleanup:
+    sqlite3_finalize(writer.insert);
+    free_table(&table);
+    return status;
+}
+
+#else /* !ENABLED(GO) */
+
+/* Only Go records method sets; nothing to compare */
+int implements_update(CodeIndexDatabase *db, const char *language, const char *const *directories,
+                      int directory_count, int verbose, ImplementsStats *stats) {
+    (void)db;
+    (void)language;
+    (void)directories;
+    (void)directory_count;
+    (void)verbose;
+    if (stats) {
+        memset(stats, 0, sizeof(*stats));
+    }
+    return 0;
+}
+
+#endif /* ENABLED(GO) */
+
+int implements_print(const char *db_path, const ImplementsOptions *opts, FILE *out) {
+    sqlite3 *db = NULL;
+    if (sqlite3_open_v2(db_path, &db, SQLITE_OPEN_READONLY, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", db_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+
+    /* "io.ReadCloser": the qualifier is the package; the last dot separates
+     * it, as package names have none */
+    char package[SYMBOL_MAX_LENGTH] = "";
+    const char *name = opts->interface;
+    if (name) {
+        const char *dot = strrchr(name, '.');
+        if (dot) {
+            snprintf(package, sizeof(package), "%.*s", (int)(dot - name), name);
+            name = dot + 1;
+        }
+    }
+
+    char sql[LINE_BUFFER_LARGE * 2];
+    snprintf(sql, sizeof(sql),
+             "SELECT type, type_package, pointer, " DISPLAY_PATH("directory", "filename") ", line, "
+             "interface, interface_package, "
+             DISPLAY_PATH("interface_directory", "interface_filename") ", interface_line, "
+             "certainty, unresolved FROM implements WHERE 1%s%s%s"
+             " ORDER BY interface_package, interface, 8, certainty = 'possibly', type_package, type, 4",
+             name ? " AND interface = ?1" : "",
+             package[0] ? " AND interface_package = ?2" : "",
+             opts->certain_only ? " AND certainty = 'implements'" : "");
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot read interface satisfaction: %s (re-index to record it)\n",
+                sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+    if (name) {
+        sqlite3_bind_text(stmt, 1, name, -1, SQLITE_STATIC);
+    }
+    if (package[0]) {
+        sqlite3_bind_text(stmt, 2, package, -1, SQLITE_STATIC);
+    }
+
+    char previous[SYMBOL_MAX_LENGTH * 2 + PATH_MAX_LENGTH] = "";
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        const char *type = (const char *)sqlite3_column_text(stmt, 0);
+        const char *type_package = (const char *)sqlite3_column_text(stmt, 1);
+        int pointer = sqlite3_column_int(stmt, 2);
+        const char *file = (const char *)sqlite3_column_text(stmt, 3);
+        int line = sqlite3_column_int(stmt, 4);
+        const char *iface = (const char *)sqlite3_column_text(stmt, 5);
+        const char *iface_package = (const char *)sqlite3_column_text(stmt, 6);
+        const char *iface_file = (const char *)sqlite3_column_text(stmt, 7);
+        int iface_line = sqlite3_column_int(stmt, 8);
+        const char *certainty = (const char *)sqlite3_column_text(stmt, 9);
+        const char *unresolved = (const char *)sqlite3_column_text(stmt, 10);
+        if (!type || !file || !iface || !iface_file || !certainty) {
+            continue;
+        }
+        if (!type_package) type_package = "";
+        if (!iface_package) iface_package = "";
+        int possibly = strcmp(certainty, "possibly") == 0;
+
+        if (opts->format == IMPLEMENTS_FORMAT_NDJSON) {
+            fputs("{\"type\":", out);
+            json_write_string(out, type);
+            fputs(",\"package\":", out);
+            json_write_string(out, type_package);
+            fprintf(out, ",\"pointer\":%s,\"file\":", pointer ? "true" : "false");
+            json_write_string(out, file);
+            fprintf(out, ",\"line\":%d,\"interface\":", line);
+            json_write_string(out, iface);
+            fputs(",\"interfacePackage\":", out);
+            json_write_string(out, iface_package);
+            fputs(",\"interfaceFile\":", out);
+            json_write_string(out, iface_file);
+            fprintf(out, ",\"interfaceLine\":%d,\"certainty\":", iface_line);
+            json_write_string(out, certainty);
+            if (unresolved) {
+                fputs(",\"unresolved\":", out);
+                json_write_string(out, unresolved);
+            }
+            fputs("}\n", out);
+            continue;
+        }
+
+        /* Each interface followed by the types implementing it */
+        char heading[sizeof(previous)];
+        snprintf(heading, sizeof(heading), "%s%s%s (%s:%d)", iface_package, iface_package[0] ? "." : "",
+                 iface, iface_file, iface_line);
+        if (strcmp(heading, previous) != 0) {
+            fprintf(out, "%s\n", heading);
+            snprintf(previous, sizeof(previous), "%s", heading);
+        }
+        fprintf(out, "  %s:%d  %s%s%s%s", file, line, pointer ? "*" : "",
+                type_package, type_package[0] ? "." : "", type);
+        if (possibly) {
+            fprintf(out, "  possibly (unresolved: %s)", unresolved ? unresolved : "");
+        }
+        fputc('\n', out);
+    }
+    fflush(out);
+
+    int result = 0;
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: cannot read interface satisfaction: %s\n", sqlite3_errmsg(db));
+        result = -1;
+    }
+    sqlite3_finalize(stmt);
+    sqlite3_close(db);
+    return result;
+}
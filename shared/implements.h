@@ -0,0 +1,104 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef IMPLEMENTS_H
+#define IMPLEMENTS_H
+
+#include <stdio.h>
+#include "database.h"
+
+/*
+ * Interface satisfaction (the implements table and subcommand)
+ *
+ * Go types satisfy interfaces structurally. After each indexing run, the Go
+ * indexer compares the method set of every named type with the method set
+ * of every interface in the index and records an edge for each match:
+ *
+ *   File  implements  ReadCloser    (Read and Close, same signatures)
+ *
+ * Method sets are built from the rows the parser records: methods with
+ * their receiver as parent (modifier "pointer" or "value"), interface
+ * method specs (clue "interface") and embedded fields and interfaces
+ * (clue "embedded"), following Go's rules: methods promoted through
+ * embedded fields count, the shallowest one wins and two at the same depth
+ * cancel out, and methods with a pointer receiver only belong to *T, which
+ * the edge then records. Signatures are compared by parameter and result
+ * types, names left out; unqualified type names are qualified with the
+ * package that declares the method. An unexported method only matches an
+ * interface of its own package.
+ *
+ * Edges that depend on what is not in the index are recorded with
+ * certainty "possibly" instead of "implements", naming what could not be
+ * resolved:
+ *   - the interface embeds one that is not indexed (io.Reader, when the
+ *     standard library is not), and the type has all the methods seen
+ *   - the type embeds a type that is not indexed (sync.Mutex) and has some
+ *     of the interface's methods, the rest possibly coming from the embed
+ *   - the type or interface is generic; only method names are compared
+ *
+ * Empty interfaces (any type satisfies them) and constraints embedding
+ * comparable are skipped, as are interfaces satisfying other interfaces.
+ *
+ * A full pass replaces the whole table. Watch mode and serve's reindex run
+ * a scoped pass instead, given the directories (packages) whose files
+ * changed: it recomputes the edges of the types and interfaces declared
+ * there and of those embedding them, loading only these, every interface
+ * and, for a changed interface, the types having its first method, and
+ * keeps the other edges.
+ */
+
+typedef struct {
+    int interfaces;    /* Interfaces compared against */
+    int implements;    /* Edges recorded as certain */
+    int possibly;      /* Edges recorded as possible */
+} ImplementsStats;
+
+/* Recompute the implements table from the language's method sets
+ *
+ * Parameters:
+ *   db              - Open database (caller manages the transaction)
+ *   language        - Language of the indexer; only Go records method sets,
+ *                     others leave the table alone
+ *   directories     - Directories of the changed files, as stored in the
+ *                     index (NULL = recompute the whole table)
+ *   directory_count - Number of directories
+ *   verbose         - If non-zero, list possible edges and why on stdout
+ *   stats           - Output counts of the whole table (may be NULL)
+ *
+ * Returns: 0 on success, -1 on database or allocation error
+ */
+int implements_update(CodeIndexDatabase *db, const char *language, const char *const *directories,
+                      int directory_count, int verbose, ImplementsStats *stats);
+
+typedef enum {
+    IMPLEMENTS_FORMAT_TABLE,
+    IMPLEMENTS_FORMAT_NDJSON    /* One {"type":...,"interface":...,"certainty":...} per edge */
+} ImplementsFormat;
+
+typedef struct {
+    const char *interface;     /* "ReadCloser" or "io.ReadCloser" (NULL = all) */
+    int certain_only;          /* Leave out "possibly" edges */
+    ImplementsFormat format;
+} ImplementsOptions;
+
+/* Print the implements edges of the index at db_path to out
+ *
+ * Returns: 0 on success (including no edges), -1 on error
+ */
+int implements_print(const char *db_path, const ImplementsOptions *opts, FILE *out);
+
+#endif /* IMPLEMENTS_H */
@@ -0,0 +1,435 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "index_diff.h"
+#include "database.h"
+#include "ndjson.h"
+#include "symbol_at.h"
+#include "sql_builder.h"
+#include <sqlite3.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef enum {
+    CHANGE_ADDED,
+    CHANGE_REMOVED,
+    CHANGE_CHANGED
+} ChangeType;
+
+static const char *CHANGE_NAMES[] = { "added", "removed", "changed" };
+static const char CHANGE_MARKS[] = { '+', '-', '~' };
+
+/* One change; the entries are read again from their index when printed */
+typedef struct {
+    ChangeType type;
+    const char *kind;                  /* Static string from symbol_kind() */
+    char name[SCOPE_PATH_MAX_LENGTH + SYMBOL_MAX_LENGTH + SYMBOL_MAX_LENGTH];
+    sqlite3_int64 old_id;              /* rowid in the old index (0: none) */
+    sqlite3_int64 new_id;              /* rowid in the new index (0: none) */
+    int breaking;
+} DiffChange;
+
+typedef struct {
+    DiffChange *items;
+    int count;
+    int capacity;
+} DiffChangeList;
+
+/* The namespace column, which only builds with a language that has
+ * namespaces define ("" otherwise) */
+static const char *entry_namespace(const IndexEntry *entry) {
+    (void)entry;
+#define COLUMN(name, ...) if (strcmp(#name, "namespace") == 0) return entry->name;
+#define INT_COLUMN(name, ...)  /* skip */
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+    return "";
+}
+
+static const char *namespace_sql(void) {
+#define COLUMN(name, ...) if (strcmp(#name, "namespace") == 0) return "COALESCE(namespace, '')";
+#define INT_COLUMN(name, ...)  /* skip */
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+    return "''";
+}
+
+/* Compared definitions of one index, keyed like duplicates.c keys them;
+ * copy numbers repeated definitions of a key in file order */
+static int create_defs_table(sqlite3 *db, const char *schema, const char *table, int all) {
+    SqlQueryBuilder sql;
+    if (init_sql_builder(&sql) != 0) return -1;
+
+    const char *ns = namespace_sql();
+    int ok = sql_append(&sql,
+        "CREATE TEMP TABLE %s AS"
+        " SELECT rowid AS id, COALESCE(language, '') AS lang, directory,"
+        "        CASE WHEN %s = '' THEN filename ELSE '' END AS unit,"
+        "        %s AS ns, COALESCE(scope_path, '') AS sp,"
+        "        full_symbol, context, COALESCE(parent_symbol, '') AS parent,"
+        "        ROW_NUMBER() OVER (PARTITION BY language, directory,"
+        "            CASE WHEN %s = '' THEN filename ELSE '' END,"
+        "            %s, scope_path, full_symbol, context ORDER BY filename, line) AS copy"
+        " FROM %s.code_index"
+        " WHERE is_definition = 1 AND context NOT IN ('%s', '%s', '%s', '%s')%s",
+        table, ns, ns, ns, ns, schema,
+        context_to_string(CONTEXT_COMMENT, 1), context_to_string(CONTEXT_STRING, 1),
+        context_to_string(CONTEXT_FILENAME, 1), context_to_string(CONTEXT_IMPORT, 1),
+        all ? "" : " AND is_exported = 1") == 0 &&
+        sql_append(&sql, "; CREATE INDEX %s_key ON %s (lang, directory, unit, ns, sp, full_symbol, context)",
+                   table, table) == 0;
+
+    int rc = ok ? sqlite3_exec(db, sql.sql, NULL, NULL, NULL) : SQLITE_NOMEM;
+    free_sql_builder(&sql);
+    if (rc != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot read definitions: %s\n", sqlite3_errmsg(db));
+        return -1;
+    }
+    return 0;
+}
+
+#define KEY_MATCH \
+    "n.lang = o.lang AND n.directory = o.directory AND n.unit = o.unit AND n.ns = o.ns" \
+    " AND n.sp = o.sp AND n.full_symbol = o.full_symbol AND n.context = o.context"
+
+/* Old id, new id and whether an added symbol is a member of an interface */
+static const char *PAIRS_SQL =
+    "SELECT o.id, NULL, 0 FROM old_defs o WHERE o.copy = 1"
+    "  AND NOT EXISTS (SELECT 1 FROM new_defs n WHERE " KEY_MATCH ")"
+    " UNION ALL "
+    "SELECT NULL, n.id, EXISTS (SELECT 1 FROM main.code_index p"
+    "    WHERE p.directory = n.directory AND p.full_symbol = n.parent AND p.is_definition = 1"
+    "      AND (p.context = 'IFACE' OR (p.context = 'TYPE' AND p.clue = 'interface')))"
+    "  FROM new_defs n WHERE n.copy = 1"
+    "  AND NOT EXISTS (SELECT 1 FROM old_defs o WHERE " KEY_MATCH ")"
+    " UNION ALL "
+    "SELECT o.id, n.id, 0 FROM old_defs o JOIN new_defs n ON " KEY_MATCH
+    "  WHERE o.copy = 1 AND n.copy = 1";
+
+/* Entries of both indexes by rowid */
+typedef struct {
+    sqlite3 *db;
+    sqlite3_stmt *old_stmt;
+    sqlite3_stmt *new_stmt;
+    IndexEntry *old_entry;
+    IndexEntry *new_entry;
+} DiffReader;
+
+static int prepare_entry_statement(sqlite3 *db, const char *schema, sqlite3_stmt **stmt) {
+    char sql[1024];
+    snprintf(sql, sizeof(sql), "SELECT %s FROM %s.code_index WHERE rowid = ?", db_entry_columns(), schema);
+    if (sqlite3_prepare_v2(db, sql, -1, stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot read symbols: %s\n", sqlite3_errmsg(db));
+        return -1;
+    }
+    return 0;
+}
+
+/* Read the entry with rowid id through stmt */
+static int read_entry(sqlite3_stmt *stmt, sqlite3_int64 id, IndexEntry *entry) {
+    sqlite3_reset(stmt);
+    sqlite3_bind_int64(stmt, 1, id);
+    if (sqlite3_step(stmt) != SQLITE_ROW) {
+        fprintf(stderr, "Error: cannot read symbol %lld\n", (long long)id);
+        return -1;
+    }
+    db_read_entry(stmt, entry);
+    return 0;
+}
+
+/* Does a column take part in the comparison? Location, naming and
+ * descriptive columns do not (type_package and type_name follow type) */
+static int is_compared_column(const char *name) {
+    static const char *skipped[] = {
+        "parent_symbol", "scope_path", "namespace", "language", "type_package",
+        "type_name", "decl_group", "doc", "tokens", "is_definition"
+    };
+    for (size_t i = 0; i < sizeof(skipped) / sizeof(skipped[0]); i++) {
+        if (strcmp(name, skipped[i]) == 0) return 0;
+    }
+    return 1;
+}
+
+static int entries_differ(const IndexEntry *a, const IndexEntry *b) {
+#define COLUMN(name, ...) \
+    if (is_compared_column(#name) && strcmp(a->name, b->name) != 0) return 1;
+#define INT_COLUMN(name, ...) \
+    if (is_compared_column(#name) && a->name != b->name) return 1;
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+    return 0;
+}
+
+static void qualified_name(const IndexEntry *entry, char *buffer, size_t size) {
+    char name[SCOPE_PATH_MAX_LENGTH + SYMBOL_MAX_LENGTH];
+    symbol_qualified_name(entry, name, sizeof(name));
+    const char *ns = entry_namespace(entry);
+    snprintf(buffer, size, "%s%s%s", ns, ns[0] ? "." : "", name);
+}
+
+static int add_change(DiffChangeList *list, ChangeType type, const IndexEntry *entry,
+                      sqlite3_int64 old_id, sqlite3_int64 new_id, int breaking) {
+    if (list->count == list->capacity) {
+        int capacity = list->capacity > 0 ? list->capacity * 2 : 64;
+        DiffChange *grown = realloc(list->items, sizeof(DiffChange) * (size_t)capacity);
+        if (!grown) {
+            fprintf(stderr, "Error: out of memory comparing indexes\n");
+            return -1;
+        }
+        list->items = grown;
+        list->capacity = capacity;
+    }
+    DiffChange *change = &list->items[list->count];
+    change->type = type;
+    change->kind = symbol_kind(entry);
+    qualified_name(entry, change->name, sizeof(change->name));
+    change->old_id = old_id;
+    change->new_id = new_id;
+    change->breaking = breaking;
+    list->count++;
+    return 0;
+}
+
+/* By kind, then added, removed, changed, then name */
+static int compare_changes(const void *a, const void *b) {
+    const DiffChange *x = a, *y = b;
+    int result = strcmp(x->kind, y->kind);
+    if (result != 0) return result;
+    if (x->type != y->type) return (int)x->type - (int)y->type;
+    return strcmp(x->name, y->name);
+}
+
+static int collect_changes(DiffReader *reader, DiffChangeList *list) {
+    IndexEntry *old_entry = reader->old_entry, *new_entry = reader->new_entry;
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(reader->db, PAIRS_SQL, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot compare indexes: %s\n", sqlite3_errmsg(reader->db));
+        return -1;
+    }
+
+    int result = 0;
+    int rc;
+    while (result == 0 && (rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        sqlite3_int64 old_id = sqlite3_column_int64(stmt, 0);
+        sqlite3_int64 new_id = sqlite3_column_int64(stmt, 1);
+        int interface_member = sqlite3_column_int(stmt, 2);
+
+        if (old_id && read_entry(reader->old_stmt, old_id, old_entry) != 0) result = -1;
+        else if (new_id && read_entry(reader->new_stmt, new_id, new_entry) != 0) result = -1;
+        else if (!new_id) result = add_change(list, CHANGE_REMOVED, old_entry, old_id, 0, old_entry->is_exported);
+        else if (!old_id) result = add_change(list, CHANGE_ADDED, new_entry, 0, new_id, interface_member);
+        else if (entries_differ(old_entry, new_entry)) {
+            result = add_change(list, CHANGE_CHANGED, new_entry, old_id, new_id, old_entry->is_exported);
+        }
+    }
+    if (result == 0 && rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: cannot compare indexes: %s\n", sqlite3_errmsg(reader->db));
+        result = -1;
+    }
+    sqlite3_finalize(stmt);
+    return result;
+}
+
+/* "dir/file.go:12" as the other subcommands display files */
+static void display_location(const IndexEntry *entry, char *buffer, size_t size) {
+    const char *directory = strncmp(entry->directory, "./", 2) == 0 ? entry->directory + 2 : entry->directory;
+    snprintf(buffer, size, "%s%s:%d", directory, entry->filename, entry->line);
+}
+
+static void print_text_columns(FILE *out, const IndexEntry *a, const IndexEntry *b) {
+#define COLUMN(name, sql_type, c_type, width, full, compact, cli_long, ...) \
+    if (is_compared_column(#name) && strcmp(a->name, b->name) != 0) { \
+        fprintf(out, "      " #cli_long ": %s -> %s\n", \
+                a->name[0] ? a->name : "(none)", b->name[0] ? b->name : "(none)"); \
+    }
+#define INT_COLUMN(name, sql_type, c_type, width, full, compact, cli_long, ...) \
+    if (is_compared_column(#name) && a->name != b->name) { \
+        fprintf(out, "      " #cli_long ": %d -> %d\n", a->name, b->name); \
+    }
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+}
+
+static void print_json_columns(FILE *out, const IndexEntry *a, const IndexEntry *b) {
+    int first = 1;
+    fputs(",\"changes\":[", out);
+#define COLUMN(name, sql_type, c_type, width, full, compact, cli_long, ...) \
+    if (is_compared_column(#name) && strcmp(a->name, b->name) != 0) { \
+        fprintf(out, "%s{\"field\":\"" #cli_long "\",\"old\":", first ? "" : ","); \
+        json_write_string(out, a->name); \
+        fputs(",\"new\":", out); \
+        json_write_string(out, b->name); \
+        fputc('}', out); \
+        first = 0; \
+    }
+#define INT_COLUMN(name, sql_type, c_type, width, full, compact, cli_long, ...) \
+    if (is_compared_column(#name) && a->name != b->name) { \
+        fprintf(out, "%s{\"field\":\"" #cli_long "\",\"old\":%d,\"new\":%d}", \
+                first ? "" : ",", a->name, b->name); \
+        first = 0; \
+    }
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+    fputc(']', out);
+}
+
+static int print_change(DiffReader *reader, const DiffChange *change, DiffFormat format, FILE *out) {
+    IndexEntry *old_entry = reader->old_entry, *new_entry = reader->new_entry;
+    if (change->old_id && read_entry(reader->old_stmt, change->old_id, old_entry) != 0) return -1;
+    if (change->new_id && read_entry(reader->new_stmt, change->new_id, new_entry) != 0) return -1;
+    /* Removed symbols are located in the old index, the rest in the new one */
+    const IndexEntry *entry = change->new_id ? new_entry : old_entry;
+    char location[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH + 16];
+    display_location(entry, location, sizeof(location));
+
+    if (format == DIFF_FORMAT_NDJSON) {
+        fprintf(out, "{\"change\":\"%s\",\"kind\":\"%s\",\"name\":", CHANGE_NAMES[change->type], change->kind);
+        json_write_string(out, entry->full_symbol);
+        fputs(",\"qualified\":", out);
+        json_write_string(out, change->name);
+        fputs(",\"parent\":", out);
+        json_write_string(out, entry->parent_symbol[0] ? entry->parent_symbol : NULL);
+        const char *directory = strncmp(entry->directory, "./", 2) == 0 ? entry->directory + 2 : entry->directory;
+        char file[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH];
+        snprintf(file, sizeof(file), "%s%s", directory, entry->filename);
+        fputs(",\"file\":", out);
+        json_write_string(out, file);
+        fprintf(out, ",\"line\":%d,\"breaking\":%s", entry->line, change->breaking ? "true" : "false");
+        if (change->type == CHANGE_CHANGED) {
+            print_json_columns(out, old_entry, new_entry);
+        }
+        fputs("}\n", out);
+        return 0;
+    }
+
+    fprintf(out, "  %c %s  %s%s\n", CHANGE_MARKS[change->type], change->name, location,
+            change->breaking ? "  (breaking)" : "");
+    if (change->type == CHANGE_CHANGED) {
+        print_text_columns(out, old_entry, new_entry);
+    }
+    return 0;
+}
+
+/* PRAGMA user_version of schema, or -1 on error */
+static int schema_version(sqlite3 *db, const char *schema) {
+    char sql[64];
+    snprintf(sql, sizeof(sql), "PRAGMA %s.user_version", schema);
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db, sql, -1, &stmt, NULL) != SQLITE_OK) return -1;
+    int version = sqlite3_step(stmt) == SQLITE_ROW ? sqlite3_column_int(stmt, 0) : -1;
+    sqlite3_finalize(stmt);
+    return version;
+}
+
+int index_diff(const char *old_path, const char *new_path, const DiffOptions *opts,
+               FILE *out, DiffStats *stats) {
+    memset(stats, 0, sizeof(*stats));
+
+    sqlite3 *db = NULL;
+    if (sqlite3_open_v2(new_path, &db, SQLITE_OPEN_READONLY, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", new_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+    sqlite3_stmt *attach;
+    if (sqlite3_prepare_v2(db, "ATTACH DATABASE ? AS old", -1, &attach, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", old_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+    sqlite3_bind_text(attach, 1, old_path, -1, SQLITE_STATIC);
+    int rc = sqlite3_step(attach);
+    sqlite3_finalize(attach);
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", old_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+
+    /* Rows are read with this build's columns */
+    const char *paths[] = { old_path, new_path };
+    const char *schemas[] = { "old", "main" };
+    for (int i = 0; i < 2; i++) {
+        if (schema_version(db, schemas[i]) != DB_SCHEMA_VERSION) {
+            fprintf(stderr, "Error: index '%s' was built by an incompatible version; re-index it\n", paths[i]);
+            sqlite3_close(db);
+            return -1;
+        }
+    }
+
+    IndexEntry *entries = malloc(sizeof(IndexEntry) * 2);
+    DiffReader reader = { .db = db, .old_entry = entries, .new_entry = entries ? &entries[1] : NULL };
+    DiffChangeList changes = {0};
+    int result = -1;
+    if (!entries) {
+        fprintf(stderr, "Error: out of memory comparing indexes\n");
+        goto cleanup;
+    }
+    if (prepare_entry_statement(db, "old", &reader.old_stmt) != 0 ||
+        prepare_entry_statement(db, "main", &reader.new_stmt) != 0 ||
+        create_defs_table(db, "old", "old_defs", opts->all) != 0 ||
+        create_defs_table(db, "main", "new_defs", opts->all) != 0 ||
+        collect_changes(&reader, &changes) != 0) {
+        goto cleanup;
+    }
+
+    if (changes.count > 0) {
+        qsort(changes.items, (size_t)changes.count, sizeof(DiffChange), compare_changes);
+    }
+    const char *kind = NULL;
+    for (int i = 0; i < changes.count; i++) {
+        const DiffChange *change = &changes.items[i];
+        if (opts->format == DIFF_FORMAT_TEXT && (!kind || strcmp(kind, change->kind) != 0)) {
+            kind = change->kind;
+            fprintf(out, "%s\n", kind);
+        }
+        if (print_change(&reader, change, opts->format, out) != 0) {
+            goto cleanup;
+        }
+        switch (change->type) {
+            case CHANGE_ADDED:   stats->added++;   break;
+            case CHANGE_REMOVED: stats->removed++; break;
+            case CHANGE_CHANGED: stats->changed++; break;
+        }
+        if (change->breaking) {
+            stats->breaking++;
+        }
+    }
+    if (opts->format == DIFF_FORMAT_TEXT) {
+        if (changes.count == 0) {
+            fprintf(out, "No changes\n");
+        } else {
+            fprintf(out, "\n%d added, %d removed, %d changed (%d breaking)\n",
+                    stats->added, stats->removed, stats->changed, stats->breaking);
+        }
+    }
+    fflush(out);
+    result = 0;
+
+cleanup:
+    sqlite3_finalize(reader.old_stmt);
+    sqlite3_finalize(reader.new_stmt);
+    free(changes.items);
+    free(entries);
+    sqlite3_close(db);
+    return result;
+}
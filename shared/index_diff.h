@@ -0,0 +1,69 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef INDEX_DIFF_H
+#define INDEX_DIFF_H
+
+#include <stdio.h>
+
+/*
+ * Symbol changes between two indexes (the "diff" subcommand)
+ *
+ * Both indexes should be built from the root of their tree with the same
+ * paths, e.g. from checkouts of two versions. Definitions are matched by
+ * language, kind, namespace, scope path and name, within the same directory
+ * and, for symbols without a namespace, the same file; a symbol defined
+ * more than once under one key is compared by its first definition.
+ * Comments, strings, filenames and imports are not compared, and unless
+ * all is set, neither are symbols that are not exported.
+ *
+ * A matched symbol has changed when its signature columns differ: scope,
+ * modifier, clue, type, tags, params, returns, typeparams, value or
+ * exported. Moving a definition to another line, or editing its doc
+ * comment, is not a change. A struct that gains a field shows up as an added field whose
+ * parent is the struct.
+ *
+ * A change is breaking when it removes or changes an exported symbol, or
+ * adds a method to an interface (its implementations must add it too).
+ */
+
+typedef enum {
+    DIFF_FORMAT_TEXT,     /* Grouped by kind: + added, - removed, ~ changed */
+    DIFF_FORMAT_NDJSON    /* One JSON object per change */
+} DiffFormat;
+
+typedef struct {
+    DiffFormat format;
+    int all;              /* Compare unexported definitions too */
+} DiffOptions;
+
+typedef struct {
+    int added;
+    int removed;
+    int changed;
+    int breaking;
+} DiffStats;
+
+/* Compare the index at new_path against the one at old_path and print the
+ * changes to out
+ *
+ * Returns: 0 on success, -1 on error (reported on stderr)
+ */
+int index_diff(const char *old_path, const char *new_path, const DiffOptions *opts,
+               FILE *out, DiffStats *stats);
+
+#endif /* INDEX_DIFF_H */
@@ -0,0 +1,150 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "index_lock.h"
+#include <errno.h>
+#include <stdio.h>
+#include <string.h>
+#include <unistd.h>
+
+/* Attempts at locking the file at the lock path before giving up (a run
+ * releasing the lock removes the file it locked) */
+#define LOCK_ATTEMPTS 3
+
+void index_lock_path(const char *db_path, const char *language, char *path, size_t size) {
+    snprintf(path, size, "%s.%s.lock", db_path, language);
+}
+
+/* PID recorded in a lock file, or 0 if it has none (yet) */
+static long read_owner(FILE *fp) {
+    long pid = 0;
+    if (fscanf(fp, "%ld", &pid) != 1 || pid <= 0) {
+        pid = 0;
+    }
+    return pid;
+}
+
+#if defined(_WIN32) || defined(__MINGW32__) || defined(__MINGW64__)
+#include <windows.h>
+
+/* Windows: the lock is the lock file open without write sharing; the
+ * system closes it, and deletes it, when the process exits in any way */
+int index_lock_acquire(IndexLock *lock, const char *path, long *owner) {
+    memset(lock, 0, sizeof(*lock));
+    snprintf(lock->path, sizeof(lock->path), "%s", path);
+    *owner = 0;
+
+    HANDLE handle = CreateFileA(path, GENERIC_WRITE, FILE_SHARE_READ | FILE_SHARE_DELETE, NULL,
+                                OPEN_ALWAYS, FILE_ATTRIBUTE_NORMAL | FILE_FLAG_DELETE_ON_CLOSE, NULL);
+    if (handle == INVALID_HANDLE_VALUE) {
+        if (GetLastError() != ERROR_SHARING_VIOLATION) {
+            return -1;
+        }
+        FILE *fp = fopen(path, "r");
+        if (fp) {
+            *owner = read_owner(fp);
+            fclose(fp);
+        }
+        return 1;
+    }
+
+    char text[32];
+    int length = snprintf(text, sizeof(text), "%ld\n", (long)GetCurrentProcessId());
+    DWORD written = 0;
+    if (!SetEndOfFile(handle) || !WriteFile(handle, text, (DWORD)length, &written, NULL) ||
+        written != (DWORD)length) {
+        CloseHandle(handle);
+        return -1;
+    }
+    lock->handle = handle;
+    lock->held = 1;
+    return 0;
+}
+
+void index_lock_release(IndexLock *lock) {
+    if (lock->held) {
+        CloseHandle((HANDLE)lock->handle);  /* Deletes the file */
+        lock->held = 0;
+    }
+}
+#else
+#include <fcntl.h>
+#include <sys/file.h>
+#include <sys/stat.h>
+
+/* POSIX: the lock is flock() on the lock file, which the kernel drops when
+ * the process exits in any way, so a lock left by a killed run is never
+ * taken for a live one, and two runs cannot both reclaim it */
+int index_lock_acquire(IndexLock *lock, const char *path, long *owner) {
+    memset(lock, 0, sizeof(*lock));
+    snprintf(lock->path, sizeof(lock->path), "%s", path);
+    lock->fd = -1;
+    *owner = 0;
+
+    for (int attempt = 0; attempt < LOCK_ATTEMPTS; attempt++) {
+        int fd = open(path, O_RDWR | O_CREAT | O_CLOEXEC, 0644);
+        if (fd < 0) {
+            return -1;
+        }
+        if (flock(fd, LOCK_EX | LOCK_NB) != 0) {
+            int busy = errno == EWOULDBLOCK;
+            if (busy) {
+                FILE *fp = fdopen(fd, "r");
+                if (fp) {
+                    *owner = read_owner(fp);
+                    fclose(fp);
+                    fd = -1;
+                }
+            }
+            if (fd >= 0) {
+                close(fd);
+            }
+            return busy ? 1 : -1;
+        }
+
+        /* Locked after its holder removed it: the lock is the file at path */
+        struct stat locked, current;
+        if (fstat(fd, &locked) != 0 || stat(path, &current) != 0 ||
+            locked.st_dev != current.st_dev || locked.st_ino != current.st_ino) {
+            close(fd);
+            continue;
+        }
+
+        char text[32];
+        int length = snprintf(text, sizeof(text), "%ld\n", (long)getpid());
+        if (ftruncate(fd, 0) != 0 || write(fd, text, (size_t)length) != (ssize_t)length) {
+            remove(path);
+            close(fd);
+            return -1;
+        }
+        lock->fd = fd;
+        lock->held = 1;
+        return 0;
+    }
+    return -1;
+}
+
+void index_lock_release(IndexLock *lock) {
+    if (lock->held) {
+        /* Removed while still locked, so no run locks this file afterwards */
+        remove(lock->path);
+        close(lock->fd);
+        lock->fd = -1;
+        lock->held = 0;
+    }
+}
+#endif
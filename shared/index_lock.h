@@ -0,0 +1,62 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef INDEX_LOCK_H
+#define INDEX_LOCK_H
+
+#include <stddef.h>
+#include "constants.h"
+
+/*
+ * One indexing run per language and database
+ *
+ * An indexing run creates "<db>.<language>.lock", containing its PID, and
+ * removes it when it exits. A second run of the same language on the same
+ * database would replace the rows of the same files as the first one
+ * writes them, so it stops instead. Indexers of different languages still share a
+ * database (index-code runs them together); SQLite serializes their
+ * writes.
+ *
+ * The lock itself is held on the open lock file (flock(), or on Windows
+ * the file open without write sharing), so the system releases it when a
+ * run is killed: the file such a run leaves behind is locked again by the
+ * next run, without checking whether its PID still runs.
+ */
+
+typedef struct {
+    char path[PATH_MAX_LENGTH];
+    int held;
+#if defined(_WIN32) || defined(__MINGW32__) || defined(__MINGW64__)
+    void *handle;
+#else
+    int fd;
+#endif
+} IndexLock;
+
+/* Lock file of language's runs on db_path */
+void index_lock_path(const char *db_path, const char *language, char *path, size_t size);
+
+/* Take the lock at path
+ * Returns: 0 if it is now held, 1 if a running process holds it (its PID
+ *          in *owner, or 0 if it has not written it yet), -1 if the lock
+ *          file cannot be written */
+int index_lock_acquire(IndexLock *lock, const char *path, long *owner);
+
+/* Remove the lock file, if held */
+void index_lock_release(IndexLock *lock);
+
+#endif /* INDEX_LOCK_H */
@@ -0,0 +1,119 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "index_source.h"
+#include "parse_errors.h"
+#include "source_file.h"
+#include "custom_extractors.h"
+#include "identifier_tokens.h"
+#include "constants.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+struct SourceIndexer {
+    const IndexerConfig *config;
+    SymbolFilter filter;
+    void *parser;
+    char language[LANGUAGE_MAX_LENGTH];
+};
+
+int index_source_parse(const IndexerConfig *config, void *parser, const SymbolFilter *filter,
+                       const char *filepath, const char *project_root, ParseResult *result,
+                       char *error, size_t error_size) {
+    if (parse_file_guarded(config->parser_parse, parser, filepath, project_root, result,
+                           error, error_size) != 0) {
+        return -1;
+    }
+    custom_extract_file(&filter->extractors, filepath, project_root, result);
+    tokenize_result(result, filter->split_rules);
+    if (sort_parse_result_by_line(result) != 0) {
+        fprintf(stderr, "Warning: out of memory sorting symbols of %s\n", filepath);
+    }
+    return 0;
+}
+
+SourceIndexer *source_indexer_new(const IndexerConfig *config) {
+    SourceIndexer *indexer = calloc(1, sizeof(SourceIndexer));
+    if (!indexer) {
+        fprintf(stderr, "Failed to allocate memory for source indexer\n");
+        return NULL;
+    }
+    indexer->config = config;
+
+    /* As get_language_name() in indexer_main.c: "go/config" -> "go" */
+    const char *slash = strchr(config->data_dir, '/');
+    size_t len = slash ? (size_t)(slash - config->data_dir) : strlen(config->data_dir);
+    snprintf(indexer->language, sizeof(indexer->language), "%.*s", (int)len, config->data_dir);
+
+    if (filter_init(&indexer->filter, config->data_dir) != 0) {
+        fprintf(stderr, "Warning: Failed to load filter data\n");
+    }
+    indexer->parser = config->parser_init(&indexer->filter);
+    if (!indexer->parser) {
+        report_parser_init_failure(config, NULL);
+        filter_free_regex(&indexer->filter);
+        free(indexer);
+        return NULL;
+    }
+    return indexer;
+}
+
+int source_indexer_index(SourceIndexer *indexer, const char *path, const char *content, size_t length,
+                         ParseResult *result, char *error, size_t error_size) {
+    char cwd[PATH_MAX_LENGTH];
+    if (getcwd(cwd, sizeof(cwd)) == NULL) {
+        snprintf(cwd, sizeof(cwd), ".");
+    }
+
+    if (content) {
+        source_file_substitute(path, content, length);
+    }
+    int status = index_source_parse(indexer->config, indexer->parser, &indexer->filter, path, cwd,
+                                    result, error, error_size);
+    if (content) {
+        source_file_substitute(NULL, NULL, 0);
+    }
+    if (status != 0) {
+        return -1;
+    }
+    truncate_long_symbols(result, indexer->filter.max_symbol_length, path);
+    set_result_language(result, indexer->language);
+    return 0;
+}
+
+void source_indexer_free(SourceIndexer *indexer) {
+    if (!indexer) {
+        return;
+    }
+    indexer->config->parser_free(indexer->parser);
+    filter_free_regex(&indexer->filter);
+    free(indexer);
+}
+
+int index_source(const IndexerConfig *config, const char *path, const char *content, size_t length,
+                 ParseResult *result, char *error, size_t error_size) {
+    SourceIndexer *indexer = source_indexer_new(config);
+    if (!indexer) {
+        snprintf(error, error_size, "parser could not be created");
+        return -1;
+    }
+    int status = source_indexer_index(indexer, path, content, length, result, error, error_size);
+    source_indexer_free(indexer);
+    return status;
+}
@@ -0,0 +1,88 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef INDEX_SOURCE_H
+#define INDEX_SOURCE_H
+
+#include <stddef.h>
+#include "indexer_main.h"
+
+/*
+ * Indexing one file's source, without a database (embedding SourceMinder)
+ *
+ * index_source_parse() is the per-file step every indexing path shares:
+ * the guarded parse (parse_errors.h), custom extractor matches, and the
+ * entries in line order. The parse workers, watch mode and serve are
+ * wrappers that store its result; a program linking the shared code and a
+ * language's parser can use it the same way, or through a SourceIndexer,
+ * which also loads the language's filter and owns a parser.
+ *
+ * The language is picked by its IndexerConfig, the one each indexer's
+ * main() passes to indexer_main(). Source may be given in memory, e.g. an
+ * unsaved editor buffer: it is parsed as if it were the file at path,
+ * which need not exist. The symbols are the IndexEntry rows of the result
+ * (database.h), exactly as they would be stored: directory and filename
+ * relative to the current directory, language set, names cut to the
+ * filter's length limit.
+ */
+
+/* Parse filepath into result (cleared first)
+ *
+ * Parameters:
+ *   config       - Language callbacks
+ *   parser       - From config->parser_init(filter)
+ *   filter       - The parser's filter (its custom extractors are run)
+ *   filepath     - File to parse
+ *   project_root - Root for relative paths
+ *   result       - Output entries
+ *   error        - Output: why the file could not be parsed ("" if it was)
+ *
+ * Returns: 0 on success, -1 if the file could not be parsed
+ */
+int index_source_parse(const IndexerConfig *config, void *parser, const SymbolFilter *filter,
+                       const char *filepath, const char *project_root, ParseResult *result,
+                       char *error, size_t error_size);
+
+/* A language's filter and parser, kept for indexing many sources */
+typedef struct SourceIndexer SourceIndexer;
+
+/* Load the filter from config->data_dir and create a parser
+ * Returns: the indexer, or NULL after reporting why on stderr */
+SourceIndexer *source_indexer_new(const IndexerConfig *config);
+
+/* Index content as the file at path, or the file itself if content is NULL
+ *
+ * Parameters:
+ *   indexer - From source_indexer_new()
+ *   path    - Name the symbols are recorded under
+ *   content - Source text (need not be NUL-terminated), or NULL
+ *   length  - Bytes of content
+ *   result  - Output entries (init_parse_result() first; reused per call)
+ *   error   - Output: why the source could not be parsed ("" if it was)
+ *
+ * Returns: 0 on success, -1 if the source could not be parsed
+ */
+int source_indexer_index(SourceIndexer *indexer, const char *path, const char *content, size_t length,
+                         ParseResult *result, char *error, size_t error_size);
+
+void source_indexer_free(SourceIndexer *indexer);
+
+/* One-off source_indexer_index() with a temporary indexer */
+int index_source(const IndexerConfig *config, const char *path, const char *content, size_t length,
+                 ParseResult *result, char *error, size_t error_size);
+
+#endif /* INDEX_SOURCE_H */
@@ -0,0 +1,131 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "index_stats.h"
+#include "ndjson.h"
+#include <stdlib.h>
+#include <string.h>
+
+static void add_kind(IndexStats *stats, const char *kind, long long count) {
+    for (int i = 0; i < stats->kind_count; i++) {
+        if (strcmp(stats->kinds[i].kind, kind) == 0) {
+            stats->kinds[i].count += count;
+            return;
+        }
+    }
+    if (stats->kind_count < INDEX_STATS_MAX_KINDS) {
+        stats->kinds[stats->kind_count].kind = kind;
+        stats->kinds[stats->kind_count].count = count;
+        stats->kind_count++;
+    }
+}
+
+int index_stats_collect(CodeIndexDatabase *db, const char *language, const IndexStatsRoot *roots,
+                        int root_count, IndexStats *stats) {
+    static const char *fixed[] = INDEX_STATS_FIXED_KINDS;
+
+    stats->symbols = 0;
+    stats->embedded = 0;
+    stats->kind_count = 0;
+    for (size_t i = 0; i < sizeof(fixed) / sizeof(fixed[0]); i++) {
+        add_kind(stats, fixed[i], 0);
+    }
+
+    /* symbol_kind() only looks at context and clue */
+    IndexEntry *entry = calloc(1, sizeof(IndexEntry));
+    if (!entry) {
+        fprintf(stderr, "Failed to allocate memory for symbol counts\n");
+        return -1;
+    }
+
+    sqlite3_stmt *stmt;
+    const char *sql =
+        "SELECT context, clue, COUNT(*) FROM code_index"
+        " WHERE language = ?1 AND context NOT IN (?4, ?5, ?6)"
+        "   AND CASE WHEN ?3 = '' THEN substr(directory, 1, length(?2)) = ?2"
+        "            ELSE directory = ?2 AND filename = ?3 END"
+        " GROUP BY context, clue";
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Failed to count symbols: %s\n", sqlite3_errmsg(db->db));
+        free(entry);
+        return -1;
+    }
+    sqlite3_bind_text(stmt, 1, language, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 4, context_to_string(CONTEXT_COMMENT, 1), -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 5, context_to_string(CONTEXT_STRING, 1), -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 6, context_to_string(CONTEXT_FILENAME, 1), -1, SQLITE_STATIC);
+
+    int rc = SQLITE_DONE;
+    for (int r = 0; r < root_count && rc == SQLITE_DONE; r++) {
+        sqlite3_reset(stmt);
+        sqlite3_bind_text(stmt, 2, roots[r].directory, -1, SQLITE_STATIC);
+        sqlite3_bind_text(stmt, 3, roots[r].filename, -1, SQLITE_STATIC);
+        while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+            const char *context = (const char *)sqlite3_column_text(stmt, 0);
+            const char *clue = (const char *)sqlite3_column_text(stmt, 1);
+            long long count = sqlite3_column_int64(stmt, 2);
+
+            entry->context = string_to_context(context ? context : "");
+            snprintf(entry->clue, sizeof(entry->clue), "%s", clue ? clue : "");
+            add_kind(stats, symbol_kind(entry), count);
+            if (entry->context == CONTEXT_PROPERTY && strcmp(entry->clue, "embedded") == 0) {
+                stats->embedded += count;
+            }
+            stats->symbols += count;
+        }
+    }
+    sqlite3_finalize(stmt);
+    free(entry);
+
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Failed to count symbols: %s\n", sqlite3_errmsg(db->db));
+        return -1;
+    }
+    return 0;
+}
+
+void index_stats_print(FILE *out, const IndexStats *stats, int json) {
+    if (json) {
+        fprintf(out, "{\"files\":{\"processed\":%d,\"parsed\":%d,\"unchanged\":%d,\"failed\":%d,"
+                "\"skipped\":%d,\"generated\":%d},",
+                stats->files, stats->parsed, stats->unchanged, stats->failed, stats->skipped,
+                stats->generated);
+        fprintf(out, "\"bytes\":%lld,\"elapsed_seconds\":%.3f,", stats->bytes, stats->elapsed);
+        fprintf(out, "\"symbols\":{\"total\":%lld", stats->symbols);
+        for (int i = 0; i < stats->kind_count; i++) {
+            fputc(',', out);
+            json_write_string(out, stats->kinds[i].kind);
+            fprintf(out, ":%lld", stats->kinds[i].count);
+        }
+        fprintf(out, ",\"embedded\":%lld}}\n", stats->embedded);
+        fflush(out);
+        return;
+    }
+
+    fprintf(out, "Index statistics:\n");
+    fprintf(out, "  Files:    %d processed (%d parsed, %d unchanged, %d failed, %d skipped, %d generated)\n",
+            stats->files, stats->parsed, stats->unchanged, stats->failed, stats->skipped,
+            stats->generated);
+    fprintf(out, "  Bytes:    %lld\n", stats->bytes);
+    fprintf(out, "  Elapsed:  %.3fs\n", stats->elapsed);
+    fprintf(out, "  Symbols:  %lld\n", stats->symbols);
+    for (int i = 0; i < stats->kind_count; i++) {
+        fprintf(out, "    %-12s %lld\n", stats->kinds[i].kind, stats->kinds[i].count);
+    }
+    fprintf(out, "    %-12s %lld\n", "embedded", stats->embedded);
+    fflush(out);
+}
@@ -0,0 +1,84 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef INDEX_STATS_H
+#define INDEX_STATS_H
+
+#include <stdio.h>
+#include "database.h"
+
+/*
+ * Indexing statistics (--stats)
+ *
+ * Symbol counts are read back from the index once the run has committed, so
+ * they cover every worker and the files kept unchanged from earlier runs
+ * rather than what one worker saw. Only the rows of the run's language under
+ * its targets are counted, and comments, strings and filenames are not
+ * symbols. Kinds are the ones symbol_kind() reports in NDJSON; "embedded"
+ * counts embedded fields, which are also counted as "field".
+ */
+
+/* Kinds always reported, even when zero, so a kind that disappears shows up
+ * as 0 rather than as a missing key */
+#define INDEX_STATS_FIXED_KINDS { "struct", "interface", "alias", "type", "func", "field" }
+
+/* Enough for every kind symbol_kind() can return */
+#define INDEX_STATS_MAX_KINDS 32
+
+typedef struct {
+    const char *kind;          /* Static string from symbol_kind() */
+    long long count;
+} KindCount;
+
+typedef struct {
+    int files;                 /* Files processed (parsed + unchanged + failed + skipped + generated) */
+    int parsed;                /* Files parsed this run */
+    int unchanged;             /* Files kept from the index */
+    int failed;                /* Files that could not be parsed */
+    int skipped;               /* Files over --max-file-size */
+    int generated;             /* Generated files left out (--exclude-generated) */
+    long long bytes;           /* Total size of the files processed */
+    double elapsed;            /* Wall-clock seconds of the run */
+    long long symbols;         /* Symbols under the targets */
+    long long embedded;        /* Embedded fields */
+    KindCount kinds[INDEX_STATS_MAX_KINDS];
+    int kind_count;
+} IndexStats;
+
+/* A target of the run as stored: the files under a directory prefix
+ * ("src/"), or one file when filename is set */
+typedef struct {
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+} IndexStatsRoot;
+
+/* Count the symbols of language under roots by kind (run counters are left
+ * as set); roots should not overlap
+ *
+ * Returns: 0 on success, -1 on database error
+ */
+int index_stats_collect(CodeIndexDatabase *db, const char *language, const IndexStatsRoot *roots,
+                        int root_count, IndexStats *stats);
+
+/* Print as an indented text block, or as one JSON object on one line:
+ *   {"files":{"processed":N,"parsed":N,"unchanged":N,"failed":N,"skipped":N,"generated":N},
+ *    "bytes":N,"elapsed_seconds":S,
+ *    "symbols":{"total":N,"struct":N,...,"embedded":N}}
+ */
+void index_stats_print(FILE *out, const IndexStats *stats, int json);
+
+#endif /* INDEX_STATS_H */
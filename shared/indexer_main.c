@@ -27,12 +27,38 @@
 #include "extensions.h"
 #include "parse_result.h"
 #include "version.h"
+#include "file_utils.h"
+#include "ndjson.h"
+#include "embeds.h"
+#include "search.h"
+#include "parse_pool.h"
+#include "parse_errors.h"
+#include "index_stats.h"
+#include "index_source.h"
+#include "duplicates.h"
+#include "git_changes.h"
+#include "deps.h"
+#include "implements.h"
+#include "serve.h"
+#include "symbol_at.h"
+#include "index_diff.h"
+#include "progress.h"
+#include "unified_config.h"
+#include "archive.h"
+#include "source_file.h"
+#include "atomic_file.h"
+#include "index_lock.h"
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
 #include <sys/stat.h>
 #include <unistd.h>
 #include <signal.h>
+#include <stdint.h>
+#include <time.h>
+#include <time.h>
+#include <errno.h>
+#include <limits.h>
 
 typedef enum {
     MODE_DIRECTORIES,
@@ -61,6 +87,370 @@ static int db_exists(const char *path) {
     return (stat(path, &st) == 0);
 }
 
+/* ============================================================================
+ * File stamps (watch mode)
+ * ============================================================================
+ * Modification time and size recorded for every indexed file, keyed by the
+ * same directory + filename pair stored in the database. Watch mode uses it to
+ * skip events that did not change a file (editors often touch files without
+ * writing them) and forgets a file once its symbols are purged.
+ *
+ * A file modified in the second it was stamped may have been written again
+ * since without its stamp changing (file systems without subsecond times,
+ * or coarse ones); such a stamp only says the content must be compared.
+ */
+
+typedef struct {
+    char *key;          /* directory + filename, NULL if slot unused */
+    time_t mtime;
+    long mtime_nsec;
+    off_t size;
+    time_t stamped;     /* When the stamp was recorded */
+    int indexed;        /* 0 after the file was purged */
+} FileStamp;
+
+typedef struct {
+    FileStamp *slots;
+    size_t capacity;    /* Always a power of two */
+    size_t used;        /* Slots with a key (indexed or not) */
+} FileStampTable;
+
+#define FILE_STAMP_INITIAL_CAPACITY 1024
+
+static uint32_t hash_key(const char *key) {
+    /* FNV-1a */
+    uint32_t hash = 2166136261u;
+    for (const unsigned char *p = (const unsigned char *)key; *p; p++) {
+        hash ^= *p;
+        hash *= 16777619u;
+    }
+    return hash;
+}
+
+static void stamp_table_init(FileStampTable *table) {
+    table->capacity = FILE_STAMP_INITIAL_CAPACITY;
+    table->used = 0;
+    table->slots = calloc(table->capacity, sizeof(FileStamp));
+    if (!table->slots) {
+        fprintf(stderr, "Error: Failed to allocate file stamp table\n");
+        exit(1);
+    }
+}
+
+static void stamp_table_free(FileStampTable *table) {
+    for (size_t i = 0; i < table->capacity; i++) {
+        free(table->slots[i].key);
+    }
+    free(table->slots);
+    table->slots = NULL;
+    table->capacity = 0;
+    table->used = 0;
+}
+
+/* Find the slot for key: either the slot holding it or the empty slot where it belongs */
+static FileStamp *stamp_table_slot(FileStamp *slots, size_t capacity, const char *key) {
+    size_t mask = capacity - 1;
+    size_t i = hash_key(key) & mask;
+    while (slots[i].key && strcmp(slots[i].key, key) != 0) {
+        i = (i + 1) & mask;
+    }
+    return &slots[i];
+}
+
+static void stamp_table_grow(FileStampTable *table) {
+    size_t new_capacity = table->capacity * 2;
+    FileStamp *new_slots = calloc(new_capacity, sizeof(FileStamp));
+    if (!new_slots) {
+        fprintf(stderr, "Error: Failed to grow file stamp table\n");
+        exit(1);
+    }
+    for (size_t i = 0; i < table->capacity; i++) {
+        if (table->slots[i].key) {
+            *stamp_table_slot(new_slots, new_capacity, table->slots[i].key) = table->slots[i];
+        }
+    }
+    free(table->slots);
+    table->slots = new_slots;
+    table->capacity = new_capacity;
+}
+
+/* Big enough for any directory + filename pair stored in the database */
+#define STAMP_KEY_MAX_LENGTH (DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH)
+
+static void stamp_key(char *key, size_t size, const char *directory, const char *filename) {
+    snprintf(key, size, "%s%s", directory, filename);
+}
+
+/* Subsecond part of a file's modification time (0 where stat has none) */
+static long stat_mtime_nsec(const struct stat *st) {
+#if defined(__APPLE__)
+    return st->st_mtimespec.tv_nsec;
+#elif defined(_WIN32) || defined(__MINGW32__) || defined(__MINGW64__)
+    (void)st;
+    return 0;
+#else
+    return st->st_mtim.tv_nsec;
+#endif
+}
+
+/* Returns 1 if the file was indexed with exactly this mtime and size, -1 if
+ * it was but was modified in the second it was stamped (the content may
+ * have changed since), 0 otherwise */
+static int stamp_table_unchanged(const FileStampTable *table, const char *key, const struct stat *st) {
+    const FileStamp *stamp = stamp_table_slot(table->slots, table->capacity, key);
+    if (!stamp->key || !stamp->indexed || stamp->mtime != st->st_mtime ||
+        stamp->mtime_nsec != stat_mtime_nsec(st) || stamp->size != st->st_size) {
+        return 0;
+    }
+    return st->st_mtime >= stamp->stamped ? -1 : 1;
+}
+
+static void stamp_table_set(FileStampTable *table, const char *key, const struct stat *st) {
+    /* Keep load factor below 1/2 */
+    if ((table->used + 1) * 2 > table->capacity) {
+        stamp_table_grow(table);
+    }
+    FileStamp *stamp = stamp_table_slot(table->slots, table->capacity, key);
+    if (!stamp->key) {
+        stamp->key = safe_strdup_ctx(key, "Failed to allocate file stamp key");
+        table->used++;
+    }
+    stamp->mtime = st->st_mtime;
+    stamp->mtime_nsec = stat_mtime_nsec(st);
+    stamp->size = st->st_size;
+    stamp->stamped = time(NULL);
+    stamp->indexed = 1;
+}
+
+static void stamp_table_forget(FileStampTable *table, const char *key) {
+    FileStamp *stamp = stamp_table_slot(table->slots, table->capacity, key);
+    if (stamp->key) {
+        stamp->indexed = 0;
+    }
+}
+
+/* Record a successfully indexed file (silently ignores files that vanished) */
+static void stamp_file(FileStampTable *table, const char *filepath, const char *project_root) {
+    struct stat st;
+    if (stat(filepath, &st) != 0) return;
+
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    char key[STAMP_KEY_MAX_LENGTH];
+    get_relative_path(filepath, project_root, directory, filename);
+    stamp_key(key, sizeof(key), directory, filename);
+    stamp_table_set(table, key, &st);
+}
+
+/* Write every entry of a parsed file as NDJSON. Flushed per file so
+ * consumers reading a pipe see symbols as soon as a file is indexed. */
+static void emit_ndjson(FILE *out, const ParseResult *result) {
+    if (!out) return;
+    for (int i = 0; i < result->count; i++) {
+        ndjson_write_entry(out, &result->entries[i]);
+    }
+    fflush(out);
+}
+
+/* Run the --flatten-embeds pass in its own transaction and report counts
+ * (packages: directories of the changed files, NULL for the whole index) */
+static void run_flatten_embeds(CodeIndexDatabase *db, const FileList *packages, int verbose, int silent,
+                               FILE *ndjson_out) {
+    EmbedStats stats;
+    db_begin_transaction(db);
+    const char *const *directories = packages ? (const char *const *)packages->files : NULL;
+    int directory_count = packages ? packages->count : 0;
+    if (flatten_interface_embeds(db, directories, directory_count, verbose && !silent, ndjson_out,
+                                 &stats) != 0) {
+        fprintf(stderr, "Warning: Failed to flatten interface embeds\n");
+        db_commit_transaction(db);
+        return;
+    }
+    db_commit_transaction(db);
+
+    if (!silent) {
+        printf("Flattened interface embeds: %d promoted methods, %d unresolved embeds\n",
+               stats.promoted, stats.unresolved);
+    }
+}
+
+/* Recompute interface satisfaction in its own transaction and report counts
+ * (packages: directories of the changed files, NULL for the whole index) */
+static void run_implements(CodeIndexDatabase *db, const char *language, const FileList *packages,
+                           int verbose, int silent) {
+    ImplementsStats stats;
+    db_begin_transaction(db);
+    const char *const *directories = packages ? (const char *const *)packages->files : NULL;
+    int directory_count = packages ? packages->count : 0;
+    if (implements_update(db, language, directories, directory_count, verbose && !silent, &stats) != 0) {
+        fprintf(stderr, "Warning: Failed to compute interface satisfaction\n");
+        db_commit_transaction(db);
+        return;
+    }
+    db_commit_transaction(db);
+
+    if (!silent && stats.interfaces > 0) {
+        printf("Interface satisfaction: %d implements, %d possibly implements (%d interfaces)\n",
+               stats.implements, stats.possibly, stats.interfaces);
+    }
+}
+
+/* Whether root covers the files of other (a directory prefix of it) */
+static int stats_root_covers(const IndexStatsRoot *root, const IndexStatsRoot *other) {
+    return root->filename[0] == '\0' &&
+           strncmp(other->directory, root->directory, strlen(root->directory)) == 0;
+}
+
+/* The targets of the run as --stats counts them: directories and archives
+ * (whose entries are stored under the archive's path) by prefix, files one
+ * by one. Targets inside another one are left out, so nothing is counted
+ * twice.
+ * Returns: malloc'd roots (*count of them), or NULL if out of memory */
+static IndexStatsRoot *stats_roots(char **targets, int target_count, int *count) {
+    *count = 0;
+    char cwd[PATH_MAX_LENGTH];
+    if (getcwd(cwd, sizeof(cwd)) == NULL) {
+        snprintf(cwd, sizeof(cwd), ".");
+    }
+    IndexStatsRoot *roots = malloc(sizeof(IndexStatsRoot) * (size_t)(target_count > 0 ? target_count : 1));
+    if (!roots) {
+        return NULL;
+    }
+    for (int i = 0; i < target_count; i++) {
+        IndexStatsRoot *root = &roots[i];
+        struct stat st;
+        if ((stat(targets[i], &st) == 0 && S_ISDIR(st.st_mode)) || is_archive_path(targets[i])) {
+            /* The directory of a file inside it, as the deleted-file purge does */
+            char probe[PATH_MAX_LENGTH];
+            size_t target_len = strlen(targets[i]);
+            snprintf(probe, sizeof(probe), "%s%s-", targets[i],
+                     (target_len > 0 && targets[i][target_len - 1] == '/') ? "" : "/");
+            get_relative_path(probe, cwd, root->directory, root->filename);
+            root->filename[0] = '\0';
+        } else {
+            get_relative_path(targets[i], cwd, root->directory, root->filename);
+        }
+    }
+    for (int i = 0; i < target_count; i++) {
+        int covered = 0;
+        for (int j = 0; j < target_count && !covered; j++) {
+            if (j == i || !stats_root_covers(&roots[j], &roots[i])) {
+                continue;
+            }
+            /* Of two equal roots the first one is kept */
+            covered = !stats_root_covers(&roots[i], &roots[j]) || j < i;
+        }
+        if (!covered) {
+            roots[(*count)++] = roots[i];
+        }
+    }
+    return roots;
+}
+
+/* Check if a watched path should be ignored based on ignore_files.txt and
+ * .sourceminderignore. Need to check each directory component in the path,
+ * as the initial walk does; the last one is a directory if is_dir is set */
+static int is_watch_path_ignored(const char *path, int is_dir, const WordSet *ignore_dirs,
+                                 const IgnoreRules *rules) {
+    char path_copy[PATH_MAX_LENGTH];
+    int written = snprintf(path_copy, sizeof(path_copy), "%s", path);
+    if (written >= (int)sizeof(path_copy)) {
+        fprintf(stderr, "FATAL: filepath exceeds PATH_MAX_LENGTH (%d): %s\n",
+                PATH_MAX_LENGTH, path);
+        exit(1);
+    }
+
+    /* Use strtok_r for thread safety (future-proofing) */
+    char *saveptr;
+    char *token = strtok_r(path_copy, "/", &saveptr);
+    char partial_path[PATH_MAX_LENGTH] = "";
+    if (path[0] == '/') {
+        partial_path[0] = '/';
+        partial_path[1] = '\0';
+    }
+
+    while (token != NULL) {
+        /* Build partial path */
+        size_t partial_len = strlen(partial_path);
+        if (partial_len > 0 && partial_path[partial_len - 1] != '/') {
+            strncat(partial_path, "/", sizeof(partial_path) - partial_len - 1);
+        }
+        strncat(partial_path, token, sizeof(partial_path) - strlen(partial_path) - 1);
+
+        /* Check if this component (a directory unless it's the last) should be ignored */
+        char *next = strtok_r(NULL, "/", &saveptr);
+        if (is_entry_ignored(partial_path, token, next != NULL || is_dir, ignore_dirs, rules)) {
+            return 1;
+        }
+        token = next;
+    }
+    return 0;
+}
+
+/* Stored files kept by purge_missing_under(): those that still exist */
+static int keep_existing_file(const char *directory, const char *filename, void *ctx) {
+    if (db_stored_file_exists(directory, filename)) {
+        return 1;
+    }
+    char key[STAMP_KEY_MAX_LENGTH];
+    stamp_key(key, sizeof(key), directory, filename);
+    stamp_table_forget((FileStampTable *)ctx, key);
+    return 0;
+}
+
+/* Add a stored directory to the packages whose interface satisfaction is
+ * recomputed, once */
+static void add_changed_package(FileList *packages, const char *directory) {
+    for (int i = 0; i < packages->count; i++) {
+        if (strcmp(packages->files[i], directory) == 0) {
+            return;
+        }
+    }
+    add_file_to_list(packages, directory);
+}
+
+/* Purge the indexed files under a directory that are gone (it was deleted,
+ * renamed or moved out of the tree); its directory is added to packages
+ * Returns: number of files purged */
+static int purge_missing_under(CodeIndexDatabase *db, FileStampTable *stamps, const char *dirpath,
+                               const char *project_root, FileList *packages) {
+    char path[PATH_MAX_LENGTH];
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    int written = snprintf(path, sizeof(path), "%s/-", dirpath);  /* Any name: only its directory is used */
+    if (written < 0 || (size_t)written >= sizeof(path)) {
+        return 0;
+    }
+    get_relative_path(path, project_root, directory, filename);
+    int removed = db_delete_files_unless(db, directory, keep_existing_file, stamps);
+    if (removed <= 0) {
+        return 0;
+    }
+    add_changed_package(packages, directory);
+    return removed;
+}
+
+/* Check a file's content against the hash stored when it was indexed */
+static int stored_hash_matches(CodeIndexDatabase *db, const SymbolFilter *filter, const char *filepath,
+                               const char *directory, const char *filename) {
+    char hash[FILE_HASH_LENGTH];
+    char stored[FILE_HASH_LENGTH];
+    if (hash_file_contents(filepath, hash, sizeof(hash)) != 0) {
+        return 0;
+    }
+    filter_mix_config_hash(filter, hash);
+    return db_get_file_hash(db, directory, filename, stored, sizeof(stored)) &&
+           strcmp(hash, stored) == 0;
+}
+
+/* What the watch loop did with one file event */
+typedef enum {
+    WATCH_SKIPPED,      /* Ignored directory, or parse failed */
+    WATCH_UNCHANGED,    /* Same mtime and size as when last indexed */
+    WATCH_REINDEXED,
+    WATCH_REMOVED
+} WatchAction;
+
 /* Flag bits for tracking which CLI flags are present */
 #define FLAG_ONCE        (1 << 0)
 #define FLAG_QUIET_INIT  (1 << 1)
@@ -69,6 +459,16 @@ static int db_exists(const char *path) {
 #define FLAG_DB_FILE     (1 << 4)
 #define FLAG_EXCLUDE_DIR (1 << 5)
 #define FLAG_ECHO        (1 << 6)
+#define FLAG_WATCH       (1 << 7)
+#define FLAG_FORMAT      (1 << 8)
+#define FLAG_OUTPUT      (1 << 9)
+#define FLAG_FLATTEN     (1 << 10)
+#define FLAG_WORKERS     (1 << 11)
+#define FLAG_MAX_SIZE    (1 << 12)
+#define FLAG_QUIET       (1 << 13)
+#define FLAG_GENERATED   (1 << 14)
+#define FLAG_EXTENSIONS  (1 << 15)
+#define FLAG_EXTENSIONS_ADD (1 << 16)
 
 /* Scan CLI arguments to detect which flags are present (before config loading) */
 static int scan_cli_flags(int argc, char *argv[]) {
@@ -76,24 +476,46 @@ static int scan_cli_flags(int argc, char *argv[]) {
     for (int i = 1; i < argc; i++) {
         if (strcmp(argv[i], "--once") == 0) flags |= FLAG_ONCE;
         else if (strcmp(argv[i], "--quiet-init") == 0) flags |= FLAG_QUIET_INIT;
+        else if (strcmp(argv[i], "--quiet") == 0) flags |= FLAG_QUIET;
         else if (strcmp(argv[i], "--silent") == 0) flags |= FLAG_SILENT;
         else if (strcmp(argv[i], "--verbose") == 0) flags |= FLAG_VERBOSE;
         else if (strcmp(argv[i], "--db-file") == 0 || strcmp(argv[i], "-f") == 0) flags |= FLAG_DB_FILE;
         else if (strcmp(argv[i], "--exclude-dir") == 0) flags |= FLAG_EXCLUDE_DIR;
         else if (strcmp(argv[i], "--echo") == 0) flags |= FLAG_ECHO;
+        else if (strcmp(argv[i], "--watch") == 0) flags |= FLAG_WATCH;
+        else if (strncmp(argv[i], "--format", 8) == 0) flags |= FLAG_FORMAT;
+        else if (strncmp(argv[i], "--output", 8) == 0) flags |= FLAG_OUTPUT;
+        else if (strcmp(argv[i], "--flatten-embeds") == 0) flags |= FLAG_FLATTEN;
+        else if (strncmp(argv[i], "--workers", 9) == 0) flags |= FLAG_WORKERS;
+        else if (strncmp(argv[i], "--max-file-size", 15) == 0) flags |= FLAG_MAX_SIZE;
+        else if (strcmp(argv[i], "--exclude-generated") == 0) flags |= FLAG_GENERATED;
+        else if (strncmp(argv[i], "--extensions-add", 16) == 0) flags |= FLAG_EXTENSIONS_ADD;
+        else if (strncmp(argv[i], "--extensions", 12) == 0) flags |= FLAG_EXTENSIONS;
     }
     return flags;
 }
 
 /* Check if a config line should be skipped (CLI flag takes precedence) */
 static int should_skip_config_line(const char *line, int cli_flags) {
-    if ((cli_flags & FLAG_ONCE) && strstr(line, "--once") == line) return 1;
+    /* --once and --watch are mutually exclusive; either one on the CLI overrides both in config */
+    if ((cli_flags & (FLAG_ONCE | FLAG_WATCH)) && strstr(line, "--once") == line) return 1;
+    if ((cli_flags & (FLAG_ONCE | FLAG_WATCH)) && strstr(line, "--watch") == line) return 1;
     if ((cli_flags & FLAG_QUIET_INIT) && strstr(line, "--quiet-init") == line) return 1;
+    if ((cli_flags & FLAG_QUIET) && strcmp(line, "--quiet") == 0) return 1;
     if ((cli_flags & FLAG_SILENT) && strstr(line, "--silent") == line) return 1;
     if ((cli_flags & FLAG_VERBOSE) && strstr(line, "--verbose") == line) return 1;
     if ((cli_flags & FLAG_DB_FILE) && (strstr(line, "--db-file") == line || strstr(line, "-f") == line)) return 1;
     if ((cli_flags & FLAG_EXCLUDE_DIR) && strstr(line, "--exclude-dir") == line) return 1;
     if ((cli_flags & FLAG_ECHO) && strstr(line, "--echo") == line) return 1;
+    if ((cli_flags & FLAG_FORMAT) && strstr(line, "--format") == line) return 1;
+    if ((cli_flags & FLAG_OUTPUT) && strstr(line, "--output") == line) return 1;
+    if ((cli_flags & FLAG_FLATTEN) && strstr(line, "--flatten-embeds") == line) return 1;
+    if ((cli_flags & FLAG_WORKERS) && strstr(line, "--workers") == line) return 1;
+    if ((cli_flags & FLAG_MAX_SIZE) && strstr(line, "--max-file-size") == line) return 1;
+    if ((cli_flags & FLAG_GENERATED) && strcmp(line, "--exclude-generated") == 0) return 1;
+    if ((cli_flags & FLAG_EXTENSIONS_ADD) && strstr(line, "--extensions-add") == line) return 1;
+    if ((cli_flags & FLAG_EXTENSIONS) && strstr(line, "--extensions") == line &&
+        strstr(line, "--extensions-add") != line) return 1;
     return 0;
 }
 
@@ -188,59 +610,1673 @@ static void load_config_file(int *argc_ptr, char ***argv_ptr, int cli_flags) {
     *argv_ptr = new_argv;
 }
 
-static void print_usage(const IndexerConfig *config) {
-    printf("Usage: %s <directories...> [OPTIONS]\n", config->name);
-    printf("   or: %s <files...> [OPTIONS]\n", config->name);
-    printf("Index source code files and store symbols in a SQLite database for fast search.\n");
-    printf("Example: %s ./src --once\n", config->name);
-    printf("\n");
+/* Files of the initial pass, split by content hash into those to parse and
+ * those unchanged since the index was built (kept as stored); files over
+ * --max-file-size and, with --exclude-generated, generated files are neither */
+typedef struct {
+    char **all;                         /* Every file, in delivery order */
+    int all_count;
+    char (*hashes)[FILE_HASH_LENGTH];   /* Content hash per file ("" if unreadable) */
+    unsigned char *unchanged;           /* Per file: hash matches the index */
+    unsigned char *oversized;           /* Per file: over --max-file-size, not parsed */
+    unsigned char *generated;           /* Per file: generated (--exclude-generated), not parsed */
+    char **files;                       /* Files to parse (pointers into all) */
+    int *origin;                        /* Index in all of each file to parse */
+    int count;
+    int unchanged_count;
+    int oversized_count;
+    int generated_count;
+    long long bytes;                    /* Total size of all files */
+} ParsePlan;
+
+/* State for the initial indexing pass, filled in file order by the workers */
+typedef struct {
+    CodeIndexDatabase *db;
+    const SymbolFilter *filter;
+    FILE *ndjson_out;
+    FileStampTable *stamps;     /* NULL unless watching */
+    const char *project_root;
+    const char *language;       /* Stored with every symbol */
+    const ParsePlan *plan;
+    ParseErrorReport *errors;   /* Files that could not be parsed */
+    const GitChanges *changed;  /* --since: only these files are parsed (NULL: all) */
+    long long max_file_size;    /* --max-file-size (0: no limit) */
+    int exclude_generated;      /* --exclude-generated */
+    int replace_existing;       /* Delete a file's old rows before inserting */
+    int announce;               /* Print "Indexed ..." per file */
+    Progress *progress;         /* Progress line (disabled unless on a terminal) */
+    int parsed;                 /* Files parsed successfully */
+    int unchanged;              /* Files skipped because their content hash matched */
+    int skipped;                /* Files over max_file_size */
+    int generated;              /* Generated files left out */
+    long long bytes;            /* Total size of the files of the pass */
+    int delivered;              /* Files of plan->files seen by the callback */
+    int flushed;                /* Files of plan->all handled so far */
+} IndexPass;
+
+static void free_parse_plan(ParsePlan *plan) {
+    free(plan->hashes);
+    free(plan->unchanged);
+    free(plan->oversized);
+    free(plan->generated);
+    free(plan->files);
+    free(plan->origin);
+    memset(plan, 0, sizeof(*plan));
+}
+
+/* Hash every file, with the configuration mixed in, and compare with the
+ * index; with rebuild set all files are parsed (their hashes are still
+ * recorded). With changed set, files outside it are kept as stored without
+ * being read. Files larger than max_file_size (unless 0) are not read at
+ * all; with exclude_generated set, files with a generated code header are
+ * left out after reading their first lines.
+ * Returns: 0 on success, -1 if out of memory */
+static int plan_parse(ParsePlan *plan, CodeIndexDatabase *db, const SymbolFilter *filter,
+                      char **files, int count, const char *project_root, int rebuild,
+                      const GitChanges *changed, long long max_file_size, int exclude_generated) {
+    memset(plan, 0, sizeof(*plan));
+    plan->all = files;
+    plan->all_count = count;
+    size_t n = count > 0 ? (size_t)count : 1;
+    plan->hashes = calloc(n, sizeof(*plan->hashes));
+    plan->unchanged = calloc(n, 1);
+    plan->oversized = calloc(n, 1);
+    plan->generated = calloc(n, 1);
+    plan->files = calloc(n, sizeof(char *));
+    plan->origin = calloc(n, sizeof(int));
+    if (!plan->hashes || !plan->unchanged || !plan->oversized || !plan->generated ||
+        !plan->files || !plan->origin) {
+        free_parse_plan(plan);
+        return -1;
+    }
+
+    for (int i = 0; i < count; i++) {
+        struct stat st;
+        if (stat(files[i], &st) == 0) {
+            plan->bytes += (long long)st.st_size;
+            if (max_file_size > 0 && (long long)st.st_size > max_file_size) {
+                plan->oversized[i] = 1;
+                plan->oversized_count++;
+                continue;
+            }
+        }
+        if (exclude_generated && is_generated_file(files[i])) {
+            plan->generated[i] = 1;
+            plan->generated_count++;
+            continue;
+        }
+        if (changed && !git_changes_contains(changed, files[i])) {
+            plan->unchanged[i] = 1;
+            plan->unchanged_count++;
+            continue;
+        }
+        if (hash_file_contents(files[i], plan->hashes[i], FILE_HASH_LENGTH) != 0) {
+            plan->hashes[i][0] = '\0';
+        } else {
+            filter_mix_config_hash(filter, plan->hashes[i]);
+        }
+        if (plan->hashes[i][0] && !rebuild) {
+            char directory[DIRECTORY_MAX_LENGTH];
+            char filename[FILENAME_MAX_LENGTH];
+            char stored[FILE_HASH_LENGTH];
+            get_relative_path(files[i], project_root, directory, filename);
+            if (db_get_file_hash(db, directory, filename, stored, sizeof(stored)) &&
+                strcmp(stored, plan->hashes[i]) == 0) {
+                plan->unchanged[i] = 1;
+                plan->unchanged_count++;
+                continue;
+            }
+        }
+        plan->files[plan->count] = files[i];
+        plan->origin[plan->count] = i;
+        plan->count++;
+    }
+    return 0;
+}
+
+/* Write the stored rows of an unchanged file as NDJSON. Promoted methods are
+ * left out: --flatten-embeds recomputes (and writes) them */
+static void emit_stored_ndjson(CodeIndexDatabase *db, FILE *out, const char *filepath,
+                               const char *project_root) {
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    get_relative_path(filepath, project_root, directory, filename);
+
+    char sql[LINE_BUFFER_LARGE];
+    snprintf(sql, sizeof(sql),
+             "SELECT %s FROM code_index WHERE directory = ? AND filename = ? AND clue IS NOT 'promoted'"
+             " ORDER BY rowid", db_entry_columns());
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db->db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Warning: cannot read stored symbols of %s: %s\n", filepath, sqlite3_errmsg(db->db));
+        return;
+    }
+    sqlite3_bind_text(stmt, 1, directory, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 2, filename, -1, SQLITE_STATIC);
+
+    IndexEntry *entry = malloc(sizeof(IndexEntry));
+    if (entry) {
+        while (sqlite3_step(stmt) == SQLITE_ROW) {
+            db_read_entry(stmt, entry);
+            ndjson_write_entry(out, entry);
+        }
+        free(entry);
+    }
+    sqlite3_finalize(stmt);
+    fflush(out);
+}
+
+/* Log an oversized file and drop what the index holds for it, so its
+ * symbols don't outlive a regeneration that grew it past the limit */
+static void skip_oversized_file(CodeIndexDatabase *db, const char *filepath, const char *directory,
+                                const char *filename, long long size, long long max_file_size) {
+    fprintf(stderr, "Skipped %s: %lld bytes, over --max-file-size (%lld)\n",
+            filepath, size, max_file_size);
+    db_delete_by_file(db, directory, filename);
+}
+
+/* Handle the files of plan->all before index upto that are not parsed */
+static void flush_unparsed_files(IndexPass *pass, int upto) {
+    const ParsePlan *plan = pass->plan;
+    for (; pass->flushed < upto; pass->flushed++) {
+        const char *filepath = plan->all[pass->flushed];
+        if (plan->oversized[pass->flushed]) {
+            struct stat st;
+            char directory[DIRECTORY_MAX_LENGTH];
+            char filename[FILENAME_MAX_LENGTH];
+            get_relative_path(filepath, pass->project_root, directory, filename);
+            long long size = stat(filepath, &st) == 0 ? (long long)st.st_size : 0;
+            skip_oversized_file(pass->db, filepath, directory, filename, size, pass->max_file_size);
+            if (pass->stamps) {
+                stamp_file(pass->stamps, filepath, pass->project_root);
+            }
+            continue;
+        }
+        progress_update(pass->progress, pass->flushed + 1, plan->all_count, filepath);
+        if (plan->generated[pass->flushed]) {
+            /* Drop what an index built without --exclude-generated holds */
+            char directory[DIRECTORY_MAX_LENGTH];
+            char filename[FILENAME_MAX_LENGTH];
+            get_relative_path(filepath, pass->project_root, directory, filename);
+            db_delete_by_file(pass->db, directory, filename);
+            if (pass->stamps) {
+                stamp_file(pass->stamps, filepath, pass->project_root);
+            }
+            continue;
+        }
+        if (!plan->unchanged[pass->flushed]) {
+            continue;
+        }
+        if (pass->ndjson_out) {
+            emit_stored_ndjson(pass->db, pass->ndjson_out, filepath, pass->project_root);
+        }
+        if (pass->stamps) {
+            stamp_file(pass->stamps, filepath, pass->project_root);
+        }
+    }
+}
+
+static void index_parsed_file(const char *filepath, int status, const char *error,
+                              ParseResult *result, void *ctx) {
+    IndexPass *pass = (IndexPass *)ctx;
+    int origin = pass->plan->origin[pass->delivered++];
+    flush_unparsed_files(pass, origin);
+    pass->flushed = origin + 1;
+    progress_update(pass->progress, pass->flushed, pass->plan->all_count, filepath);
+    if (status != 0) {
+        /* Old rows and hash are kept, so the file is retried next run */
+        parse_report_add(pass->errors, filepath, error);
+        return;
+    }
+    truncate_long_symbols(result, pass->filter->max_symbol_length, filepath);
+    set_result_language(result, pass->language);
+
+    /* Same directory/filename pair the parser stores */
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    get_relative_path(filepath, pass->project_root, directory, filename);
+
+    /* Delete existing entries for this file, even if it has no symbols now */
+    if (pass->replace_existing) {
+        db_delete_by_file(pass->db, directory, filename);
+    }
+
+    /* Insert new entries */
+    for (int j = 0; j < result->count; j++) {
+        db_insert(pass->db, &result->entries[j]);
+    }
+    for (int j = 0; j < result->import_count; j++) {
+        db_insert_import(pass->db, directory, filename, pass->language, &result->imports[j]);
+    }
+    const char *hash = pass->plan->hashes[origin];
+    if (hash[0]) {
+        db_set_file_hash(pass->db, directory, filename, hash);
+    }
+    emit_ndjson(pass->ndjson_out, result);
+
+    if (pass->stamps) {
+        stamp_file(pass->stamps, filepath, pass->project_root);
+    }
+
+    if (pass->announce) {
+        printf("Indexed %s: %d entries\n", filepath, result->count);
+    }
+    pass->parsed++;
+}
+
+/* Index files of one pass: parse the changed ones, keep the rest
+ * Returns: 0 on success, -1 if out of memory or parsing could not start */
+static int run_index_pass(IndexPass *pass, const IndexerConfig *config, SymbolFilter *filter,
+                          int workers, int debug, char **files, int count, int rebuild) {
+    ParsePlan plan;
+    if (plan_parse(&plan, pass->db, filter, files, count, pass->project_root, rebuild, pass->changed,
+                   pass->max_file_size, pass->exclude_generated) != 0) {
+        fprintf(stderr, "Failed to allocate memory for file hashes\n");
+        return -1;
+    }
+    pass->plan = &plan;
+    pass->delivered = 0;
+    pass->flushed = 0;
+
+    int rc = 0;
+    if (plan.count > 0 &&
+        parse_files_parallel(config, filter, workers, debug, plan.files, plan.count,
+                             pass->project_root, index_parsed_file, pass) != 0) {
+        rc = -1;
+    }
+    if (rc == 0) {
+        flush_unparsed_files(pass, plan.all_count);
+    }
+    progress_clear(pass->progress);
+    pass->unchanged += plan.unchanged_count;
+    pass->skipped += plan.oversized_count;
+    pass->generated += plan.generated_count;
+    pass->bytes += plan.bytes;
+
+    pass->plan = NULL;
+    free_parse_plan(&plan);
+    return rc;
+}
+
+/* Load the .sourceminderignore of every directory target
+ * Returns: array of target_count rule sets, NULL if out of memory (no rules) */
+static IgnoreRules *load_target_ignore_rules(char **targets, int target_count, int announce) {
+    IgnoreRules *rules = calloc((size_t)target_count, sizeof(IgnoreRules));
+    if (!rules) {
+        fprintf(stderr, "Warning: out of memory loading %s files; not applied\n", IGNORE_RULES_FILENAME);
+        return NULL;
+    }
+    for (int i = 0; i < target_count; i++) {
+        int count = ignore_rules_load(&rules[i], targets[i]);
+        if (count < 0) {
+            fprintf(stderr, "Warning: out of memory loading %s/%s\n", rules[i].base, IGNORE_RULES_FILENAME);
+        } else if (count > 0 && announce) {
+            printf("Ignore patterns: %d from %s/%s\n", count, rules[i].base, IGNORE_RULES_FILENAME);
+        }
+    }
+    return rules;
+}
+
+static void free_target_ignore_rules(IgnoreRules *rules, int target_count) {
+    if (!rules) {
+        return;
+    }
+    for (int i = 0; i < target_count; i++) {
+        ignore_rules_free(&rules[i]);
+    }
+    free(rules);
+}
+
+/* Rule set of the target directory a watched path is under (NULL if none) */
+static const IgnoreRules *target_ignore_rules(const IgnoreRules *rules, int target_count, const char *path) {
+    if (!rules) {
+        return NULL;
+    }
+    for (int i = 0; i < target_count; i++) {
+        if (ignore_rules_covers(&rules[i], path)) {
+            return &rules[i];
+        }
+    }
+    return NULL;
+}
+
+/* Free the watch mode parser and result buffer (either may be NULL) */
+static void free_watch_parser(const IndexerConfig *config, void *parser, ParseResult *result) {
+    if (result) {
+        free_parse_result(result);
+        free(result);
+    }
+    if (parser) {
+        config->parser_free(parser);
+    }
+}
+
+/* Language stored with every symbol: the first component of the config
+ * directory ("typescript/config" -> "typescript") */
+static void get_language_name(const IndexerConfig *config, char *language, size_t size) {
+    const char *slash = strchr(config->data_dir, '/');
+    size_t len = slash ? (size_t)(slash - config->data_dir) : strlen(config->data_dir);
+    snprintf(language, size, "%.*s", (int)len, config->data_dir);
+}
+
+/* Parse one file and replace its rows, imports and hash (watch mode, serve)
+ * Returns: 0 on success, -1 if it could not be parsed (message in error;
+ * the old rows are kept) */
+static int reindex_file(const IndexerConfig *config, void *parser, ParseResult *result,
+                        SymbolFilter *filter, CodeIndexDatabase *db, const char *filepath,
+                        const char *project_root, const char *language, FILE *ndjson_out,
+                        char *error, size_t error_size) {
+    trim_parse_result(result);  /* One buffer serves every file the daemon sees */
+    if (index_source_parse(config, parser, filter, filepath, project_root, result,
+                           error, error_size) != 0) {
+        return -1;
+    }
+    truncate_long_symbols(result, filter->max_symbol_length, filepath);
+    set_result_language(result, language);
+
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    get_relative_path(filepath, project_root, directory, filename);
+
+    /* Always delete, so a file emptied of symbols loses its old ones */
+    db_delete_by_file(db, directory, filename);
+
+    /* Insert new entries */
+    for (int j = 0; j < result->count; j++) {
+        db_insert(db, &result->entries[j]);
+    }
+    for (int j = 0; j < result->import_count; j++) {
+        db_insert_import(db, directory, filename, language, &result->imports[j]);
+    }
+
+    char hash[FILE_HASH_LENGTH];
+    if (hash_file_contents(filepath, hash, sizeof(hash)) == 0) {
+        filter_mix_config_hash(filter, hash);
+        db_set_file_hash(db, directory, filename, hash);
+    }
+
+    emit_ndjson(ndjson_out, result);
+    return 0;
+}
+
+static int compare_paths(const void *a, const void *b) {
+    return strcmp(*(char *const *)a, *(char *const *)b);
+}
+
+/* An archive target, indexed entry by entry without extracting it; an
+ * entry is recorded under the archive's path followed by its path inside
+ * ("vendor.tar.gz/pkg/a.go"). Counts go to the pass */
+typedef struct {
+    IndexPass *pass;
+    const IndexerConfig *config;
+    SymbolFilter *filter;
+    void *parser;
+    ParseResult *result;
+    const char *archive;
+    const ExcludeDirs *exclude_dirs;
+    int rebuild;
+    FileList seen;          /* Stored path of every entry selected, to drop the ones removed */
+    int entries;            /* Entries selected */
+} ArchiveIndex;
+
+/* Path an entry is recorded under
+ * Returns: 0 on success, -1 if it is too long */
+static int archive_entry_path(const ArchiveIndex *index, const char *name, char *path, size_t size) {
+    int written = snprintf(path, size, "%s/%s", index->archive, name);
+    return (written < 0 || (size_t)written >= size) ? -1 : 0;
+}
+
+static int select_archive_entry(const char *name, long long size, void *ctx) {
+    ArchiveIndex *index = (ArchiveIndex *)ctx;
+    IndexPass *pass = index->pass;
+    if (!is_archive_entry_indexed(name, index->exclude_dirs, filter_get_extensions(pass->filter),
+                                  filter_get_ignore_dirs(pass->filter))) {
+        return 0;
+    }
+    char path[PATH_MAX_LENGTH];
+    if (archive_entry_path(index, name, path, sizeof(path)) != 0) {
+        fprintf(stderr, "Warning: skipping %s/%s (path too long)\n", index->archive, name);
+        return 0;
+    }
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    char stored[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH];
+    get_relative_path(path, pass->project_root, directory, filename);
+    snprintf(stored, sizeof(stored), "%s%s", directory, filename);
+    index->entries++;
+    add_file_to_list(&index->seen, stored);
+
+    if (pass->max_file_size > 0 && size > pass->max_file_size) {
+        skip_oversized_file(pass->db, path, directory, filename, size, pass->max_file_size);
+        pass->skipped++;
+        return 0;
+    }
+    pass->bytes += size;
+    return 1;
+}
+
+static int index_archive_entry(const char *name, const char *content, size_t length, void *ctx) {
+    ArchiveIndex *index = (ArchiveIndex *)ctx;
+    IndexPass *pass = index->pass;
+    char path[PATH_MAX_LENGTH];
+    archive_entry_path(index, name, path, sizeof(path));  /* Checked when selected */
+
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    get_relative_path(path, pass->project_root, directory, filename);
+
+    if (pass->exclude_generated && is_generated_content(content, length)) {
+        db_delete_by_file(pass->db, directory, filename);
+        pass->generated++;
+        return 0;
+    }
+
+    char hash[FILE_HASH_LENGTH];
+    char stored[FILE_HASH_LENGTH];
+    hash_contents(content, length, hash, sizeof(hash));
+    filter_mix_config_hash(pass->filter, hash);
+    if (!index->rebuild && db_get_file_hash(pass->db, directory, filename, stored, sizeof(stored)) &&
+        strcmp(stored, hash) == 0) {
+        if (pass->ndjson_out) {
+            emit_stored_ndjson(pass->db, pass->ndjson_out, path, pass->project_root);
+        }
+        pass->unchanged++;
+        return 0;
+    }
+
+    char error[ERROR_MESSAGE_BUFFER];
+    source_file_substitute(path, content, length);
+    int status = reindex_file(index->config, index->parser, index->result, index->filter, pass->db,
+                              path, pass->project_root, pass->language, pass->ndjson_out,
+                              error, sizeof(error));
+    source_file_substitute(NULL, NULL, 0);
+    if (status != 0) {
+        parse_report_add(pass->errors, path, error);
+        return 0;
+    }
+    /* reindex_file() hashes the file on disk, which an entry is not */
+    db_set_file_hash(pass->db, directory, filename, hash);
+
+    if (pass->announce) {
+        printf("Indexed %s: %d entries\n", path, index->result->count);
+    }
+    pass->parsed++;
+    return 0;
+}
+
+/* Stored entries of the archive kept: those still in it */
+static int archive_entry_seen(const char *directory, const char *filename, void *ctx) {
+    const FileList *seen = (const FileList *)ctx;
+    char path[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH];
+    snprintf(path, sizeof(path), "%s%s", directory, filename);
+    const char *key = path;
+    return bsearch(&key, seen->files, (size_t)seen->count, sizeof(char *), compare_paths) != NULL;
+}
+
+/* Index the source files of an archive, dropping stored entries it no
+ * longer has
+ * Returns: number of entries selected, -1 if parsing could not start */
+static int index_archive(IndexPass *pass, const IndexerConfig *config, SymbolFilter *filter,
+                         const char *archive, const ExcludeDirs *exclude_dirs, int rebuild,
+                         int report_removed) {
+    ArchiveIndex index = {
+        .pass = pass,
+        .config = config,
+        .filter = filter,
+        .archive = archive,
+        .exclude_dirs = exclude_dirs,
+        .rebuild = rebuild,
+    };
+    index.parser = config->parser_init(filter);
+    if (!index.parser) {
+        report_parser_init_failure(config, archive);
+        return -1;
+    }
+    index.result = malloc(sizeof(ParseResult));
+    if (!index.result || init_parse_result(index.result) != 0) {
+        fprintf(stderr, "Failed to initialize parse result\n");
+        free(index.result);
+        config->parser_free(index.parser);
+        return -1;
+    }
+    init_file_list(&index.seen);
+
+    ArchiveVisitor visitor = { select_archive_entry, index_archive_entry, &index };
+    char error[ERROR_MESSAGE_BUFFER];
+    if (archive_for_each(archive, &visitor, error, sizeof(error)) != 0) {
+        /* Stored entries are kept: the rest of the archive was not seen */
+        parse_report_add(pass->errors, archive, error);
+    } else {
+        char prefix[DIRECTORY_MAX_LENGTH];
+        char unused[FILENAME_MAX_LENGTH];
+        char probe[PATH_MAX_LENGTH];
+        snprintf(probe, sizeof(probe), "%s/-", archive);
+        get_relative_path(probe, pass->project_root, prefix, unused);
+        qsort(index.seen.files, (size_t)index.seen.count, sizeof(char *), compare_paths);
+        int removed = db_delete_files_unless(pass->db, prefix, archive_entry_seen, &index.seen);
+        if (removed > 0 && report_removed) {
+            printf("Removed %d deleted file%s from the index\n", removed, removed == 1 ? "" : "s");
+        }
+    }
+
+    free_file_list(&index.seen);
+    free_watch_parser(config, index.parser, index.result);
+    return index.entries;
+}
+
+/* Parse a --max-file-size value: bytes, or with a K, M or G suffix
+ * Returns: 0 on success, -1 if not a size */
+static int parse_file_size(const char *text, long long *size) {
+    char *end;
+    errno = 0;
+    long long value = strtoll(text, &end, 10);
+    if (end == text || value < 0 || errno == ERANGE) {
+        return -1;
+    }
+    long long scale = 1;
+    switch (*end) {
+        case 'k': case 'K': scale = 1024LL; end++; break;
+        case 'm': case 'M': scale = 1024LL * 1024; end++; break;
+        case 'g': case 'G': scale = 1024LL * 1024 * 1024; end++; break;
+        default: break;
+    }
+    if (*end != '\0' || value > LLONG_MAX / scale) {
+        return -1;
+    }
+    *size = value * scale;
+    return 0;
+}
+
+/* Read the --files-from list into files: one path per line, "-" for stdin;
+ * blank lines are skipped
+ * Returns: 0 on success, -1 if the list cannot be read */
+static int read_file_list(const char *source, FileList *files) {
+    int from_stdin = strcmp(source, "-") == 0;
+    /* Plain fopen: a list may come from a pipe, e.g. --files-from=<(git ls-files) */
+    FILE *f = from_stdin ? stdin : fopen(source, "r");
+    if (!f) {
+        fprintf(stderr, "Error: cannot read file list '%s'\n", source);
+        return -1;
+    }
+    char line[PATH_MAX_LENGTH];
+    int line_number = 0;
+    while (fgets(line, sizeof(line), f)) {
+        line_number++;
+        size_t len = strnlength(line, sizeof(line));
+        if (len > 0 && line[len - 1] != '\n' && !feof(f)) {
+            fprintf(stderr, "Warning: skipping line %d of the file list (path too long)\n", line_number);
+            int c;
+            while ((c = fgetc(f)) != EOF && c != '\n') {}
+            continue;
+        }
+        while (len > 0 && (line[len - 1] == '\n' || line[len - 1] == '\r')) {
+            line[--len] = '\0';
+        }
+        if (len == 0) continue;
+        add_file_to_list(files, line);
+    }
+    int failed = ferror(f);
+    if (!from_stdin) {
+        fclose(f);
+    }
+    if (failed) {
+        fprintf(stderr, "Error: failed reading file list '%s'\n", source);
+        return -1;
+    }
+    return 0;
+}
+
+static void print_usage(const IndexerConfig *config) {
+    printf("Usage: %s <directories...> [OPTIONS]\n", config->name);
+    printf("   or: %s <files...> [OPTIONS]\n", config->name);
+    printf("   or: %s search <query> [OPTIONS]\n", config->name);
+    printf("   or: %s deps [PACKAGE] [OPTIONS]\n", config->name);
+    printf("   or: %s implements [INTERFACE] [OPTIONS]\n", config->name);
+    printf("   or: %s serve [OPTIONS]\n", config->name);
+    printf("   or: %s symbol-at <file> <line> <column> [OPTIONS]\n", config->name);
+    printf("   or: %s diff <old-index> <new-index> [OPTIONS]\n", config->name);
+    printf("   or: %s validate [DIRECTORY...] [OPTIONS]\n", config->name);
+    printf("Index source code files and store symbols in a SQLite database for fast search.\n");
+    printf("Example: %s ./src --once\n", config->name);
+    printf("\n");
+
+    printf("Quick Start:\n");
+    printf("  %s ./src                    # Watch and re-index on changes\n", config->name);
+    printf("  %s ./src --once             # Index once and exit\n", config->name);
+    printf("  %s ./src --quiet-init       # Quiet initial index, show re-index\n", config->name);
+    printf("  %s ./src --silent           # Completely silent\n", config->name);
+    printf("\n");
+
+    printf("Tip: Use --once to disable daemon mode and run a single indexing pass\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --once                     run once and exit (disable daemon mode)\n");
+    printf("      --watch                    keep watching after the initial index (default for directories)\n");
+    printf("      --quiet                    no progress line or per-file messages during the initial index\n");
+    printf("      --quiet-init               suppress initial indexing output (still shows re-index messages)\n");
+    printf("      --silent                   suppress all output (initial + re-index messages)\n");
+    printf("      --verbose                  show preflight checks, validation and per-tick watch summaries\n");
+    printf("      --exclude-dir DIR...       exclude directories (can specify multiple)\n");
+    printf("      --follow-symlinks          walk symlinked directories (default: skip them)\n");
+    printf("      --files-from=PATH          index the files listed in PATH, one per line (-: stdin)\n");
+    printf("      --force-extension          index listed files even if their extension is not configured\n");
+    printf("      --extensions=LIST          index these extensions this run instead of the configured ones (.py,.cgi)\n");
+    printf("      --extensions-add=LIST      index these extensions this run as well as the configured ones\n");
+    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
+    printf("      --format=FORMAT            symbol output: text (default) or ndjson (one JSON object per symbol)\n");
+    printf("      --output PATH              write --format=ndjson symbols to PATH instead of stdout\n");
+    printf("      --flatten-embeds           add methods of embedded interfaces to the embedding interface\n");
+    printf("      --workers N                parse files on N threads (default: number of CPUs)\n");
+    printf("      --rebuild                  re-parse every file, even if unchanged since the last run\n");
+    printf("      --since=REF                only parse files changed since git REF; keep the rest as stored\n");
+    printf("      --max-file-size=SIZE       skip files larger than SIZE (K/M/G suffix; default 10M, 0: no limit)\n");
+    printf("      --exclude-generated        skip generated files (\"Code generated ... DO NOT EDIT.\" headers)\n");
+    printf("      --strict                   exit with status 1 if any file could not be parsed\n");
+    printf("      --warn-duplicates          report definitions of the same kind and qualified name\n");
+    printf("      --stats[=FORMAT]           print index statistics at the end: text (default) or json\n");
+    printf("      --echo MESSAGE             print message and continue (for testing)\n");
+    printf("\n");
+
+    printf("Incremental Indexing:\n");
+    printf("  The index records a content hash for every file. Files whose hash is\n");
+    printf("  unchanged keep their stored symbols instead of being parsed again, and\n");
+    printf("  files deleted from an indexed directory are dropped. Use --rebuild after\n");
+    printf("  changing the language config files. An index built by an incompatible\n");
+    printf("  version is rebuilt automatically.\n");
+    printf("\n");
+    printf("  --since=REF narrows a single pass to the files git reports as changed\n");
+    printf("  between REF and the working tree (git diff --name-only REF); every other\n");
+    printf("  file keeps its stored symbols. Deleted files are purged. It fails if the\n");
+    printf("  current directory is not in a git repository.\n");
+    printf("\n");
+
+    printf("Large Files:\n");
+    printf("  Files over --max-file-size are skipped with a message on stderr and\n");
+    printf("  dropped from the index. Other files are streamed to the parser in\n");
+    printf("  chunks, and large ones are memory-mapped rather than read into memory.\n");
+    printf("  Watch mode and serve read each file into memory whole, so there\n");
+    printf("  --max-file-size is also the most a file's text takes while it is parsed.\n");
+    printf("\n");
+
+    printf("Generated Files:\n");
+    printf("  With --exclude-generated, files whose first %d lines have a generated\n", GENERATED_HEADER_LINES);
+    printf("  code comment (\"Code generated ... DO NOT EDIT.\", \"@generated\",\n");
+    printf("  \"<auto-generated>\") are skipped and dropped from the index. --verbose\n");
+    printf("  reports how many were skipped.\n");
+    printf("\n");
+
+    printf("Archives:\n");
+    printf("  .tar, .tar.gz, .tgz and .zip file targets are indexed without extracting\n");
+    printf("  them: entries are picked like files of a directory and parsed from memory,\n");
+    printf("  and their symbols are recorded under the archive's path followed by the\n");
+    printf("  entry's (vendor.tar.gz/pkg/file.go). Entries removed from an archive are\n");
+    printf("  dropped from the index.\n");
+    printf("\n");
+
+    printf("Parse Errors:\n");
+    printf("  A file that cannot be read or parsed is skipped (keeping its old symbols)\n");
+    printf("  and indexing continues. The files that failed, and why, are listed on\n");
+    printf("  stderr at the end of the run. With --strict the exit status is then 1.\n");
+    printf("\n");
+
+    printf("Concurrent Runs:\n");
+    printf("  Indexers of different languages may share a database. A run holds\n");
+    printf("  <db>.<language>.lock while it works; a second run of the same language\n");
+    printf("  on that database exits with an error, unless the PID in the lock is no\n");
+    printf("  longer running. --output files are replaced once the initial pass is\n");
+    printf("  complete, never left half-written.\n");
+    printf("\n");
+
+    printf("Daemon Mode (Default):\n");
+    printf("  By default, %s runs in daemon mode, watching for file changes.\n", config->name);
+    printf("  Press Ctrl+C to stop gracefully.\n");
+    printf("\n");
+
+    printf("  Changed files are re-indexed; files whose modification time and size\n");
+    printf("  are unchanged are skipped. Deleted files, and the old name of renamed\n");
+    printf("  files, have their symbols purged from the index.\n");
+    printf("\n");
+
+    printf("  Note: Daemon mode only works with directory mode, not individual files\n");
+    printf("        or --files-from lists.\n");
+    printf("        Use --once to index files and exit immediately.\n");
+    printf("        --watch with file targets is an error.\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  %s ./src                             # Watch and re-index on changes\n", config->name);
+    printf("  %s ./src --once                      # Index once and exit\n", config->name);
+    printf("  %s ./src ./lib                       # Index multiple directories\n", config->name);
+    printf("  %s ./src --quiet-init                # Quiet initial index, show re-index\n", config->name);
+    printf("  %s ./src --silent                    # Completely silent\n", config->name);
+    printf("  %s ../ --exclude-dir indexer tests   # Exclude specific directories\n", config->name);
+    printf("  %s ./src -f /dev/shm/code-index.db  # Use custom database location\n", config->name);
+    printf("  %s ./src --once --format=ndjson | jq .name   # Pipe symbols to other tools\n", config->name);
+    printf("  %s ./src --once --rebuild            # Re-parse everything\n", config->name);
+    printf("  %s ./src --once --strict             # Fail the build on parse errors\n", config->name);
+    printf("  %s ./src --once --stats=json         # Symbol counts per kind, for CI\n", config->name);
+    printf("  %s ./src --since=origin/main         # Index only the files a PR touched\n", config->name);
+    printf("  fd -e go | %s --files-from=-         # Index exactly the files fd selected\n", config->name);
+    printf("  %s ./scripts --once --extensions-add=.cgi  # Also index .cgi files, this run only\n", config->name);
+    printf("  %s main.go deps/lib.tar.gz --once    # Index a file and an archive's sources\n", config->name);
+    printf("\n");
+    printf("  %s search UserService --kind=struct   # Search the built index\n", config->name);
+    printf("  %s deps net/http                      # Files that import a package\n", config->name);
+    printf("  %s implements io.ReadCloser           # Types that satisfy an interface\n", config->name);
+    printf("  %s serve                              # JSON-RPC queries on stdin, for editors\n", config->name);
+    printf("  %s symbol-at src/main.go 12 5         # Symbol at line 12, column 5\n", config->name);
+    printf("  %s diff v1.db v2.db                   # API changes between two indexes\n", config->name);
+    printf("  %s validate                           # Check the configuration, index nothing\n", config->name);
+    printf("\n");
+    printf("  When NDJSON goes to stdout, human-readable progress output is suppressed.\n");
+    printf("\n");
+
+    printf("Configuration:\n");
+    printf("  Config file: ~/.smconfig (CLI flags override config)\n");
+    printf("  Format: [ic] section header, then one flag per line (e.g., \"--once\" or \"--quiet-init\")\n");
+    printf("\n");
+}
+
+/* ============================================================================
+ * Parser initialization errors
+ * ============================================================================
+ */
+
+/* ABI version of a grammar (0 if the indexer has no language callback) */
+static uint32_t grammar_abi(const IndexerConfig *config) {
+    if (!config->language) {
+        return 0;
+    }
+    const TSLanguage *language = config->language();
+    return language ? ts_language_version(language) : 0;
+}
+
+static int grammar_abi_supported(uint32_t abi) {
+    return abi >= TREE_SITTER_MIN_COMPATIBLE_LANGUAGE_VERSION && abi <= TREE_SITTER_LANGUAGE_VERSION;
+}
+
+void report_parser_init_failure(const IndexerConfig *config, const char *first_file) {
+    const char *grammar = config->grammar_name ? config->grammar_name : config->name;
+    const char *version = config->grammar_version ? config->grammar_version : "unknown";
+    uint32_t abi = grammar_abi(config);
+
+    fprintf(stderr, "Error: %s: failed to initialize the %s parser\n", config->name, grammar);
+    fprintf(stderr, "  Grammar:    tree-sitter-%s %s", grammar, version);
+    if (abi > 0) {
+        fprintf(stderr, " (language ABI %u)", abi);
+    }
+    fprintf(stderr, "\n");
+    fprintf(stderr, "  Runtime:    tree-sitter, language ABI %d to %d\n",
+            TREE_SITTER_MIN_COMPATIBLE_LANGUAGE_VERSION, TREE_SITTER_LANGUAGE_VERSION);
+    fprintf(stderr, "  First file: %s\n", first_file ? first_file : "(none yet)");
+
+    if (abi == 0) {
+        return;
+    }
+    if (abi > TREE_SITTER_LANGUAGE_VERSION) {
+        fprintf(stderr, "  ABI mismatch: the grammar (ABI %u) is newer than the tree-sitter runtime supports.\n", abi);
+        fprintf(stderr, "  Upgrade libtree-sitter, or use an older tree-sitter-%s, then rerun ./configure && make.\n", grammar);
+    } else if (abi < TREE_SITTER_MIN_COMPATIBLE_LANGUAGE_VERSION) {
+        fprintf(stderr, "  ABI mismatch: the grammar (ABI %u) is older than the tree-sitter runtime supports.\n", abi);
+        fprintf(stderr, "  Use a newer tree-sitter-%s (or regenerate its parser), then rerun ./configure && make.\n", grammar);
+    } else {
+        fprintf(stderr, "  The grammar ABI is compatible, so tree-sitter could not create the parser (out of memory?).\n");
+    }
+}
+
+int check_grammar_abi(const IndexerConfig *config, const char *first_file) {
+    uint32_t abi = grammar_abi(config);
+    if (abi == 0 || grammar_abi_supported(abi)) {
+        return 0;
+    }
+    report_parser_init_failure(config, first_file);
+    return -1;
+}
+
+/* ============================================================================
+ * search subcommand
+ * ============================================================================
+ */
+
+static void print_search_usage(const IndexerConfig *config) {
+    printf("Usage: %s search <query> [OPTIONS]\n", config->name);
+    printf("Search an existing index and list matching symbols, best matches first.\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --kind=KIND                only symbols of this kind (struct, interface, func, field, ...)\n");
+    printf("      --file=PATTERN             only files matching PATTERN (glob; a plain word matches anywhere)\n");
+    printf("      --format=FORMAT            table (default), ndjson (one JSON object per symbol)\n");
+    printf("                                 or lsp (JSON array of LSP WorkspaceSymbol objects)\n");
+    printf("      --limit N                  maximum results (default: %d)\n", SEARCH_DEFAULT_LIMIT);
+    printf("      --dedupe                   merge symbols with the same name, kind and parent\n");
+    printf("                                 (e.g. one type per build-tagged file) into one result\n");
+    printf("      --exported-only            only exported symbols (the public API)\n");
+    printf("      --fuzzy                    also match symbols containing the query's letters in order\n");
+    printf("                                 (RdClsr finds ReadCloser), closest first\n");
+    printf("  -i, --ignore-case              match regardless of case (readcloser finds ReadCloser)\n");
+    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
+    printf("\n");
+
+    printf("  Exact names rank above prefixes, prefixes above substrings, and\n");
+    printf("  definitions above uses. Stopwords in the query are ignored. Matching is\n");
+    printf("  case-sensitive unless -i is given. With --fuzzy, results are ranked by\n");
+    printf("  edit distance to the query first, then as above.\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  %s search UserService\n", config->name);
+    printf("  %s search user --kind=struct --file='internal/*'\n", config->name);
+    printf("  %s search handler --format=ndjson | jq .file\n", config->name);
+    printf("  %s search handler --format=lsp\n", config->name);
+    printf("  %s search Config --dedupe --format=ndjson | jq .locations\n", config->name);
+    printf("  %s search client --exported-only --kind=func\n", config->name);
+    printf("  %s search RdClsr --fuzzy\n", config->name);
+    printf("  %s search readcloser -i\n", config->name);
+    printf("\n");
+}
+
+/* Value of "--name=VALUE" or "--name VALUE" at argv[*i] (advancing *i past a
+ * separate value), NULL if argv[*i] is not this option */
+static const char *option_value(int argc, char *argv[], int *i, const char *name, int *missing) {
+    size_t len = strlen(name);
+    if (strncmp(argv[*i], name, len) != 0) {
+        return NULL;
+    }
+    if (argv[*i][len] == '=') {
+        return argv[*i] + len + 1;
+    }
+    if (argv[*i][len] != '\0') {
+        return NULL;
+    }
+    if (*i + 1 < argc) {
+        return argv[++(*i)];
+    }
+    *missing = 1;
+    return NULL;
+}
+
+static int run_search(int argc, char *argv[], const IndexerConfig *config) {
+    int exit_code = 1;
+    SymbolFilter *filter = NULL;
+    char query[LINE_BUFFER_LARGE] = "";
+    size_t query_len = 0;
+    const char *db_file = "code-index.db";
+    const char *format = "table";
+    SearchOptions opts = { .limit = SEARCH_DEFAULT_LIMIT, .format = SEARCH_FORMAT_TABLE };
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_search_usage(config);
+            exit_code = 0;
+            goto cleanup;
+        } else if ((value = option_value(argc, argv, &i, "--kind", &missing)) != NULL) {
+            opts.kind = value;
+        } else if ((value = option_value(argc, argv, &i, "--file", &missing)) != NULL) {
+            opts.file_pattern = value;
+        } else if ((value = option_value(argc, argv, &i, "--format", &missing)) != NULL) {
+            format = value;
+        } else if ((value = option_value(argc, argv, &i, "--limit", &missing)) != NULL) {
+            opts.limit = atoi(value);
+        } else if (strcmp(argv[i], "--dedupe") == 0) {
+            opts.dedupe = 1;
+        } else if (strcmp(argv[i], "--exported-only") == 0) {
+            opts.exported_only = 1;
+        } else if (strcmp(argv[i], "--fuzzy") == 0) {
+            opts.fuzzy = 1;
+        } else if (strcmp(argv[i], "--ignore-case") == 0 || strcmp(argv[i], "-i") == 0) {
+            opts.ignore_case = 1;
+        } else if ((value = option_value(argc, argv, &i, "--db-file", &missing)) != NULL ||
+                   (value = option_value(argc, argv, &i, "-f", &missing)) != NULL) {
+            db_file = value;
+        } else if (missing) {
+            /* handled below */
+        } else if (argv[i][0] == '-') {
+            fprintf(stderr, "Error: unknown search option '%s'\n", argv[i]);
+            goto cleanup;
+        } else {
+            /* Query words may be given as one argument or several */
+            int written = snprintf(query + query_len, sizeof(query) - query_len, "%s%s",
+                                   query_len > 0 ? " " : "", argv[i]);
+            if (written < 0 || (size_t)written >= sizeof(query) - query_len) {
+                fprintf(stderr, "Error: search query too long\n");
+                goto cleanup;
+            }
+            query_len += (size_t)written;
+        }
+        if (missing) {
+            fprintf(stderr, "Error: %s requires a value\n", argv[i]);
+            goto cleanup;
+        }
+    }
+
+    if (query_len == 0) {
+        print_search_usage(config);
+        goto cleanup;
+    }
+    if (strcmp(format, "ndjson") == 0) {
+        opts.format = SEARCH_FORMAT_NDJSON;
+    } else if (strcmp(format, "lsp") == 0) {
+        opts.format = SEARCH_FORMAT_LSP;
+    } else if (strcmp(format, "table") != 0) {
+        fprintf(stderr, "Error: unknown search format '%s' (expected table, ndjson or lsp)\n", format);
+        goto cleanup;
+    }
+    if (opts.limit <= 0) {
+        fprintf(stderr, "Error: --limit must be a positive number\n");
+        goto cleanup;
+    }
+    if (opts.kind && !search_kind_is_valid(opts.kind)) {
+        fprintf(stderr, "Error: unknown symbol kind '%s'\n", opts.kind);
+        goto cleanup;
+    }
+    if (!db_exists(db_file)) {
+        fprintf(stderr, "Error: no index at '%s' (run %s <directory> --once first)\n",
+                db_file, config->name);
+        goto cleanup;
+    }
+
+    /* Stopwords only; the rest of the filter is for indexing */
+    filter = malloc(sizeof(SymbolFilter));
+    if (!filter) {
+        fprintf(stderr, "Failed to allocate memory for filter\n");
+        goto cleanup;
+    }
+    if (filter_init(filter, config->data_dir) != 0) {
+        fprintf(stderr, "Warning: Failed to load filter data\n");
+    }
+
+    opts.query = query;
+    if (search_index(db_file, filter, &opts, stdout) == 0) {
+        exit_code = 0;
+    }
+
+cleanup:
+    if (filter) {
+        filter_free_regex(filter);
+        free(filter);
+    }
+    return exit_code;
+}
+
+static void print_deps_usage(const IndexerConfig *config) {
+    printf("Usage: %s deps [PACKAGE] [OPTIONS]\n", config->name);
+    printf("List the imports recorded in an existing index: every file and what it\n");
+    printf("imports, or with PACKAGE the files that import it.\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --file=PATTERN             only importing files matching PATTERN (glob; a plain word matches anywhere)\n");
+    printf("      --format=FORMAT            table (default), ndjson (one JSON object per import)\n");
+    printf("                                 or dot (Graphviz digraph of file -> import)\n");
+    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
+    printf("\n");
+
+    printf("  PACKAGE is matched exactly as imported (\"net/http\", \"./utils\",\n");
+    printf("  \"os.path\"). A glob matches as such, and PACKAGE/... also matches\n");
+    printf("  everything below PACKAGE. Imports are recorded for Go, TypeScript and\n");
+    printf("  Python; they are not resolved to files.\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  %s deps                                   # The whole import graph\n", config->name);
+    printf("  %s deps github.com/acme/app/internal/...  # Who imports internal packages\n", config->name);
+    printf("  %s deps --file='cmd/*'\n", config->name);
+    printf("  %s deps --format=dot | dot -Tsvg > imports.svg\n", config->name);
+    printf("\n");
+}
+
+/* deps subcommand: print the import graph of an existing index */
+static int run_deps(int argc, char *argv[], const IndexerConfig *config) {
+    const char *db_file = "code-index.db";
+    const char *format = "table";
+    DepsOptions opts = { .format = DEPS_FORMAT_TABLE };
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_deps_usage(config);
+            return 0;
+        } else if ((value = option_value(argc, argv, &i, "--file", &missing)) != NULL) {
+            opts.file_pattern = value;
+        } else if ((value = option_value(argc, argv, &i, "--format", &missing)) != NULL) {
+            format = value;
+        } else if ((value = option_value(argc, argv, &i, "--db-file", &missing)) != NULL ||
+                   (value = option_value(argc, argv, &i, "-f", &missing)) != NULL) {
+            db_file = value;
+        } else if (missing) {
+            /* handled below */
+        } else if (argv[i][0] == '-' && argv[i][1] != '\0') {
+            fprintf(stderr, "Error: unknown deps option '%s'\n", argv[i]);
+            return 1;
+        } else if (opts.package) {
+            fprintf(stderr, "Error: deps takes one package\n");
+            return 1;
+        } else {
+            opts.package = argv[i];
+        }
+        if (missing) {
+            fprintf(stderr, "Error: %s requires a value\n", argv[i]);
+            return 1;
+        }
+    }
+
+    if (strcmp(format, "ndjson") == 0) {
+        opts.format = DEPS_FORMAT_NDJSON;
+    } else if (strcmp(format, "dot") == 0) {
+        opts.format = DEPS_FORMAT_DOT;
+    } else if (strcmp(format, "table") != 0) {
+        fprintf(stderr, "Error: unknown deps format '%s' (expected table, ndjson or dot)\n", format);
+        return 1;
+    }
+    if (!db_exists(db_file)) {
+        fprintf(stderr, "Error: no index at '%s' (run %s <directory> --once first)\n",
+                db_file, config->name);
+        return 1;
+    }
+    return deps_print(db_file, &opts, stdout) == 0 ? 0 : 1;
+}
+
+static void print_implements_usage(const IndexerConfig *config) {
+    printf("Usage: %s implements [INTERFACE] [OPTIONS]\n", config->name);
+    printf("List the types of an existing index that satisfy an interface, found by\n");
+    printf("comparing method sets, or without INTERFACE every interface and its types.\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --certain                  leave out \"possibly\" edges\n");
+    printf("      --format=FORMAT            table (default) or ndjson (one JSON object per edge)\n");
+    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
+    printf("\n");
+
+    printf("  INTERFACE is a name (ReadCloser) or qualified with its package\n");
+    printf("  (io.ReadCloser). A type whose methods only match through a pointer is\n");
+    printf("  shown as *T. When the interface or type embeds something that is not\n");
+    printf("  indexed, or either is generic, the match is listed as possibly, with what\n");
+    printf("  could not be resolved. Recorded for Go, after each indexing run.\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  %s implements ReadCloser\n", config->name);
+    printf("  %s implements store.Repository --certain\n", config->name);
+    printf("  %s implements --format=ndjson | jq -r .type\n", config->name);
+    printf("\n");
+}
+
+/* implements subcommand: print the types satisfying an interface */
+static int run_implements_query(int argc, char *argv[], const IndexerConfig *config) {
+    const char *db_file = "code-index.db";
+    const char *format = "table";
+    ImplementsOptions opts = { .format = IMPLEMENTS_FORMAT_TABLE };
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_implements_usage(config);
+            return 0;
+        } else if (strcmp(argv[i], "--certain") == 0) {
+            opts.certain_only = 1;
+        } else if ((value = option_value(argc, argv, &i, "--format", &missing)) != NULL) {
+            format = value;
+        } else if ((value = option_value(argc, argv, &i, "--db-file", &missing)) != NULL ||
+                   (value = option_value(argc, argv, &i, "-f", &missing)) != NULL) {
+            db_file = value;
+        } else if (missing) {
+            /* handled below */
+        } else if (argv[i][0] == '-' && argv[i][1] != '\0') {
+            fprintf(stderr, "Error: unknown implements option '%s'\n", argv[i]);
+            return 1;
+        } else if (opts.interface) {
+            fprintf(stderr, "Error: implements takes one interface\n");
+            return 1;
+        } else {
+            opts.interface = argv[i];
+        }
+        if (missing) {
+            fprintf(stderr, "Error: %s requires a value\n", argv[i]);
+            return 1;
+        }
+    }
+
+    if (strcmp(format, "ndjson") == 0) {
+        opts.format = IMPLEMENTS_FORMAT_NDJSON;
+    } else if (strcmp(format, "table") != 0) {
+        fprintf(stderr, "Error: unknown implements format '%s' (expected table or ndjson)\n", format);
+        return 1;
+    }
+    if (!db_exists(db_file)) {
+        fprintf(stderr, "Error: no index at '%s' (run %s <directory> --once first)\n",
+                db_file, config->name);
+        return 1;
+    }
+    return implements_print(db_file, &opts, stdout) == 0 ? 0 : 1;
+}
+
+static void print_symbol_at_usage(const IndexerConfig *config) {
+    printf("Usage: %s symbol-at <file> <line> <column> [OPTIONS]\n", config->name);
+    printf("Print the innermost symbol of an existing index whose source range holds\n");
+    printf("a position (line and column from 1), qualified with the declarations\n");
+    printf("that enclose it. Exits with status 1 if no symbol is there.\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --format=FORMAT            text (default) or ndjson (the symbol as --format=ndjson writes it)\n");
+    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  %s symbol-at src/server.go 42 7     # src/server.go:42:6  FUNC  Server.HandleRequest\n",
+           config->name);
+    printf("  %s symbol-at src/server.go 42 7 --format=ndjson | jq -r .scopepath\n", config->name);
+    printf("\n");
+}
+
+/* Parse a 1-based line or column argument
+ * Returns: the number, or 0 if arg is not a positive number */
+static int position_argument(const char *arg) {
+    char *end;
+    errno = 0;
+    long value = strtol(arg, &end, 10);
+    if (errno != 0 || end == arg || *end != '\0' || value < 1 || value > INT_MAX) {
+        return 0;
+    }
+    return (int)value;
+}
+
+/* symbol-at subcommand: what symbol is at a file position */
+static int run_symbol_at(int argc, char *argv[], const IndexerConfig *config) {
+    const char *db_file = "code-index.db";
+    const char *format = "text";
+    const char *positional[3];
+    int positional_count = 0;
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_symbol_at_usage(config);
+            return 0;
+        } else if ((value = option_value(argc, argv, &i, "--format", &missing)) != NULL) {
+            format = value;
+        } else if ((value = option_value(argc, argv, &i, "--db-file", &missing)) != NULL ||
+                   (value = option_value(argc, argv, &i, "-f", &missing)) != NULL) {
+            db_file = value;
+        } else if (missing) {
+            /* handled below */
+        } else if (argv[i][0] == '-' && argv[i][1] != '\0') {
+            fprintf(stderr, "Error: unknown symbol-at option '%s'\n", argv[i]);
+            return 1;
+        } else if (positional_count == 3) {
+            fprintf(stderr, "Error: symbol-at takes a file, a line and a column\n");
+            return 1;
+        } else {
+            positional[positional_count++] = argv[i];
+        }
+        if (missing) {
+            fprintf(stderr, "Error: %s requires a value\n", argv[i]);
+            return 1;
+        }
+    }
+
+    if (positional_count < 3) {
+        print_symbol_at_usage(config);
+        return 1;
+    }
+    int line = position_argument(positional[1]);
+    int column = position_argument(positional[2]);
+    if (line == 0 || column == 0) {
+        fprintf(stderr, "Error: line and column must be numbers from 1\n");
+        return 1;
+    }
+    SymbolAtFormat at_format;
+    if (strcmp(format, "text") == 0) {
+        at_format = SYMBOL_AT_FORMAT_TEXT;
+    } else if (strcmp(format, "ndjson") == 0) {
+        at_format = SYMBOL_AT_FORMAT_NDJSON;
+    } else {
+        fprintf(stderr, "Error: unknown symbol-at format '%s' (expected text or ndjson)\n", format);
+        return 1;
+    }
+    if (!db_exists(db_file)) {
+        fprintf(stderr, "Error: no index at '%s' (run %s <directory> --once first)\n",
+                db_file, config->name);
+        return 1;
+    }
+
+    int found = symbol_at_print(db_file, positional[0], line, column, at_format, stdout);
+    if (found == 0) {
+        fprintf(stderr, "No symbol at %s:%d:%d\n", positional[0], line, column);
+    }
+    return found == 1 ? 0 : 1;
+}
+
+static void print_diff_usage(const IndexerConfig *config) {
+    printf("Usage: %s diff <old-index> <new-index> [OPTIONS]\n", config->name);
+    printf("Compare two indexes, built from two versions of a tree, and list the\n");
+    printf("symbols added, removed and changed, grouped by kind. A symbol has changed\n");
+    printf("when its signature does: type, params, returns, modifiers, tags, ...\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --format=FORMAT            text (default) or ndjson (one JSON object per change)\n");
+    printf("      --all                      compare unexported definitions too (default: exported only)\n");
+    printf("      --fail-on-breaking         exit with status 1 if any change is breaking\n");
+    printf("\n");
+
+    printf("  Both indexes should be built from the root of their tree with the same\n");
+    printf("  paths. A change is breaking when it removes or changes an exported\n");
+    printf("  symbol, or adds a method to an interface. Errors exit with status 2.\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  git worktree add /tmp/v1 v1.0 && (cd /tmp/v1 && %s . --once)\n", config->name);
+    printf("  %s diff /tmp/v1/code-index.db code-index.db\n", config->name);
+    printf("  %s diff old.db new.db --format=ndjson --fail-on-breaking  # Gate CI on API breaks\n",
+           config->name);
+    printf("\n");
+}
+
+/* diff subcommand: symbol changes between two indexes */
+static int run_diff(int argc, char *argv[], const IndexerConfig *config) {
+    const char *format = "text";
+    const char *paths[2];
+    int path_count = 0;
+    int fail_on_breaking = 0;
+    DiffOptions opts = { .format = DIFF_FORMAT_TEXT };
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_diff_usage(config);
+            return 0;
+        } else if ((value = option_value(argc, argv, &i, "--format", &missing)) != NULL) {
+            format = value;
+        } else if (strcmp(argv[i], "--all") == 0) {
+            opts.all = 1;
+        } else if (strcmp(argv[i], "--fail-on-breaking") == 0) {
+            fail_on_breaking = 1;
+        } else if (missing) {
+            fprintf(stderr, "Error: %s requires a value\n", argv[i]);
+            return 2;
+        } else if (argv[i][0] == '-' && argv[i][1] != '\0') {
+            fprintf(stderr, "Error: unknown diff option '%s'\n", argv[i]);
+            return 2;
+        } else if (path_count == 2) {
+            fprintf(stderr, "Error: diff takes an old and a new index\n");
+            return 2;
+        } else {
+            paths[path_count++] = argv[i];
+        }
+    }
+
+    if (path_count < 2) {
+        print_diff_usage(config);
+        return 2;
+    }
+    if (strcmp(format, "ndjson") == 0) {
+        opts.format = DIFF_FORMAT_NDJSON;
+    } else if (strcmp(format, "text") != 0) {
+        fprintf(stderr, "Error: unknown diff format '%s' (expected text or ndjson)\n", format);
+        return 2;
+    }
+    for (int i = 0; i < 2; i++) {
+        if (!db_exists(paths[i])) {
+            fprintf(stderr, "Error: no index at '%s'\n", paths[i]);
+            return 2;
+        }
+    }
+
+    DiffStats stats;
+    if (index_diff(paths[0], paths[1], &opts, stdout, &stats) != 0) {
+        return 2;
+    }
+    return (fail_on_breaking && stats.breaking > 0) ? 1 : 0;
+}
+
+static void print_validate_usage(const IndexerConfig *config) {
+    printf("Usage: %s validate [DIRECTORY...] [OPTIONS]\n", config->name);
+    printf("Run the preflight checks of an indexing run and exit, without indexing:\n");
+    printf("the configuration in %s (stopwords, keywords, file extensions, ignored\n", config->data_dir);
+    printf("files, regex patterns, symbol limits, identifier split rules, extractors),\n");
+    printf("the .sourceminderignore of each DIRECTORY, and compile-time constants.\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --verbose                  show every check, not only failures\n");
+    printf("      --extensions=LIST          check LIST as the run's file extensions\n");
+    printf("      --extensions-add=LIST      check LIST as extensions added to the configured ones\n");
+    printf("\n");
+
+    printf("  Exits with status 0 when everything is valid, 1 when any check fails.\n");
+    printf("\n");
+
+    printf("Examples:\n");
+    printf("  %s validate                        # Check the configuration\n", config->name);
+    printf("  %s validate ./src ./lib --verbose  # Also check their .sourceminderignore\n", config->name);
+    printf("\n");
+}
+
+/* validate subcommand: the preflight checks of an indexing run, on their own */
+static int run_validate(int argc, char *argv[], const IndexerConfig *config) {
+    char *roots[MAX_TARGETS];
+    int root_count = 0;
+    int verbose = 0;
+    const char *extensions = NULL;
+    const char *extensions_add = NULL;
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_validate_usage(config);
+            return 0;
+        } else if (strcmp(argv[i], "--verbose") == 0) {
+            verbose = 1;
+        } else if ((value = option_value(argc, argv, &i, "--extensions", &missing)) != NULL) {
+            extensions = value;
+        } else if ((value = option_value(argc, argv, &i, "--extensions-add", &missing)) != NULL) {
+            extensions_add = value;
+        } else if (missing) {
+            fprintf(stderr, "Error: %s requires a list of extensions\n", argv[i]);
+            return 1;
+        } else if (argv[i][0] == '-' && argv[i][1] != '\0') {
+            fprintf(stderr, "Error: unknown validate option '%s'\n", argv[i]);
+            return 1;
+        } else if (root_count == MAX_TARGETS) {
+            fprintf(stderr, "Error: too many directories (max %d)\n", MAX_TARGETS);
+            return 1;
+        } else {
+            roots[root_count++] = argv[i];
+        }
+    }
+
+    for (int i = 0; i < root_count; i++) {
+        struct stat st;
+        if (stat(roots[i], &st) != 0 || !S_ISDIR(st.st_mode)) {
+            fprintf(stderr, "Error: not a directory: %s\n", roots[i]);
+            return 1;
+        }
+    }
+
+    /* The same checks an indexing run starts with */
+    if (preflight_validation(config->data_dir, root_count > 0 ? roots : NULL, root_count,
+                             extensions, extensions_add, verbose) != 0) {
+        return 1;
+    }
+    if (!verbose) {
+        UnifiedConfig unified;
+        int unified_status = unified_config_load(&unified, config->data_dir);
+        printf("Configuration is valid (%s, %s)\n", config->data_dir,
+               unified_status == 1 ? UNIFIED_CONFIG_FILENAME : ".txt files");
+        unified_config_free(&unified);
+    }
+    return 0;
+}
+
+static void print_serve_usage(const IndexerConfig *config) {
+    printf("Usage: %s serve [OPTIONS]\n", config->name);
+    printf("Answer JSON-RPC 2.0 requests against an existing index, keeping the index\n");
+    printf("and parser loaded between them. Requests are read from stdin, with\n");
+    printf("Content-Length headers (as LSP frames them) or one per line, and answered\n");
+    printf("the same way on stdout.\n");
+    printf("\n");
+
+    printf("Options:\n");
+    printf("      --socket=PATH              listen on a unix socket instead, one client at a time\n");
+    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
+    printf("\n");
+
+    printf("Methods:\n");
+    printf("  search   {query, kind, file, limit, fuzzy, exportedOnly, dedupe}\n");
+    printf("  symbolAt {file, line, column}     innermost symbol there (line and column from 1)\n");
+    printf("  reindex  {file}                   parse one file again (or drop it if deleted)\n");
+    printf("  shutdown                          stop, checkpointing the index\n");
+    printf("\n");
+
+    printf("Example:\n");
+    printf("  echo '{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"search\",\"params\":{\"query\":\"user\"}}' | %s serve\n",
+           config->name);
+    printf("\n");
+}
+
+/* What serve's reindex method needs to parse and store a file */
+typedef struct {
+    const IndexerConfig *config;
+    void *parser;
+    ParseResult *result;
+    SymbolFilter *filter;
+    CodeIndexDatabase *db;
+    const char *project_root;
+    const char *language;
+} ServeIndexer;
+
+/* "./src/a.go" and "src/a.go" are one file, stored under whichever form
+ * the index was built with. Editors send either, so use the form the
+ * index already has (the given one for a new file).
+ * Returns: 0 on success, -1 if the path is too long */
+static int stored_path_form(CodeIndexDatabase *db, const char *filepath, const char *project_root,
+                            char *path, size_t size) {
+    const char *rel = filepath;
+    size_t root_len = strlen(project_root);
+    if (root_len > 0 && strncmp(filepath, project_root, root_len) == 0 && filepath[root_len] == '/') {
+        rel = filepath + root_len + 1;
+    }
+    int written = snprintf(path, size, "%s", rel);
+    if (written < 0 || (size_t)written >= size) return -1;
+
+    char alternate[PATH_MAX_LENGTH];
+    if (strncmp(rel, "./", 2) == 0) {
+        written = snprintf(alternate, sizeof(alternate), "%s", rel + 2);
+    } else if (rel[0] != '/') {
+        written = snprintf(alternate, sizeof(alternate), "./%s", rel);
+    } else {
+        return 0;
+    }
+    if (written < 0 || (size_t)written >= sizeof(alternate) || (size_t)written >= size) return 0;
+
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    char hash[FILE_HASH_LENGTH];
+    get_relative_path(path, project_root, directory, filename);
+    if (db_get_file_hash(db, directory, filename, hash, sizeof(hash))) return 0;
+    get_relative_path(alternate, project_root, directory, filename);
+    if (db_get_file_hash(db, directory, filename, hash, sizeof(hash))) {
+        snprintf(path, size, "%s", alternate);
+    }
+    return 0;
+}
+
+/* serve's reindex method (see ServeReindexFunc) */
+static int serve_reindex(void *ctx, const char *filepath, int *symbols, int *removed,
+                         char *error, size_t error_size) {
+    ServeIndexer *indexer = (ServeIndexer *)ctx;
+    char path[PATH_MAX_LENGTH];
+    if (stored_path_form(indexer->db, filepath, indexer->project_root, path, sizeof(path)) != 0) {
+        snprintf(error, error_size, "path too long");
+        return -1;
+    }
+
+    /* Its package's interface satisfaction is recomputed with it (no
+     * output: stdout may carry the responses) */
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    get_relative_path(path, indexer->project_root, directory, filename);
+    const char *packages[] = { directory };
+
+    struct stat st;
+    if (stat(path, &st) != 0 || !S_ISREG(st.st_mode)) {
+        db_begin_transaction(indexer->db);
+        db_delete_by_file(indexer->db, directory, filename);
+        if (implements_update(indexer->db, indexer->language, packages, 1, 0, NULL) != 0) {
+            fprintf(stderr, "Warning: Failed to compute interface satisfaction\n");
+        }
+        db_commit_transaction(indexer->db);
+        *removed = 1;
+        return 0;
+    }
+    if (!path_matches_extensions(path, filter_get_extensions(indexer->filter))) {
+        snprintf(error, error_size, "%s does not match the configured file extensions", filepath);
+        return -1;
+    }
+
+    db_begin_transaction(indexer->db);
+    int rc = reindex_file(indexer->config, indexer->parser, indexer->result, indexer->filter,
+                          indexer->db, path, indexer->project_root, indexer->language, NULL,
+                          error, error_size);
+    if (implements_update(indexer->db, indexer->language, packages, 1, 0, NULL) != 0) {
+        fprintf(stderr, "Warning: Failed to compute interface satisfaction\n");
+    }
+    db_commit_transaction(indexer->db);
+    if (rc == 0) {
+        *symbols = indexer->result->count;
+    }
+    return rc;
+}
+
+/* serve subcommand: answer JSON-RPC requests until shutdown */
+static int run_serve(int argc, char *argv[], const IndexerConfig *config) {
+    const char *db_file = "code-index.db";
+    const char *socket_path = NULL;
+
+    for (int i = 1; i < argc; i++) {
+        int missing = 0;
+        const char *value;
+        if (strcmp(argv[i], "--help") == 0 || strcmp(argv[i], "-h") == 0) {
+            print_serve_usage(config);
+            return 0;
+        } else if ((value = option_value(argc, argv, &i, "--socket", &missing)) != NULL) {
+            socket_path = value;
+        } else if ((value = option_value(argc, argv, &i, "--db-file", &missing)) != NULL ||
+                   (value = option_value(argc, argv, &i, "-f", &missing)) != NULL) {
+            db_file = value;
+        } else if (!missing) {
+            fprintf(stderr, "Error: unknown serve option '%s'\n", argv[i]);
+            return 1;
+        }
+        if (missing) {
+            fprintf(stderr, "Error: %s requires a value\n", argv[i]);
+            return 1;
+        }
+    }
+    if (!db_exists(db_file)) {
+        fprintf(stderr, "Error: no index at '%s' (run %s <directory> --once first)\n",
+                db_file, config->name);
+        return 1;
+    }
 
-    printf("Quick Start:\n");
-    printf("  %s ./src                    # Watch and re-index on changes\n", config->name);
-    printf("  %s ./src --once             # Index once and exit\n", config->name);
-    printf("  %s ./src --quiet-init       # Quiet initial index, show re-index\n", config->name);
-    printf("  %s ./src --silent           # Completely silent\n", config->name);
-    printf("\n");
+    int exit_code = 1;
+    void *parser = NULL;
+    ParseResult *result = NULL;
+    int db_open = 0;
+    CodeIndexDatabase db;
+    SymbolFilter *filter = malloc(sizeof(SymbolFilter));
+    if (!filter) {
+        fprintf(stderr, "Failed to allocate memory for filter\n");
+        goto cleanup;
+    }
+    if (filter_init(filter, config->data_dir) != 0) {
+        fprintf(stderr, "Warning: Failed to load filter data\n");
+    }
 
-    printf("Tip: Use --once to disable daemon mode and run a single indexing pass\n");
-    printf("\n");
+    char cwd[PATH_MAX_LENGTH];
+    if (getcwd(cwd, sizeof(cwd)) == NULL) {
+        snprintf(cwd, sizeof(cwd), ".");
+    }
+    char language[LINE_BUFFER_SMALL];
+    get_language_name(config, language, sizeof(language));
 
-    printf("Options:\n");
-    printf("      --once                     run once and exit (disable daemon mode)\n");
-    printf("      --quiet-init               suppress initial indexing output (still shows re-index messages)\n");
-    printf("      --silent                   suppress all output (initial + re-index messages)\n");
-    printf("      --verbose                  show preflight checks and validation\n");
-    printf("      --exclude-dir DIR...       exclude directories (can specify multiple)\n");
-    printf("  -f, --db-file PATH             database file location (default: code-index.db)\n");
-    printf("      --echo MESSAGE             print message and continue (for testing)\n");
-    printf("\n");
+    int rebuilt = 0;
+    if (db_init_store(&db, db_file, &rebuilt) != SQLITE_OK) {
+        fprintf(stderr, "Failed to initialize database\n");
+        goto cleanup;
+    }
+    db_open = 1;
+    if (rebuilt) {
+        fprintf(stderr, "Warning: index %s was built by an incompatible version; "
+                "it is empty until rebuilt\n", db_file);
+    }
+    if (db_enable_concurrent_writes(&db) != SQLITE_OK) {
+        fprintf(stderr, "Warning: Failed to enable WAL mode. Concurrent indexing may not work.\n");
+    }
 
-    printf("Daemon Mode (Default):\n");
-    printf("  By default, %s runs in daemon mode, watching for file changes.\n", config->name);
-    printf("  Press Ctrl+C to stop gracefully.\n");
-    printf("\n");
+    ServeContext ctx = { .db = db.db, .filter = filter, .socket_path = socket_path };
+    ServeIndexer indexer = {
+        .config = config,
+        .filter = filter,
+        .db = &db,
+        .project_root = cwd,
+        .language = language,
+    };
+    source_file_set_mapping(0);  /* As in watch mode */
+    parser = config->parser_init(filter);
+    if (parser) {
+        result = malloc(sizeof(ParseResult));
+        if (result && init_parse_result(result) != 0) {
+            free(result);
+            result = NULL;
+        }
+    }
+    if (parser && result) {
+        indexer.parser = parser;
+        indexer.result = result;
+        ctx.reindex = serve_reindex;
+        ctx.reindex_ctx = &indexer;
+    } else {
+        /* Queries still work */
+        fprintf(stderr, "Warning: parser unavailable; reindex is disabled\n");
+    }
 
-    printf("  Note: Daemon mode only works with directory mode, not individual files.\n");
-    printf("        Use --once to index files and exit immediately.\n");
-    printf("\n");
+    if (serve_run(&ctx) == 0) {
+        exit_code = 0;
+    }
 
-    printf("Examples:\n");
-    printf("  %s ./src                             # Watch and re-index on changes\n", config->name);
-    printf("  %s ./src --once                      # Index once and exit\n", config->name);
-    printf("  %s ./src ./lib                       # Index multiple directories\n", config->name);
-    printf("  %s ./src --quiet-init                # Quiet initial index, show re-index\n", config->name);
-    printf("  %s ./src --silent                    # Completely silent\n", config->name);
-    printf("  %s ../ --exclude-dir indexer tests   # Exclude specific directories\n", config->name);
-    printf("  %s ./src -f /dev/shm/code-index.db  # Use custom database location\n", config->name);
-    printf("\n");
+    /* Fold the write-ahead log into the index so it is complete on its own */
+    if (sqlite3_exec(db.db, "PRAGMA wal_checkpoint(TRUNCATE);", NULL, NULL, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Warning: could not checkpoint %s: %s\n", db_file, sqlite3_errmsg(db.db));
+    }
 
-    printf("Configuration:\n");
-    printf("  Config file: ~/.smconfig (CLI flags override config)\n");
-    printf("  Format: [ic] section header, then one flag per line (e.g., \"--once\" or \"--quiet-init\")\n");
-    printf("\n");
+cleanup:
+    free_watch_parser(config, parser, result);
+    if (filter) {
+        filter_free_regex(filter);
+        free(filter);
+    }
+    if (db_open) {
+        db_close(&db);
+    }
+    return exit_code;
 }
 
 int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
+    /* Subcommands take their own options */
+    if (argc >= 2 && strcmp(argv[1], "search") == 0) {
+        return run_search(argc - 1, argv + 1, config);
+    }
+    if (argc >= 2 && strcmp(argv[1], "deps") == 0) {
+        return run_deps(argc - 1, argv + 1, config);
+    }
+    if (argc >= 2 && strcmp(argv[1], "implements") == 0) {
+        return run_implements_query(argc - 1, argv + 1, config);
+    }
+    if (argc >= 2 && strcmp(argv[1], "serve") == 0) {
+        return run_serve(argc - 1, argv + 1, config);
+    }
+    if (argc >= 2 && strcmp(argv[1], "symbol-at") == 0) {
+        return run_symbol_at(argc - 1, argv + 1, config);
+    }
+    if (argc >= 2 && strcmp(argv[1], "diff") == 0) {
+        return run_diff(argc - 1, argv + 1, config);
+    }
+    if (argc >= 2 && strcmp(argv[1], "validate") == 0) {
+        return run_validate(argc - 1, argv + 1, config);
+    }
+
     /* Check for --help flag first */
     int show_help = 0;
     for (int i = 1; i < argc; i++) {
@@ -266,26 +2302,129 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
     }
 
     int quiet_init = 0;
+    int quiet = 0;
     int silent = 0;
     int verbose = 0;
     int debug = 0;
     int daemon_mode = 1;  /* Daemon mode enabled by default */
+    int watch_requested = 0;  /* --watch given explicitly */
+    int once_requested = 0;
     ExcludeDirs exclude_dirs = { .count = 0 };
+    char language[LANGUAGE_MAX_LENGTH];
+    get_language_name(config, language, sizeof(language));
     char *targets[MAX_TARGETS];
     int target_count = 0;
     IndexMode mode = MODE_DIRECTORIES;
     const char *db_file = "code-index.db"; /* Default database location */
+    int ndjson = 0;                        /* --format=ndjson */
+    int flatten_embeds = 0;                /* --flatten-embeds */
+    const char *output_path = NULL;        /* --output (default: stdout) */
+    int workers = parse_pool_default_workers(); /* --workers */
+    int rebuild = 0;                       /* --rebuild */
+    int strict = 0;                        /* --strict */
+    int stats = 0;                         /* --stats */
+    int stats_json = 0;                    /* --stats=json */
+    const char *since = NULL;              /* --since=<git-ref> */
+    long long max_file_size = DEFAULT_MAX_FILE_SIZE; /* --max-file-size (0: no limit) */
+    int exclude_generated = 0;             /* --exclude-generated */
+    const char *files_from = NULL;         /* --files-from (-: stdin) */
+    int force_extension = 0;               /* --force-extension */
+    const char *extensions_list = NULL;    /* --extensions (instead of the configured ones) */
+    const char *extensions_add = NULL;     /* --extensions-add */
+    int warn_duplicates = 0;               /* --warn-duplicates */
+    int follow_symlinks = 0;               /* --follow-symlinks */
 
     /* Parse arguments */
     for (int i = 1; i < argc; i++) {
         if (strcmp(argv[i], "--once") == 0) {
             daemon_mode = 0;
+            once_requested = 1;
+        } else if (strcmp(argv[i], "--watch") == 0) {
+            daemon_mode = 1;
+            watch_requested = 1;
         } else if (strcmp(argv[i], "--quiet-init") == 0) {
             quiet_init = 1;
+        } else if (strcmp(argv[i], "--quiet") == 0) {
+            quiet = 1;
         } else if (strcmp(argv[i], "--silent") == 0) {
             silent = 1;
         } else if (strcmp(argv[i], "--verbose") == 0) {
             verbose = 1;
+        } else if (strcmp(argv[i], "--flatten-embeds") == 0) {
+            flatten_embeds = 1;
+        } else if (strcmp(argv[i], "--exclude-generated") == 0) {
+            exclude_generated = 1;
+        } else if (strcmp(argv[i], "--rebuild") == 0) {
+            rebuild = 1;
+        } else if (strcmp(argv[i], "--strict") == 0) {
+            strict = 1;
+        } else if (strcmp(argv[i], "--stats") == 0 || strncmp(argv[i], "--stats=", 8) == 0) {
+            stats = 1;
+            if (argv[i][7] == '=') {
+                if (strcmp(argv[i] + 8, "json") == 0) {
+                    stats_json = 1;
+                } else if (strcmp(argv[i] + 8, "text") != 0) {
+                    fprintf(stderr, "Error: unknown stats format '%s' (expected text or json)\n", argv[i] + 8);
+                    return 1;
+                }
+            }
+        } else if (strcmp(argv[i], "--since") == 0 || strncmp(argv[i], "--since=", 8) == 0) {
+            if (argv[i][7] == '=') {
+                since = argv[i] + 8;
+            } else if (i + 1 < argc) {
+                since = argv[++i];
+            }
+            if (!since || since[0] == '\0') {
+                fprintf(stderr, "Error: --since requires a git ref\n");
+                return 1;
+            }
+        } else if (strcmp(argv[i], "--max-file-size") == 0 || strncmp(argv[i], "--max-file-size=", 16) == 0) {
+            const char *size = NULL;
+            if (argv[i][15] == '=') {
+                size = argv[i] + 16;
+            } else if (i + 1 < argc) {
+                size = argv[++i];
+            }
+            if (!size || parse_file_size(size, &max_file_size) != 0) {
+                fprintf(stderr, "Error: --max-file-size requires a size in bytes (K, M or G suffix allowed, 0 for no limit)\n");
+                return 1;
+            }
+        } else if (strcmp(argv[i], "--files-from") == 0 || strncmp(argv[i], "--files-from=", 13) == 0) {
+            if (argv[i][12] == '=') {
+                files_from = argv[i] + 13;
+            } else if (i + 1 < argc) {
+                files_from = argv[++i];
+            }
+            if (!files_from || files_from[0] == '\0') {
+                fprintf(stderr, "Error: --files-from requires a path (- for stdin)\n");
+                return 1;
+            }
+        } else if (strcmp(argv[i], "--force-extension") == 0) {
+            force_extension = 1;
+        } else if (strcmp(argv[i], "--extensions") == 0 || strncmp(argv[i], "--extensions=", 13) == 0 ||
+                   strcmp(argv[i], "--extensions-add") == 0 || strncmp(argv[i], "--extensions-add=", 17) == 0) {
+            int add = strncmp(argv[i], "--extensions-add", 16) == 0;
+            const char *option = add ? "--extensions-add" : "--extensions";
+            size_t len = strlen(option);
+            const char *list = NULL;
+            if (argv[i][len] == '=') {
+                list = argv[i] + len + 1;
+            } else if (i + 1 < argc) {
+                list = argv[++i];
+            }
+            if (!list) {
+                fprintf(stderr, "Error: %s requires a list of extensions (e.g. .py,.pyw)\n", option);
+                return 1;
+            }
+            if (add) {
+                extensions_add = list;
+            } else {
+                extensions_list = list;
+            }
+        } else if (strcmp(argv[i], "--warn-duplicates") == 0) {
+            warn_duplicates = 1;
+        } else if (strcmp(argv[i], "--follow-symlinks") == 0) {
+            follow_symlinks = 1;
         } else if (strcmp(argv[i], "--debug") == 0) {
             debug = 1;
         } else if (strcmp(argv[i], "--echo") == 0) {
@@ -304,6 +2443,49 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
                 fprintf(stderr, "Error: --db-file/-f requires an argument\n");
                 return 1;
             }
+        } else if (strncmp(argv[i], "--format", 8) == 0 &&
+                   (argv[i][8] == '=' || argv[i][8] == '\0')) {
+            const char *format = NULL;
+            if (argv[i][8] == '=') {
+                format = argv[i] + 9;
+            } else if (i + 1 < argc) {
+                format = argv[++i];
+            }
+            if (!format || format[0] == '\0') {
+                fprintf(stderr, "Error: --format requires an argument (text or ndjson)\n");
+                return 1;
+            }
+            if (strcmp(format, "ndjson") == 0) {
+                ndjson = 1;
+            } else if (strcmp(format, "text") == 0) {
+                ndjson = 0;
+            } else {
+                fprintf(stderr, "Error: unknown format '%s' (expected text or ndjson)\n", format);
+                return 1;
+            }
+        } else if (strcmp(argv[i], "--output") == 0 || strncmp(argv[i], "--output=", 9) == 0) {
+            if (argv[i][8] == '=') {
+                output_path = argv[i] + 9;
+            } else if (i + 1 < argc) {
+                i++;
+                output_path = argv[i];
+            } else {
+                fprintf(stderr, "Error: --output requires an argument\n");
+                return 1;
+            }
+        } else if (strcmp(argv[i], "--workers") == 0 || strncmp(argv[i], "--workers=", 10) == 0) {
+            if (argv[i][9] == '=') {
+                workers = atoi(argv[i] + 10);
+            } else if (i + 1 < argc) {
+                i++;
+                workers = atoi(argv[i]);
+            } else {
+                workers = 0;
+            }
+            if (workers < 1) {
+                fprintf(stderr, "Error: --workers requires a positive number\n");
+                return 1;
+            }
         } else if (strcmp(argv[i], "--exclude-dir") == 0) {
             /* Collect all exclude dirs until we hit another flag or end */
             while (i + 1 < argc && argv[i + 1][0] != '-') {
@@ -320,11 +2502,31 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
         }
     }
 
-    if (target_count == 0) {
+    if (files_from && target_count > 0) {
+        fprintf(stderr, "Error: --files-from cannot be combined with directory or file targets\n");
+        return 1;
+    }
+    if (target_count == 0 && !files_from) {
         fprintf(stderr, "Error: at least one directory or file required\n");
         return 1;
     }
 
+    if (output_path && !ndjson) {
+        fprintf(stderr, "Error: --output requires --format=ndjson\n");
+        return 1;
+    }
+
+    /* Keep stdout clean for the JSON stream */
+    if (ndjson && !output_path) {
+        silent = 1;
+        verbose = 0;
+    }
+
+    if (watch_requested && once_requested) {
+        fprintf(stderr, "Error: --watch and --once cannot be used together\n");
+        return 1;
+    }
+
     /* Determine mode: files or directories */
     int dir_count = 0;
     int file_count = 0;
@@ -341,40 +2543,114 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
         return 1;
     }
 
-    mode = (file_count > 0) ? MODE_FILES : MODE_DIRECTORIES;
+    mode = (file_count > 0 || files_from) ? MODE_FILES : MODE_DIRECTORIES;
 
     /* Daemon mode only works with directory mode */
+    if (mode == MODE_FILES && watch_requested) {
+        fprintf(stderr, "Error: --watch requires directory targets (file targets are indexed once)\n");
+        return 1;
+    }
     if (mode == MODE_FILES && daemon_mode) {
         daemon_mode = 0;  /* Silently disable for file mode */
     }
 
+    /* --since is a single pass over the changes of a branch */
+    if (since && mode == MODE_FILES) {
+        fprintf(stderr, "Error: --since requires directory targets\n");
+        return 1;
+    }
+    if (since && watch_requested) {
+        fprintf(stderr, "Error: --since and --watch cannot be used together\n");
+        return 1;
+    }
+    if (since) {
+        daemon_mode = 0;
+    }
+    /* A daemon outlives the files it maps: one truncated mid-parse would
+     * kill it with SIGBUS */
+    if (daemon_mode) {
+        source_file_set_mapping(0);
+    }
+
     /* PREFLIGHT VALIDATION - Check ALL configuration before proceeding */
-    if (preflight_validation(config->data_dir, verbose) != 0) {
+    if (preflight_validation(config->data_dir, mode == MODE_DIRECTORIES ? targets : NULL,
+                             mode == MODE_DIRECTORIES ? target_count : 0,
+                             extensions_list, extensions_add, verbose) != 0) {
         return EXIT_FAILURE;
     }
 
+    /* Not a git repository or an unknown ref: fail rather than index everything */
+    GitChanges changes = { .count = 0 };
+    if (since && git_changes_load(&changes, since) != 0) {
+        return 1;
+    }
+
     /* Initialize filter (all files validated) */
     SymbolFilter *filter = malloc(sizeof(SymbolFilter));
     if (!filter) {
         fprintf(stderr, "Failed to allocate memory for filter\n");
+        git_changes_free(&changes);
         return 1;
     }
     if (filter_init(filter, config->data_dir) != 0) {
         fprintf(stderr, "Warning: Failed to load filter data\n");
     }
+    /* This run only; the configuration is left as it is */
+    char extensions_error[ERROR_MESSAGE_BUFFER];
+    if (filter_override_extensions(&filter->file_extensions, extensions_list, extensions_add,
+                                   extensions_error, sizeof(extensions_error)) != 0) {
+        fprintf(stderr, "Error: %s\n", extensions_error);
+        filter_free_regex(filter);
+        free(filter);
+        git_changes_free(&changes);
+        return 1;
+    }
 
     const FileExtensions *extensions = filter_get_extensions(filter);
     const WordSet *ignore_dirs = filter_get_ignore_dirs(filter);
 
+    /* Files to index in file mode: the targets, or the --files-from list */
+    char **file_targets = targets;
+    int file_target_count = target_count;
+    FileList listed_files;
+    init_file_list(&listed_files);
+    if (files_from) {
+        if (read_file_list(files_from, &listed_files) != 0) {
+            free_file_list(&listed_files);
+            filter_free_regex(filter);
+            free(filter);
+            return 1;
+        }
+        /* Listed files bypass the walk and its ignore lists, but not the
+         * extensions unless forced */
+        int kept = 0;
+        for (int i = 0; i < listed_files.count; i++) {
+            char *path = listed_files.files[i];
+            if (is_directory(path)) {
+                fprintf(stderr, "Warning: skipping '%s' (a directory)\n", path);
+                free(path);
+            } else if (!force_extension && !path_matches_extensions(path, extensions)) {
+                fprintf(stderr, "Warning: skipping '%s' (does not match configured extensions)\n", path);
+                free(path);
+            } else {
+                listed_files.files[kept++] = path;
+            }
+        }
+        listed_files.count = kept;
+        file_targets = listed_files.files;
+        file_target_count = listed_files.count;
+    }
+
     /* Validate file extensions if in file mode */
-    if (mode == MODE_FILES) {
+    if (mode == MODE_FILES && !files_from && !force_extension) {
         for (int i = 0; i < target_count; i++) {
-            if (!path_matches_extensions(targets[i], extensions)) {
+            if (!path_matches_extensions(targets[i], extensions) && !is_archive_path(targets[i])) {
                 fprintf(stderr, "Error: File '%s' does not match configured extensions:", targets[i]);
                 for (int j = 0; j < extensions->count; j++) {
                     fprintf(stderr, " %s", extensions->extensions[j]);
                 }
                 fprintf(stderr, "\n");
+                free_file_list(&listed_files);
                 filter_free_regex(filter);
                 free(filter);
                 return 1;
@@ -389,6 +2665,9 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
                 printf(" %s", targets[i]);
             }
             printf("\n");
+        } else if (files_from) {
+            printf("Indexing %d file%s listed in %s\n", file_target_count, file_target_count == 1 ? "" : "s",
+                   strcmp(files_from, "-") == 0 ? "stdin" : files_from);
         } else {
             printf("Indexing files:");
             for (int i = 0; i < target_count; i++) {
@@ -401,6 +2680,9 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
             printf(" %s", extensions->extensions[i]);
         }
         printf("\n");
+        if (since) {
+            printf("Files changed since %s: %d (%d deleted)\n", since, changes.count, changes.deleted);
+        }
         if (exclude_dirs.count > 0) {
             printf("Excluding directories:");
             for (int i = 0; i < exclude_dirs.count; i++) {
@@ -408,60 +2690,127 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
             }
             printf("\n");
         }
+        if (filter->min_symbol_length != MIN_SYMBOL_LENGTH || filter->max_symbol_length != SYMBOL_MAX_LENGTH - 1) {
+            printf("Symbol length limits: %d-%d\n", filter->min_symbol_length, filter->max_symbol_length);
+        }
+        if (filter->extractors.count > 0) {
+            printf("Custom extractors:");
+            for (int i = 0; i < filter->extractors.count; i++) {
+                printf(" %s", filter->extractors.extractors[i].kind);
+            }
+            printf("\n");
+        }
+    }
+
+    /* One run of this language per database; held until the end (watch mode too) */
+    IndexLock lock;
+    char lock_path[PATH_MAX_LENGTH];
+    long lock_owner = 0;
+    index_lock_path(db_file, language, lock_path, sizeof(lock_path));
+    int locked = index_lock_acquire(&lock, lock_path, &lock_owner);
+    if (locked != 0) {
+        if (locked > 0 && lock_owner > 0) {
+            fprintf(stderr, "Error: %s is already being indexed by %s (PID %ld; lock file %s)\n",
+                    db_file, config->name, lock_owner, lock_path);
+        } else if (locked > 0) {
+            fprintf(stderr, "Error: %s is already being indexed by %s (lock file %s)\n",
+                    db_file, config->name, lock_path);
+        } else {
+            fprintf(stderr, "Error: cannot create lock file %s\n", lock_path);
+        }
+        free_file_list(&listed_files);
+        filter_free_regex(filter);
+        git_changes_free(&changes);
+        free(filter);
+        return 1;
     }
 
-    /* Initialize database */
+    /* Initialize database (an index from another schema version is rebuilt) */
     CodeIndexDatabase db;
-    if (db_init(&db, db_file) != SQLITE_OK) {
+    int rebuilt = 0;
+    if (db_init_store(&db, db_file, &rebuilt) != SQLITE_OK) {
         fprintf(stderr, "Failed to initialize database\n");
+        index_lock_release(&lock);
+        free_file_list(&listed_files);
         filter_free_regex(filter);
+        git_changes_free(&changes);
         free(filter);
         return 1;
     }
 
+    if (rebuilt && !silent) {
+        printf("Index %s was built by an incompatible version; rebuilding it\n", db_file);
+    }
+
     /* Enable WAL mode for concurrent access */
     if (db_enable_concurrent_writes(&db) != SQLITE_OK) {
         fprintf(stderr, "Warning: Failed to enable WAL mode. Concurrent indexing may not work.\n");
         /* Continue anyway - not fatal */
     }
 
-    /* Initialize parser using language-specific callback */
-    void *parser = config->parser_init(filter);
-    if (!parser) {
-        fprintf(stderr, "Failed to initialize parser\n");
-        filter_free_regex(filter);
-        free(filter);
-        db_close(&db);
-        return 1;
+    /* Debug traces from several workers would interleave */
+    if (debug) {
+        workers = 1;
     }
 
-    /* Enable debug mode if requested */
-    if (debug && config->parser_set_debug) {
-        config->parser_set_debug(parser, 1);
+    /* Parser and result buffer for watch mode re-indexing; created after the
+     * initial pass, which uses one parser per worker */
+    void *parser = NULL;
+    ParseResult *result = NULL;
+
+    /* Open NDJSON destination (stdout unless --output was given); a file
+     * replaces the previous one only once the initial pass is complete */
+    FILE *ndjson_out = NULL;
+    AtomicFile output_file = { .fp = NULL };
+    if (ndjson) {
+        if (output_path) {
+            ndjson_out = atomic_file_open(&output_file, output_path);
+            if (!ndjson_out) {
+                fprintf(stderr, "Error: cannot open output file '%s'\n", output_path);
+                free_file_list(&listed_files);
+                filter_free_regex(filter);
+                git_changes_free(&changes);
+                free(filter);
+                db_close(&db);
+                index_lock_release(&lock);
+                return 1;
+            }
+        } else {
+            ndjson_out = stdout;
+        }
     }
 
-    /* Allocate and initialize parse result buffer */
-    ParseResult *result = malloc(sizeof(ParseResult));
-    if (!result) {
-        fprintf(stderr, "Failed to allocate memory for parse result\n");
-        config->parser_free(parser);
-        filter_free_regex(filter);
-        free(filter);
-        db_close(&db);
-        return 1;
+    int total_files_processed = 0;
+    int total_files_unchanged = 0;     /* Skipped: content hash matched the index */
+    int total_files_skipped = 0;       /* Over --max-file-size */
+    int total_files_generated = 0;     /* Generated, with --exclude-generated */
+    int total_files_parsed = 0;
+    long long total_bytes = 0;
+
+    /* Only needed in watch mode, but cheap to keep during the initial pass */
+    FileStampTable stamps;
+    stamp_table_init(&stamps);
+
+    /* .sourceminderignore of each directory target, kept for watch mode */
+    IgnoreRules *ignore_rules = NULL;
+    if (mode == MODE_DIRECTORIES) {
+        ignore_rules = load_target_ignore_rules(targets, target_count, !quiet_init && !silent);
     }
 
-    if (init_parse_result(result) != 0) {
-        fprintf(stderr, "Failed to initialize parse result\n");
-        free(result);
-        config->parser_free(parser);
-        filter_free_regex(filter);
-        free(filter);
-        db_close(&db);
-        return 1;
-    }
+    int index_failed = 0;  /* Parse workers could not be set up */
 
-    int total_files_processed = 0;
+    /* Files of the initial pass that could not be parsed */
+    ParseErrorReport parse_errors;
+    parse_report_init(&parse_errors);
+
+    struct timespec started;
+    clock_gettime(CLOCK_MONOTONIC, &started);
+
+    /* On a terminal the progress line replaces the per-file lines; --verbose
+     * keeps them, --quiet drops both */
+    Progress progress;
+    progress_init(&progress, !quiet && !quiet_init && !silent && !verbose && progress_available());
+    int announce_files = !quiet && !quiet_init && !silent && !progress.enabled;
 
     /* Begin transaction for better performance */
     db_begin_transaction(&db);
@@ -476,38 +2825,76 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
             snprintf(cwd, sizeof(cwd), ".");
         }
 
-        for (int i = 0; i < target_count; i++) {
-            /* Parse the file to get directory and filename */
-            if (config->parser_parse(parser, targets[i], cwd, result) == 0) {
-                if (result->count > 0) {
-                    /* Delete existing entries if database existed */
-                    if (db_already_exists) {
-                        db_delete_by_file(&db, result->entries[0].directory, result->entries[0].filename);
-                    }
-
-                    /* Insert new entries */
-                    for (int j = 0; j < result->count; j++) {
-                        db_insert(&db, &result->entries[j]);
+        IndexPass pass = {
+            .db = &db,
+            .filter = filter,
+            .ndjson_out = ndjson_out,
+            .project_root = cwd,
+            .language = language,
+            .errors = &parse_errors,
+            .max_file_size = max_file_size,
+            .exclude_generated = exclude_generated,
+            .replace_existing = db_already_exists,  /* Only if database existed */
+            .announce = announce_files,
+            .progress = &progress,
+        };
+        qsort(file_targets, (size_t)file_target_count, sizeof(char *), compare_paths);
+
+        /* Archive targets go last, each indexed entry by entry */
+        int source_count = file_target_count;
+        if (!files_from) {
+            source_count = 0;
+            char **archives = malloc((size_t)file_target_count * sizeof(char *));
+            if (archives) {
+                int archive_count = 0;
+                for (int i = 0; i < file_target_count; i++) {
+                    if (is_archive_path(file_targets[i])) {
+                        archives[archive_count++] = file_targets[i];
+                    } else {
+                        file_targets[source_count++] = file_targets[i];
                     }
                 }
+                memcpy(file_targets + source_count, archives, (size_t)archive_count * sizeof(char *));
+                free(archives);
+            } else {
+                fprintf(stderr, "Failed to allocate memory for archive targets\n");
+                index_failed = 1;
+            }
+        }
 
-                if (!quiet_init && !silent) {
-                    printf("Indexed %s: %d entries\n", targets[i], result->count);
-                }
-                total_files_processed++;
+        if (!index_failed && run_index_pass(&pass, config, filter, workers, debug, file_targets,
+                                            source_count, rebuild) != 0) {
+            index_failed = 1;
+        }
+        total_files_processed += source_count;
+        for (int i = source_count; i < file_target_count && !index_failed; i++) {
+            int entries = index_archive(&pass, config, filter, file_targets[i], &exclude_dirs, rebuild,
+                                        !quiet_init && !silent);
+            if (entries < 0) {
+                index_failed = 1;
+            } else {
+                total_files_processed += entries;
             }
         }
+        total_files_unchanged += pass.unchanged;
+        total_files_skipped += pass.skipped;
+        total_files_generated += pass.generated;
+        total_files_parsed += pass.parsed;
+        total_bytes += pass.bytes;
     } else {
         /* Directory mode: walk directories and find files */
         FileList *files = malloc(sizeof(FileList));
         if (!files) {
             fprintf(stderr, "Failed to allocate memory for file list\n");
-            free_parse_result(result);
-            free(result);
-            config->parser_free(parser);
+            atomic_file_abort(&output_file);
+            parse_report_free(&parse_errors);
+            stamp_table_free(&stamps);
+            free_target_ignore_rules(ignore_rules, target_count);
             filter_free_regex(filter);
+            git_changes_free(&changes);
             free(filter);
             db_close(&db);
+            index_lock_release(&lock);
             return 1;
         }
         init_file_list(files);
@@ -518,38 +2905,63 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
             snprintf(cwd, sizeof(cwd), ".");
         }
 
+        WalkOptions walk_options = { .follow_symlinks = follow_symlinks, .verbose = verbose && !silent };
+
         for (int dir_idx = 0; dir_idx < target_count; dir_idx++) {
             /* Reset file list for each directory */
             if (dir_idx > 0) {
                 free_file_list(files);
                 init_file_list(files);
             }
-            find_files(targets[dir_idx], files, &exclude_dirs, extensions, ignore_dirs);
+            find_files(targets[dir_idx], files, &exclude_dirs, extensions, ignore_dirs,
+                       ignore_rules ? &ignore_rules[dir_idx] : NULL, &walk_options);
 
             if (!quiet_init && !silent && target_count > 1) {
                 printf("Found %d files in %s\n", files->count, targets[dir_idx]);
             }
 
-            /* Index each file */
-            for (int i = 0; i < files->count; i++) {
-                if (config->parser_parse(parser, files->files[i], cwd, result) == 0) {
-                    if (result->count > 0) {
-                        /* Delete existing entries for this file */
-                        db_delete_by_file(&db, result->entries[0].directory, result->entries[0].filename);
-
-                        /* Insert new entries */
-                        for (int j = 0; j < result->count; j++) {
-                            db_insert(&db, &result->entries[j]);
-                        }
-                    }
+            /* Index each file; sorted so output does not depend on walk or worker order */
+            IndexPass pass = {
+                .db = &db,
+                .filter = filter,
+                .ndjson_out = ndjson_out,
+                .stamps = daemon_mode ? &stamps : NULL,
+                .project_root = cwd,
+                .language = language,
+                .errors = &parse_errors,
+                .changed = since ? &changes : NULL,
+                .max_file_size = max_file_size,
+                .exclude_generated = exclude_generated,
+                .replace_existing = 1,
+                .announce = announce_files,
+                .progress = &progress,
+            };
+            qsort(files->files, (size_t)files->count, sizeof(char *), compare_paths);
+            if (run_index_pass(&pass, config, filter, workers, debug, files->files, files->count,
+                               rebuild) != 0) {
+                index_failed = 1;
+                break;
+            }
 
-                    if (!quiet_init && !silent) {
-                        printf("Indexed %s: %d entries\n", files->files[i], result->count);
-                    }
-                }
+            /* Drop files deleted since the index was built */
+            char prefix[DIRECTORY_MAX_LENGTH];
+            char unused[FILENAME_MAX_LENGTH];
+            char probe[PATH_MAX_LENGTH];
+            size_t target_len = strlen(targets[dir_idx]);
+            snprintf(probe, sizeof(probe), "%s%s-", targets[dir_idx],
+                     (target_len > 0 && targets[dir_idx][target_len - 1] == '/') ? "" : "/");
+            get_relative_path(probe, cwd, prefix, unused);
+            int removed = db_delete_missing_files(&db, prefix);
+            if (removed > 0 && !quiet_init && !silent) {
+                printf("Removed %d deleted file%s from the index\n", removed, removed == 1 ? "" : "s");
             }
 
             total_files_processed += files->count;
+            total_files_unchanged += pass.unchanged;
+            total_files_skipped += pass.skipped;
+            total_files_generated += pass.generated;
+            total_files_parsed += pass.parsed;
+            total_bytes += pass.bytes;
         }
 
         free_file_list(files);
@@ -558,9 +2970,111 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
 
     /* Commit transaction */
     db_commit_transaction(&db);
+    git_changes_free(&changes);
 
-    if (!quiet_init && !silent) {
-        printf("Indexing complete: %d files processed\n", total_files_processed);
+    /* The pool already reported why; keep what was indexed but stop here */
+    if (index_failed) {
+        daemon_mode = 0;
+        flatten_embeds = 0;
+    }
+
+    if (!quiet_init && !silent && !index_failed) {
+        /* Only the non-zero counts: "(3 unchanged, 1 skipped)" */
+        char details[LINE_BUFFER_MEDIUM] = "";
+        size_t used = 0;
+        const char *labels[] = { "unchanged", "failed", "skipped", "generated" };
+        int counts[] = { total_files_unchanged, parse_errors.count, total_files_skipped,
+                         total_files_generated };
+        for (size_t k = 0; k < sizeof(counts) / sizeof(counts[0]); k++) {
+            if (counts[k] > 0 && used < sizeof(details)) {
+                int written = snprintf(details + used, sizeof(details) - used, "%s%d %s",
+                                       used > 0 ? ", " : "", counts[k], labels[k]);
+                if (written > 0) {
+                    used += (size_t)written;
+                }
+            }
+        }
+        if (used > 0) {
+            printf("Indexing complete: %d files processed (%s)\n", total_files_processed, details);
+        } else {
+            printf("Indexing complete: %d files processed\n", total_files_processed);
+        }
+    }
+    if (verbose && exclude_generated && !silent && !index_failed) {
+        printf("Skipped %d generated file%s\n", total_files_generated, total_files_generated == 1 ? "" : "s");
+    }
+
+    /* On stderr even with --silent, like other errors */
+    fflush(stdout);
+    parse_report_print(&parse_errors);
+    int strict_failed = strict && parse_errors.count > 0;
+    int files_failed = parse_errors.count;
+    parse_report_free(&parse_errors);
+
+    if (flatten_embeds) {
+        run_flatten_embeds(&db, NULL, verbose, silent || quiet_init, ndjson_out);
+    } else if (!index_failed) {
+        /* Promoted rows of an earlier --flatten-embeds run would go stale */
+        db_begin_transaction(&db);
+        if (clear_interface_embeds(&db) != 0) {
+            fprintf(stderr, "Warning: Failed to remove promoted interface methods\n");
+        }
+        db_commit_transaction(&db);
+    }
+    if (!index_failed) {
+        run_implements(&db, language, NULL, verbose, silent || quiet_init);
+    }
+
+    /* Warnings, so on stderr even with --silent */
+    if (warn_duplicates && !index_failed) {
+        duplicates_report(&db, stderr);
+    }
+
+    /* Asked for explicitly, so shown even with --silent; on stderr when
+     * stdout carries the NDJSON stream */
+    if (stats && !index_failed) {
+        IndexStats index_stats = {
+            .files = total_files_processed,
+            .parsed = total_files_parsed,
+            .unchanged = total_files_unchanged,
+            .failed = files_failed,
+            .skipped = total_files_skipped,
+            .generated = total_files_generated,
+            .bytes = total_bytes,
+        };
+        struct timespec finished;
+        clock_gettime(CLOCK_MONOTONIC, &finished);
+        index_stats.elapsed = (double)(finished.tv_sec - started.tv_sec) +
+                              (double)(finished.tv_nsec - started.tv_nsec) / 1e9;
+        int root_count = 0;
+        IndexStatsRoot *roots = stats_roots(mode == MODE_FILES ? file_targets : targets,
+                                            mode == MODE_FILES ? file_target_count : target_count,
+                                            &root_count);
+        if (!roots) {
+            fprintf(stderr, "Failed to allocate memory for symbol counts\n");
+        } else if (index_stats_collect(&db, language, roots, root_count, &index_stats) == 0) {
+            index_stats_print((ndjson && !output_path) ? stderr : stdout, &index_stats, stats_json);
+        }
+        free(roots);
+    }
+    free_file_list(&listed_files);
+
+    /* Move the --output file into place; watch mode then appends to it */
+    if (output_path) {
+        ndjson_out = NULL;
+        if (index_failed) {
+            atomic_file_abort(&output_file);
+        } else if (atomic_file_commit(&output_file) != 0) {
+            fprintf(stderr, "Error: cannot write output file '%s'\n", output_path);
+            index_failed = 1;
+            daemon_mode = 0;
+        } else if (daemon_mode && mode == MODE_DIRECTORIES) {
+            ndjson_out = fopen(output_path, "a");
+            if (!ndjson_out) {
+                fprintf(stderr, "Warning: cannot reopen output file '%s'; re-indexed symbols are not written\n",
+                        output_path);
+            }
+        }
     }
 
     /* Enter daemon mode if enabled and in directory mode */
@@ -569,20 +3083,45 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
         signal(SIGINT, signal_handler);
         signal(SIGTERM, signal_handler);
 
-        if (!silent) {
-            printf("Watching for file changes (Press Ctrl+C to stop)...\n");
+        /* Initialize parser using language-specific callback */
+        parser = config->parser_init(filter);
+        if (parser) {
+            result = malloc(sizeof(ParseResult));
+            if (result && init_parse_result(result) != 0) {
+                free(result);
+                result = NULL;
+            }
         }
+        FileWatcher *watcher = NULL;
+        if (!parser) {
+            report_parser_init_failure(config, NULL);
+        } else if (!result) {
+            fprintf(stderr, "Failed to initialize parse result\n");
+        } else {
+            /* Enable debug mode if requested */
+            if (debug && config->parser_set_debug) {
+                config->parser_set_debug(parser, 1);
+            }
+
+            if (!silent) {
+                printf("Watching for file changes (Press Ctrl+C to stop)...\n");
+            }
 
-        /* Initialize file watcher */
-        FileWatcher *watcher = file_watcher_init();
+            /* Initialize file watcher */
+            watcher = file_watcher_init();
+            if (!watcher) {
+                fprintf(stderr, "Failed to initialize file watcher\n");
+            }
+        }
         if (!watcher) {
-            fprintf(stderr, "Failed to initialize file watcher\n");
-            free_parse_result(result);
-            free(result);
-            config->parser_free(parser);
+            if (ndjson_out && ndjson_out != stdout) fclose(ndjson_out);
+            stamp_table_free(&stamps);
+            free_target_ignore_rules(ignore_rules, target_count);
+            free_watch_parser(config, parser, result);
             filter_free_regex(filter);
             free(filter);
             db_close(&db);
+            index_lock_release(&lock);
             return 1;
         }
 
@@ -601,8 +3140,16 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
             }
         }
 
+        /* Get current working directory for relative path calculation */
+        char cwd[PATH_MAX_LENGTH];
+        if (getcwd(cwd, sizeof(cwd)) == NULL) {
+            snprintf(cwd, sizeof(cwd), ".");
+        }
+
         /* Watch loop */
         FileEvent events[MAX_FILE_EVENTS];
+        WalkOptions walk_options = { .follow_symlinks = follow_symlinks, .verbose = verbose && !silent };
+        int tick = 0;
         while (keep_running) {
             int event_count = file_watcher_wait(watcher, events, MAX_FILE_EVENTS);
             if (event_count < 0) {
@@ -613,70 +3160,165 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
             }
 
             if (event_count == 0) continue;
+            tick++;
 
             /* Re-index changed files */
             db_begin_transaction(&db);
 
+            FileList batch;
+            FileList packages;  /* Directories of the files changed */
+            init_file_list(&batch);
+            init_file_list(&packages);
+            int purged = 0;
             for (int i = 0; i < event_count; i++) {
-                /* Check if file should be ignored based on ignore_files.txt
-                 * Need to check each directory component in the path */
-                int should_skip = 0;
-                char path_copy[PATH_MAX_LENGTH];
-                int written = snprintf(path_copy, sizeof(path_copy), "%s", events[i].filepath);
-                if (written >= (int)sizeof(path_copy)) {
-                    fprintf(stderr, "FATAL: filepath exceeds PATH_MAX_LENGTH (%d): %s\n",
-                            PATH_MAX_LENGTH, events[i].filepath);
-                    exit(1);
+                int is_dir = events[i].type == FILE_EVENT_DIRECTORY;
+                const IgnoreRules *rules = target_ignore_rules(ignore_rules, target_count, events[i].filepath);
+                if (is_watch_path_ignored(events[i].filepath, is_dir, ignore_dirs, rules)) {
+                    continue;
                 }
+                if (!is_dir) {
+                    add_file_to_list(&batch, events[i].filepath);
+                    continue;
+                }
+                /* Its files get no events: purge those gone (deleted, moved
+                 * out or renamed) and index those now under it */
+                int removed = purge_missing_under(&db, &stamps, events[i].filepath, cwd, &packages);
+                if (removed > 0) {
+                    purged += removed;
+                    if (!silent) {
+                        printf("Removed: %s/ (%d files)\n", events[i].filepath, removed);
+                    }
+                }
+                if (is_directory(events[i].filepath)) {
+                    find_files(events[i].filepath, &batch, &exclude_dirs, extensions, ignore_dirs,
+                               rules, &walk_options);
+                }
+            }
 
-                /* Use strtok_r for thread safety (future-proofing) */
-                char *saveptr;
-                char *token = strtok_r(path_copy, "/", &saveptr);
-                char partial_path[PATH_MAX_LENGTH] = "";
+            size_t slots = batch.count > 0 ? (size_t)batch.count : 1;
+            WatchAction *actions = calloc(slots, sizeof(WatchAction));
+            int *symbol_counts = calloc(slots, sizeof(int));
+            if (!actions || !symbol_counts) {
+                fprintf(stderr, "Error: out of memory handling file events\n");
+                free(actions);
+                free(symbol_counts);
+                free_file_list(&batch);
+                free_file_list(&packages);
+                db_commit_transaction(&db);
+                break;
+            }
 
-                while (token != NULL) {
-                    /* Build partial path */
-                    if (partial_path[0] != '\0') {
-                        strncat(partial_path, "/", sizeof(partial_path) - strlen(partial_path) - 1);
+            for (int i = 0; i < batch.count; i++) {
+                const char *path = batch.files[i];
+                actions[i] = WATCH_SKIPPED;
+                symbol_counts[i] = 0;
+
+                /* Same directory/filename pair the parser stores, so deletes
+                 * work even when the file no longer exists */
+                char directory[DIRECTORY_MAX_LENGTH];
+                char filename[FILENAME_MAX_LENGTH];
+                char key[STAMP_KEY_MAX_LENGTH];
+                get_relative_path(path, cwd, directory, filename);
+                stamp_key(key, sizeof(key), directory, filename);
+
+                /* Trust the file system over the event type: a rename reports
+                 * the old name as deleted and the new one as created, and an
+                 * editor's save-by-rename may report either order */
+                struct stat st;
+                if (stat(path, &st) != 0 || !S_ISREG(st.st_mode)) {
+                    char stored[FILE_HASH_LENGTH];
+                    if (!db_get_file_hash(&db, directory, filename, stored, sizeof(stored))) {
+                        stamp_table_forget(&stamps, key);
+                        continue;  /* Never indexed, or purged with its directory */
                     }
-                    strncat(partial_path, token, sizeof(partial_path) - strlen(partial_path) - 1);
+                    db_delete_by_file(&db, directory, filename);
+                    stamp_table_forget(&stamps, key);
+                    add_changed_package(&packages, directory);
+                    actions[i] = WATCH_REMOVED;
 
-                    /* Check if this directory component should be ignored */
-                    if (is_path_ignored(partial_path, token, ignore_dirs)) {
-                        should_skip = 1;
-                        break;
+                    if (!silent) {
+                        printf("Removed: %s\n", path);
                     }
-                    token = strtok_r(NULL, "/", &saveptr);
+                    continue;
                 }
 
-                if (should_skip) {
-                    continue;  /* Skip ignored files */
+                int stamp = stamp_table_unchanged(&stamps, key, &st);
+                if (stamp == 1 || (stamp < 0 && stored_hash_matches(&db, filter, path, directory, filename))) {
+                    stamp_table_set(&stamps, key, &st);
+                    actions[i] = WATCH_UNCHANGED;
+                    continue;
                 }
 
-                /* Get current working directory for relative path calculation */
-                char cwd[PATH_MAX_LENGTH];
-                if (getcwd(cwd, sizeof(cwd)) == NULL) {
-                    snprintf(cwd, sizeof(cwd), ".");
+                if (max_file_size > 0 && (long long)st.st_size > max_file_size) {
+                    skip_oversized_file(&db, path, directory, filename,
+                                        (long long)st.st_size, max_file_size);
+                    stamp_table_set(&stamps, key, &st);
+                    add_changed_package(&packages, directory);
+                    actions[i] = WATCH_SKIPPED;
+                    continue;
                 }
 
-                if (config->parser_parse(parser, events[i].filepath, cwd, result) == 0) {
-                    if (result->count > 0) {
-                        /* Delete existing entries for this file */
-                        db_delete_by_file(&db, result->entries[0].directory, result->entries[0].filename);
-
-                        /* Insert new entries */
-                        for (int j = 0; j < result->count; j++) {
-                            db_insert(&db, &result->entries[j]);
-                        }
+                if (exclude_generated && is_generated_file(path)) {
+                    db_delete_by_file(&db, directory, filename);
+                    stamp_table_set(&stamps, key, &st);
+                    add_changed_package(&packages, directory);
+                    actions[i] = WATCH_SKIPPED;
+                    if (verbose && !silent) {
+                        printf("Skipped generated file: %s\n", path);
                     }
+                    continue;
+                }
+
+                char parse_error[ERROR_MESSAGE_BUFFER];
+                add_changed_package(&packages, directory);
+                if (reindex_file(config, parser, result, filter, &db, path, cwd,
+                                 language, ndjson_out, parse_error, sizeof(parse_error)) != 0) {
+                    fprintf(stderr, "Failed: %s: %s\n", path, parse_error);
+                } else {
+                    stamp_table_set(&stamps, key, &st);
+                    actions[i] = WATCH_REINDEXED;
+                    symbol_counts[i] = result->count;
 
                     if (!silent) {
-                        printf("Reindexed: %s (%d symbols)\n", events[i].filepath, result->count);
+                        printf("Reindexed: %s (%d symbols)\n", path, result->count);
                     }
                 }
             }
 
             db_commit_transaction(&db);
+
+            if (flatten_embeds && packages.count > 0) {
+                run_flatten_embeds(&db, &packages, verbose, silent, ndjson_out);
+            }
+            if (packages.count > 0) {
+                run_implements(&db, language, &packages, verbose, silent);
+            }
+
+            if (verbose && !silent) {
+                int reindexed = 0, removed = purged, unchanged = 0, skipped = 0;
+                for (int i = 0; i < batch.count; i++) {
+                    switch (actions[i]) {
+                        case WATCH_REINDEXED: reindexed++; break;
+                        case WATCH_REMOVED:   removed++;   break;
+                        case WATCH_UNCHANGED: unchanged++; break;
+                        case WATCH_SKIPPED:   skipped++;   break;
+                    }
+                }
+
+                printf("[watch] tick %d: %d re-indexed, %d removed, %d unchanged, %d skipped\n",
+                       tick, reindexed, removed, unchanged, skipped);
+                for (int i = 0; i < batch.count; i++) {
+                    if (actions[i] == WATCH_REINDEXED) {
+                        printf("[watch]   re-indexed %s (%d symbols)\n", batch.files[i], symbol_counts[i]);
+                    } else if (actions[i] == WATCH_REMOVED) {
+                        printf("[watch]   removed    %s\n", batch.files[i]);
+                    }
+                }
+            }
+            free(actions);
+            free(symbol_counts);
+            free_file_list(&batch);
+            free_file_list(&packages);
         }
 
         if (!silent) {
@@ -687,12 +3329,16 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
     }
 
     /* Cleanup */
-    free_parse_result(result);
-    free(result);
-    config->parser_free(parser);
+    if (ndjson_out && ndjson_out != stdout) {
+        fclose(ndjson_out);
+    }
+    stamp_table_free(&stamps);
+    free_target_ignore_rules(ignore_rules, target_count);
+    free_watch_parser(config, parser, result);
     filter_free_regex(filter);
     free(filter);
     db_close(&db);
+    index_lock_release(&lock);
 
     /* Free config args if we allocated a new argv */
     if (argv != original_argv) {
@@ -703,5 +3349,5 @@ int indexer_main(int argc, char *argv[], const IndexerConfig *config) {
         free(argv);  /* Free the argv array itself */
     }
 
-    return 0;
+    return (index_failed || strict_failed) ? 1 : 0;
 }
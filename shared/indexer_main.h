@@ -21,6 +21,7 @@
 #include "database.h"
 #include "filter.h"
 #include "parse_result.h"
+#include <tree_sitter/api.h>
 
 /* Function pointer types for language-specific operations */
 typedef void* (*ParserInitFunc)(SymbolFilter *filter);
@@ -29,14 +30,19 @@ typedef void (*ParserFreeFunc)(void *parser);
 
 /* Configuration for a specific language indexer */
 typedef void (*ParserSetDebugFunc)(void *parser, int debug);
+typedef const TSLanguage *(*GrammarLanguageFunc)(void);
 
 typedef struct IndexerConfig {
     const char *name;                 /* Indexer name (e.g., "index-ts", "index-c") */
-    const char *data_dir;             /* Language data directory (e.g., "typescript/data") */
+    const char *data_dir;             /* Language data directory (e.g., "typescript/config"); its
+                                       * first component is the language stored with each symbol */
     ParserInitFunc parser_init;       /* Function to initialize parser */
     ParserParseFunc parser_parse;     /* Function to parse a file */
     ParserFreeFunc parser_free;       /* Function to free parser resources */
     ParserSetDebugFunc parser_set_debug; /* Optional function to enable debug mode */
+    const char *grammar_name;         /* Grammar for error messages (e.g., "go") */
+    const char *grammar_version;      /* Grammar version detected by configure (may be "unknown") */
+    GrammarLanguageFunc language;     /* Optional: grammar entry point, for ABI checks */
 } IndexerConfig;
 
 /* Main indexer entry point
@@ -49,4 +55,21 @@ typedef struct IndexerConfig {
  */
 int indexer_main(int argc, char *argv[], const IndexerConfig *config);
 
+/* Check the grammar's ABI version against the linked tree-sitter runtime
+ *
+ * On mismatch, reports it (via report_parser_init_failure) naming
+ * first_file, the file that was about to be parsed (NULL if unknown).
+ *
+ * Returns: 0 if compatible or unknown (no language callback), -1 if not
+ */
+int check_grammar_abi(const IndexerConfig *config, const char *first_file);
+
+/* Explain a parser_init() failure on stderr
+ *
+ * Names the grammar and version the indexer was built with, the grammar
+ * ABI version and the range the tree-sitter runtime accepts (saying so
+ * explicitly when they do not match), and first_file (NULL if unknown).
+ */
+void report_parser_init_failure(const IndexerConfig *config, const char *first_file);
+
 #endif /* INDEXER_MAIN_H */
@@ -0,0 +1,43 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef INDEXERS_H
+#define INDEXERS_H
+
+#include "indexer_main.h"
+
+/*
+ * The IndexerConfig of each language (<language>/<prefix>_indexer.c)
+ *
+ * Each index-<language> command passes its language's config to
+ * indexer_main(); a program embedding the indexers (index_source.h) passes
+ * it to index_source() or source_indexer_new() instead. libsourceminder.a
+ * (make lib) holds the shared code and every language enabled by
+ * ./configure, so only the getters of those languages are defined; link
+ * it with -lsqlite3 -ltree-sitter -lpthread -lz.
+ */
+const IndexerConfig *c_indexer_config(void);
+const IndexerConfig *go_indexer_config(void);
+const IndexerConfig *javascript_indexer_config(void);
+const IndexerConfig *perl_indexer_config(void);
+const IndexerConfig *php_indexer_config(void);
+const IndexerConfig *python_indexer_config(void);
+const IndexerConfig *ruby_indexer_config(void);
+const IndexerConfig *rust_indexer_config(void);
+const IndexerConfig *typescript_indexer_config(void);
+
+#endif /* INDEXERS_H */
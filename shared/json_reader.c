@@ -0,0 +1,352 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "json_reader.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct {
+    const char *p;
+    const char *end;
+    char *error;
+    size_t error_size;
+} JsonParser;
+
+static void parse_fail(JsonParser *parser, const char *message) {
+    if (parser->error[0] == '\0') {
+        snprintf(parser->error, parser->error_size, "%s", message);
+    }
+}
+
+static void skip_space(JsonParser *parser) {
+    while (parser->p < parser->end &&
+           (*parser->p == ' ' || *parser->p == '\t' || *parser->p == '\n' || *parser->p == '\r')) {
+        parser->p++;
+    }
+}
+
+/* Consume the literal word (true, false, null) */
+static int parse_word(JsonParser *parser, const char *word) {
+    size_t len = strlen(word);
+    if ((size_t)(parser->end - parser->p) < len || memcmp(parser->p, word, len) != 0) {
+        parse_fail(parser, "invalid literal");
+        return -1;
+    }
+    parser->p += len;
+    return 0;
+}
+
+static int hex_value(char c) {
+    if (c >= '0' && c <= '9') return c - '0';
+    if (c >= 'a' && c <= 'f') return c - 'a' + 10;
+    if (c >= 'A' && c <= 'F') return c - 'A' + 10;
+    return -1;
+}
+
+/* Four hex digits after "\u"; -1 if malformed */
+static long parse_hex4(JsonParser *parser) {
+    if (parser->end - parser->p < 4) {
+        return -1;
+    }
+    long code = 0;
+    for (int i = 0; i < 4; i++) {
+        int digit = hex_value(parser->p[i]);
+        if (digit < 0) {
+            return -1;
+        }
+        code = code * 16 + digit;
+    }
+    parser->p += 4;
+    return code;
+}
+
+/* Append code point as UTF-8; out has room (escapes are longer than their encoding) */
+static size_t put_utf8(char *out, long code) {
+    if (code < 0x80) {
+        out[0] = (char)code;
+        return 1;
+    }
+    if (code < 0x800) {
+        out[0] = (char)(0xC0 | (code >> 6));
+        out[1] = (char)(0x80 | (code & 0x3F));
+        return 2;
+    }
+    if (code < 0x10000) {
+        out[0] = (char)(0xE0 | (code >> 12));
+        out[1] = (char)(0x80 | ((code >> 6) & 0x3F));
+        out[2] = (char)(0x80 | (code & 0x3F));
+        return 3;
+    }
+    out[0] = (char)(0xF0 | (code >> 18));
+    out[1] = (char)(0x80 | ((code >> 12) & 0x3F));
+    out[2] = (char)(0x80 | ((code >> 6) & 0x3F));
+    out[3] = (char)(0x80 | (code & 0x3F));
+    return 4;
+}
+
+/* Parse a string starting at the opening quote
+ * Returns: malloc'd decoded string, NULL on error */
+static char *parse_string(JsonParser *parser) {
+    parser->p++;  /* opening quote */
+    const char *start = parser->p;
+    while (parser->p < parser->end && *parser->p != '"') {
+        if (*parser->p == '\\') parser->p++;
+        parser->p++;
+    }
+    if (parser->p >= parser->end) {
+        parse_fail(parser, "unterminated string");
+        return NULL;
+    }
+    const char *close = parser->p;
+
+    /* Decoded text is never longer than the escaped text */
+    char *out = malloc((size_t)(close - start) + 1);
+    if (!out) {
+        parse_fail(parser, "out of memory");
+        return NULL;
+    }
+    size_t len = 0;
+    parser->p = start;
+    while (parser->p < close) {
+        unsigned char c = (unsigned char)*parser->p++;
+        if (c < 0x20) {
+            parse_fail(parser, "control character in string");
+            free(out);
+            return NULL;
+        }
+        if (c != '\\') {
+            out[len++] = (char)c;
+            continue;
+        }
+        char escape = *parser->p++;
+        switch (escape) {
+            case '"':  out[len++] = '"';  break;
+            case '\\': out[len++] = '\\'; break;
+            case '/':  out[len++] = '/';  break;
+            case 'b':  out[len++] = '\b'; break;
+            case 'f':  out[len++] = '\f'; break;
+            case 'n':  out[len++] = '\n'; break;
+            case 'r':  out[len++] = '\r'; break;
+            case 't':  out[len++] = '\t'; break;
+            case 'u': {
+                long code = parse_hex4(parser);
+                if (code >= 0xD800 && code <= 0xDBFF && close - parser->p >= 6 &&
+                    parser->p[0] == '\\' && parser->p[1] == 'u') {
+                    parser->p += 2;
+                    long low = parse_hex4(parser);
+                    if (low >= 0xDC00 && low <= 0xDFFF) {
+                        code = 0x10000 + ((code - 0xD800) << 10) + (low - 0xDC00);
+                    } else {
+                        code = -1;
+                    }
+                }
+                if (code < 0 || (code >= 0xD800 && code <= 0xDFFF)) {
+                    parse_fail(parser, "invalid \\u escape");
+                    free(out);
+                    return NULL;
+                }
+                len += put_utf8(out + len, code);
+                break;
+            }
+            default:
+                parse_fail(parser, "invalid escape in string");
+                free(out);
+                return NULL;
+        }
+    }
+    out[len] = '\0';
+    parser->p = close + 1;
+    return out;
+}
+
+static JsonValue *parse_value(JsonParser *parser, int depth);
+
+/* Parse the elements (array) or members (object) after the opening bracket */
+static int parse_container(JsonParser *parser, JsonValue *container, char closing, int depth) {
+    parser->p++;
+    skip_space(parser);
+    if (parser->p < parser->end && *parser->p == closing) {
+        parser->p++;
+        return 0;
+    }
+    JsonValue **tail = &container->child;
+    for (;;) {
+        char *key = NULL;
+        if (container->type == JSON_OBJECT) {
+            skip_space(parser);
+            if (parser->p >= parser->end || *parser->p != '"') {
+                parse_fail(parser, "expected member name");
+                return -1;
+            }
+            key = parse_string(parser);
+            if (!key) {
+                return -1;
+            }
+            skip_space(parser);
+            if (parser->p >= parser->end || *parser->p != ':') {
+                parse_fail(parser, "expected ':' after member name");
+                free(key);
+                return -1;
+            }
+            parser->p++;
+        }
+        JsonValue *element = parse_value(parser, depth + 1);
+        if (!element) {
+            free(key);
+            return -1;
+        }
+        element->key = key;
+        *tail = element;
+        tail = &element->next;
+
+        skip_space(parser);
+        if (parser->p < parser->end && *parser->p == ',') {
+            parser->p++;
+            continue;
+        }
+        if (parser->p < parser->end && *parser->p == closing) {
+            parser->p++;
+            return 0;
+        }
+        parse_fail(parser, closing == ']' ? "expected ',' or ']'" : "expected ',' or '}'");
+        return -1;
+    }
+}
+
+static int parse_number(JsonParser *parser, JsonValue *value) {
+    /* strtod needs a terminated copy; numbers are short */
+    char digits[64];
+    size_t len = 0;
+    while (parser->p + len < parser->end && len < sizeof(digits) - 1 &&
+           strchr("+-0123456789.eE", parser->p[len]) != NULL) {
+        len++;
+    }
+    memcpy(digits, parser->p, len);
+    digits[len] = '\0';
+    char *end;
+    value->number = strtod(digits, &end);
+    if (len == 0 || end != digits + len) {
+        parse_fail(parser, "invalid number");
+        return -1;
+    }
+    parser->p += len;
+    return 0;
+}
+
+static JsonValue *parse_value(JsonParser *parser, int depth) {
+    if (depth > JSON_MAX_DEPTH) {
+        parse_fail(parser, "nesting too deep");
+        return NULL;
+    }
+    skip_space(parser);
+    if (parser->p >= parser->end) {
+        parse_fail(parser, "unexpected end of input");
+        return NULL;
+    }
+    JsonValue *value = calloc(1, sizeof(JsonValue));
+    if (!value) {
+        parse_fail(parser, "out of memory");
+        return NULL;
+    }
+
+    int rc = 0;
+    char c = *parser->p;
+    if (c == '{') {
+        value->type = JSON_OBJECT;
+        rc = parse_container(parser, value, '}', depth);
+    } else if (c == '[') {
+        value->type = JSON_ARRAY;
+        rc = parse_container(parser, value, ']', depth);
+    } else if (c == '"') {
+        value->type = JSON_STRING;
+        value->string = parse_string(parser);
+        rc = value->string ? 0 : -1;
+    } else if (c == 't' || c == 'f') {
+        value->type = JSON_BOOL;
+        value->boolean = c == 't';
+        rc = parse_word(parser, c == 't' ? "true" : "false");
+    } else if (c == 'n') {
+        value->type = JSON_NULL;
+        rc = parse_word(parser, "null");
+    } else if (c == '-' || (c >= '0' && c <= '9')) {
+        value->type = JSON_NUMBER;
+        rc = parse_number(parser, value);
+    } else {
+        parse_fail(parser, "unexpected character");
+        rc = -1;
+    }
+    if (rc != 0) {
+        json_free(value);
+        return NULL;
+    }
+    return value;
+}
+
+JsonValue *json_parse(const char *text, size_t length, char *error, size_t error_size) {
+    JsonParser parser = { text, text + length, error, error_size };
+    error[0] = '\0';
+    JsonValue *root = parse_value(&parser, 0);
+    if (!root) {
+        return NULL;
+    }
+    skip_space(&parser);
+    if (parser.p != parser.end) {
+        parse_fail(&parser, "trailing characters after value");
+        json_free(root);
+        return NULL;
+    }
+    return root;
+}
+
+void json_free(JsonValue *value) {
+    while (value) {
+        JsonValue *next = value->next;
+        json_free(value->child);
+        free(value->string);
+        free(value->key);
+        free(value);
+        value = next;
+    }
+}
+
+const JsonValue *json_get(const JsonValue *object, const char *key) {
+    if (!object || object->type != JSON_OBJECT) {
+        return NULL;
+    }
+    for (const JsonValue *member = object->child; member; member = member->next) {
+        if (strcmp(member->key, key) == 0) {
+            return member;
+        }
+    }
+    return NULL;
+}
+
+const char *json_get_string(const JsonValue *object, const char *key) {
+    const JsonValue *value = json_get(object, key);
+    return value && value->type == JSON_STRING ? value->string : NULL;
+}
+
+double json_get_number(const JsonValue *object, const char *key, double fallback) {
+    const JsonValue *value = json_get(object, key);
+    return value && value->type == JSON_NUMBER ? value->number : fallback;
+}
+
+int json_get_bool(const JsonValue *object, const char *key, int fallback) {
+    const JsonValue *value = json_get(object, key);
+    return value && value->type == JSON_BOOL ? value->boolean : fallback;
+}
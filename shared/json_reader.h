@@ -0,0 +1,67 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef JSON_READER_H
+#define JSON_READER_H
+
+#include <stddef.h>
+
+/*
+ * Minimal JSON parser (RFC 8259) for reading requests (serve)
+ *
+ * The document is parsed into a tree of JsonValue nodes; strings are
+ * decoded to UTF-8 (\uXXXX escapes and surrogate pairs included). Numbers
+ * are kept as doubles. Nesting is limited to JSON_MAX_DEPTH levels.
+ */
+
+#define JSON_MAX_DEPTH 64
+
+typedef enum {
+    JSON_NULL,
+    JSON_BOOL,
+    JSON_NUMBER,
+    JSON_STRING,
+    JSON_ARRAY,
+    JSON_OBJECT
+} JsonType;
+
+typedef struct JsonValue {
+    JsonType type;
+    int boolean;               /* JSON_BOOL */
+    double number;             /* JSON_NUMBER */
+    char *string;              /* JSON_STRING, decoded */
+    char *key;                 /* Member name when the parent is an object */
+    struct JsonValue *child;   /* First element or member (arrays, objects) */
+    struct JsonValue *next;    /* Next sibling */
+} JsonValue;
+
+/* Parse text (length bytes, need not be NUL-terminated)
+ * Returns: the root value (free with json_free), or NULL with a message in
+ * error if the text is not one valid JSON value or memory runs out */
+JsonValue *json_parse(const char *text, size_t length, char *error, size_t error_size);
+
+void json_free(JsonValue *value);
+
+/* Member of an object by name, NULL if absent or value is not an object */
+const JsonValue *json_get(const JsonValue *object, const char *key);
+
+/* Typed member access: NULL or fallback when absent or of another type */
+const char *json_get_string(const JsonValue *object, const char *key);
+double json_get_number(const JsonValue *object, const char *key, double fallback);
+int json_get_bool(const JsonValue *object, const char *key, int fallback);
+
+#endif /* JSON_READER_H */
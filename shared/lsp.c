@@ -0,0 +1,155 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "lsp.h"
+#include "ndjson.h"
+#include "file_utils.h"
+#include <string.h>
+
+LspSymbolKind lsp_symbol_kind(const IndexEntry *entry) {
+    switch (entry->context) {
+        case CONTEXT_TYPE:
+            if (strcmp(entry->clue, "struct") == 0) return LSP_KIND_STRUCT;
+            if (strcmp(entry->clue, "interface") == 0) return LSP_KIND_INTERFACE;
+            return LSP_KIND_CLASS;
+        case CONTEXT_ALIAS:     return LSP_KIND_CLASS;
+        case CONTEXT_CLASS:     return LSP_KIND_CLASS;
+        case CONTEXT_EXCEPTION: return LSP_KIND_CLASS;
+        case CONTEXT_INTERFACE: return LSP_KIND_INTERFACE;
+        case CONTEXT_TRAIT:     return LSP_KIND_INTERFACE;
+        case CONTEXT_FUNCTION:
+            return entry->parent_symbol[0] ? LSP_KIND_METHOD : LSP_KIND_FUNCTION;
+        case CONTEXT_CALL:      return LSP_KIND_FUNCTION;
+        case CONTEXT_LAMBDA:    return LSP_KIND_FUNCTION;
+        case CONTEXT_PROPERTY:  return LSP_KIND_FIELD;
+        case CONTEXT_VARIABLE:
+            return strcmp(entry->modifier, "const") == 0 ? LSP_KIND_CONSTANT : LSP_KIND_VARIABLE;
+        case CONTEXT_ENUM:      return LSP_KIND_ENUM;
+        case CONTEXT_ENUM_CASE: return LSP_KIND_ENUM_MEMBER;
+        case CONTEXT_NAMESPACE: return LSP_KIND_NAMESPACE;
+        case CONTEXT_IMPORT:    return LSP_KIND_MODULE;
+        case CONTEXT_EXPORT:    return LSP_KIND_MODULE;
+        case CONTEXT_FILENAME:  return LSP_KIND_FILE;
+        case CONTEXT_STRING:    return LSP_KIND_STRING;
+        case CONTEXT_LABEL:     return LSP_KIND_KEY;
+        case CONTEXT_GOTO:      return LSP_KIND_KEY;
+        case CONTEXT_CUSTOM:    return LSP_KIND_KEY;
+        default:                return LSP_KIND_VARIABLE;
+    }
+}
+
+/* Write bytes of a path, percent-encoding all but unreserved characters and '/' */
+static void write_uri_path(FILE *out, const char *path) {
+    for (const unsigned char *p = (const unsigned char *)path; *p; p++) {
+        if ((*p >= 'a' && *p <= 'z') || (*p >= 'A' && *p <= 'Z') || (*p >= '0' && *p <= '9') ||
+            *p == '/' || *p == '-' || *p == '.' || *p == '_' || *p == '~') {
+            fputc(*p, out);
+        } else {
+            fprintf(out, "%%%02X", *p);
+        }
+    }
+}
+
+void lsp_write_uri(FILE *out, const char *base_dir, const char *path) {
+    fputs("\"file://", out);
+    if (path[0] != '/') {
+        if (strncmp(path, "./", 2) == 0) {
+            path += 2;
+        }
+        write_uri_path(out, base_dir);
+        if (base_dir[0] && base_dir[strlen(base_dir) - 1] != '/') {
+            fputc('/', out);
+        }
+    }
+    write_uri_path(out, path);
+    fputc('"', out);
+}
+
+/* Convert byte columns of 1-based lines to the UTF-16 code units LSP counts:
+ * a 4-byte UTF-8 sequence is a surrogate pair, any other sequence one unit.
+ * The columns are kept as they are if the file can't be read */
+static void convert_columns(const char *path, int start_line, int *start_column,
+                            int end_line, int *end_column) {
+    FILE *file = fopen(path, "rb");
+    if (!file) {
+        return;
+    }
+    int line = 1, bytes = 0, units = 0;
+    int start_done = 0;
+    while (line <= end_line) {
+        if (!start_done && line == start_line && bytes == *start_column) {
+            *start_column = units;
+            start_done = 1;
+        }
+        if (line == end_line && bytes == *end_column) {
+            *end_column = units;
+            break;
+        }
+        int c = getc(file);
+        if (c == EOF) {
+            break;
+        }
+        if (c == '\n') {
+            line++;
+            bytes = units = 0;
+            continue;
+        }
+        bytes++;
+        if ((c & 0xC0) != 0x80) {
+            units += c >= 0xF0 ? 2 : 1;
+        }
+    }
+    fclose(file);
+}
+
+void lsp_write_symbol(FILE *out, const IndexEntry *entry, const char *base_dir) {
+    char path[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH];
+    snprintf(path, sizeof(path), "%s%s", entry->directory, entry->filename);
+
+    /* Stored range is "row:col - row:col" with 1-based rows */
+    int start_line = entry->line, start_column = 0;
+    int end_line = entry->line, end_column = 0;
+    if (entry->source_location[0] != '\0' &&
+        parse_source_location(entry->source_location, &start_line, &start_column,
+                              &end_line, &end_column) != 0) {
+        start_line = end_line = entry->line;
+        start_column = end_column = 0;
+    }
+    if (start_column > 0 || end_column > 0) {
+        char full_path[PATH_MAX_LENGTH + sizeof(path)];
+        int written = path[0] == '/'
+            ? snprintf(full_path, sizeof(full_path), "%s", path)
+            : snprintf(full_path, sizeof(full_path), "%s/%s", base_dir, path);
+        if (written > 0 && (size_t)written < sizeof(full_path)) {
+            convert_columns(full_path, start_line, &start_column, end_line, &end_column);
+        }
+    }
+
+    fputs("{\"name\":", out);
+    json_write_string(out, entry->full_symbol);
+    fprintf(out, ",\"kind\":%d", (int)lsp_symbol_kind(entry));
+    if (entry->parent_symbol[0]) {
+        fputs(",\"containerName\":", out);
+        json_write_string(out, entry->parent_symbol);
+    }
+    fputs(",\"location\":{\"uri\":", out);
+    lsp_write_uri(out, base_dir, path);
+    fprintf(out, ",\"range\":{\"start\":{\"line\":%d,\"character\":%d},"
+                 "\"end\":{\"line\":%d,\"character\":%d}}}}",
+            start_line > 0 ? start_line - 1 : 0, start_column,
+            end_line > 0 ? end_line - 1 : 0, end_column);
+}
@@ -0,0 +1,91 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef LSP_H
+#define LSP_H
+
+#include <stdio.h>
+#include "database.h"
+
+/*
+ * LSP-shaped output (search --format=lsp)
+ *
+ * Entries are written as LSP WorkspaceSymbol objects:
+ *
+ *   {"name":"Close","kind":6,"containerName":"File",
+ *    "location":{"uri":"file:///src/os/file.go",
+ *                "range":{"start":{"line":82,"character":0},
+ *                         "end":{"line":87,"character":1}}}}
+ *
+ * containerName is the parent symbol and is left out when there is none.
+ *
+ * Lines are 0-based as in LSP. Characters are UTF-16 code units, as LSP
+ * expects; the stored byte columns are converted by reading the lines from
+ * the file, and are kept as bytes if it can't be read. Entries without a
+ * recorded definition range get an empty range at the start of their line.
+ */
+
+/* LSP SymbolKind values (Language Server Protocol 3.17) */
+typedef enum {
+    LSP_KIND_FILE = 1,
+    LSP_KIND_MODULE = 2,
+    LSP_KIND_NAMESPACE = 3,
+    LSP_KIND_PACKAGE = 4,
+    LSP_KIND_CLASS = 5,
+    LSP_KIND_METHOD = 6,
+    LSP_KIND_PROPERTY = 7,
+    LSP_KIND_FIELD = 8,
+    LSP_KIND_CONSTRUCTOR = 9,
+    LSP_KIND_ENUM = 10,
+    LSP_KIND_INTERFACE = 11,
+    LSP_KIND_FUNCTION = 12,
+    LSP_KIND_VARIABLE = 13,
+    LSP_KIND_CONSTANT = 14,
+    LSP_KIND_STRING = 15,
+    LSP_KIND_KEY = 20,
+    LSP_KIND_ENUM_MEMBER = 22,
+    LSP_KIND_STRUCT = 23,
+    LSP_KIND_EVENT = 24
+} LspSymbolKind;
+
+/* Map an index entry to an LSP SymbolKind
+ *
+ * Go types are refined by clue (Struct, Interface, else Class); aliases are
+ * Class like the types they name; functions with a parent are Methods;
+ * fields, embedded ones included, are Fields; const variables are Constants.
+ */
+LspSymbolKind lsp_symbol_kind(const IndexEntry *entry);
+
+/* Write a file:// URI for a stored path, percent-encoding reserved bytes
+ *
+ * Parameters:
+ *   out      - Output stream
+ *   base_dir - Absolute directory relative paths are resolved against
+ *   path     - Stored path (relative, possibly with a leading "./", or absolute)
+ */
+void lsp_write_uri(FILE *out, const char *base_dir, const char *path);
+
+/* Write one entry as a WorkspaceSymbol object (no trailing newline)
+ *
+ * Parameters:
+ *   out      - Output stream
+ *   entry    - Index entry
+ *   base_dir - Absolute directory the index paths are relative to
+ */
+void lsp_write_symbol(FILE *out, const IndexEntry *entry, const char *base_dir);
+
+#endif /* LSP_H */
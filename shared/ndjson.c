@@ -0,0 +1,229 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "ndjson.h"
+#include "file_utils.h"
+#include "struct_tags.h"
+#include "signature.h"
+#include <string.h>
+
+void json_write_string(FILE *out, const char *str) {
+    if (!str) {
+        fputs("null", out);
+        return;
+    }
+
+    fputc('"', out);
+    for (const unsigned char *p = (const unsigned char *)str; *p; p++) {
+        switch (*p) {
+            case '"':  fputs("\\\"", out); break;
+            case '\\': fputs("\\\\", out); break;
+            case '\n': fputs("\\n", out); break;
+            case '\r': fputs("\\r", out); break;
+            case '\t': fputs("\\t", out); break;
+            default:
+                if (*p < 0x20) {
+                    fprintf(out, "\\u%04x", *p);
+                } else {
+                    fputc(*p, out);
+                }
+                break;
+        }
+    }
+    fputc('"', out);
+}
+
+const char *symbol_kind(const IndexEntry *entry) {
+    switch (entry->context) {
+        case CONTEXT_TYPE:
+            if (strcmp(entry->clue, "struct") == 0) return "struct";
+            if (strcmp(entry->clue, "interface") == 0) return "interface";
+            return "type";
+        case CONTEXT_CLASS:     return "class";
+        case CONTEXT_INTERFACE: return "interface";
+        case CONTEXT_FUNCTION:  return "func";
+        case CONTEXT_ARGUMENT:  return "arg";
+        case CONTEXT_VARIABLE:  return "var";
+        case CONTEXT_EXCEPTION: return "exception";
+        case CONTEXT_PROPERTY:  return "field";
+        case CONTEXT_COMMENT:   return "comment";
+        case CONTEXT_STRING:    return "string";
+        case CONTEXT_FILENAME:  return "file";
+        case CONTEXT_IMPORT:    return "import";
+        case CONTEXT_EXPORT:    return "export";
+        case CONTEXT_CALL:      return "call";
+        case CONTEXT_NAMESPACE: return "namespace";
+        case CONTEXT_ENUM:      return "enum";
+        case CONTEXT_ENUM_CASE: return "case";
+        case CONTEXT_TRAIT:     return "trait";
+        case CONTEXT_LAMBDA:    return "lambda";
+        case CONTEXT_LABEL:     return "label";
+        case CONTEXT_GOTO:      return "goto";
+        case CONTEXT_ALIAS:     return "alias";
+        case CONTEXT_CUSTOM:    return "custom";
+        default:                return "unknown";
+    }
+}
+
+#if ENABLED(GO)
+/* Write a non-empty signature list as a JSON array of {name, type, variadic} */
+static void write_signature_list(FILE *out, const char *key, const char *list) {
+    if (list[0] == '\0') return;
+
+    SignatureParam params[MAX_SIGNATURE_PARAMS];
+    int count = parse_signature_list(list, params, MAX_SIGNATURE_PARAMS);
+    fprintf(out, ",\"%s\":[", key);
+    for (int i = 0; i < count; i++) {
+        if (i > 0) fputc(',', out);
+        fputc('{', out);
+        if (params[i].name[0]) {
+            fputs("\"name\":", out);
+            json_write_string(out, params[i].name);
+            fputc(',', out);
+        }
+        fputs("\"type\":", out);
+        json_write_string(out, params[i].type);
+        if (params[i].variadic) {
+            fputs(",\"variadic\":true", out);
+        }
+        fputc('}', out);
+    }
+    fputc(']', out);
+}
+#endif
+
+/* Write "file", "line" and (when known) "column", "endLine" and "endColumn"
+ * members, each preceded by a comma unless first */
+static void write_location(FILE *out, const char *directory, const char *filename, int line,
+                           const char *source_location, int first) {
+    /* Paths are stored relative to the working directory, sometimes with a
+     * leading "./" (e.g. "./src/"); drop it so file paths are uniform */
+    if (strncmp(directory, "./", 2) == 0) {
+        directory += 2;
+    }
+    char file[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH];
+    snprintf(file, sizeof(file), "%s%s", directory, filename);
+
+    fputs(first ? "\"file\":" : ",\"file\":", out);
+    json_write_string(out, file);
+    fprintf(out, ",\"line\":%d", line);
+
+    /* Columns come from the source range ("row:col - row:col", 0-based
+     * columns, end exclusive); emitted 1-based to match line */
+    int start_line, start_column, end_line, end_column;
+    if (source_location[0] != '\0' &&
+        parse_source_location(source_location, &start_line, &start_column,
+                              &end_line, &end_column) == 0) {
+        fprintf(out, ",\"column\":%d,\"endLine\":%d,\"endColumn\":%d",
+                start_column + 1, end_line, end_column + 1);
+    }
+}
+
+/* Every member of an entry's object, without the braces */
+static void write_entry_members(FILE *out, const IndexEntry *entry) {
+    fputs("\"name\":", out);
+    json_write_string(out, entry->full_symbol);
+    fputs(",\"kind\":", out);
+    json_write_string(out, symbol_kind(entry));
+    write_location(out, entry->directory, entry->filename, entry->line, entry->source_location, 0);
+
+    fputs(",\"parent\":", out);
+    json_write_string(out, entry->parent_symbol[0] ? entry->parent_symbol : NULL);
+
+    /* Aliases carry their target in the type column */
+    int is_alias = (entry->context == CONTEXT_ALIAS);
+    if (is_alias) {
+        fputs(",\"target\":", out);
+        json_write_string(out, entry->type);
+    }
+
+    /* X-Macro: remaining text columns, only when set */
+#define COLUMN(name, sql_type, c_type, width, full, compact, cli_long, ...) \
+    if (strcmp(#name, "parent_symbol") != 0 && strcmp(#name, "tags") != 0 && \
+        strcmp(#name, "params") != 0 && strcmp(#name, "returns") != 0 && \
+        strcmp(#name, "type_params") != 0 && strcmp(#name, "tokens") != 0 && \
+        !(is_alias && strcmp(#name, "type") == 0) && entry->name[0] != '\0') { \
+        fputs(",\"" #cli_long "\":", out); \
+        json_write_string(out, entry->name); \
+    }
+#define INT_COLUMN(name, ...)  /* handled below */
+#include "column_schema.def"
+#undef COLUMN
+#undef INT_COLUMN
+
+#if ENABLED(GO)
+    /* Struct field tags as an object: {"json":"id,omitempty","db":"id"} */
+    if (entry->tags[0] != '\0') {
+        StructTag pairs[MAX_STRUCT_TAGS];
+        int count = parse_struct_tag(entry->tags, pairs, MAX_STRUCT_TAGS, NULL);
+        fputs(",\"tags\":{", out);
+        for (int i = 0; i < count; i++) {
+            if (i > 0) fputc(',', out);
+            json_write_string(out, pairs[i].key);
+            fputc(':', out);
+            json_write_string(out, pairs[i].value);
+        }
+        fputc('}', out);
+    }
+
+    /* Signatures as arrays: [{"name":"r","type":"io.Reader"},{"type":"Option","variadic":true}] */
+    write_signature_list(out, "params", entry->params);
+    write_signature_list(out, "returns", entry->returns);
+    write_signature_list(out, "typeparams", entry->type_params);
+#endif
+
+    if (entry->is_definition) {
+        fputs(",\"definition\":true", out);
+    }
+    if (entry->is_exported) {
+        fputs(",\"exported\":true", out);
+    }
+    if (strcmp(entry->clue, "embedded") == 0) {
+        fputs(",\"embedded\":true", out);
+        if (strcmp(entry->modifier, "pointer") == 0) {
+            fputs(",\"pointer\":true", out);
+        }
+    }
+}
+
+void ndjson_write_object(FILE *out, const IndexEntry *entry,
+                         const SymbolLocation *locations, int count) {
+    fputc('{', out);
+    write_entry_members(out, entry);
+    if (locations) {
+        fputs(",\"locations\":[", out);
+        for (int i = 0; i < count; i++) {
+            fputs(i > 0 ? ",{" : "{", out);
+            write_location(out, locations[i].directory, locations[i].filename, locations[i].line,
+                           locations[i].source_location, 1);
+            fputc('}', out);
+        }
+        fputc(']', out);
+    }
+    fputc('}', out);
+}
+
+void ndjson_write_entry(FILE *out, const IndexEntry *entry) {
+    ndjson_write_object(out, entry, NULL, 0);
+    fputc('\n', out);
+}
+
+void ndjson_write_entry_locations(FILE *out, const IndexEntry *entry,
+                                  const SymbolLocation *locations, int count) {
+    ndjson_write_object(out, entry, locations, count);
+    fputc('\n', out);
+}
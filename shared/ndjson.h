@@ -0,0 +1,99 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef NDJSON_H
+#define NDJSON_H
+
+#include <stdio.h>
+#include "database.h"
+
+/**
+ * Write a JSON string literal (with surrounding quotes) to out.
+ *
+ * Escapes quotes, backslashes and control characters. Bytes >= 0x80 are
+ * written unchanged, so UTF-8 source text passes through as-is.
+ *
+ * @param out Output stream
+ * @param str String to write (NULL is written as null)
+ */
+void json_write_string(FILE *out, const char *str);
+
+/**
+ * Map an index entry to the symbol kind used in machine-readable output.
+ *
+ * Kinds are lowercase words: "struct", "interface", "alias", "type", "func",
+ * "field", "var", "class", ... Go type definitions are refined using the clue
+ * column (struct/interface); everything else follows the context. Matches
+ * of custom extractors are "custom", with the extractor's kind in the clue.
+ *
+ * @param entry Index entry
+ * @return Static string, never NULL
+ */
+const char *symbol_kind(const IndexEntry *entry);
+
+/* One of the places a deduplicated symbol is declared (search --dedupe) */
+typedef struct {
+    char directory[DIRECTORY_MAX_LENGTH];
+    char filename[FILENAME_MAX_LENGTH];
+    int line;
+    char source_location[SOURCE_LOCATION_MAX_LENGTH];
+} SymbolLocation;
+
+/**
+ * Write one index entry as a single-line JSON object followed by a newline.
+ *
+ * Fields: name, kind, file, line, column (only when the source location is
+ * known), parent (null when empty), then any non-empty extensible columns
+ * under their CLI long names. Aliases report their type column as "target";
+ * struct field tags are written as an object of key/value pairs, and
+ * params/returns/typeparams as arrays of {name, type, variadic} (name and
+ * variadic only when set; a type parameter's type is its constraint).
+ * Embedded fields get "embedded": true (and "pointer": true for *T); their
+ * type as written is in "type".
+ * Definitions and exported symbols get "definition": true / "exported": true.
+ *
+ * @param out Output stream
+ * @param entry Index entry
+ */
+void ndjson_write_entry(FILE *out, const IndexEntry *entry);
+
+/**
+ * Write an entry that stands for several identical symbols, adding a
+ * "locations" array of {file, line, column} objects. The entry's own
+ * file/line/column stay as the first (primary) location.
+ *
+ * @param out Output stream
+ * @param entry Index entry (best-ranked of the merged rows)
+ * @param locations Every location, primary first
+ * @param count Number of locations
+ */
+void ndjson_write_entry_locations(FILE *out, const IndexEntry *entry,
+                                  const SymbolLocation *locations, int count);
+
+/**
+ * Write the JSON object of an entry without the trailing newline, for
+ * embedding in other JSON (serve results).
+ *
+ * @param out Output stream
+ * @param entry Index entry
+ * @param locations Locations as for ndjson_write_entry_locations, or NULL
+ * @param count Number of locations
+ */
+void ndjson_write_object(FILE *out, const IndexEntry *entry,
+                         const SymbolLocation *locations, int count);
+
+#endif /* NDJSON_H */
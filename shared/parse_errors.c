@@ -0,0 +1,180 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "parse_errors.h"
+#include "string_utils.h"
+#include <setjmp.h>
+#include <stdarg.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define PARSE_REPORT_INITIAL_CAPACITY 16
+
+/* Guard and reason of the file being parsed on this thread */
+static _Thread_local jmp_buf g_abort_env;
+static _Thread_local int g_guarded;
+static _Thread_local char g_reason[ERROR_MESSAGE_BUFFER];
+
+/* Resources of the guarded file, freed if it is abandoned */
+static _Thread_local TSTree *g_tree;
+static _Thread_local TSParser *g_ts_parser;
+static _Thread_local SourceFile g_source;
+static _Thread_local int g_source_held;
+
+void parse_error_set(const char *format, ...) {
+    if (g_reason[0]) {
+        return;
+    }
+    va_list args;
+    va_start(args, format);
+    vsnprintf(g_reason, sizeof(g_reason), format, args);
+    va_end(args);
+}
+
+_Noreturn void parse_abort(const char *format, ...) {
+    if (!g_reason[0]) {
+        va_list args;
+        va_start(args, format);
+        vsnprintf(g_reason, sizeof(g_reason), format, args);
+        va_end(args);
+    }
+    if (g_guarded) {
+        longjmp(g_abort_env, 1);
+    }
+    fprintf(stderr, "Error: %s\n", g_reason);
+    exit(1);
+}
+
+int parse_abort_is_guarded(void) {
+    return g_guarded;
+}
+
+void parse_guard_hold_tree(TSTree *tree, TSParser *ts_parser) {
+    if (g_guarded) {
+        g_tree = tree;
+        g_ts_parser = ts_parser;
+    }
+}
+
+void parse_guard_hold_source(const SourceFile *source) {
+    if (g_guarded) {
+        g_source = *source;
+        g_source_held = 1;
+    }
+}
+
+void parse_guard_release_source(void) {
+    g_source_held = 0;
+}
+
+/* Free what the abandoned file left allocated */
+static void free_held(void) {
+    if (g_tree) {
+        ts_tree_delete(g_tree);
+    }
+    if (g_ts_parser) {
+        ts_parser_delete(g_ts_parser);
+    }
+    if (g_source_held) {
+        source_file_close(&g_source);
+    }
+}
+
+int parse_file_guarded(ParserParseFunc parse, void *parser, const char *filepath,
+                       const char *project_root, ParseResult *result,
+                       char *reason, size_t reason_size) {
+    g_reason[0] = '\0';
+    /* Scopes left open by an aborted file must not leak into this one */
+    result->scope_path[0] = '\0';
+    result->scope_depth = 0;
+    reset_node_text_spans();
+
+    /* volatile: modified between setjmp and a possible longjmp */
+    volatile int status = -1;
+    if (setjmp(g_abort_env) == 0) {
+        g_guarded = 1;
+        status = parse(parser, filepath, project_root, result);
+    } else {
+        free_held();
+        result->count = 0;  /* Drop what was extracted before the abort */
+        result->import_count = 0;
+    }
+    g_guarded = 0;
+    g_tree = NULL;
+    g_ts_parser = NULL;
+    g_source_held = 0;
+
+    if (status != 0 && !g_reason[0]) {
+        snprintf(g_reason, sizeof(g_reason), "parser failed");
+    }
+    snprintf(reason, reason_size, "%s", status != 0 ? g_reason : "");
+    g_reason[0] = '\0';
+    return status;
+}
+
+void parse_report_init(ParseErrorReport *report) {
+    report->failures = NULL;
+    report->count = 0;
+    report->listed = 0;
+    report->capacity = 0;
+}
+
+void parse_report_add(ParseErrorReport *report, const char *filepath, const char *reason) {
+    report->count++;
+    if (report->listed == report->capacity) {
+        int new_capacity = report->capacity ? report->capacity * 2 : PARSE_REPORT_INITIAL_CAPACITY;
+        ParseFailure *grown = realloc(report->failures, (size_t)new_capacity * sizeof(ParseFailure));
+        if (!grown) {
+            fprintf(stderr, "Warning: out of memory recording parse error of %s: %s\n", filepath, reason);
+            return;
+        }
+        report->failures = grown;
+        report->capacity = new_capacity;
+    }
+    char *copy = try_strdup_ctx(filepath, "Failed to record parse error");
+    if (!copy) {
+        fprintf(stderr, "Warning: out of memory recording parse error of %s: %s\n", filepath, reason);
+        return;
+    }
+    ParseFailure *failure = &report->failures[report->listed];
+    failure->filepath = copy;
+    snprintf(failure->reason, sizeof(failure->reason), "%s", reason);
+    report->listed++;
+}
+
+void parse_report_print(const ParseErrorReport *report) {
+    if (report->count == 0) {
+        return;
+    }
+    fprintf(stderr, "\nParse errors: %d file%s could not be indexed\n",
+            report->count, report->count == 1 ? "" : "s");
+    for (int i = 0; i < report->listed; i++) {
+        fprintf(stderr, "  %s: %s\n", report->failures[i].filepath, report->failures[i].reason);
+    }
+    if (report->listed < report->count) {
+        fprintf(stderr, "  (%d more not listed: out of memory)\n", report->count - report->listed);
+    }
+}
+
+void parse_report_free(ParseErrorReport *report) {
+    for (int i = 0; i < report->listed; i++) {
+        free(report->failures[i].filepath);
+    }
+    free(report->failures);
+    parse_report_init(report);
+}
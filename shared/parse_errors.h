@@ -0,0 +1,97 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef PARSE_ERRORS_H
+#define PARSE_ERRORS_H
+
+#include <stddef.h>
+#include "constants.h"
+#include "indexer_main.h"
+#include "source_file.h"
+
+/*
+ * Per-file parse failures
+ *
+ * A file that cannot be indexed (unreadable, rejected by the grammar, or
+ * holding text the extraction code cannot handle, such as a huge generated
+ * literal) must not stop the run. Parsers record why with parse_error_set()
+ * before returning -1. Errors found deep inside the extraction code, where
+ * returning is not an option, call parse_abort(): under
+ * parse_file_guarded() it abandons the file and unwinds back there; with no
+ * guard on the thread it exits, failing fast as before.
+ *
+ * What the parser allocated for an aborted file is freed by the guard: the
+ * source (source_file_open() registers it, source_file_close() forgets it)
+ * and the syntax tree, which parsers hand over with parse_guard_hold_tree().
+ * Parsers reset the rest of their per-file state at the start of every file.
+ */
+
+/* Record why the file being parsed on this thread failed; the first
+ * reason recorded for a file wins */
+void parse_error_set(const char *format, ...) __attribute__((format(printf, 1, 2)));
+
+/* Abandon the file being parsed on this thread (see above) */
+_Noreturn void parse_abort(const char *format, ...) __attribute__((format(printf, 1, 2)));
+
+/* Whether parse_abort() would unwind rather than exit on this thread */
+int parse_abort_is_guarded(void);
+
+/* Free tree, and ts_parser unless NULL (a parser created for this file
+ * only), if the file is abandoned. Call right after parsing; the parser
+ * still deletes them itself when it returns. No-op without a guard. */
+void parse_guard_hold_tree(TSTree *tree, TSParser *ts_parser);
+
+/* Free a copy of source if the file is abandoned, or stop doing so
+ * (used by source_file_open() and source_file_close()) */
+void parse_guard_hold_source(const SourceFile *source);
+void parse_guard_release_source(void);
+
+/* Call parse for one file, catching parse_abort()
+ *
+ * Returns: parse's return value, or -1 if the file was abandoned.
+ *          On failure reason holds why ("" on success).
+ */
+int parse_file_guarded(ParserParseFunc parse, void *parser, const char *filepath,
+                       const char *project_root, ParseResult *result,
+                       char *reason, size_t reason_size);
+
+/* Failures of one run, in the order they were added */
+typedef struct {
+    char *filepath;
+    char reason[ERROR_MESSAGE_BUFFER];
+} ParseFailure;
+
+typedef struct {
+    ParseFailure *failures;
+    int count;          /* Files that failed, listed or not */
+    int listed;         /* Entries of failures */
+    int capacity;
+} ParseErrorReport;
+
+void parse_report_init(ParseErrorReport *report);
+
+/* Add a failure (copies both strings; on allocation failure the file is
+ * still counted in the summary line) */
+void parse_report_add(ParseErrorReport *report, const char *filepath, const char *reason);
+
+/* Print "N files could not be indexed" and one line per file to stderr
+ * (nothing if there were no failures) */
+void parse_report_print(const ParseErrorReport *report);
+
+void parse_report_free(ParseErrorReport *report);
+
+#endif /* PARSE_ERRORS_H */
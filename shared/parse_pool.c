@@ -0,0 +1,210 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "parse_pool.h"
+#include "parse_errors.h"
+#include "index_source.h"
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <unistd.h>
+
+/* Files a worker may run ahead of the callback, per worker */
+#define POOL_WINDOW_PER_WORKER 2
+
+typedef struct {
+    ParseResult result;
+    int status;
+    char error[ERROR_MESSAGE_BUFFER];  /* Why the file failed ("" if parsed) */
+    int ready;            /* Parsed, waiting for the callback */
+} PoolSlot;
+
+typedef struct {
+    pthread_mutex_t lock;
+    pthread_cond_t changed;
+    const IndexerConfig *config;
+    char **files;
+    int count;
+    const char *project_root;
+    const SymbolFilter *filter;
+    int next;             /* Next file to hand to a worker */
+    int delivered;        /* Files passed to the callback so far */
+    int window;           /* Slots in the ring; file i uses slot i % window */
+    PoolSlot *slots;
+} ParsePool;
+
+typedef struct {
+    ParsePool *pool;
+    void *parser;
+    ParseResult result;   /* Swapped with the slot buffer after each file */
+    pthread_t thread;
+} PoolWorker;
+
+static void *worker_main(void *arg) {
+    PoolWorker *worker = (PoolWorker *)arg;
+    ParsePool *pool = worker->pool;
+
+    for (;;) {
+        pthread_mutex_lock(&pool->lock);
+        while (pool->next < pool->count && pool->next >= pool->delivered + pool->window) {
+            pthread_cond_wait(&pool->changed, &pool->lock);
+        }
+        if (pool->next >= pool->count) {
+            pthread_mutex_unlock(&pool->lock);
+            break;
+        }
+        int index = pool->next++;
+        pthread_mutex_unlock(&pool->lock);
+
+        char error[ERROR_MESSAGE_BUFFER];
+        int status = index_source_parse(pool->config, worker->parser, pool->filter, pool->files[index],
+                                        pool->project_root, &worker->result, error, sizeof(error));
+
+        pthread_mutex_lock(&pool->lock);
+        PoolSlot *slot = &pool->slots[index % pool->window];
+        ParseResult spare = slot->result;
+        slot->result = worker->result;
+        slot->status = status;
+        snprintf(slot->error, sizeof(slot->error), "%s", error);
+        slot->ready = 1;
+        worker->result = spare;
+        pthread_cond_broadcast(&pool->changed);
+        pthread_mutex_unlock(&pool->lock);
+    }
+
+    /* Freed here rather than by the caller: parsers may keep per-thread state */
+    pool->config->parser_free(worker->parser);
+    worker->parser = NULL;
+    return NULL;
+}
+
+int parse_pool_default_workers(void) {
+    long cpus = sysconf(_SC_NPROCESSORS_ONLN);
+    return cpus > 0 ? (int)cpus : 1;
+}
+
+int parse_files_parallel(const IndexerConfig *config, SymbolFilter *filter, int workers, int debug,
+                         char **files, int count, const char *project_root,
+                         ParsedFileFunc on_file, void *ctx) {
+    if (count <= 0) {
+        return 0;
+    }
+    if (workers > count) workers = count;
+    if (workers < 1) workers = 1;
+
+    int result = -1;
+    int started = 0;
+    ParsePool pool = {
+        .config = config,
+        .files = files,
+        .filter = filter,
+        .count = count,
+        .project_root = project_root,
+        .window = workers * POOL_WINDOW_PER_WORKER,
+    };
+    /* Grammars that set their language per file would otherwise fail on
+     * every file with a less helpful message */
+    if (check_grammar_abi(config, files[0]) != 0) {
+        return -1;
+    }
+
+    PoolWorker *pool_workers = calloc((size_t)workers, sizeof(PoolWorker));
+    pool.slots = calloc((size_t)pool.window, sizeof(PoolSlot));
+    if (!pool_workers || !pool.slots) {
+        fprintf(stderr, "Failed to allocate memory for %d parse workers\n", workers);
+        goto cleanup;
+    }
+    for (int i = 0; i < pool.window; i++) {
+        if (init_parse_result(&pool.slots[i].result) != 0) {
+            fprintf(stderr, "Failed to initialize parse result\n");
+            goto cleanup;
+        }
+    }
+    for (int i = 0; i < workers; i++) {
+        pool_workers[i].pool = &pool;
+        if (init_parse_result(&pool_workers[i].result) != 0) {
+            fprintf(stderr, "Failed to initialize parse result\n");
+            goto cleanup;
+        }
+        pool_workers[i].parser = config->parser_init(filter);
+        if (!pool_workers[i].parser) {
+            report_parser_init_failure(config, files[0]);
+            goto cleanup;
+        }
+        if (debug && config->parser_set_debug) {
+            config->parser_set_debug(pool_workers[i].parser, 1);
+        }
+    }
+
+    pthread_mutex_init(&pool.lock, NULL);
+    pthread_cond_init(&pool.changed, NULL);
+
+    for (int i = 0; i < workers; i++) {
+        if (pthread_create(&pool_workers[i].thread, NULL, worker_main, &pool_workers[i]) != 0) {
+            break;
+        }
+        started++;
+    }
+
+    if (started == 0) {
+        fprintf(stderr, "Failed to start parse workers\n");
+    } else {
+        for (int i = 0; i < count; i++) {
+            PoolSlot *slot = &pool.slots[i % pool.window];
+
+            pthread_mutex_lock(&pool.lock);
+            while (!slot->ready) {
+                pthread_cond_wait(&pool.changed, &pool.lock);
+            }
+            pthread_mutex_unlock(&pool.lock);
+
+            /* No worker touches this slot until delivered moves past it */
+            on_file(files[i], slot->status, slot->error, &slot->result, ctx);
+            trim_parse_result(&slot->result);
+
+            pthread_mutex_lock(&pool.lock);
+            slot->ready = 0;
+            pool.delivered++;
+            pthread_cond_broadcast(&pool.changed);
+            pthread_mutex_unlock(&pool.lock);
+        }
+        result = 0;
+    }
+
+    for (int i = 0; i < started; i++) {
+        pthread_join(pool_workers[i].thread, NULL);
+    }
+    pthread_cond_destroy(&pool.changed);
+    pthread_mutex_destroy(&pool.lock);
+
+cleanup:
+    if (pool_workers) {
+        for (int i = 0; i < workers; i++) {
+            /* Only parsers of workers that never started are left */
+            if (pool_workers[i].parser) config->parser_free(pool_workers[i].parser);
+            free_parse_result(&pool_workers[i].result);
+        }
+        free(pool_workers);
+    }
+    if (pool.slots) {
+        for (int i = 0; i < pool.window; i++) {
+            free_parse_result(&pool.slots[i].result);
+        }
+        free(pool.slots);
+    }
+    return result;
+}
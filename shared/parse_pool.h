@@ -0,0 +1,75 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef PARSE_POOL_H
+#define PARSE_POOL_H
+
+#include "indexer_main.h"
+
+/*
+ * Parallel file parsing (--workers)
+ *
+ * Files are parsed by a pool of worker threads. Tree-sitter parsers are not
+ * safe to share, so every worker gets its own parser instance, created on
+ * the calling thread (in order, so one-time symbol table setup in the
+ * language parsers never races) and freed on the worker's own thread (so
+ * per-thread query caches are released).
+ *
+ * Parsed files are handed back under a mutex and delivered to the callback
+ * on the calling thread in file order, with each file's entries (custom
+ * extractor matches included) sorted by line, so the index and any NDJSON output are identical for any number of
+ * workers. A file that fails to parse (see parse_errors.h) is delivered
+ * with its reason and does not stop the others. Workers run at most a few files ahead of the callback, which
+ * bounds memory when one file is slow.
+ */
+
+/* Called on the calling thread once per file, in order
+ *
+ * Parameters:
+ *   filepath - File as passed in
+ *   status   - parser_parse() return value (0 = parsed)
+ *   error    - Why the file could not be parsed ("" if it was)
+ *   result   - Entries for the file (only valid during the call)
+ *   ctx      - Caller context
+ */
+typedef void (*ParsedFileFunc)(const char *filepath, int status, const char *error,
+                               ParseResult *result, void *ctx);
+
+/* Number of online CPUs (at least 1); the default worker count */
+int parse_pool_default_workers(void);
+
+/* Parse files with up to workers threads
+ *
+ * Parameters:
+ *   config       - Language callbacks (parser_init/parse/free/set_debug)
+ *   filter       - Filter passed to parser_init (shared read-only)
+ *   workers      - Worker threads (clamped to 1..count)
+ *   debug        - Enable parser debug output
+ *   files        - Files to parse, in the order results should be delivered
+ *   count        - Number of files
+ *   project_root - Root for relative paths (passed to parser_parse)
+ *   on_file      - Result callback
+ *   ctx          - Passed to on_file
+ *
+ * Returns: 0 on success, -1 if a parser or buffer could not be created
+ *          (nothing is parsed in that case)
+ */
+int parse_files_parallel(const IndexerConfig *config, SymbolFilter *filter, int workers, int debug,
+                         char **files, int count, const char *project_root,
+                         ParsedFileFunc on_file, void *ctx);
+
+#endif /* PARSE_POOL_H */
@@ -24,12 +24,20 @@
 #include <stdlib.h>
 #include <string.h>
 
-/* Initial capacity for ParseResult dynamic array */
-#define PARSE_RESULT_INITIAL_CAPACITY 1000
+/* Initial capacity for ParseResult dynamic array; kept small since an
+ * entry is several KB and every worker holds a few results */
+#define PARSE_RESULT_INITIAL_CAPACITY 64
+/* Results grown past this many entries are trimmed back by trim_parse_result() */
+#define PARSE_RESULT_RETAIN_CAPACITY 1024
 /* Growth factor when capacity is exceeded */
 #define PARSE_RESULT_GROWTH_FACTOR 2
 
 int init_parse_result(ParseResult *result) {
+    result->scope_path[0] = '\0';
+    result->scope_depth = 0;
+    result->imports = NULL;
+    result->import_count = 0;
+    result->import_capacity = 0;
     result->entries = malloc(PARSE_RESULT_INITIAL_CAPACITY * sizeof(IndexEntry));
     if (!result->entries) {
         result->count = 0;
@@ -48,6 +56,23 @@ void free_parse_result(ParseResult *result) {
     }
     result->count = 0;
     result->capacity = 0;
+    free(result->imports);
+    result->imports = NULL;
+    result->import_count = 0;
+    result->import_capacity = 0;
+}
+
+void trim_parse_result(ParseResult *result) {
+    if (result->capacity <= PARSE_RESULT_RETAIN_CAPACITY) {
+        return;
+    }
+    IndexEntry *trimmed = realloc(result->entries, PARSE_RESULT_INITIAL_CAPACITY * sizeof(IndexEntry));
+    if (!trimmed) {
+        return;  /* Keep the bigger buffer */
+    }
+    result->entries = trimmed;
+    result->capacity = PARSE_RESULT_INITIAL_CAPACITY;
+    result->count = 0;
 }
 
 /* Ensure ParseResult has capacity for at least one more entry
@@ -76,6 +101,17 @@ static int ensure_capacity(ParseResult *result) {
     return 0;
 }
 
+/* Value of an INTEGER extensible column ("1", "0"); 0 if unset or not a number */
+static int parse_int_column(const char *value) {
+    if (!value) {
+        return 0;
+    }
+    char *endptr;
+    errno = 0;
+    long val = strtol(value, &endptr, 10);
+    return (errno == 0 && *endptr == '\0') ? (int)val : 0;
+}
+
 void add_entry(ParseResult *result, const char *symbol, int line,
               ContextType context, const char *directory,
               const char *filename, const char *source_location,
@@ -114,26 +150,174 @@ void add_entry(ParseResult *result, const char *symbol, int line,
     snprintf(entry->filename, sizeof(entry->filename), "%s", filename);
     entry->line = line;
     entry->context = context;
-    snprintf(entry->source_location, sizeof(entry->source_location), "%s", source_location ? source_location : "");
+    /* Without a definition range, the identifier's own span when it is known */
+    entry->source_location[0] = '\0';
+    if (source_location) {
+        snprintf(entry->source_location, sizeof(entry->source_location), "%s", source_location);
+    } else if (context != CONTEXT_COMMENT && context != CONTEXT_STRING && context != CONTEXT_FILENAME) {
+        node_text_span(symbol, line, entry->source_location, sizeof(entry->source_location));
+    }
 
     /* Extract extensible columns from struct (default to empty string if NULL) */
     snprintf(entry->parent_symbol, sizeof(entry->parent_symbol), "%s", ext && ext->parent ? ext->parent : "");
-#if ENABLED(TYPESCRIPT) || ENABLED(PHP) || ENABLED(GO) || ENABLED(PYTHON)
+    snprintf(entry->scope_path, sizeof(entry->scope_path), "%s",
+             ext && ext->scopepath ? ext->scopepath : result->scope_path);
+#if ENABLED(TYPESCRIPT) || ENABLED(JAVASCRIPT) || ENABLED(PHP) || ENABLED(GO) || ENABLED(PYTHON) || ENABLED(PERL) || ENABLED(RUST) || ENABLED(RUBY)
     snprintf(entry->scope, sizeof(entry->scope), "%s", ext && ext->scope ? ext->scope : "");
     snprintf(entry->namespace, sizeof(entry->namespace), "%s", ext && ext->namespace ? ext->namespace : "");
 #endif
     snprintf(entry->modifier, sizeof(entry->modifier), "%s", ext && ext->modifier ? ext->modifier : "");
     snprintf(entry->clue, sizeof(entry->clue), "%s", ext && ext->clue ? ext->clue : "");
     snprintf(entry->type, sizeof(entry->type), "%s", ext && ext->type ? ext->type : "");
+    snprintf(entry->language, sizeof(entry->language), "%s", ext && ext->language ? ext->language : "");
+#if ENABLED(GO)
+    snprintf(entry->tags, sizeof(entry->tags), "%s", ext && ext->tags ? ext->tags : "");
+    snprintf(entry->params, sizeof(entry->params), "%s", ext && ext->params ? ext->params : "");
+    snprintf(entry->returns, sizeof(entry->returns), "%s", ext && ext->returns ? ext->returns : "");
+    snprintf(entry->type_params, sizeof(entry->type_params), "%s", ext && ext->typeparams ? ext->typeparams : "");
+    snprintf(entry->type_package, sizeof(entry->type_package), "%s", ext && ext->typepkg ? ext->typepkg : "");
+    snprintf(entry->type_name, sizeof(entry->type_name), "%s", ext && ext->typename ? ext->typename : "");
+    snprintf(entry->value, sizeof(entry->value), "%s", ext && ext->value ? ext->value : "");
+    snprintf(entry->decl_group, sizeof(entry->decl_group), "%s", ext && ext->group ? ext->group : "");
+#endif
+    snprintf(entry->doc, sizeof(entry->doc), "%s", ext && ext->doc ? ext->doc : "");
     /* INTEGER columns: parse string to int */
-    if (ext && ext->definition) {
-        char *endptr;
-        errno = 0;
-        long val = strtol(ext->definition, &endptr, 10);
-        entry->is_definition = (errno == 0 && *endptr == '\0') ? (int)val : 0;
-    } else {
-        entry->is_definition = 0;
-    }
+    entry->is_definition = parse_int_column(ext ? ext->definition : NULL);
+    entry->is_exported = parse_int_column(ext ? ext->exported : NULL);
 
     result->count++;
 }
+
+void add_import(ParseResult *result, const char *path, int line) {
+    if (!path[0]) {
+        return;
+    }
+    if (result->import_count == result->import_capacity) {
+        int new_capacity = result->import_capacity ? result->import_capacity * PARSE_RESULT_GROWTH_FACTOR : 16;
+        ImportEdge *grown = realloc(result->imports, (size_t)new_capacity * sizeof(ImportEdge));
+        if (!grown) {
+            fprintf(stderr, "FATAL: Failed to allocate memory for import %d\n", result->import_count);
+            exit(EXIT_FAILURE);
+        }
+        result->imports = grown;
+        result->import_capacity = new_capacity;
+    }
+    ImportEdge *edge = &result->imports[result->import_count];
+    snprintf(edge->path, sizeof(edge->path), "%s", path);
+    edge->line = line;
+    result->import_count++;
+}
+
+void push_scope(ParseResult *result, const char *name) {
+    int depth = result->scope_depth++;
+    if (depth >= SCOPE_PATH_MAX_DEPTH) {
+        return;
+    }
+    size_t length = strlen(result->scope_path);
+    result->scope_marks[depth] = length;
+    size_t name_length = strlen(name);
+    size_t separator = length > 0 ? 1 : 0;
+    if (name_length == 0 || length + separator + name_length >= sizeof(result->scope_path)) {
+        return;
+    }
+    if (separator) {
+        result->scope_path[length] = '.';
+    }
+    memcpy(result->scope_path + length + separator, name, name_length + 1);
+}
+
+void pop_scope(ParseResult *result) {
+    if (result->scope_depth == 0) {
+        return;
+    }
+    int depth = --result->scope_depth;
+    if (depth < SCOPE_PATH_MAX_DEPTH) {
+        result->scope_path[result->scope_marks[depth]] = '\0';
+    }
+}
+
+/* Cut str to at most max_length bytes without splitting a UTF-8 sequence
+ * Returns: 1 if str was shortened */
+static int truncate_utf8(char *str, size_t max_length) {
+    if (strlen(str) <= max_length) {
+        return 0;
+    }
+    size_t cut = max_length;
+    while (cut > 0 && ((unsigned char)str[cut] & 0xC0) == 0x80) {
+        cut--;
+    }
+    str[cut] = '\0';
+    return 1;
+}
+
+int truncate_long_symbols(ParseResult *result, int max_length, const char *filepath) {
+    int truncated = 0;
+    for (int i = 0; i < result->count; i++) {
+        IndexEntry *entry = &result->entries[i];
+        if (strlen(entry->full_symbol) <= (size_t)max_length && strlen(entry->symbol) <= (size_t)max_length) {
+            continue;
+        }
+        fprintf(stderr, "Warning: %s:%d: symbol longer than %d characters truncated: %.40s...\n",
+                filepath, entry->line, max_length, entry->full_symbol);
+        truncate_utf8(entry->full_symbol, (size_t)max_length);
+        truncate_utf8(entry->symbol, (size_t)max_length);
+        truncated++;
+    }
+    return truncated;
+}
+
+void set_result_language(ParseResult *result, const char *language) {
+    for (int i = 0; i < result->count; i++) {
+        snprintf(result->entries[i].language, sizeof(result->entries[i].language), "%s", language);
+    }
+}
+
+typedef struct {
+    int line;
+    int index;
+} LineKey;
+
+static int compare_line_keys(const void *a, const void *b) {
+    const LineKey *ka = (const LineKey *)a;
+    const LineKey *kb = (const LineKey *)b;
+    if (ka->line != kb->line) {
+        return (ka->line > kb->line) - (ka->line < kb->line);
+    }
+    return (ka->index > kb->index) - (ka->index < kb->index);
+}
+
+int sort_parse_result_by_line(ParseResult *result) {
+    /* Parsers emit mostly in line order; nested declarations are the exception */
+    int in_order = 1;
+    for (int i = 1; i < result->count; i++) {
+        if (result->entries[i].line < result->entries[i - 1].line) {
+            in_order = 0;
+            break;
+        }
+    }
+    if (in_order) {
+        return 0;
+    }
+
+    LineKey *keys = malloc((size_t)result->count * sizeof(LineKey));
+    IndexEntry *sorted = malloc((size_t)result->capacity * sizeof(IndexEntry));
+    if (!keys || !sorted) {
+        free(keys);
+        free(sorted);
+        return -1;
+    }
+
+    for (int i = 0; i < result->count; i++) {
+        keys[i].line = result->entries[i].line;
+        keys[i].index = i;
+    }
+    qsort(keys, (size_t)result->count, sizeof(LineKey), compare_line_keys);
+    for (int i = 0; i < result->count; i++) {
+        sorted[i] = result->entries[keys[i].index];
+    }
+
+    free(keys);
+    free(result->entries);
+    result->entries = sorted;
+    return 0;
+}
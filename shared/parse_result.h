@@ -26,6 +26,13 @@ typedef struct ParseResult {
     IndexEntry *entries;     /* Dynamic array of index entries */
     int count;               /* Current number of entries */
     int capacity;            /* Allocated capacity */
+    ImportEdge *imports;     /* Imports of the file (grown on first use) */
+    int import_count;
+    int import_capacity;
+    /* Enclosing declarations while parsing (push_scope/pop_scope) */
+    char scope_path[SCOPE_PATH_MAX_LENGTH];
+    size_t scope_marks[SCOPE_PATH_MAX_DEPTH];  /* scope_path length before each push */
+    int scope_depth;
 } ParseResult;
 
 /* Initialize a ParseResult with initial capacity
@@ -33,6 +40,10 @@ typedef struct ParseResult {
  */
 int init_parse_result(ParseResult *result);
 
+/* Give back the memory of a result that grew big for one file, so buffers
+ * reused across files stay small. Drops the entries (count becomes 0). */
+void trim_parse_result(ParseResult *result);
+
 /* Free all memory associated with a ParseResult */
 void free_parse_result(ParseResult *result);
 
@@ -52,4 +63,32 @@ void add_entry(ParseResult *result, const char *symbol, int line,
               const char *filename, const char *source_location,
               const ExtColumns *ext);
 
+/* Record that the file imports path (a package or module, quotes removed).
+ * Unlike symbols, imports are not filtered: the edge exists whatever the
+ * stopwords say. Empty paths are ignored. */
+void add_import(ParseResult *result, const char *path, int line);
+
+/* Enter and leave a named declaration (class, function, module, ...)
+ * Entries added in between get the enclosing names, outermost first, as
+ * their scope_path ("User.save"); top-level entries get an empty one.
+ * Parsers push after adding the declaration itself, so a method's own
+ * entry has its class as scope and its locals have "Class.method".
+ * Scopes past SCOPE_PATH_MAX_DEPTH, or names that would overflow the path,
+ * are counted but not added, so pushes and pops stay balanced. */
+void push_scope(ParseResult *result, const char *name);
+void pop_scope(ParseResult *result);
+
+/* Truncate symbols longer than max_length bytes (at a UTF-8 character
+ * boundary), warning once per symbol
+ * Returns: number of entries truncated */
+int truncate_long_symbols(ParseResult *result, int max_length, const char *filepath);
+
+/* Tag every entry with the language of the indexer that parsed it */
+void set_result_language(ParseResult *result, const char *language);
+
+/* Sort entries by line, keeping the parser's order within a line
+ * Returns: 0 on success, -1 on allocation failure (entries left unchanged)
+ */
+int sort_parse_result_by_line(ParseResult *result);
+
 #endif /* PARSE_RESULT_H */
@@ -0,0 +1,99 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "progress.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+#ifndef _WIN32
+#include <sys/ioctl.h>
+#endif
+
+#define PROGRESS_DEFAULT_WIDTH 80
+
+static int terminal_width(void) {
+#ifdef TIOCGWINSZ
+    struct winsize ws;
+    if (ioctl(STDERR_FILENO, TIOCGWINSZ, &ws) == 0 && ws.ws_col > 0) {
+        return ws.ws_col;
+    }
+#endif
+    const char *columns = getenv("COLUMNS");
+    int width = columns ? atoi(columns) : 0;
+    return width > 0 ? width : PROGRESS_DEFAULT_WIDTH;
+}
+
+static long long elapsed_ms(const struct timespec *since, const struct timespec *now) {
+    return (long long)(now->tv_sec - since->tv_sec) * 1000 +
+           (now->tv_nsec - since->tv_nsec) / 1000000;
+}
+
+int progress_available(void) {
+    return isatty(STDERR_FILENO);
+}
+
+void progress_init(Progress *progress, int enabled) {
+    memset(progress, 0, sizeof(*progress));
+    progress->enabled = enabled;
+    if (enabled) {
+        progress->width = terminal_width();
+    }
+}
+
+void progress_update(Progress *progress, int done, int total, const char *path) {
+    if (!progress->enabled || total <= 0) return;
+
+    struct timespec now;
+    clock_gettime(CLOCK_MONOTONIC, &now);
+    if (progress->drawn && done < total && elapsed_ms(&progress->last, &now) < PROGRESS_INTERVAL_MS) {
+        return;
+    }
+    progress->last = now;
+
+    /* Directory of the file, "." for files at the top */
+    const char *slash = path ? strrchr(path, '/') : NULL;
+    int dir_len = slash ? (int)(slash - path) : 1;
+    const char *dir = slash ? path : ".";
+
+    char prefix[64];
+    int prefix_len = snprintf(prefix, sizeof(prefix), "[%*d/%d] %3d%%  ",
+                              snprintf(NULL, 0, "%d", total), done, total,
+                              (int)((long long)done * 100 / total));
+    if (prefix_len < 0) return;
+
+    /* Keep the end of a long directory, which names it best */
+    int room = progress->width - 1 - prefix_len;
+    const char *ellipsis = "";
+    if (room < 4) {
+        dir_len = 0;
+    } else if (dir_len > room) {
+        dir += dir_len - (room - 3);
+        dir_len = room - 3;
+        ellipsis = "...";
+    }
+    fprintf(stderr, "\r%s%s%.*s\033[K", prefix, ellipsis, dir_len, dir);
+    fflush(stderr);
+    progress->drawn = 1;
+}
+
+void progress_clear(Progress *progress) {
+    if (!progress->enabled || !progress->drawn) return;
+    fprintf(stderr, "\r\033[K");
+    fflush(stderr);
+    progress->drawn = 0;
+}
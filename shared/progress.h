@@ -0,0 +1,54 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef PROGRESS_H
+#define PROGRESS_H
+
+#include <time.h>
+
+/*
+ * Progress line for the initial indexing pass
+ *
+ * Shown on stderr only when it is a terminal, as one line rewritten in
+ * place: "[ 1234/5678]  22%  src/server/handlers". Updates are drawn at
+ * most every PROGRESS_INTERVAL_MS, and the line is cleared before anything
+ * else is printed, so it never ends up in logs or scrollback.
+ */
+
+#define PROGRESS_INTERVAL_MS 100
+
+typedef struct {
+    int enabled;
+    int width;                 /* Terminal columns */
+    int drawn;                 /* A line is on screen */
+    struct timespec last;      /* When it was last drawn */
+} Progress;
+
+/* Whether a progress line can be shown (stderr is a terminal) */
+int progress_available(void);
+
+/* Set up a progress line; with enabled 0 every call is a no-op */
+void progress_init(Progress *progress, int enabled);
+
+/* Report that done of total files are handled, the last being path; drawn
+ * if the interval has passed or done reached total */
+void progress_update(Progress *progress, int done, int total, const char *path);
+
+/* Erase the line, if one is on screen */
+void progress_clear(Progress *progress);
+
+#endif /* PROGRESS_H */
@@ -0,0 +1,494 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "search.h"
+#include "database.h"
+#include "ndjson.h"
+#include "lsp.h"
+#include "sql_builder.h"
+#include <ctype.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+/* Directory without the leading "./" some paths are stored with, so file
+ * patterns and output match what the user sees (same as ndjson.c) */
+#define DISPLAY_PATH \
+    "(CASE WHEN substr(directory, 1, 2) = './' THEN substr(directory, 3) " \
+    "ELSE directory END || filename)"
+
+/* Terms are made of letters, digits, '_' and '$' (see split_query()), none
+ * of them GLOB wildcards, so they go into GLOB patterns as they are */
+typedef struct {
+    char words[SEARCH_MAX_TERMS][WORD_MAX_LENGTH];                /* for = and GLOB */
+    char escaped[SEARCH_MAX_TERMS][WORD_MAX_LENGTH * 2];          /* LIKE-escaped (tokens) */
+    char letters[SEARCH_MAX_TERMS][WORD_MAX_LENGTH * 2];          /* GLOB "*r*e*d*" (fuzzy) */
+    int count;
+} SearchTerms;
+
+/* Bind numbers of term i's forms; the file pattern comes after all terms */
+#define TERM_WORD(i)    (3 * (i) + 1)
+#define TERM_LIKE(i)    (3 * (i) + 2)
+#define TERM_LETTERS(i) (3 * (i) + 3)
+
+/* Split the query into terms (lowercased if ignore_case), dropping
+ * stopwords unless that would leave nothing to search for */
+static void split_query(const char *query, SymbolFilter *filter, int ignore_case, SearchTerms *terms) {
+    char all[SEARCH_MAX_TERMS][WORD_MAX_LENGTH];
+    char lower[SEARCH_MAX_TERMS][WORD_MAX_LENGTH];  /* Stopwords are lowercase */
+    int all_count = 0;
+    const char *p = query;
+
+    while (*p && all_count < SEARCH_MAX_TERMS) {
+        while (*p && !isalnum((unsigned char)*p) && *p != '_' && *p != '$') p++;
+        size_t len = 0;
+        while (p[len] && (isalnum((unsigned char)p[len]) || p[len] == '_' || p[len] == '$')) len++;
+        if (len == 0) break;
+        if (len >= WORD_MAX_LENGTH) len = WORD_MAX_LENGTH - 1;
+        for (size_t i = 0; i < len; i++) {
+            lower[all_count][i] = (char)tolower((unsigned char)p[i]);
+            all[all_count][i] = ignore_case ? lower[all_count][i] : p[i];
+        }
+        all[all_count][len] = '\0';
+        lower[all_count][len] = '\0';
+        all_count++;
+        p += len;
+        while (isalnum((unsigned char)*p) || *p == '_' || *p == '$') p++;  /* rest of a long word */
+    }
+
+    terms->count = 0;
+    for (int pass = 0; pass < 2 && terms->count == 0; pass++) {
+        for (int i = 0; i < all_count; i++) {
+            if (pass == 0 && filter_is_stopword(filter, lower[i])) {
+                continue;
+            }
+            memcpy(terms->words[terms->count], all[i], sizeof(all[i]));
+            terms->count++;
+        }
+    }
+
+    /* LIKE patterns escape the wildcard characters themselves */
+    for (int i = 0; i < terms->count; i++) {
+        char *dst = terms->escaped[i];
+        char *letters = terms->letters[i];
+        *letters++ = '*';
+        for (const char *src = terms->words[i]; *src; src++) {
+            if (*src == '%' || *src == '_' || *src == '\\') {
+                *dst++ = '\\';
+            }
+            *dst++ = *src;
+            *letters++ = *src;
+            *letters++ = '*';
+        }
+        *dst = '\0';
+        *letters = '\0';
+    }
+}
+
+/* SQL function edit_distance(a, b): Levenshtein distance in bytes. b is a
+ * query term, so at most WORD_MAX_LENGTH - 1 long. */
+static void sql_edit_distance(sqlite3_context *ctx, int argc, sqlite3_value **argv) {
+    (void)argc;
+    const unsigned char *a = sqlite3_value_text(argv[0]);
+    const unsigned char *b = sqlite3_value_text(argv[1]);
+    if (!a || !b) {
+        sqlite3_result_null(ctx);
+        return;
+    }
+    size_t b_len = strlen((const char *)b);
+    if (b_len >= WORD_MAX_LENGTH) b_len = WORD_MAX_LENGTH - 1;
+
+    int previous[WORD_MAX_LENGTH];
+    int current[WORD_MAX_LENGTH];
+    for (size_t j = 0; j <= b_len; j++) previous[j] = (int)j;
+    for (size_t i = 0; a[i]; i++) {
+        current[0] = (int)i + 1;
+        for (size_t j = 1; j <= b_len; j++) {
+            int substitute = previous[j - 1] + (a[i] != b[j - 1]);
+            int insert = current[j - 1] + 1;
+            int remove = previous[j] + 1;
+            int best = substitute < insert ? substitute : insert;
+            current[j] = best < remove ? best : remove;
+        }
+        memcpy(previous, current, (b_len + 1) * sizeof(int));
+    }
+    sqlite3_result_int(ctx, previous[b_len]);
+}
+
+/* Compact context name of the first context whose kind is kind, or NULL */
+static const char *context_for_kind(const char *kind) {
+    IndexEntry probe;
+    probe.clue[0] = '\0';
+    for (int c = CONTEXT_CLASS; c <= CONTEXT_CUSTOM; c++) {
+        probe.context = (ContextType)c;
+        if (strcmp(symbol_kind(&probe), kind) == 0) {
+            return context_to_string((ContextType)c, 1);
+        }
+    }
+    return NULL;
+}
+
+int search_kind_is_valid(const char *kind) {
+    return strcmp(kind, "struct") == 0 || context_for_kind(kind) != NULL;
+}
+
+/* Append the SQL condition selecting rows of the given kind. Go types are
+ * split by clue, mirroring symbol_kind() */
+static int append_kind_condition(SqlQueryBuilder *sql, const char *kind) {
+    const char *type = context_to_string(CONTEXT_TYPE, 1);
+
+    if (strcmp(kind, "struct") == 0) {
+        return sql_append(sql, " AND context = '%s' AND clue = 'struct'", type);
+    }
+    if (strcmp(kind, "interface") == 0) {
+        return sql_append(sql, " AND (context = '%s' OR (context = '%s' AND clue = 'interface'))",
+                          context_to_string(CONTEXT_INTERFACE, 1), type);
+    }
+    if (strcmp(kind, "type") == 0) {
+        return sql_append(sql, " AND context = '%s' AND clue NOT IN ('struct', 'interface')", type);
+    }
+    return sql_append(sql, " AND context = '%s'", context_for_kind(kind));
+}
+
+/* Column the terms are matched against: GLOB and = are case-sensitive, so
+ * ignoring case means the lowercased symbol */
+static const char *match_column(const SearchOptions *opts) {
+    return opts->ignore_case ? "symbol" : "full_symbol";
+}
+
+/* Condition for a row matching term i */
+static int append_term_match(SqlQueryBuilder *sql, int i, const SearchOptions *opts, int fuzzy) {
+    if (fuzzy) {
+        return sql_append(sql, "%s GLOB ?%d", match_column(opts), TERM_LETTERS(i));
+    }
+    return sql_append(sql, "%s GLOB '*' || ?%d || '*'", match_column(opts), TERM_WORD(i));
+}
+
+static int build_query(SqlQueryBuilder *sql, const SearchTerms *terms, const SearchOptions *opts) {
+    const char *column = match_column(opts);
+    if (sql_append(sql, "SELECT %s, (0", db_entry_columns()) != 0) return -1;
+    for (int i = 0; i < terms->count; i++) {
+        int word = TERM_WORD(i), like = TERM_LIKE(i);
+        if (sql_append(sql,
+                " + CASE WHEN %s = ?%d THEN 100"
+                " WHEN %s GLOB ?%d || '*' THEN 50"
+                " WHEN ' ' || tokens || ' ' LIKE '%% ' || ?%d || ' %%' ESCAPE '\\' THEN 40"
+                " WHEN %s GLOB '*' || ?%d || '*' THEN 20 ELSE 0 END",
+                column, word, column, word, like, column, word) != 0) return -1;
+    }
+    if (sql_append(sql,
+            " + CASE WHEN is_definition = 1 THEN 25 ELSE 0 END"
+            " + CASE WHEN context IN ('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s') THEN 10 ELSE 0 END"
+            ") AS score",
+            context_to_string(CONTEXT_TYPE, 1), context_to_string(CONTEXT_CLASS, 1),
+            context_to_string(CONTEXT_INTERFACE, 1), context_to_string(CONTEXT_FUNCTION, 1),
+            context_to_string(CONTEXT_ENUM, 1), context_to_string(CONTEXT_TRAIT, 1),
+            context_to_string(CONTEXT_NAMESPACE, 1), context_to_string(CONTEXT_ALIAS, 1)) != 0) return -1;
+
+    /* Distance to the closest term the row matches (scalar min() needs two
+     * or more arguments) */
+    if (opts->fuzzy) {
+        if (sql_append(sql, ", %s", terms->count > 1 ? "min(" : "") != 0) return -1;
+        for (int i = 0; i < terms->count; i++) {
+            if (sql_append(sql, "%sCASE WHEN ", i > 0 ? ", " : "") != 0 ||
+                append_term_match(sql, i, opts, 1) != 0 ||
+                sql_append(sql, " THEN edit_distance(%s, ?%d) ELSE %d END",
+                           column, TERM_WORD(i), SYMBOL_MAX_LENGTH) != 0) return -1;
+        }
+        if (sql_append(sql, "%s AS distance", terms->count > 1 ? ")" : "") != 0) return -1;
+    }
+
+    if (sql_append(sql, " FROM code_index WHERE context NOT IN ('%s', '%s', '%s') AND (",
+                   context_to_string(CONTEXT_COMMENT, 1), context_to_string(CONTEXT_STRING, 1),
+                   context_to_string(CONTEXT_FILENAME, 1)) != 0) return -1;
+    for (int i = 0; i < terms->count; i++) {
+        if ((i > 0 && sql_append(sql, " OR ") != 0) ||
+            append_term_match(sql, i, opts, opts->fuzzy) != 0) return -1;
+    }
+    if (sql_append(sql, ")") != 0) return -1;
+
+    if (opts->kind && append_kind_condition(sql, opts->kind) != 0) return -1;
+    if (opts->exported_only && sql_append(sql, " AND is_exported = 1") != 0) return -1;
+    if (opts->file_pattern &&
+        sql_append(sql, " AND " DISPLAY_PATH " GLOB ?%d", TERM_WORD(terms->count)) != 0) return -1;
+
+    if (sql_append(sql, " ORDER BY %sscore DESC, length(symbol), directory, filename, line",
+                   opts->fuzzy ? "distance, " : "") != 0) return -1;
+    /* Merged rows don't count towards the limit, so dedupe reads them all */
+    return opts->dedupe ? 0 : sql_append(sql, " LIMIT %d", opts->limit);
+}
+
+/* Locations merged into one result (dedupe) */
+typedef struct {
+    SymbolLocation *locations;
+    int count;
+    int capacity;
+} LocationList;
+
+/* Index of the hit that row duplicates (same name, kind and parent), or -1 */
+static int find_duplicate(const IndexEntry *hits, int count, const IndexEntry *row) {
+    for (int i = 0; i < count; i++) {
+        if (strcmp(hits[i].full_symbol, row->full_symbol) == 0 &&
+            strcmp(hits[i].parent_symbol, row->parent_symbol) == 0 &&
+            strcmp(symbol_kind(&hits[i]), symbol_kind(row)) == 0) {
+            return i;
+        }
+    }
+    return -1;
+}
+
+static int add_location(LocationList *list, const IndexEntry *row) {
+    if (list->count == list->capacity) {
+        int capacity = list->capacity > 0 ? list->capacity * 2 : 4;
+        SymbolLocation *grown = realloc(list->locations, sizeof(SymbolLocation) * (size_t)capacity);
+        if (!grown) {
+            return -1;
+        }
+        list->locations = grown;
+        list->capacity = capacity;
+    }
+    SymbolLocation *location = &list->locations[list->count];
+    snprintf(location->directory, sizeof(location->directory), "%s", row->directory);
+    snprintf(location->filename, sizeof(location->filename), "%s", row->filename);
+    location->line = row->line;
+    snprintf(location->source_location, sizeof(location->source_location), "%s", row->source_location);
+    list->count++;
+    return 0;
+}
+
+/* Make room for result number count; the buffers grow as results arrive
+ * instead of being sized for the limit up front */
+static int reserve_hit(IndexEntry **hits, LocationList **merged, int dedupe,
+                       int count, int *capacity) {
+    if (count < *capacity) {
+        return 0;
+    }
+    int grown_capacity = *capacity > 0 ? *capacity * 2 : 16;
+    IndexEntry *grown = realloc(*hits, sizeof(IndexEntry) * (size_t)grown_capacity);
+    if (!grown) {
+        return -1;
+    }
+    *hits = grown;
+    if (dedupe) {
+        LocationList *grown_merged = realloc(*merged, sizeof(LocationList) * (size_t)grown_capacity);
+        if (!grown_merged) {
+            return -1;
+        }
+        memset(grown_merged + *capacity, 0, sizeof(LocationList) * (size_t)(grown_capacity - *capacity));
+        *merged = grown_merged;
+    }
+    *capacity = grown_capacity;
+    return 0;
+}
+
+static void display_file(const char *directory, const char *filename, int line, char *buf, size_t size) {
+    if (strncmp(directory, "./", 2) == 0) {
+        directory += 2;
+    }
+    snprintf(buf, size, "%s%s:%d", directory, filename, line);
+}
+
+/* merged is NULL unless deduplicating; further locations of a merged
+ * symbol are listed under it */
+static void print_table(FILE *out, const IndexEntry *hits, const LocationList *merged, int count) {
+    char location[DIRECTORY_MAX_LENGTH + FILENAME_MAX_LENGTH + 16];
+    int name_width = 4, kind_width = 4, file_width = 4;
+
+    for (int i = 0; i < count; i++) {
+        int len = (int)strlen(hits[i].full_symbol);
+        if (len > name_width) name_width = len;
+        len = (int)strlen(symbol_kind(&hits[i]));
+        if (len > kind_width) kind_width = len;
+        display_file(hits[i].directory, hits[i].filename, hits[i].line, location, sizeof(location));
+        len = (int)strlen(location);
+        if (len > file_width) file_width = len;
+        for (int j = 1; merged && j < merged[i].count; j++) {
+            const SymbolLocation *other = &merged[i].locations[j];
+            display_file(other->directory, other->filename, other->line, location, sizeof(location));
+            len = (int)strlen(location);
+            if (len > file_width) file_width = len;
+        }
+    }
+
+    fprintf(out, "%-*s  %-*s  %-*s  %s\n", name_width, "NAME", kind_width, "KIND",
+            file_width, "FILE", "PARENT");
+    for (int i = 0; i < count; i++) {
+        display_file(hits[i].directory, hits[i].filename, hits[i].line, location, sizeof(location));
+        fprintf(out, "%-*s  %-*s  ", name_width, hits[i].full_symbol,
+                kind_width, symbol_kind(&hits[i]));
+        if (hits[i].parent_symbol[0] != '\0') {
+            fprintf(out, "%-*s  %s\n", file_width, location, hits[i].parent_symbol);
+        } else {
+            fprintf(out, "%s\n", location);
+        }
+        for (int j = 1; merged && j < merged[i].count; j++) {
+            const SymbolLocation *other = &merged[i].locations[j];
+            display_file(other->directory, other->filename, other->line, location, sizeof(location));
+            fprintf(out, "%-*s  %-*s  %s\n", name_width, "", kind_width, "", location);
+        }
+    }
+}
+
+int search_index(const char *db_path, SymbolFilter *filter, const SearchOptions *opts, FILE *out) {
+    sqlite3 *db = NULL;
+    if (sqlite3_open_v2(db_path, &db, SQLITE_OPEN_READONLY, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", db_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+    int result = search_index_db(db, filter, opts, out);
+    sqlite3_close(db);
+    return result;
+}
+
+int search_index_db(sqlite3 *db, SymbolFilter *filter, const SearchOptions *opts, FILE *out) {
+    SearchTerms terms;
+    split_query(opts->query, filter, opts->ignore_case, &terms);
+    if (terms.count == 0) {
+        fprintf(stderr, "Error: search query '%s' contains no searchable words\n", opts->query);
+        return -1;
+    }
+
+    int result = -1;
+    sqlite3_stmt *stmt = NULL;
+    IndexEntry *hits = NULL;
+    LocationList *merged = NULL;
+    int count = 0;
+    SqlQueryBuilder sql;
+    if (init_sql_builder(&sql) != 0) {
+        fprintf(stderr, "Error: out of memory building search query\n");
+        return -1;
+    }
+
+    if (opts->fuzzy &&
+        sqlite3_create_function(db, "edit_distance", 2, SQLITE_UTF8 | SQLITE_DETERMINISTIC, NULL,
+                                sql_edit_distance, NULL, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot register edit_distance: %s\n", sqlite3_errmsg(db));
+        goto cleanup;
+    }
+    if (build_query(&sql, &terms, opts) != 0) {
+        fprintf(stderr, "Error: search query too large\n");
+        goto cleanup;
+    }
+    if (sqlite3_prepare_v2(db, sql.sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: search failed: %s\n", sqlite3_errmsg(db));
+        goto cleanup;
+    }
+
+    for (int i = 0; i < terms.count; i++) {
+        sqlite3_bind_text(stmt, TERM_WORD(i), terms.words[i], -1, SQLITE_STATIC);
+        sqlite3_bind_text(stmt, TERM_LIKE(i), terms.escaped[i], -1, SQLITE_STATIC);
+        sqlite3_bind_text(stmt, TERM_LETTERS(i), terms.letters[i], -1, SQLITE_STATIC);
+    }
+    char glob[PATH_MAX_LENGTH];
+    if (opts->file_pattern) {
+        /* A plain word matches anywhere in the path */
+        if (strpbrk(opts->file_pattern, "*?[")) {
+            snprintf(glob, sizeof(glob), "%s", opts->file_pattern);
+        } else {
+            snprintf(glob, sizeof(glob), "*%s*", opts->file_pattern);
+        }
+        sqlite3_bind_text(stmt, TERM_WORD(terms.count), glob, -1, SQLITE_STATIC);
+    }
+
+    int capacity = 0;
+    int rc = SQLITE_DONE;
+    if (!opts->dedupe) {
+        while (count < opts->limit && (rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+            if (reserve_hit(&hits, &merged, 0, count, &capacity) != 0) {
+                fprintf(stderr, "Error: out of memory for search results\n");
+                goto cleanup;
+            }
+            db_read_entry(stmt, &hits[count]);
+            count++;
+        }
+    } else {
+        IndexEntry row;
+        while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+            db_read_entry(stmt, &row);
+            int target = find_duplicate(hits, count, &row);
+            if (target < 0) {
+                if (count == opts->limit) {
+                    continue;  /* Only later duplicates of kept results matter now */
+                }
+                if (reserve_hit(&hits, &merged, 1, count, &capacity) != 0) {
+                    fprintf(stderr, "Error: out of memory for search results\n");
+                    goto cleanup;
+                }
+                target = count;
+                hits[count] = row;
+                count++;
+            }
+            if (add_location(&merged[target], &row) != 0) {
+                fprintf(stderr, "Error: out of memory for search results\n");
+                goto cleanup;
+            }
+        }
+    }
+    if ((opts->dedupe || count < opts->limit) && rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: search failed: %s\n", sqlite3_errmsg(db));
+        goto cleanup;
+    }
+
+    if (opts->format == SEARCH_FORMAT_NDJSON) {
+        for (int i = 0; i < count; i++) {
+            if (merged) {
+                ndjson_write_entry_locations(out, &hits[i], merged[i].locations, merged[i].count);
+            } else {
+                ndjson_write_entry(out, &hits[i]);
+            }
+        }
+    } else if (opts->format == SEARCH_FORMAT_LSP) {
+        /* Stored paths are relative to the directory the index was built from,
+         * which is where search runs (next to code-index.db). A WorkspaceSymbol
+         * has one location, so merged symbols give their primary one. */
+        char cwd[PATH_MAX_LENGTH];
+        if (!getcwd(cwd, sizeof(cwd))) {
+            fprintf(stderr, "Error: cannot determine current directory for file URIs\n");
+            goto cleanup;
+        }
+        fputc('[', out);
+        for (int i = 0; i < count; i++) {
+            fputs(i > 0 ? ",\n" : "\n", out);
+            lsp_write_symbol(out, &hits[i], cwd);
+        }
+        fputs(count > 0 ? "\n]\n" : "]\n", out);
+    } else if (opts->format == SEARCH_FORMAT_JSON) {
+        fputc('[', out);
+        for (int i = 0; i < count; i++) {
+            if (i > 0) fputc(',', out);
+            ndjson_write_object(out, &hits[i], merged ? merged[i].locations : NULL,
+                                merged ? merged[i].count : 0);
+        }
+        fputc(']', out);
+    } else if (count == 0) {
+        fprintf(stderr, "No symbols match '%s'\n", opts->query);
+    } else {
+        print_table(out, hits, merged, count);
+    }
+    result = 0;
+
+cleanup:
+    for (int i = 0; merged && i < count; i++) {
+        free(merged[i].locations);
+    }
+    free(merged);
+    free(hits);
+    sqlite3_finalize(stmt);
+    free_sql_builder(&sql);
+    return result;
+}
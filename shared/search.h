@@ -0,0 +1,96 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef SEARCH_H
+#define SEARCH_H
+
+#include <stdio.h>
+#include <sqlite3.h>
+#include "filter.h"
+
+/*
+ * Ranked symbol search over an existing index (the "search" subcommand)
+ *
+ * The query is split into terms on whitespace and punctuation. Terms that
+ * are stopwords are dropped unless nothing else is left, so "find the user"
+ * searches for "find" and "user". A row's score is the sum over terms of
+ *
+ *   exact symbol match   100
+ *   prefix match          50
+ *   whole token match     40  ("Closer" in ReadCloser, see identifier_tokens.h)
+ *   substring match       20
+ *
+ * plus 25 for definitions and 10 for declarations of types, functions and
+ * other named kinds. Ties go to shorter symbols, then file and line.
+ * Comment, string and filename rows are not searched. Matching is exact and
+ * case-sensitive on the symbol as written (full_symbol); with ignore_case
+ * set it uses the lowercased symbol column and lowercases the terms.
+ * Token matches ignore case either way (tokens are stored lowercased).
+ *
+ * With fuzzy set, a term also matches symbols containing its letters in
+ * order ("RdClsr" matches ReadCloser). Rows are then ranked by their
+ * Levenshtein distance to the closest matching term first, the score above
+ * breaking ties. Stopwords are dropped before matching, as always.
+ *
+ * With dedupe set, rows with the same name, kind and parent (a type declared
+ * once per build-tagged file, say) are merged into the best-ranked one,
+ * which keeps its rank and lists every location in result order. The limit
+ * then counts merged symbols.
+ */
+
+#define SEARCH_DEFAULT_LIMIT 20
+#define SEARCH_MAX_TERMS 8
+
+typedef enum {
+    SEARCH_FORMAT_TABLE,
+    SEARCH_FORMAT_NDJSON,
+    SEARCH_FORMAT_LSP,      /* JSON array of LSP WorkspaceSymbol objects */
+    SEARCH_FORMAT_JSON      /* One-line JSON array of NDJSON objects (serve) */
+} SearchFormat;
+
+typedef struct {
+    const char *query;         /* Search text (one or more words) */
+    const char *kind;          /* Only this kind, e.g. "struct" (NULL = any) */
+    const char *file_pattern;  /* Only files matching this glob (NULL = any) */
+    int limit;                 /* Maximum results */
+    int dedupe;                /* Merge identical symbols (see above) */
+    int exported_only;         /* Only exported symbols (is_exported = 1) */
+    int fuzzy;                 /* Subsequence matches ranked by edit distance */
+    int ignore_case;           /* Match lowercased symbols and terms */
+    SearchFormat format;
+} SearchOptions;
+
+/* Search the index at db_path and print ranked results to out
+ *
+ * Parameters:
+ *   db_path - Existing index database (opened read-only)
+ *   filter  - Loaded filter (stopwords)
+ *   opts    - Query and filters
+ *   out     - Output stream
+ *
+ * Returns: 0 on success (including no matches), -1 on error
+ */
+int search_index(const char *db_path, SymbolFilter *filter, const SearchOptions *opts, FILE *out);
+
+/* Same as search_index, on an index that is already open */
+int search_index_db(sqlite3 *db, SymbolFilter *filter, const SearchOptions *opts, FILE *out);
+
+/* Check that kind is a name search understands (see symbol_kind())
+ * Returns: 1 if valid, 0 if not */
+int search_kind_is_valid(const char *kind);
+
+#endif /* SEARCH_H */
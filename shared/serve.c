@@ -0,0 +1,534 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "serve.h"
+#include "constants.h"
+#include "json_reader.h"
+#include "ndjson.h"
+#include "search.h"
+#include "symbol_at.h"
+#include <ctype.h>
+#include <errno.h>
+#include <signal.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#if !defined(_WIN32) && !defined(__MINGW32__) && !defined(__MINGW64__)
+#define SERVE_HAS_SOCKETS 1
+#include <sys/socket.h>
+#include <sys/stat.h>
+#include <sys/un.h>
+#include <unistd.h>
+#endif
+
+/* Largest request accepted, in bytes */
+#define SERVE_MAX_MESSAGE (1024 * 1024)
+#define SERVE_SOCKET_BACKLOG 4
+
+/* JSON-RPC 2.0 error codes */
+#define RPC_PARSE_ERROR      (-32700)
+#define RPC_INVALID_REQUEST  (-32600)
+#define RPC_METHOD_NOT_FOUND (-32601)
+#define RPC_INVALID_PARAMS   (-32602)
+#define RPC_INTERNAL_ERROR   (-32603)
+
+static volatile sig_atomic_t stop_requested = 0;
+
+static void serve_signal_handler(int signum) {
+    (void)signum;
+    stop_requested = 1;
+}
+
+typedef struct {
+    char *data;
+    size_t length;
+    size_t capacity;
+} MessageBuffer;
+
+typedef struct {
+    const ServeContext *ctx;
+    MessageBuffer buffer;     /* Request being read */
+    FILE *result;             /* Scratch: result of the method being run */
+    FILE *message;            /* Scratch: response being built */
+    int stop;                 /* Set by shutdown/exit */
+} ServeSession;
+
+/* Make room for needed bytes plus a NUL
+ * Returns: 0 on success, -1 if out of memory */
+static int buffer_reserve(MessageBuffer *buf, size_t needed) {
+    if (needed + 1 <= buf->capacity) return 0;
+    size_t capacity = buf->capacity ? buf->capacity : LINE_BUFFER_LARGE;
+    while (capacity < needed + 1) capacity *= 2;
+    char *data = realloc(buf->data, capacity);
+    if (!data) return -1;
+    buf->data = data;
+    buf->capacity = capacity;
+    return 0;
+}
+
+/* Read one line without its "\n" or "\r\n"
+ * Returns: 0 on success, 1 if it was over SERVE_MAX_MESSAGE (consumed and
+ * dropped), -1 at end of input or on a read error */
+static int read_line(FILE *in, MessageBuffer *buf) {
+    buf->length = 0;
+    int oversized = 0;
+    int c;
+    while ((c = fgetc(in)) != EOF && c != '\n') {
+        if (oversized || buf->length >= SERVE_MAX_MESSAGE || buffer_reserve(buf, buf->length + 1) != 0) {
+            oversized = 1;
+            continue;
+        }
+        buf->data[buf->length++] = (char)c;
+    }
+    if (c == EOF && buf->length == 0 && !oversized) return -1;
+    if (oversized) return 1;
+    if (buf->length > 0 && buf->data[buf->length - 1] == '\r') buf->length--;
+    if (buffer_reserve(buf, buf->length) != 0) return 1;
+    buf->data[buf->length] = '\0';
+    return 0;
+}
+
+/* Is line the header name (any case) followed by a colon? */
+static int header_is(const char *line, const char *name) {
+    size_t i = 0;
+    for (; name[i]; i++) {
+        if (tolower((unsigned char)line[i]) != tolower((unsigned char)name[i])) return 0;
+    }
+    return line[i] == ':';
+}
+
+/* Read one request, header-framed or a single line, into buf
+ * Returns: 0 on success (framed set), 1 if too large, -1 at end of input
+ * or on a read error */
+static int read_message(FILE *in, MessageBuffer *buf, int *framed) {
+    int rc;
+    do {
+        rc = read_line(in, buf);
+    } while (rc == 0 && buf->length == 0);  /* Blank lines between messages */
+    if (rc != 0) return rc;
+
+    size_t start = strspn(buf->data, " \t");
+    if (buf->data[start] == '{' || buf->data[start] == '[' || !strchr(buf->data, ':')) {
+        *framed = 0;
+        return 0;
+    }
+
+    /* Headers up to a blank line; only Content-Length matters */
+    long length = -1;
+    do {
+        if (header_is(buf->data, "Content-Length")) {
+            char *end;
+            length = strtol(buf->data + strlen("Content-Length:"), &end, 10);
+            if (end == buf->data + strlen("Content-Length:")) length = -1;
+        }
+    } while ((rc = read_line(in, buf)) == 0 && buf->length > 0);
+    if (rc < 0) return -1;
+    if (length < 0 || length > SERVE_MAX_MESSAGE) {
+        /* The next message can't be found without the length */
+        fprintf(stderr, "Error: request header has no usable Content-Length (limit %d bytes)\n",
+                SERVE_MAX_MESSAGE);
+        return -1;
+    }
+    if (buffer_reserve(buf, (size_t)length) != 0) {
+        fprintf(stderr, "Error: out of memory reading a request\n");
+        return -1;
+    }
+    if (fread(buf->data, 1, (size_t)length, in) != (size_t)length) return -1;
+    buf->length = (size_t)length;
+    buf->data[buf->length] = '\0';
+    *framed = 1;
+    return 0;
+}
+
+/* Copy the first length bytes of from to to
+ * Returns: 0 on success, -1 on an I/O error */
+static int copy_bytes(FILE *from, long length, FILE *to) {
+    char chunk[LINE_BUFFER_LARGE];
+    rewind(from);
+    while (length > 0) {
+        size_t want = length < (long)sizeof(chunk) ? (size_t)length : sizeof(chunk);
+        size_t got = fread(chunk, 1, want, from);
+        if (got == 0 || fwrite(chunk, 1, got, to) != got) return -1;
+        length -= (long)got;
+    }
+    return 0;
+}
+
+static void write_id(FILE *out, const JsonValue *id) {
+    if (id && id->type == JSON_STRING) {
+        json_write_string(out, id->string);
+    } else if (id && id->type == JSON_NUMBER && id->number == (double)(long long)id->number) {
+        fprintf(out, "%lld", (long long)id->number);
+    } else if (id && id->type == JSON_NUMBER) {
+        fprintf(out, "%.17g", id->number);
+    } else {
+        fputs("null", out);
+    }
+}
+
+/* Send a response: the result in s->result (result_length bytes) when
+ * code is 0, else an error
+ * Returns: 0 on success, -1 if the response could not be written */
+static int send_response(ServeSession *s, FILE *out, int framed, const JsonValue *id,
+                         int code, const char *error, long result_length) {
+    FILE *message = s->message;
+    rewind(message);
+    fputs("{\"jsonrpc\":\"2.0\",\"id\":", message);
+    write_id(message, id);
+    if (code == 0) {
+        fputs(",\"result\":", message);
+        if (copy_bytes(s->result, result_length, message) != 0) return -1;
+    } else {
+        fprintf(message, ",\"error\":{\"code\":%d,\"message\":", code);
+        json_write_string(message, error);
+        fputc('}', message);
+    }
+    fputc('}', message);
+    long length = ftell(message);
+    if (length < 0 || ferror(message)) return -1;
+
+    if (framed) {
+        fprintf(out, "Content-Length: %ld\r\n\r\n", length);
+    }
+    if (copy_bytes(message, length, out) != 0) return -1;
+    if (!framed) {
+        fputc('\n', out);
+    }
+    return fflush(out) == 0 && !ferror(out) ? 0 : -1;
+}
+
+/* Param by name, or by position when params is an array; JSON null
+ * counts as absent */
+static const JsonValue *param(const JsonValue *params, const char *name, int position) {
+    const JsonValue *value = NULL;
+    if (params && params->type == JSON_OBJECT) {
+        value = json_get(params, name);
+    } else if (params && params->type == JSON_ARRAY) {
+        value = params->child;
+        for (int i = 0; value && i < position; i++) value = value->next;
+    }
+    return value && value->type != JSON_NULL ? value : NULL;
+}
+
+/* Typed params: absent gives NULL or the fallback, another type sets *bad */
+static const char *string_param(const JsonValue *params, const char *name, int position, int *bad) {
+    const JsonValue *value = param(params, name, position);
+    if (value && value->type != JSON_STRING) *bad = 1;
+    return value && value->type == JSON_STRING ? value->string : NULL;
+}
+
+static int int_param(const JsonValue *params, const char *name, int position, int fallback, int *bad) {
+    const JsonValue *value = param(params, name, position);
+    if (!value) return fallback;
+    if (value->type != JSON_NUMBER || value->number != (double)(int)value->number) {
+        *bad = 1;
+        return fallback;
+    }
+    return (int)value->number;
+}
+
+static int bool_param(const JsonValue *params, const char *name, int position, int *bad) {
+    const JsonValue *value = param(params, name, position);
+    if (value && value->type != JSON_BOOL) *bad = 1;
+    return value && value->type == JSON_BOOL ? value->boolean : 0;
+}
+
+/* Methods write their result to s->result and return 0, or return an
+ * RPC error code with a message in error */
+typedef int (*MethodFunc)(ServeSession *s, const JsonValue *params, char *error, size_t error_size);
+
+static int method_search(ServeSession *s, const JsonValue *params, char *error, size_t error_size) {
+    int bad = 0;
+    SearchOptions opts = { .format = SEARCH_FORMAT_JSON };
+    opts.query = string_param(params, "query", 0, &bad);
+    opts.kind = string_param(params, "kind", 1, &bad);
+    opts.file_pattern = string_param(params, "file", 2, &bad);
+    opts.limit = int_param(params, "limit", 3, SEARCH_DEFAULT_LIMIT, &bad);
+    opts.fuzzy = bool_param(params, "fuzzy", 4, &bad);
+    opts.exported_only = bool_param(params, "exportedOnly", 5, &bad);
+    opts.dedupe = bool_param(params, "dedupe", 6, &bad);
+    opts.ignore_case = bool_param(params, "ignoreCase", 7, &bad);
+
+    if (bad) {
+        snprintf(error, error_size, "search takes a query, kind and file (strings), limit (integer) "
+                 "and fuzzy, exportedOnly, dedupe and ignoreCase (booleans)");
+        return RPC_INVALID_PARAMS;
+    }
+    if (!opts.query || !opts.query[0]) {
+        snprintf(error, error_size, "search needs a query");
+        return RPC_INVALID_PARAMS;
+    }
+    if (opts.limit <= 0) {
+        snprintf(error, error_size, "limit must be a positive number");
+        return RPC_INVALID_PARAMS;
+    }
+    if (opts.kind && !search_kind_is_valid(opts.kind)) {
+        snprintf(error, error_size, "unknown symbol kind '%s'", opts.kind);
+        return RPC_INVALID_PARAMS;
+    }
+    if (search_index_db(s->ctx->db, s->ctx->filter, &opts, s->result) != 0) {
+        snprintf(error, error_size, "search failed (details on the server's stderr)");
+        return RPC_INTERNAL_ERROR;
+    }
+    return 0;
+}
+
+static int method_symbol_at(ServeSession *s, const JsonValue *params, char *error, size_t error_size) {
+    int bad = 0;
+    const char *file = string_param(params, "file", 0, &bad);
+    int line = int_param(params, "line", 1, 0, &bad);
+    int column = int_param(params, "column", 2, 0, &bad);
+    if (bad || !file || line < 1 || column < 1) {
+        snprintf(error, error_size, "symbolAt takes a file, a line and a column (both from 1)");
+        return RPC_INVALID_PARAMS;
+    }
+
+    /* 1-based like the columns of results; source locations count from 0 */
+    IndexEntry entry;
+    int found = symbol_at(s->ctx->db, file, line, column - 1, &entry);
+    if (found < 0) {
+        snprintf(error, error_size, "symbol lookup failed (details on the server's stderr)");
+        return RPC_INTERNAL_ERROR;
+    }
+    if (found) {
+        ndjson_write_object(s->result, &entry, NULL, 0);
+    } else {
+        fputs("null", s->result);
+    }
+    return 0;
+}
+
+static int method_reindex(ServeSession *s, const JsonValue *params, char *error, size_t error_size) {
+    if (!s->ctx->reindex) {
+        snprintf(error, error_size, "reindex is not available");
+        return RPC_METHOD_NOT_FOUND;
+    }
+    int bad = 0;
+    const char *file = string_param(params, "file", 0, &bad);
+    if (bad || !file || !file[0]) {
+        snprintf(error, error_size, "reindex takes a file");
+        return RPC_INVALID_PARAMS;
+    }
+
+    int symbols = 0, removed = 0;
+    if (s->ctx->reindex(s->ctx->reindex_ctx, file, &symbols, &removed, error, error_size) != 0) {
+        return RPC_INTERNAL_ERROR;
+    }
+    fputs("{\"file\":", s->result);
+    json_write_string(s->result, file);
+    if (removed) {
+        fputs(",\"removed\":true}", s->result);
+    } else {
+        fprintf(s->result, ",\"symbols\":%d}", symbols);
+    }
+    return 0;
+}
+
+static int method_shutdown(ServeSession *s, const JsonValue *params, char *error, size_t error_size) {
+    (void)params;
+    (void)error;
+    (void)error_size;
+    fputs("null", s->result);
+    s->stop = 1;
+    return 0;
+}
+
+static const struct {
+    const char *name;
+    MethodFunc run;
+} methods[] = {
+    { "search",   method_search },
+    { "symbolAt", method_symbol_at },
+    { "reindex",  method_reindex },
+    { "shutdown", method_shutdown },
+    { "exit",     method_shutdown },
+};
+
+/* Run one request and answer it (unless it is a notification)
+ * Returns: 0 on success, -1 if the response could not be written */
+static int handle_message(ServeSession *s, FILE *out, int framed) {
+    char error[ERROR_MESSAGE_BUFFER];
+    JsonValue *request = json_parse(s->buffer.data, s->buffer.length, error, sizeof(error));
+    if (!request) {
+        return send_response(s, out, framed, NULL, RPC_PARSE_ERROR, error, 0);
+    }
+
+    const JsonValue *id = json_get(request, "id");
+    const JsonValue *params = json_get(request, "params");
+    const char *method = json_get_string(request, "method");
+    int rc;
+    if (request->type != JSON_OBJECT || !method ||
+        (id && id->type != JSON_NUMBER && id->type != JSON_STRING && id->type != JSON_NULL) ||
+        (params && params->type != JSON_OBJECT && params->type != JSON_ARRAY)) {
+        rc = send_response(s, out, framed, NULL, RPC_INVALID_REQUEST,
+                           "expected an object with a method, an optional number or string id "
+                           "and optional params", 0);
+        json_free(request);
+        return rc;
+    }
+
+    int code = RPC_METHOD_NOT_FOUND;
+    snprintf(error, sizeof(error), "unknown method '%s'", method);
+    rewind(s->result);
+    for (size_t i = 0; i < sizeof(methods) / sizeof(methods[0]); i++) {
+        if (strcmp(methods[i].name, method) == 0) {
+            code = methods[i].run(s, params, error, sizeof(error));
+            break;
+        }
+    }
+    long result_length = ftell(s->result);
+    if (code == 0 && (result_length < 0 || ferror(s->result))) {
+        code = RPC_INTERNAL_ERROR;
+        snprintf(error, sizeof(error), "cannot buffer the result");
+    }
+
+    rc = 0;
+    if (id) {
+        rc = send_response(s, out, framed, id, code, error, result_length);
+    }
+    json_free(request);
+    return rc;
+}
+
+/* Answer the requests of one input until it ends or the server stops
+ * Returns: 0 on end of input or a stop, -1 on an I/O error */
+static int serve_stream(ServeSession *s, FILE *in, FILE *out) {
+    while (!stop_requested && !s->stop) {
+        int framed = 0;
+        int rc = read_message(in, &s->buffer, &framed);
+        if (rc < 0) {
+            return ferror(in) && !stop_requested ? -1 : 0;
+        }
+        if (rc > 0) {
+            if (send_response(s, out, 0, NULL, RPC_INVALID_REQUEST, "request too large", 0) != 0) {
+                return -1;
+            }
+            continue;
+        }
+        if (handle_message(s, out, framed) != 0) {
+            return -1;
+        }
+    }
+    return 0;
+}
+
+#ifdef SERVE_HAS_SOCKETS
+/* Accept clients on a unix socket, one at a time, until the server stops
+ * Returns: 0 on a stop, -1 if the socket could not be set up */
+static int serve_socket(ServeSession *s, const char *path) {
+    struct sockaddr_un addr;
+    memset(&addr, 0, sizeof(addr));
+    addr.sun_family = AF_UNIX;
+    if (strlen(path) >= sizeof(addr.sun_path)) {
+        fprintf(stderr, "Error: socket path too long: %s\n", path);
+        return -1;
+    }
+    memcpy(addr.sun_path, path, strlen(path) + 1);
+
+    /* Replace the socket of an earlier run, but never another file */
+    struct stat st;
+    if (lstat(path, &st) == 0) {
+        if (!S_ISSOCK(st.st_mode)) {
+            fprintf(stderr, "Error: '%s' exists and is not a socket\n", path);
+            return -1;
+        }
+        unlink(path);
+    }
+
+    int listener = socket(AF_UNIX, SOCK_STREAM, 0);
+    if (listener < 0 || bind(listener, (struct sockaddr *)&addr, sizeof(addr)) != 0 ||
+        listen(listener, SERVE_SOCKET_BACKLOG) != 0) {
+        fprintf(stderr, "Error: cannot listen on '%s': %s\n", path, strerror(errno));
+        if (listener >= 0) close(listener);
+        return -1;
+    }
+    fprintf(stderr, "Listening on %s\n", path);
+
+    while (!stop_requested && !s->stop) {
+        int client = accept(listener, NULL, NULL);
+        if (client < 0) {
+            if (errno != EINTR) {
+                fprintf(stderr, "Warning: accept failed: %s\n", strerror(errno));
+            }
+            continue;
+        }
+        int client_out = dup(client);
+        FILE *in = fdopen(client, "r");
+        FILE *out = client_out >= 0 ? fdopen(client_out, "w") : NULL;
+        if (in && out) {
+            /* A client that goes away only ends its own connection */
+            serve_stream(s, in, out);
+        } else {
+            fprintf(stderr, "Warning: cannot open client connection: %s\n", strerror(errno));
+        }
+        if (in) fclose(in); else close(client);
+        if (out) fclose(out); else if (client_out >= 0) close(client_out);
+    }
+
+    close(listener);
+    unlink(path);
+    return 0;
+}
+#else
+static int serve_socket(ServeSession *s, const char *path) {
+    (void)s;
+    fprintf(stderr, "Error: --socket %s: unix sockets are not supported on this platform\n", path);
+    return -1;
+}
+#endif
+
+int serve_run(const ServeContext *ctx) {
+#ifdef SERVE_HAS_SOCKETS
+    /* No SA_RESTART, so a signal interrupts a blocked read or accept */
+    struct sigaction action;
+    memset(&action, 0, sizeof(action));
+    action.sa_handler = serve_signal_handler;
+    sigemptyset(&action.sa_mask);
+    sigaction(SIGINT, &action, NULL);
+    sigaction(SIGTERM, &action, NULL);
+    /* A client hanging up mid-response is a write error, not a crash */
+    signal(SIGPIPE, SIG_IGN);
+#else
+    signal(SIGINT, serve_signal_handler);
+    signal(SIGTERM, serve_signal_handler);
+#endif
+
+    ServeSession s = { .ctx = ctx };
+    int result = -1;
+    s.result = tmpfile();
+    s.message = tmpfile();
+    if (!s.result || !s.message) {
+        fprintf(stderr, "Error: cannot create scratch files for responses: %s\n", strerror(errno));
+        goto cleanup;
+    }
+
+    if (ctx->socket_path) {
+        result = serve_socket(&s, ctx->socket_path);
+    } else {
+        result = serve_stream(&s, stdin, stdout);
+        if (result != 0) {
+            fprintf(stderr, "Error: serve: cannot read requests or write responses\n");
+        }
+    }
+
+cleanup:
+    if (s.result) fclose(s.result);
+    if (s.message) fclose(s.message);
+    free(s.buffer.data);
+    return result;
+}
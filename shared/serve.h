@@ -0,0 +1,64 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef SERVE_H
+#define SERVE_H
+
+#include <stddef.h>
+#include <sqlite3.h>
+#include "filter.h"
+
+/*
+ * JSON-RPC 2.0 server over an open index (the "serve" subcommand)
+ *
+ * Requests are read from stdin, or from each client of a unix socket in
+ * turn, and answered in the framing they came in: LSP-style headers
+ * ("Content-Length: N\r\n\r\n" then N bytes of JSON) or one JSON message
+ * per line. Params are named, or positional in the order listed:
+ *
+ *   search   {query, kind, file, limit, fuzzy, exportedOnly, dedupe}
+ *            -> array of symbols, as search --format=ndjson writes them
+ *   symbolAt {file, line, column}  (both 1-based, as in results)
+ *            -> innermost symbol there (see symbol_at.h), or null
+ *   reindex  {file}
+ *            -> {"file", "symbols": N}, or {"file", "removed": true} when
+ *               the file is gone and its rows were dropped
+ *   shutdown -> null, and the server stops
+ *
+ * Notifications (no id) get no reply; "exit" stops the server too.
+ * SIGINT and SIGTERM stop it after the request in progress.
+ */
+
+/* Re-index one file (reindex)
+ * Returns: 0 with symbols set, or with removed set if the file no longer
+ * exists; -1 with a message in error */
+typedef int (*ServeReindexFunc)(void *ctx, const char *filepath, int *symbols, int *removed,
+                                char *error, size_t error_size);
+
+typedef struct {
+    sqlite3 *db;                  /* Open index, shared by every method */
+    SymbolFilter *filter;         /* Stopwords for search */
+    ServeReindexFunc reindex;     /* NULL: reindex is not available */
+    void *reindex_ctx;
+    const char *socket_path;      /* Listen here instead of stdin (NULL = stdin/stdout) */
+} ServeContext;
+
+/* Answer requests until shutdown, end of input or a signal
+ * Returns: 0 on a clean stop, -1 on an I/O or setup error */
+int serve_run(const ServeContext *ctx);
+
+#endif /* SERVE_H */
@@ -0,0 +1,230 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "signature.h"
+#include <ctype.h>
+#include <stdio.h>
+#include <string.h>
+
+static int is_word_char(unsigned char c) {
+    return c == '_' || c >= 0x80 || isalnum(c);
+}
+
+/* Skip whitespace and comments; sets *newline if a line break was skipped.
+ * Returns non-zero if anything was skipped. */
+static int skip_gap(const char **p, int *newline) {
+    const char *start = *p;
+    for (;;) {
+        const char *s = *p;
+        if (isspace((unsigned char)*s)) {
+            if (*s == '\n') *newline = 1;
+            (*p)++;
+        } else if (s[0] == '/' && s[1] == '/') {
+            while (**p && **p != '\n') (*p)++;
+        } else if (s[0] == '/' && s[1] == '*') {
+            const char *end = strstr(s + 2, "*/");
+            *p = end ? end + 2 : s + strlen(s);
+        } else {
+            break;
+        }
+    }
+    return *p != start;
+}
+
+/* Whether out (len characters so far) ends with word as a whole word */
+static int ends_with_word(const char *out, size_t len, const char *word) {
+    size_t n = strlen(word);
+    if (len < n || strncmp(out + len - n, word, n) != 0) return 0;
+    return len == n || !is_word_char((unsigned char)out[len - n - 1]);
+}
+
+/* Whether the whitespace between out and next is written as a space */
+static int keeps_space(const char *out, size_t len, const char *next) {
+    char prev = out[len - 1];
+
+    if (strchr(" ([{*.~]", prev)) return 0;
+    if (*next == ',' || *next == ')' || *next == ']' || *next == '}') return 0;
+    /* chan (<-chan int) keeps its space, as gofmt writes it */
+    if (*next == '(' && ends_with_word(out, len, "chan")) return 1;
+    if ((*next == '{' || *next == '(') && is_word_char((unsigned char)prev)) return 0;
+    if (*next == '[' && ends_with_word(out, len, "map")) return 0;
+    if (*next == '.' && strncmp(next, "...", 3) != 0) return 0;
+    if (strncmp(next, "<-", 2) == 0 && ends_with_word(out, len, "chan")) return 0;
+    /* <-chan int, but chan<- chan int (a send-only channel of channels) */
+    if (len >= 2 && strncmp(out + len - 2, "<-", 2) == 0 && strncmp(next, "chan", 4) == 0 &&
+        !is_word_char((unsigned char)next[4])) {
+        return ends_with_word(out, len - 2, "chan");
+    }
+    return 1;
+}
+
+static void put_char(char *out, size_t size, size_t *len, char c) {
+    if (*len + 1 < size) out[(*len)++] = c;
+}
+
+void normalize_type_expression(const char *type, char *out, size_t size) {
+    size_t len = 0;
+    int brace_depth = 0;
+    const char *p = type;
+
+    if (size == 0) return;
+    while (*p) {
+        int newline = 0;
+        if (skip_gap(&p, &newline)) {
+            if (len == 0 || !*p) continue;
+            if (brace_depth > 0 && newline && out[len - 1] != '{' && out[len - 1] != ';' &&
+                *p != '}' && *p != ';') {
+                put_char(out, size, &len, ';');
+                put_char(out, size, &len, ' ');
+            } else if (keeps_space(out, len, p)) {
+                put_char(out, size, &len, ' ');
+            }
+            continue;
+        }
+
+        char c = *p++;
+        if (c == ',' || c == ';') {
+            if (len > 0 && out[len - 1] == ' ') len--;  /* "M() ; N()" */
+            skip_gap(&p, &newline);
+            if (*p && *p != ')' && *p != ']' && *p != '}') {
+                put_char(out, size, &len, c);
+                put_char(out, size, &len, ' ');
+            }
+        } else if (c == '|') {
+            if (len > 0 && out[len - 1] != ' ') put_char(out, size, &len, ' ');
+            put_char(out, size, &len, '|');
+            put_char(out, size, &len, ' ');
+            skip_gap(&p, &newline);
+        } else if (c == '"' || c == '`' || c == '\'') {
+            /* Literal: copy through the closing quote */
+            put_char(out, size, &len, c);
+            while (*p && *p != c) {
+                if (*p == '\\' && c != '`' && p[1]) put_char(out, size, &len, *p++);
+                put_char(out, size, &len, *p++);
+            }
+            if (*p) put_char(out, size, &len, *p++);
+        } else {
+            if (c == '{') brace_depth++;
+            if (c == '}' && brace_depth > 0) brace_depth--;
+            put_char(out, size, &len, c);
+        }
+    }
+    out[len] = '\0';
+}
+
+int signature_append(char *list, size_t size, const char *name, const char *type, int variadic) {
+    char normalized[SYMBOL_MAX_LENGTH];
+    normalize_type_expression(type, normalized, sizeof(normalized));
+    type = normalized;
+
+    size_t used = strlen(list);
+    int written = snprintf(list + used, size - used, "%s%s%s%s%s",
+                           used > 0 ? ", " : "",
+                           name && name[0] ? name : "",
+                           name && name[0] ? " " : "",
+                           variadic ? "..." : "",
+                           type);
+    if (written < 0 || (size_t)written >= size - used) {
+        list[used] = '\0';
+        return -1;
+    }
+    return 0;
+}
+
+/* Check if a word can be a parameter name (keywords that start types can't) */
+static int is_parameter_name(const char *word, size_t len) {
+    static const char *type_keywords[] = { "chan", "func", "interface", "map", "struct", NULL };
+
+    if (len == 0) return 0;
+    for (size_t i = 0; i < len; i++) {
+        unsigned char c = (unsigned char)word[i];
+        if (!(c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+              (i > 0 && c >= '0' && c <= '9'))) {
+            return 0;
+        }
+    }
+    for (int i = 0; type_keywords[i]; i++) {
+        if (strlen(type_keywords[i]) == len && strncmp(word, type_keywords[i], len) == 0) {
+            return 0;
+        }
+    }
+    return 1;
+}
+
+/* Parse one element ("name ...type") of length len */
+static void parse_element(const char *element, size_t len, SignatureParam *param) {
+    param->name[0] = '\0';
+    param->variadic = 0;
+
+    /* A leading identifier followed by a space is the name */
+    const char *type = element;
+    const char *space = memchr(element, ' ', len);
+    if (space && is_parameter_name(element, (size_t)(space - element))) {
+        snprintf(param->name, sizeof(param->name), "%.*s", (int)(space - element), element);
+        type = space + 1;
+    }
+
+    size_t type_len = len - (size_t)(type - element);
+    if (type_len >= 3 && strncmp(type, "...", 3) == 0) {
+        param->variadic = 1;
+        type += 3;
+        type_len -= 3;
+    }
+    snprintf(param->type, sizeof(param->type), "%.*s", (int)type_len, type);
+}
+
+int parse_signature_list(const char *list, SignatureParam *params, int max_params) {
+    int count = 0;
+    if (!list || !list[0]) return 0;
+
+    const char *start = list;
+    int depth = 0;
+    for (const char *p = list; count < max_params; p++) {
+        if (*p == '(' || *p == '[' || *p == '{') {
+            depth++;
+        } else if ((*p == ')' || *p == ']' || *p == '}') && depth > 0) {
+            depth--;
+        } else if (*p == '\0' || (depth == 0 && p[0] == ',' && p[1] == ' ')) {
+            parse_element(start, (size_t)(p - start), &params[count]);
+            count++;
+            if (*p == '\0') break;
+            start = p + 2;
+            p++;
+        }
+    }
+    return count;
+}
+
+void signature_result_type(const char *returns, char *out, size_t size) {
+    SignatureParam params[MAX_SIGNATURE_PARAMS];
+    int count = parse_signature_list(returns, params, MAX_SIGNATURE_PARAMS);
+
+    if (size == 0) return;
+    out[0] = '\0';
+    if (count == 1) {
+        snprintf(out, size, "%s", params[0].type);
+        return;
+    }
+
+    size_t used = 0;
+    for (int i = 0; i < count && used < size; i++) {
+        int written = snprintf(out + used, size - used, "%s%s%s", i == 0 ? "(" : ", ",
+                               params[i].type, i == count - 1 ? ")" : "");
+        if (written < 0) break;
+        used += (size_t)written;
+    }
+}
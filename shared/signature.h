@@ -0,0 +1,102 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef SIGNATURE_H
+#define SIGNATURE_H
+
+#include <stddef.h>
+#include "constants.h"
+
+/*
+ * Function signature lists
+ *
+ * Parameters and return types are stored as ordered lists, one element per
+ * parameter, separated by ", ":
+ *
+ *   name type       named parameter        r io.Reader
+ *   type            unnamed parameter      int
+ *   name ...type    variadic parameter     opts ...Option
+ *
+ * Parameters sharing a type ("a, b int") are written out one per element
+ * ("a int, b int"). Commas inside a type (func(int, string) error) are
+ * nested in brackets, so only top-level ", " separates elements.
+ *
+ * Types are stored in a canonical spelling (see normalize_type_expression),
+ * so the same type written with different spacing compares equal.
+ */
+
+typedef struct {
+    char name[SYMBOL_MAX_LENGTH];   /* Empty for unnamed parameters */
+    char type[SYMBOL_MAX_LENGTH];   /* Without the variadic "..." */
+    int variadic;
+} SignatureParam;
+
+/* Rewrite a type expression with canonical spacing
+ *
+ * Whitespace runs, line breaks included, become one space where a space
+ * separates two words (chan int, func() error) and are dropped next to
+ * brackets, '*', '.' and '~', so "map[ string ] interface {}" becomes
+ * "map[string]interface{}". Commas and semicolons are followed by one
+ * space and have none before them, '|' has one space on each side, a
+ * trailing comma or semicolon before a closing bracket is dropped, and
+ * line breaks between members inside braces become "; ".
+ * String literals (struct tags) are copied unchanged.
+ *
+ * Parameters:
+ *   type - Type as written in the source
+ *   out  - Output buffer (may not overlap type)
+ *   size - Size of out; longer results are truncated
+ */
+void normalize_type_expression(const char *type, char *out, size_t size);
+
+/* Append one element to a signature list
+ *
+ * Parameters:
+ *   list     - List being built (start with an empty string)
+ *   size     - Size of list buffer
+ *   name     - Parameter name, NULL or "" if unnamed
+ *   type     - Parameter type (without "..."), normalized before appending
+ *   variadic - Non-zero for a variadic parameter
+ *
+ * Returns: 0 on success, -1 if the element did not fit (list unchanged)
+ */
+int signature_append(char *list, size_t size, const char *name, const char *type, int variadic);
+
+/* Split a signature list back into its elements
+ *
+ * Parameters:
+ *   list       - Stored list, e.g. "r io.Reader, opts ...Option"
+ *   params     - Output array
+ *   max_params - Capacity of params
+ *
+ * Returns: number of elements parsed (0 for an empty list)
+ */
+int parse_signature_list(const char *list, SignatureParam *params, int max_params);
+
+/* Write the result type of a returns list, without the names
+ *
+ * One element gives its type ("error"), more give the types in parentheses
+ * ("n int, err error" gives "(int, error)"); an empty list gives "".
+ *
+ * Parameters:
+ *   returns - Stored returns list
+ *   out     - Output buffer; longer results are truncated
+ *   size    - Size of out
+ */
+void signature_result_type(const char *returns, char *out, size_t size);
+
+#endif /* SIGNATURE_H */
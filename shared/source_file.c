@@ -0,0 +1,171 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "source_file.h"
+#include "file_opener.h"
+#include "parse_errors.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+#if !defined(_WIN32) && !defined(__MINGW32__) && !defined(__MINGW64__)
+#include <sys/mman.h>
+#include <unistd.h>
+#define SOURCE_CAN_MAP 1
+#endif
+
+/* Set by source_file_set_mapping() */
+static int g_map_large_files = 1;
+
+void source_file_set_mapping(int enabled) {
+    g_map_large_files = enabled;
+}
+
+#ifdef SOURCE_CAN_MAP
+/* Map the file if worth it. The bytes after the end of the file in its last
+ * page read as zero, which terminates text, so a file filling its last page
+ * exactly is read instead.
+ * Returns: 1 if mapped, 0 to read the file instead */
+static int map_source(SourceFile *source, int fd, size_t size) {
+    long page = sysconf(_SC_PAGESIZE);
+    if (!g_map_large_files || size < SOURCE_MAP_THRESHOLD || page <= 0 || size % (size_t)page == 0) {
+        return 0;
+    }
+    void *mapping = mmap(NULL, size, PROT_READ, MAP_PRIVATE, fd, 0);
+    if (mapping == MAP_FAILED) {
+        return 0;
+    }
+    madvise(mapping, size, MADV_SEQUENTIAL);
+    source->mapping = mapping;
+    source->mapped_size = size;
+    source->text = mapping;
+    return 1;
+}
+#endif
+
+/* Set by source_file_substitute() */
+static _Thread_local const char *g_substitute_path;
+static _Thread_local const char *g_substitute_content;
+static _Thread_local size_t g_substitute_length;
+
+void source_file_substitute(const char *filepath, const char *content, size_t length) {
+    g_substitute_path = filepath;
+    g_substitute_content = filepath ? content : NULL;
+    g_substitute_length = filepath ? length : 0;
+}
+
+/* Copied rather than borrowed: content need not be NUL-terminated */
+static int open_substitute(SourceFile *source) {
+    source->buffer = malloc(g_substitute_length + 1);
+    if (!source->buffer) {
+        parse_error_set("out of memory copying source");
+        return -1;
+    }
+    if (g_substitute_length > 0) {
+        memcpy(source->buffer, g_substitute_content, g_substitute_length);
+    }
+    source->buffer[g_substitute_length] = '\0';
+    source->length = g_substitute_length;
+    source->text = source->buffer;
+    return 0;
+}
+
+static int open_source(SourceFile *source, const char *filepath) {
+    memset(source, 0, sizeof(*source));
+    if (g_substitute_path && strcmp(g_substitute_path, filepath) == 0) {
+        return open_substitute(source);
+    }
+    FILE *fp = safe_fopen_follow(filepath, "rb", 0);  /* binary mode for accurate byte count */
+    if (!fp) {
+        parse_error_set("cannot open file");
+        return -1;
+    }
+
+    struct stat st;
+    if (fstat(fileno(fp), &st) != 0) {
+        parse_error_set("cannot stat file");
+        fclose(fp);
+        return -1;
+    }
+    size_t file_size = (size_t)st.st_size;
+    source->length = file_size;
+
+#ifdef SOURCE_CAN_MAP
+    if (map_source(source, fileno(fp), file_size)) {
+        fclose(fp);
+        return 0;
+    }
+#endif
+
+    source->buffer = malloc(file_size + 1);
+    if (!source->buffer) {
+        parse_error_set("out of memory reading file");
+        fclose(fp);
+        return -1;
+    }
+    size_t bytes_read = fread(source->buffer, 1, file_size, fp);
+    fclose(fp);
+    if (bytes_read != file_size) {
+        parse_error_set("short read (expected %zu bytes, got %zu)", file_size, bytes_read);
+        source_file_close(source);
+        return -1;
+    }
+    source->buffer[bytes_read] = '\0';
+    source->text = source->buffer;
+    return 0;
+}
+
+int source_file_open(SourceFile *source, const char *filepath) {
+    if (open_source(source, filepath) != 0) {
+        return -1;
+    }
+    parse_guard_hold_source(source);  /* Freed if the file is abandoned */
+    return 0;
+}
+
+static const char *read_source_chunk(void *payload, uint32_t byte_index, TSPoint position,
+                                     uint32_t *bytes_read) {
+    (void)position;
+    const SourceFile *source = payload;
+    if (byte_index >= source->length) {
+        *bytes_read = 0;
+        return "";
+    }
+    size_t left = source->length - byte_index;
+    *bytes_read = (uint32_t)(left < SOURCE_INPUT_CHUNK ? left : SOURCE_INPUT_CHUNK);
+    return source->text + byte_index;
+}
+
+TSInput source_file_input(SourceFile *source) {
+    TSInput input = {
+        .payload = source,
+        .read = read_source_chunk,
+        .encoding = TSInputEncodingUTF8,
+    };
+    return input;
+}
+
+void source_file_close(SourceFile *source) {
+    parse_guard_release_source();
+#ifdef SOURCE_CAN_MAP
+    if (source->mapping) {
+        munmap(source->mapping, source->mapped_size);
+    }
+#endif
+    free(source->buffer);
+    memset(source, 0, sizeof(*source));
+}
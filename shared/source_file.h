@@ -0,0 +1,73 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef SOURCE_FILE_H
+#define SOURCE_FILE_H
+
+#include <stddef.h>
+#include <tree_sitter/api.h>
+
+/*
+ * Source files for the parsers
+ *
+ * Large files are memory-mapped rather than copied onto the heap: their
+ * pages come from the page cache as the parser reaches them and can be
+ * dropped again under memory pressure, so parsing a huge generated file
+ * on every worker no longer adds its size to each worker's RSS. Small
+ * files, every file on Windows, and every file once mapping is turned off
+ * (watch mode and serve) are read into a buffer as before.
+ *
+ * Either way text is NUL-terminated and read-only, and tree-sitter pulls it
+ * in chunks through source_file_input() instead of getting it in one piece.
+ */
+
+/* Files at least this big are mapped (when the platform can) */
+#define SOURCE_MAP_THRESHOLD (1024 * 1024)
+
+/* Bytes handed to tree-sitter per read callback */
+#define SOURCE_INPUT_CHUNK (64 * 1024)
+
+typedef struct {
+    const char *text;    /* File contents, NUL-terminated */
+    size_t length;       /* Bytes, without the terminator */
+    char *buffer;        /* Heap copy, or NULL if text is mapped */
+    void *mapping;       /* The mapping, or NULL if text is a heap copy */
+    size_t mapped_size;
+} SourceFile;
+
+/* Open a file for parsing (a copy of the substitute content, if the
+ * calling thread set one for exactly this path)
+ * Returns: 0 on success, -1 after recording why with parse_error_set() */
+int source_file_open(SourceFile *source, const char *filepath);
+
+/* Have source_file_open() on the calling thread read content instead of the
+ * file at filepath, which need not exist (an unsaved editor buffer). Both
+ * are borrowed until cleared with a NULL filepath. */
+void source_file_substitute(const char *filepath, const char *content, size_t length);
+
+/* Turn mapping of large files on or off for every thread (on by default).
+ * Long-running processes turn it off: a mapped file truncated while it is
+ * parsed raises SIGBUS on the next page read. Set before parsing starts. */
+void source_file_set_mapping(int enabled);
+
+/* tree-sitter input reading source in SOURCE_INPUT_CHUNK pieces, for
+ * ts_parser_parse(); source must stay open while the tree is built */
+TSInput source_file_input(SourceFile *source);
+
+void source_file_close(SourceFile *source);
+
+#endif
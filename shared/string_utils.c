@@ -17,10 +17,25 @@
  */
 #include "string_utils.h"
 #include "constants.h"
+#include "parse_errors.h"
 #include <string.h>
 #include <stdio.h>
 #include <stdlib.h>
 
+/* safe_extract_node_text() results for node_text_span(): add_entry() usually
+ * follows the extraction of its symbol, with a few others in between */
+#define NODE_TEXT_SPANS 8
+
+typedef struct {
+    char text[SYMBOL_MAX_LENGTH];
+    TSPoint start;
+    TSPoint end;
+} NodeTextSpan;
+
+static _Thread_local NodeTextSpan g_spans[NODE_TEXT_SPANS];
+static _Thread_local int g_span_count;
+static _Thread_local int g_span_next;
+
 #if defined(_WIN32) || defined(__MINGW32__) || defined(__MINGW64__)
 /* Windows: backtrace not available */
 #define HAS_BACKTRACE 0
@@ -62,8 +77,23 @@ void safe_extract_node_text(const char *source_code, TSNode node, char *buffer,
     uint32_t end = ts_node_end_byte(node);
     uint32_t length = end - start;
 
+    /* While indexing, text that doesn't fit is cut at a character boundary,
+     * as symbol_limits.txt truncates symbols, rather than losing the file */
+    if (length >= buffer_size && parse_abort_is_guarded()) {
+        uint32_t kept = (uint32_t)buffer_size - 1;
+        while (kept > 0 && ((unsigned char)source_code[start + kept] & 0xC0) == 0x80) {
+            kept--;
+        }
+        const char *node_type = ts_node_type(node);
+        fprintf(stderr, "Warning: %s:%u: %s text longer than %zu bytes truncated: %.40s...\n",
+                filename ? filename : "<unknown>", ts_node_start_point(node).row + 1,
+                node_type ? node_type : "node", buffer_size - 1, source_code + start);
+        length = kept;
+    }
+
     /* Check if text will fit (need length + 1 for null terminator) */
     if (length >= buffer_size) {
+
         /* Extract preview for error message */
         char preview[100];
         uint32_t preview_len = length < sizeof(preview) - 1 ? length : sizeof(preview) - 1;
@@ -155,6 +185,37 @@ void safe_extract_node_text(const char *source_code, TSNode node, char *buffer,
     /* Safe to copy */
     memcpy(buffer, source_code + start, length);
     buffer[length] = '\0';
+
+    TSPoint start_point = ts_node_start_point(node);
+    TSPoint end_point = ts_node_end_point(node);
+    if (length > 0 && length < SYMBOL_MAX_LENGTH && start_point.row == end_point.row) {
+        NodeTextSpan *span = &g_spans[g_span_next];
+        memcpy(span->text, buffer, length + 1);
+        span->start = start_point;
+        span->end = end_point;
+        g_span_next = (g_span_next + 1) % NODE_TEXT_SPANS;
+        if (g_span_count < NODE_TEXT_SPANS) {
+            g_span_count++;
+        }
+    }
+}
+
+int node_text_span(const char *text, int line, char *buffer, size_t buffer_size) {
+    for (int i = 1; i <= g_span_count; i++) {
+        const NodeTextSpan *span = &g_spans[(g_span_next - i + NODE_TEXT_SPANS) % NODE_TEXT_SPANS];
+        if ((int)span->start.row + 1 == line && strcmp(span->text, text) == 0) {
+            snprintf(buffer, buffer_size, "%u:%u - %u:%u",
+                     span->start.row + 1, span->start.column,
+                     span->end.row + 1, span->end.column);
+            return 1;
+        }
+    }
+    return 0;
+}
+
+void reset_node_text_spans(void) {
+    g_span_count = 0;
+    g_span_next = 0;
 }
 
 void format_source_location(TSNode node, char *buffer, size_t buffer_size) {
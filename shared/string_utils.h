@@ -36,7 +36,9 @@ void to_lowercase_copy(const char *src, char *dst, size_t size);
 
 /* Safe tree-sitter node text extraction
  * Extracts text from a tree-sitter node into buffer.
- * EXITS with error if text exceeds buffer_size.
+ * Text that exceeds buffer_size is truncated at a character boundary, with a
+ * warning, while a file is being indexed (under a parse guard); elsewhere it
+ * EXITS with an error.
  * Parameters:
  *   source_code  - Original source code
  *   node         - Tree-sitter node to extract text from
@@ -57,6 +59,17 @@ void safe_extract_node_text(const char *source_code, TSNode node, char *buffer,
  */
 void format_source_location(TSNode node, char *buffer, size_t buffer_size);
 
+/* Identifier spans for symbols without a definition range
+ * safe_extract_node_text() remembers the last few single-line texts it
+ * extracted on this thread, with their ranges. node_text_span() formats the
+ * range of the most recent one that equals text and starts on line (1-based)
+ * as format_source_location() would.
+ * Returns: 1 if found, 0 if not */
+int node_text_span(const char *text, int line, char *buffer, size_t buffer_size);
+
+/* Forget the remembered texts (before parsing another file) */
+void reset_node_text_spans(void);
+
 /* Safe strdup with contextual error reporting
  * Duplicates string with malloc error checking.
  * EXITS on allocation failure with error message to stderr.
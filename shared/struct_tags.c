@@ -0,0 +1,84 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "struct_tags.h"
+#include <stdio.h>
+#include <string.h>
+
+int parse_struct_tag(const char *tag, StructTag *pairs, int max_pairs, int *malformed) {
+    int count = 0;
+    if (malformed) *malformed = 0;
+    if (!tag) return 0;
+
+    const char *p = tag;
+    while (*p) {
+        /* Skip leading space */
+        while (*p == ' ' || *p == '\t') p++;
+        if (*p == '\0') break;
+
+        /* Key: up to ':' (no spaces, quotes or control characters) */
+        const char *key_start = p;
+        while ((unsigned char)*p > ' ' && *p != ':' && *p != '"' && *p != 0x7f) p++;
+        size_t key_len = (size_t)(p - key_start);
+        if (key_len == 0 || p[0] != ':' || p[1] != '"') {
+            if (malformed) *malformed = 1;
+            break;
+        }
+        p += 2;  /* Skip :" */
+
+        /* Value: up to the closing unescaped quote */
+        const char *value_start = p;
+        while (*p && *p != '"') {
+            if (*p == '\\' && p[1] != '\0') p++;
+            p++;
+        }
+        if (*p != '"') {
+            if (malformed) *malformed = 1;  /* Unterminated value */
+            break;
+        }
+        size_t value_len = (size_t)(p - value_start);
+        p++;  /* Skip closing quote */
+
+        if (count >= max_pairs || key_len >= sizeof(pairs[0].key) ||
+            value_len >= sizeof(pairs[0].value)) {
+            if (malformed) *malformed = 1;
+            continue;  /* Drop this pair, keep parsing */
+        }
+
+        snprintf(pairs[count].key, sizeof(pairs[count].key), "%.*s", (int)key_len, key_start);
+        snprintf(pairs[count].value, sizeof(pairs[count].value), "%.*s", (int)value_len, value_start);
+        count++;
+    }
+
+    return count;
+}
+
+void format_struct_tags(const StructTag *pairs, int count, char *buffer, size_t buffer_size) {
+    if (buffer_size == 0) return;
+    buffer[0] = '\0';
+
+    size_t used = 0;
+    for (int i = 0; i < count; i++) {
+        int written = snprintf(buffer + used, buffer_size - used, "%s%s:\"%s\"",
+                               used > 0 ? " " : "", pairs[i].key, pairs[i].value);
+        if (written < 0 || (size_t)written >= buffer_size - used) {
+            buffer[used] = '\0';  /* Drop the partial pair */
+            break;
+        }
+        used += (size_t)written;
+    }
+}
@@ -0,0 +1,58 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef STRUCT_TAGS_H
+#define STRUCT_TAGS_H
+
+#include <stddef.h>
+#include "constants.h"
+
+/*
+ * Struct field tag parsing (Go conventional tag syntax)
+ *
+ * A tag is a sequence of space-separated key:"value" pairs, e.g.
+ *   json:"id,omitempty" db:"user_id"
+ *
+ * Keys are runs of non-space characters other than ':' and '"'. Values are
+ * double-quoted with backslash escapes. This is the format read by Go's
+ * reflect.StructTag.Lookup, which stops at the first malformed pair; so do
+ * we, keeping the pairs before it.
+ */
+
+typedef struct {
+    char key[WORD_MAX_LENGTH];
+    char value[TAGS_MAX_LENGTH];    /* Raw text between the quotes, escapes kept */
+} StructTag;
+
+/* Parse a tag (without surrounding backquotes) into key/value pairs
+ *
+ * Parameters:
+ *   tag       - Tag text, e.g. json:"id" db:"id"
+ *   pairs     - Output array
+ *   max_pairs - Capacity of pairs
+ *   malformed - Set to 1 if parsing stopped at a malformed pair or a pair
+ *               did not fit (may be NULL)
+ *
+ * Returns: number of pairs parsed (0 if none)
+ */
+int parse_struct_tag(const char *tag, StructTag *pairs, int max_pairs, int *malformed);
+
+/* Write pairs back in normalized form: key:"value" separated by single spaces
+ * Pairs that do not fit in the buffer are dropped whole. */
+void format_struct_tags(const StructTag *pairs, int count, char *buffer, size_t buffer_size);
+
+#endif /* STRUCT_TAGS_H */
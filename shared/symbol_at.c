@@ -0,0 +1,144 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "symbol_at.h"
+#include "constants.h"
+#include "file_utils.h"
+#include "ndjson.h"
+#include <stdio.h>
+#include <string.h>
+#include <unistd.h>
+
+/* As in search.c: paths without the leading "./" they may be stored with */
+#define DISPLAY_PATH \
+    "(CASE WHEN substr(directory, 1, 2) = './' THEN substr(directory, 3) " \
+    "ELSE directory END || filename)"
+
+/* Path as DISPLAY_PATH gives it: absolute paths under the current
+ * directory made relative, and any leading "./" dropped */
+static const char *display_path(const char *path, const char *cwd) {
+    size_t cwd_len = strlen(cwd);
+    if (path[0] == '/' && cwd_len > 0 && strncmp(path, cwd, cwd_len) == 0 && path[cwd_len] == '/') {
+        path += cwd_len + 1;
+    }
+    while (path[0] == '.' && path[1] == '/') {
+        path += 2;
+    }
+    return path;
+}
+
+/* Does the range hold the position? (end inclusive, see symbol_at.h) */
+static int range_contains(int start_line, int start_column, int end_line, int end_column,
+                          int line, int column) {
+    if (line < start_line || line > end_line) return 0;
+    if (line == start_line && column < start_column) return 0;
+    if (line == end_line && column > end_column) return 0;
+    return 1;
+}
+
+int symbol_at(sqlite3 *db, const char *path, int line, int column, IndexEntry *entry) {
+    char cwd[PATH_MAX_LENGTH];
+    if (!getcwd(cwd, sizeof(cwd))) {
+        cwd[0] = '\0';
+    }
+    const char *relative = display_path(path, cwd);
+    const char *slash = strrchr(relative, '/');
+    const char *basename = slash ? slash + 1 : relative;
+
+    char sql[LINE_BUFFER_LARGE];
+    snprintf(sql, sizeof(sql),
+             "SELECT %s FROM code_index WHERE filename = ?1 AND " DISPLAY_PATH " = ?2"
+             " AND source_location != '' AND context NOT IN ('%s', '%s', '%s')",
+             db_entry_columns(), context_to_string(CONTEXT_COMMENT, 1),
+             context_to_string(CONTEXT_STRING, 1), context_to_string(CONTEXT_FILENAME, 1));
+    sqlite3_stmt *stmt;
+    if (sqlite3_prepare_v2(db, sql, -1, &stmt, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: symbol lookup failed: %s\n", sqlite3_errmsg(db));
+        return -1;
+    }
+    sqlite3_bind_text(stmt, 1, basename, -1, SQLITE_STATIC);
+    sqlite3_bind_text(stmt, 2, relative, -1, SQLITE_STATIC);
+
+    int found = 0;
+    int best_lines = 0, best_columns = 0;
+    IndexEntry row;
+    int rc;
+    while ((rc = sqlite3_step(stmt)) == SQLITE_ROW) {
+        db_read_entry(stmt, &row);
+        int start_line, start_column, end_line, end_column;
+        if (parse_source_location(row.source_location, &start_line, &start_column,
+                                  &end_line, &end_column) != 0 ||
+            !range_contains(start_line, start_column, end_line, end_column, line, column)) {
+            continue;
+        }
+
+        int lines = end_line - start_line;
+        int columns = end_column - start_column;
+        int better = !found || lines < best_lines ||
+                     (lines == best_lines && columns < best_columns) ||
+                     (lines == best_lines && columns == best_columns &&
+                      row.is_definition == 1 && entry->is_definition != 1);
+        if (better) {
+            *entry = row;
+            best_lines = lines;
+            best_columns = columns;
+            found = 1;
+        }
+    }
+    if (rc != SQLITE_DONE) {
+        fprintf(stderr, "Error: symbol lookup failed: %s\n", sqlite3_errmsg(db));
+        found = -1;
+    }
+    sqlite3_finalize(stmt);
+    return found;
+}
+
+void symbol_qualified_name(const IndexEntry *entry, char *buffer, size_t size) {
+    snprintf(buffer, size, "%s%s%s", entry->scope_path,
+             entry->scope_path[0] ? "." : "", entry->full_symbol);
+}
+
+int symbol_at_print(const char *db_path, const char *path, int line, int column,
+                    SymbolAtFormat format, FILE *out) {
+    sqlite3 *db = NULL;
+    if (sqlite3_open_v2(db_path, &db, SQLITE_OPEN_READONLY, NULL) != SQLITE_OK) {
+        fprintf(stderr, "Error: cannot open index '%s': %s\n", db_path, sqlite3_errmsg(db));
+        sqlite3_close(db);
+        return -1;
+    }
+
+    IndexEntry entry;
+    int found = symbol_at(db, path, line, column - 1, &entry);
+    sqlite3_close(db);
+    if (found != 1) {
+        return found;
+    }
+
+    if (format == SYMBOL_AT_FORMAT_NDJSON) {
+        ndjson_write_entry(out, &entry);
+        return 1;
+    }
+
+    int start_line = entry.line, start_column = 0, end_line, end_column;
+    parse_source_location(entry.source_location, &start_line, &start_column, &end_line, &end_column);
+    const char *directory = strncmp(entry.directory, "./", 2) == 0 ? entry.directory + 2 : entry.directory;
+    char name[SCOPE_PATH_MAX_LENGTH + SYMBOL_MAX_LENGTH];
+    symbol_qualified_name(&entry, name, sizeof(name));
+    fprintf(out, "%s%s:%d:%d  %s  %s\n", directory, entry.filename, start_line, start_column + 1,
+            context_to_string(entry.context, 1), name);
+    return 1;
+}
@@ -0,0 +1,69 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird 
+ * 
+ * This file is part of SourceMinder.
+ * 
+ * SourceMinder is free software: you can redistribute it and/or modify 
+ * it under the terms of the GNU General Public License as published by 
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but 
+ * WITHOUT ANY WARRANTY; without even the implied warranty of 
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU 
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License 
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef SYMBOL_AT_H
+#define SYMBOL_AT_H
+
+#include <stdio.h>
+#include <sqlite3.h>
+#include "database.h"
+
+/*
+ * Symbol under a cursor position (serve's symbolAt, the symbol-at subcommand)
+ *
+ * Rows whose source_location range contains the position are candidates:
+ * definitions store the range of the whole declaration, other symbols the
+ * span of their identifier. The innermost one wins (fewest lines, then
+ * fewest columns), definitions before other rows of the same range, so a
+ * cursor on a call finds the call and one elsewhere in a body finds the
+ * enclosing function. Comment, string and filename rows are not
+ * candidates. The end of a range counts as inside it, so a cursor just
+ * past an identifier still finds it.
+ */
+
+typedef enum {
+    SYMBOL_AT_FORMAT_TEXT,      /* file:line:column  KIND  Scope.path.name */
+    SYMBOL_AT_FORMAT_NDJSON     /* The entry as --format=ndjson writes it */
+} SymbolAtFormat;
+
+/* Find the innermost symbol at a position
+ *
+ * Parameters:
+ *   db     - Open index
+ *   path   - File as the editor names it: relative to the directory the
+ *            index was built from, with or without "./", or absolute
+ *            below the current directory
+ *   line   - 1-based line
+ *   column - 0-based column
+ *   entry  - Output: the symbol (when found)
+ *
+ * Returns: 1 if found, 0 if no symbol is there, -1 on error
+ */
+int symbol_at(sqlite3 *db, const char *path, int line, int column, IndexEntry *entry);
+
+/* Name of an entry qualified with its scope path ("User.save.options") */
+void symbol_qualified_name(const IndexEntry *entry, char *buffer, size_t size);
+
+/* Print the symbol at a position of the index at db_path to out
+ * (line and column 1-based, as editors count)
+ *
+ * Returns: 1 if found, 0 if no symbol is there, -1 on error
+ */
+int symbol_at_print(const char *db_path, const char *path, int line, int column,
+                    SymbolAtFormat format, FILE *out);
+
+#endif /* SYMBOL_AT_H */
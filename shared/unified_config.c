@@ -0,0 +1,332 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#include "unified_config.h"
+#include "file_opener.h"
+#include "paths.h"
+#include <stdarg.h>
+#include <stdlib.h>
+#include <string.h>
+
+static const char *const LIST_KEYS[CONFIG_LIST_COUNT] = {
+    [CONFIG_LIST_STOPWORDS] = "stopwords",
+    [CONFIG_LIST_KEYWORDS] = "keywords",
+    [CONFIG_LIST_FILE_EXTENSIONS] = "file_extensions",
+    [CONFIG_LIST_IGNORE_FILES] = "ignore_files",
+    [CONFIG_LIST_REGEX_PATTERNS] = "regex_patterns",
+};
+
+typedef struct {
+    const char *p;
+    int line;
+    UnifiedConfig *config;
+} TomlParser;
+
+const char *unified_config_key(ConfigListId id) {
+    return ((int)id >= 0 && id < CONFIG_LIST_COUNT) ? LIST_KEYS[id] : "";
+}
+
+static int parse_error(TomlParser *parser, const char *format, ...) {
+    va_list args;
+    va_start(args, format);
+    vsnprintf(parser->config->error, sizeof(parser->config->error), format, args);
+    va_end(args);
+    parser->config->error_line = parser->line;
+    return -1;
+}
+
+/* Skip spaces and tabs, and with newlines set also newlines and comments */
+static void skip_space(TomlParser *parser, int newlines) {
+    for (;;) {
+        char c = *parser->p;
+        if (c == ' ' || c == '\t' || c == '\r') {
+            parser->p++;
+        } else if (newlines && c == '\n') {
+            parser->line++;
+            parser->p++;
+        } else if (newlines && c == '#') {
+            while (*parser->p && *parser->p != '\n') parser->p++;
+        } else {
+            return;
+        }
+    }
+}
+
+/* The rest of a line after a value: nothing but a comment */
+static int expect_line_end(TomlParser *parser) {
+    skip_space(parser, 0);
+    if (*parser->p == '#') {
+        while (*parser->p && *parser->p != '\n') parser->p++;
+    }
+    if (*parser->p != '\0' && *parser->p != '\n') {
+        return parse_error(parser, "Unexpected '%c' after value", *parser->p);
+    }
+    return 0;
+}
+
+/* Parse a "basic" or 'literal' string into out (without newlines, which
+ * would split a .txt line) */
+static int parse_string(TomlParser *parser, char *out, size_t size) {
+    char quote = *parser->p++;
+    size_t len = 0;
+
+    while (*parser->p != quote) {
+        char c = *parser->p;
+        if (c == '\0' || c == '\n') {
+            return parse_error(parser, "Unterminated string");
+        }
+        if (c == '\\' && quote == '"') {
+            parser->p++;
+            switch (*parser->p) {
+                case '\\': c = '\\'; break;
+                case '"':  c = '"'; break;
+                case 't':  c = '\t'; break;
+                default:
+                    return parse_error(parser, "Unsupported escape '\\%c'", *parser->p ? *parser->p : ' ');
+            }
+        }
+        if (len + 1 >= size) {
+            return parse_error(parser, "String too long (maximum %zu characters)", size - 1);
+        }
+        out[len++] = c;
+        parser->p++;
+    }
+    parser->p++;
+    out[len] = '\0';
+    return 0;
+}
+
+static int add_item(TomlParser *parser, ConfigList *list, const char *value) {
+    if (list->count == list->capacity) {
+        int capacity = list->capacity ? list->capacity * 2 : 32;
+        char **items = realloc(list->items, (size_t)capacity * sizeof(*items));
+        if (!items) {
+            return parse_error(parser, "Out of memory");
+        }
+        list->items = items;
+        int *lines = realloc(list->lines, (size_t)capacity * sizeof(*lines));
+        if (!lines) {
+            return parse_error(parser, "Out of memory");
+        }
+        list->lines = lines;
+        list->capacity = capacity;
+    }
+
+    char *copy = strdup(value);
+    if (!copy) {
+        return parse_error(parser, "Out of memory");
+    }
+    list->items[list->count] = copy;
+    list->lines[list->count] = parser->line;
+    list->count++;
+    return 0;
+}
+
+/* Parse "[ "a", 'b', ]" spanning any number of lines */
+static int parse_array(TomlParser *parser, ConfigList *list) {
+    char value[LINE_BUFFER_LARGE];
+
+    if (*parser->p != '[') {
+        return parse_error(parser, "Expected an array of strings");
+    }
+    parser->p++;
+
+    for (;;) {
+        skip_space(parser, 1);
+        if (*parser->p == ']') {
+            parser->p++;
+            return 0;
+        }
+        if (*parser->p != '"' && *parser->p != '\'') {
+            if (*parser->p == '\0') {
+                return parse_error(parser, "Unterminated array");
+            }
+            return parse_error(parser, "Expected a string in array");
+        }
+        if (parse_string(parser, value, sizeof(value)) != 0 ||
+            add_item(parser, list, value) != 0) {
+            return -1;
+        }
+
+        skip_space(parser, 1);
+        if (*parser->p == ',') {
+            parser->p++;
+        } else if (*parser->p != ']') {
+            return parse_error(parser, "Expected ',' or ']' after string");
+        }
+    }
+}
+
+static int parse_document(TomlParser *parser) {
+    for (;;) {
+        skip_space(parser, 1);
+        if (*parser->p == '\0') {
+            return 0;
+        }
+        if (*parser->p == '[') {
+            return parse_error(parser, "Tables are not supported; set keys at the top level");
+        }
+
+        char key[WORD_MAX_LENGTH];
+        size_t len = 0;
+        if (*parser->p == '"' || *parser->p == '\'') {
+            if (parse_string(parser, key, sizeof(key)) != 0) {
+                return -1;
+            }
+        } else {
+            const char *start = parser->p;
+            while ((*parser->p >= 'a' && *parser->p <= 'z') || (*parser->p >= 'A' && *parser->p <= 'Z') ||
+                   (*parser->p >= '0' && *parser->p <= '9') || *parser->p == '_' || *parser->p == '-') {
+                parser->p++;
+            }
+            len = (size_t)(parser->p - start);
+            if (len == 0) {
+                return parse_error(parser, "Expected a key");
+            }
+            snprintf(key, sizeof(key), "%.*s", (int)len, start);
+        }
+
+        int id = 0;
+        while (id < CONFIG_LIST_COUNT && strcmp(LIST_KEYS[id], key) != 0) {
+            id++;
+        }
+        if (id == CONFIG_LIST_COUNT) {
+            return parse_error(parser, "Unknown key '%s'", key);
+        }
+        ConfigList *list = &parser->config->lists[id];
+        if (list->present) {
+            return parse_error(parser, "Duplicate key '%s'", key);
+        }
+
+        skip_space(parser, 0);
+        if (*parser->p != '=') {
+            return parse_error(parser, "Expected '=' after '%s'", key);
+        }
+        parser->p++;
+        skip_space(parser, 0);
+
+        list->present = 1;
+        if (parse_array(parser, list) != 0 || expect_line_end(parser) != 0) {
+            return -1;
+        }
+    }
+}
+
+int unified_config_parse(UnifiedConfig *config, const char *path) {
+    memset(config, 0, sizeof(*config));
+    snprintf(config->path, sizeof(config->path), "%s", path);
+
+    FILE *fp = safe_fopen(path, "rb", 1);
+    if (!fp) {
+        return 0;
+    }
+
+    char *text = malloc(UNIFIED_CONFIG_MAX_SIZE + 1);
+    if (!text) {
+        fclose(fp);
+        snprintf(config->error, sizeof(config->error), "Out of memory");
+        return -1;
+    }
+    size_t size = fread(text, 1, UNIFIED_CONFIG_MAX_SIZE + 1, fp);
+    int read_error = ferror(fp);
+    fclose(fp);
+
+    int status = 1;
+    if (read_error) {
+        snprintf(config->error, sizeof(config->error), "Cannot read file");
+        status = -1;
+    } else if (size > UNIFIED_CONFIG_MAX_SIZE) {
+        snprintf(config->error, sizeof(config->error),
+                 "File too large (maximum %d bytes)", UNIFIED_CONFIG_MAX_SIZE);
+        status = -1;
+    } else if (memchr(text, '\0', size)) {
+        snprintf(config->error, sizeof(config->error), "File contains a NUL byte");
+        status = -1;
+    } else {
+        text[size] = '\0';
+        TomlParser parser = { text, 1, config };
+        if (parse_document(&parser) != 0) {
+            status = -1;
+        }
+    }
+
+    free(text);
+    return status;
+}
+
+int unified_config_load(UnifiedConfig *config, const char *lang_data_dir) {
+    char path[PATH_MAX_LENGTH];
+    char resolved_path[PATH_MAX_LENGTH];
+
+    snprintf(path, sizeof(path), "%s/%s", lang_data_dir, UNIFIED_CONFIG_FILENAME);
+    if (resolve_data_file(path, resolved_path, sizeof(resolved_path)) != 0) {
+        memset(config, 0, sizeof(*config));
+        return 0;
+    }
+    return unified_config_parse(config, resolved_path);
+}
+
+void unified_config_free(UnifiedConfig *config) {
+    for (int id = 0; id < CONFIG_LIST_COUNT; id++) {
+        ConfigList *list = &config->lists[id];
+        for (int i = 0; i < list->count; i++) {
+            free(list->items[i]);
+        }
+        free(list->items);
+        free(list->lines);
+        memset(list, 0, sizeof(*list));
+    }
+}
+
+int config_reader_open(ConfigLineReader *reader, const UnifiedConfig *config,
+                       ConfigListId id, const char *txt_path) {
+    memset(reader, 0, sizeof(*reader));
+
+    if (config && config->lists[id].present) {
+        reader->list = &config->lists[id];
+        return 0;
+    }
+    if (!txt_path) {
+        return -1;
+    }
+    reader->fp = safe_fopen(txt_path, "r", 0);
+    return reader->fp ? 0 : -1;
+}
+
+char *config_reader_gets(ConfigLineReader *reader, char *buf, int size) {
+    if (reader->fp) {
+        if (!fgets(buf, size, reader->fp)) {
+            return NULL;
+        }
+        reader->line++;
+        return buf;
+    }
+    if (!reader->list || reader->next >= reader->list->count || size <= 0) {
+        return NULL;
+    }
+    reader->line = reader->list->lines[reader->next];
+    snprintf(buf, (size_t)size, "%s\n", reader->list->items[reader->next]);
+    reader->next++;
+    return buf;
+}
+
+void config_reader_close(ConfigLineReader *reader) {
+    if (reader->fp) {
+        fclose(reader->fp);
+    }
+    memset(reader, 0, sizeof(*reader));
+}
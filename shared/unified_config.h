@@ -0,0 +1,102 @@
+/* SourceMinder
+ * Copyright 2025 Eli Bird
+ *
+ * This file is part of SourceMinder.
+ *
+ * SourceMinder is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or (at
+ *  your option) any later version.
+ *
+ * SourceMinder is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * General Public License for more details.
+ * You should have received a copy of the GNU General Public License
+ * along with SourceMinder. If not, see <https://www.gnu.org/licenses/>.
+ */
+#ifndef UNIFIED_CONFIG_H
+#define UNIFIED_CONFIG_H
+
+#include <stdio.h>
+#include "constants.h"
+
+/*
+ * Unified language configuration (<language>/config/sourceminder.toml)
+ *
+ * One TOML file holding the word lists that otherwise live in separate
+ * .txt files, as arrays of strings:
+ *
+ *     keywords = ["func", "go", "defer"]
+ *     file_extensions = [".go"]
+ *     ignore_files = [
+ *         "vendor",      # Comments work as in TOML
+ *         "testdata",
+ *     ]
+ *
+ * Only top-level "key = [ ... ]" entries with basic ("...") or literal
+ * ('...') strings are accepted. Each element is read exactly like one line
+ * of the .txt file it replaces; lists the file does not set are still read
+ * from their .txt file.
+ */
+
+typedef enum {
+    CONFIG_LIST_STOPWORDS,        /* Layered over shared stopwords.txt */
+    CONFIG_LIST_KEYWORDS,
+    CONFIG_LIST_FILE_EXTENSIONS,
+    CONFIG_LIST_IGNORE_FILES,
+    CONFIG_LIST_REGEX_PATTERNS,   /* Replaces shared regex-patterns.txt */
+    CONFIG_LIST_COUNT
+} ConfigListId;
+
+typedef struct {
+    int present;       /* The file sets this list */
+    int count;
+    int capacity;
+    char **items;
+    int *lines;        /* Line of each item in the file */
+} ConfigList;
+
+typedef struct {
+    char path[PATH_MAX_LENGTH];
+    ConfigList lists[CONFIG_LIST_COUNT];
+    char error[ERROR_MESSAGE_BUFFER];  /* Why the file is invalid */
+    int error_line;
+} UnifiedConfig;
+
+/* Reads lines from a .txt file or from a list of the unified file, the way
+ * fgets() reads them from a file */
+typedef struct {
+    FILE *fp;
+    const ConfigList *list;
+    int next;
+    int line;          /* Line of the last line read */
+} ConfigLineReader;
+
+/* Key of a list in the unified file ("stopwords", "file_extensions", ...) */
+const char *unified_config_key(ConfigListId id);
+
+/* Load <lang_data_dir>/sourceminder.toml (found like the other data files)
+ *
+ * Returns: 1 if it was loaded, 0 if there is none,
+ *          -1 if it is invalid (config->error and config->error_line say why)
+ * config must be released with unified_config_free() in every case. */
+int unified_config_load(UnifiedConfig *config, const char *lang_data_dir);
+
+/* Parse a unified config file; same results as unified_config_load() */
+int unified_config_parse(UnifiedConfig *config, const char *path);
+
+void unified_config_free(UnifiedConfig *config);
+
+/* Read list id from config when it sets it (config may be NULL), otherwise
+ * from txt_path (may be NULL)
+ * Returns: 0 on success, -1 if neither has the list */
+int config_reader_open(ConfigLineReader *reader, const UnifiedConfig *config,
+                       ConfigListId id, const char *txt_path);
+
+/* Next line including its newline, like fgets(); NULL at the end */
+char *config_reader_gets(ConfigLineReader *reader, char *buf, int size);
+
+void config_reader_close(ConfigLineReader *reader);
+
+#endif /* UNIFIED_CONFIG_H */
@@ -22,6 +22,12 @@
 #include "string_utils.h"
 #include "paths.h"
 #include "constants.h"
+#include "ignore_rules.h"
+#include "filter.h"
+#include "custom_extractors.h"
+#include "identifier_tokens.h"
+#include "unified_config.h"
+#include <regex.h>
 #include <string.h>
 #include <stdlib.h>
 
@@ -208,11 +214,381 @@ ValidationResult validate_file_extensions(const char *filepath) {
 /* Validate regex patterns configuration */
 ValidationResult validate_regex_patterns(const char *filepath) {
     /* Check count */
-    ValidationResult result = validate_line_count(filepath, MAX_FILTER_WORDS, 0);
+    ValidationResult result = validate_line_count(filepath, MAX_REGEX_PATTERNS, 1);
     if (result.code != VALIDATE_OK) return result;
 
     /* Regex patterns can be quite long, use larger buffer */
-    return validate_line_length(filepath, LINE_BUFFER_LARGE);
+    result = validate_line_length(filepath, LINE_BUFFER_LARGE);
+    if (result.code != VALIDATE_OK) return result;
+
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        result.code = VALIDATE_FILE_MISSING;
+        snprintf(result.message, sizeof(result.message), "Cannot open file");
+        return result;
+    }
+
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    size_t valid = 0;
+    size_t invalid = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        line[strcspn(line, "\n")] = '\0';
+        if (line[0] == '\0' || line[0] == '#') {
+            continue;
+        }
+
+        regex_t regex;
+        int ret = regcomp(&regex, line, REG_EXTENDED | REG_NOSUB);
+        if (ret != 0) {
+            char reason[ERROR_MESSAGE_BUFFER / 2];
+            regerror(ret, &regex, reason, sizeof(reason));
+            ValidationResult bad = {0};
+            bad.code = VALIDATE_INVALID_PATTERN;
+            bad.line = line_num;
+            snprintf(bad.filepath, sizeof(bad.filepath), "%s", filepath);
+            snprintf(bad.message, sizeof(bad.message), "Invalid regex '%.64s': %s", line, reason);
+            print_validation_error(&bad);
+            invalid++;
+            continue;
+        }
+        regfree(&regex);
+        valid++;
+    }
+    fclose(fp);
+
+    if (invalid > 0) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "%zu invalid pattern%s (see above)", invalid, invalid == 1 ? "" : "s");
+        return result;
+    }
+
+    result.code = VALIDATE_OK;
+    result.actual_value = valid;
+    return result;
+}
+
+/* Validate extractors.txt */
+ValidationResult validate_custom_extractors_file(const char *filepath) {
+    ValidationResult result = validate_line_length(filepath, LINE_BUFFER_LARGE);
+    if (result.code != VALIDATE_OK) return result;
+
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        result.code = VALIDATE_FILE_MISSING;
+        snprintf(result.message, sizeof(result.message), "Cannot open file");
+        return result;
+    }
+
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    size_t valid = 0;
+    size_t invalid = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        CustomExtractor extractor;
+        char error[ERROR_MESSAGE_BUFFER / 2];
+        int parsed = custom_extractor_parse(line, &extractor, error, sizeof(error));
+        if (parsed == 1) {
+            regfree(&extractor.regex);
+            valid++;
+        } else if (parsed < 0) {
+            ValidationResult bad = {0};
+            bad.code = VALIDATE_INVALID_PATTERN;
+            bad.line = line_num;
+            snprintf(bad.filepath, sizeof(bad.filepath), "%s", filepath);
+            line[strcspn(line, "\r\n")] = '\0';
+            snprintf(bad.message, sizeof(bad.message), "Invalid extractor '%.64s': %s", line, error);
+            print_validation_error(&bad);
+            invalid++;
+        }
+    }
+    fclose(fp);
+
+    if (invalid > 0) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "%zu invalid extractor%s (see above)", invalid, invalid == 1 ? "" : "s");
+        return result;
+    }
+    if (valid > MAX_CUSTOM_EXTRACTORS) {
+        result.code = VALIDATE_TOO_MANY_LINES;
+        result.actual_value = valid;
+        result.max_allowed = MAX_CUSTOM_EXTRACTORS;
+        snprintf(result.message, sizeof(result.message),
+                 "Too many extractors: found %zu, maximum allowed is %d", valid, MAX_CUSTOM_EXTRACTORS);
+        return result;
+    }
+
+    result.code = VALIDATE_OK;
+    result.actual_value = valid;
+    return result;
+}
+
+/* Validate .sourceminderignore patterns */
+ValidationResult validate_ignore_rules_file(const char *filepath) {
+    ValidationResult result = validate_line_length(filepath, LINE_BUFFER_LARGE);
+    if (result.code != VALIDATE_OK) return result;
+
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        result.code = VALIDATE_FILE_MISSING;
+        snprintf(result.message, sizeof(result.message), "Cannot open file");
+        return result;
+    }
+
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    size_t valid = 0;
+    size_t invalid = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        IgnoreRule rule;
+        const char *error;
+        int parsed = ignore_rule_parse(line, &rule, &error);
+        if (parsed == 1) {
+            valid++;
+        } else if (parsed < 0) {
+            ValidationResult bad = {0};
+            bad.code = VALIDATE_INVALID_PATTERN;
+            bad.line = line_num;
+            snprintf(bad.filepath, sizeof(bad.filepath), "%s", filepath);
+            line[strcspn(line, "\r\n")] = '\0';
+            snprintf(bad.message, sizeof(bad.message), "Invalid pattern '%.128s': %s", line, error);
+            print_validation_error(&bad);
+            invalid++;
+        }
+    }
+    fclose(fp);
+
+    if (invalid > 0) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "%zu invalid pattern%s (see above)", invalid, invalid == 1 ? "" : "s");
+        return result;
+    }
+
+    result.code = VALIDATE_OK;
+    result.actual_value = valid;
+    return result;
+}
+
+/* Validate symbol_limits.txt */
+ValidationResult validate_symbol_limits_file(const char *filepath, int *min_length, int *max_length) {
+    ValidationResult result = validate_line_length(filepath, LINE_BUFFER_LARGE);
+    if (result.code != VALIDATE_OK) return result;
+
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        result.code = VALIDATE_FILE_MISSING;
+        snprintf(result.message, sizeof(result.message), "Cannot open file");
+        return result;
+    }
+
+    int min_value = *min_length;
+    int max_value = *max_length;
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    size_t invalid = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        const char *error;
+        if (filter_parse_symbol_limit(line, &min_value, &max_value, &error) < 0) {
+            ValidationResult bad = {0};
+            bad.code = VALIDATE_INVALID_PATTERN;
+            bad.line = line_num;
+            snprintf(bad.filepath, sizeof(bad.filepath), "%s", filepath);
+            line[strcspn(line, "\r\n")] = '\0';
+            snprintf(bad.message, sizeof(bad.message), "Invalid limit '%.128s': %s", line, error);
+            print_validation_error(&bad);
+            invalid++;
+        }
+    }
+    fclose(fp);
+
+    if (invalid > 0) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "%zu invalid line%s (see above)", invalid, invalid == 1 ? "" : "s");
+        return result;
+    }
+    if (min_value > max_value) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "min_length (%d) is greater than max_length (%d)", min_value, max_value);
+        return result;
+    }
+
+    *min_length = min_value;
+    *max_length = max_value;
+    result.code = VALIDATE_OK;
+    return result;
+}
+
+/* Validate identifier_split.txt */
+ValidationResult validate_identifier_split_file(const char *filepath, int *rules) {
+    ValidationResult result = validate_line_length(filepath, LINE_BUFFER_LARGE);
+    if (result.code != VALIDATE_OK) return result;
+
+    FILE *fp = safe_fopen(filepath, "r", 1);
+    if (!fp) {
+        result.code = VALIDATE_FILE_MISSING;
+        snprintf(result.message, sizeof(result.message), "Cannot open file");
+        return result;
+    }
+
+    int value = 0;
+    char line[LINE_BUFFER_LARGE];
+    int line_num = 0;
+    size_t invalid = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_num++;
+        const char *error;
+        if (identifier_parse_split_rule(line, &value, &error) < 0) {
+            ValidationResult bad = {0};
+            bad.code = VALIDATE_INVALID_PATTERN;
+            bad.line = line_num;
+            snprintf(bad.filepath, sizeof(bad.filepath), "%s", filepath);
+            line[strcspn(line, "\r\n")] = '\0';
+            snprintf(bad.message, sizeof(bad.message), "Invalid rule '%.128s': %s", line, error);
+            print_validation_error(&bad);
+            invalid++;
+        }
+    }
+    fclose(fp);
+
+    if (invalid > 0) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "%zu invalid line%s (see above)", invalid, invalid == 1 ? "" : "s");
+        return result;
+    }
+
+    *rules = value;
+    result.code = VALIDATE_OK;
+    return result;
+}
+
+/* Name a list of a unified config file in results ("go/config/sourceminder.toml (keywords)") */
+static void set_list_filepath(ValidationResult *result, const UnifiedConfig *config, ConfigListId id) {
+    snprintf(result->filepath, sizeof(result->filepath), "%.*s (%s)",
+             (int)(sizeof(result->filepath) - WORD_MAX_LENGTH), config->path, unified_config_key(id));
+}
+
+/* Validate a word list of sourceminder.toml */
+ValidationResult validate_unified_list(const UnifiedConfig *config, ConfigListId id, size_t max_words,
+                                       size_t max_word_length, int allow_empty) {
+    const ConfigList *list = &config->lists[id];
+    ValidationResult result = {0};
+    set_list_filepath(&result, config, id);
+
+    size_t count = 0;
+    for (int i = 0; i < list->count; i++) {
+        size_t len = strlen(list->items[i]);
+        if (len == 0) continue;
+
+        count++;
+        if (count > max_words) {
+            result.code = VALIDATE_TOO_MANY_LINES;
+            result.line = list->lines[i];
+            result.actual_value = count;
+            result.max_allowed = max_words;
+            snprintf(result.message, sizeof(result.message),
+                    "Too many entries: found %zu, maximum allowed is %zu", count, max_words);
+            return result;
+        }
+        if (len >= max_word_length) {
+            result.code = VALIDATE_LINE_TOO_LONG;
+            result.line = list->lines[i];
+            result.actual_value = len;
+            result.max_allowed = max_word_length;
+            snprintf(result.message, sizeof(result.message),
+                    "Entry too long: found %zu characters, maximum allowed is %zu",
+                    len, max_word_length - 1);
+            return result;
+        }
+    }
+
+    if (count == 0 && !allow_empty) {
+        result.code = VALIDATE_EMPTY_FILE;
+        snprintf(result.message, sizeof(result.message), "List is empty");
+        return result;
+    }
+
+    result.code = VALIDATE_OK;
+    result.actual_value = count;
+    return result;
+}
+
+/* Validate the regex_patterns list of sourceminder.toml */
+ValidationResult validate_unified_patterns(const UnifiedConfig *config) {
+    const ConfigList *list = &config->lists[CONFIG_LIST_REGEX_PATTERNS];
+    ValidationResult result = {0};
+    set_list_filepath(&result, config, CONFIG_LIST_REGEX_PATTERNS);
+
+    size_t valid = 0;
+    size_t invalid = 0;
+    for (int i = 0; i < list->count; i++) {
+        const char *pattern = list->items[i];
+        if (pattern[0] == '\0' || pattern[0] == '#') {
+            continue;
+        }
+
+        regex_t regex;
+        int ret = regcomp(&regex, pattern, REG_EXTENDED | REG_NOSUB);
+        if (ret != 0) {
+            char reason[ERROR_MESSAGE_BUFFER / 2];
+            regerror(ret, &regex, reason, sizeof(reason));
+            ValidationResult bad = {0};
+            bad.code = VALIDATE_INVALID_PATTERN;
+            bad.line = list->lines[i];
+            set_list_filepath(&bad, config, CONFIG_LIST_REGEX_PATTERNS);
+            snprintf(bad.message, sizeof(bad.message), "Invalid regex '%.64s': %s", pattern, reason);
+            print_validation_error(&bad);
+            invalid++;
+            continue;
+        }
+        regfree(&regex);
+        valid++;
+    }
+
+    if (invalid > 0) {
+        result.code = VALIDATE_INVALID_PATTERN;
+        snprintf(result.message, sizeof(result.message),
+                 "%zu invalid pattern%s (see above)", invalid, invalid == 1 ? "" : "s");
+        return result;
+    }
+    if (valid > MAX_REGEX_PATTERNS) {
+        result.code = VALIDATE_TOO_MANY_LINES;
+        result.actual_value = valid;
+        result.max_allowed = MAX_REGEX_PATTERNS;
+        snprintf(result.message, sizeof(result.message),
+                 "Too many patterns: found %zu, maximum allowed is %d", valid, MAX_REGEX_PATTERNS);
+        return result;
+    }
+
+    result.code = VALIDATE_OK;
+    result.actual_value = valid;
+    return result;
+}
+
+/* Preflight check of one list of sourceminder.toml, in place of its .txt file
+ * Returns: 0 if it is valid, -1 if not (the error is printed) */
+static int check_unified_list(const UnifiedConfig *config, ConfigListId id, size_t max_words,
+                              size_t max_word_length, int allow_empty, const char *noun, int verbose) {
+    if (verbose) printf("Checking %s (%s)...\n", config->path, unified_config_key(id));
+
+    ValidationResult result = (id == CONFIG_LIST_REGEX_PATTERNS)
+        ? validate_unified_patterns(config)
+        : validate_unified_list(config, id, max_words, max_word_length, allow_empty);
+    if (result.code != VALIDATE_OK) {
+        print_validation_error(&result);
+        return -1;
+    }
+    if (verbose) printf("  VALID (%zu %s)\n", result.actual_value, noun);
+    return 0;
 }
 
 /* Print detailed validation error message */
@@ -233,15 +609,35 @@ void print_validation_error(const ValidationResult *result) {
 }
 
 /* Preflight validation: Check ALL configuration before proceeding */
-int preflight_validation(const char *lang_data_dir, int verbose) {
+int preflight_validation(const char *lang_data_dir, char *const *roots, int root_count,
+                         const char *extensions, const char *extensions_add, int verbose) {
     char filepath[PATH_MAX_LENGTH];
     char resolved_path[PATH_MAX_LENGTH];
     ValidationResult result;
     int failed = 0;
 
+    /* sourceminder.toml takes the place of the .txt files whose lists it sets */
+    UnifiedConfig unified;
+    int unified_status = unified_config_load(&unified, lang_data_dir);
+    const UnifiedConfig *lists = (unified_status == 1) ? &unified : NULL;
+    if (unified_status < 0) {
+        ValidationResult bad = {0};
+        bad.code = VALIDATE_INVALID_PATTERN;
+        bad.line = unified.error_line;
+        snprintf(bad.filepath, sizeof(bad.filepath), "%.*s", (int)sizeof(bad.filepath) - 1, unified.path);
+        snprintf(bad.message, sizeof(bad.message), "%s", unified.error);
+        print_validation_error(&bad);
+        failed = 1;
+    }
+
     if (verbose) {
         printf("=== Preflight Validation ===\n");
-        printf("Data directory: %s\n\n", lang_data_dir);
+        printf("Data directory: %s\n", lang_data_dir);
+        if (lists) {
+            printf("Config format: %s (lists it leaves out are read from .txt files)\n\n", unified.path);
+        } else {
+            printf("Config format: .txt files%s\n\n", unified_status < 0 ? " (" UNIFIED_CONFIG_FILENAME " is invalid)" : "");
+        }
     }
 
     /* --- Required Files --- */
@@ -263,83 +659,231 @@ int preflight_validation(const char *lang_data_dir, int verbose) {
         failed = 1;
     }
 
-    /* 2. Validate keywords.txt (language-specific) */
-    snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, KEYWORDS_FILENAME);
-    if (verbose) printf("Checking %s...\n", filepath);
-
-    if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
-        result = validate_word_list_file(resolved_path, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 0);
+    /* The language layer over it (optional) */
+    snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, STOPWORDS_FILENAME);
+    if (lists && lists->lists[CONFIG_LIST_STOPWORDS].present) {
+        if (check_unified_list(lists, CONFIG_LIST_STOPWORDS, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 1,
+                               "lines", verbose) != 0) {
+            failed = 1;
+        }
+    } else if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
+        if (verbose) printf("Checking %s...\n", filepath);
+        result = validate_word_list_file(resolved_path, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 1);
         if (result.code != VALIDATE_OK) {
             print_validation_error(&result);
             failed = 1;
         } else if (verbose) {
-            printf("  VALID (%zu words)\n", result.actual_value);
+            printf("  VALID (%zu lines)\n", result.actual_value);
+        }
+    }
+    if (verbose) {
+        WordSet *stopwords = malloc(sizeof(*stopwords));
+        if (stopwords) {
+            filter_load_stopwords(stopwords, lang_data_dir);
+            printf("  Effective stopwords: %d\n", stopwords->count);
+            free(stopwords);
+        }
+    }
+
+    /* 2. Validate keywords.txt (language-specific) */
+    snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, KEYWORDS_FILENAME);
+    if (lists && lists->lists[CONFIG_LIST_KEYWORDS].present) {
+        if (check_unified_list(lists, CONFIG_LIST_KEYWORDS, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 0,
+                               "words", verbose) != 0) {
+            failed = 1;
         }
     } else {
-        fprintf(stderr, "ERROR: Cannot find required file: %s\n", filepath);
-        failed = 1;
+        if (verbose) printf("Checking %s...\n", filepath);
+
+        if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
+            result = validate_word_list_file(resolved_path, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 0);
+            if (result.code != VALIDATE_OK) {
+                print_validation_error(&result);
+                failed = 1;
+            } else if (verbose) {
+                printf("  VALID (%zu words)\n", result.actual_value);
+            }
+        } else {
+            fprintf(stderr, "ERROR: Cannot find required file: %s\n", filepath);
+            failed = 1;
+        }
     }
 
     /* 3. Validate file-extensions.txt */
     snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, FILE_EXTENSIONS_FILENAME);
-    if (verbose) printf("Checking %s...\n", filepath);
+    if (lists && lists->lists[CONFIG_LIST_FILE_EXTENSIONS].present) {
+        if (check_unified_list(lists, CONFIG_LIST_FILE_EXTENSIONS, MAX_FILE_EXTENSIONS,
+                               FILE_EXTENSION_MAX_LENGTH, 0, "extensions", verbose) != 0) {
+            failed = 1;
+        }
+    } else {
+        if (verbose) printf("Checking %s...\n", filepath);
 
-    if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
-        result = validate_file_extensions(resolved_path);
-        if (result.code != VALIDATE_OK) {
-            print_validation_error(&result);
+        if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
+            result = validate_file_extensions(resolved_path);
+            if (result.code != VALIDATE_OK) {
+                print_validation_error(&result);
+                failed = 1;
+            } else if (verbose) {
+                printf("  VALID (%zu extensions)\n", result.actual_value);
+            }
+        } else {
+            fprintf(stderr, "ERROR: Cannot find required file: %s\n", filepath);
+            failed = 1;
+        }
+    }
+
+    /* The extensions the run indexes: the configured ones or --extensions,
+     * plus --extensions-add */
+    if (verbose || extensions || extensions_add) {
+        FileExtensions effective;
+        char error[ERROR_MESSAGE_BUFFER];
+        filter_load_extensions(&effective, lang_data_dir);
+        if (filter_override_extensions(&effective, extensions, extensions_add, error, sizeof(error)) != 0) {
+            fprintf(stderr, "ERROR: %s\n", error);
             failed = 1;
         } else if (verbose) {
-            printf("  VALID (%zu extensions)\n", result.actual_value);
+            printf("  Effective extensions:");
+            for (int i = 0; i < effective.count; i++) {
+                printf(" %s", effective.extensions[i]);
+            }
+            printf("%s\n", extensions ? " (--extensions)" : extensions_add ? " (with --extensions-add)" : "");
         }
-    } else {
-        fprintf(stderr, "ERROR: Cannot find required file: %s\n", filepath);
-        failed = 1;
     }
 
     /* --- Optional Files (warnings only) --- */
 
     /* 4. Validate ignore_files.txt (optional) */
     snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, IGNORE_FILES_FILENAME);
+    if (lists && lists->lists[CONFIG_LIST_IGNORE_FILES].present) {
+        if (check_unified_list(lists, CONFIG_LIST_IGNORE_FILES, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 1,
+                               "directories", verbose) != 0) {
+            failed = 1;
+        }
+    } else {
+        if (verbose) printf("Checking %s...\n", filepath);
+
+        if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
+            result = validate_file_exists(resolved_path);
+            if (result.code == VALIDATE_OK) {
+                result = validate_word_list_file(resolved_path, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 1);
+                if (result.code != VALIDATE_OK) {
+                    print_validation_error(&result);
+                    failed = 1;
+                } else if (verbose) {
+                    printf("  VALID (%zu directories)\n", result.actual_value);
+                }
+            }
+        } else if (verbose) {
+            printf("  WARNING: Not found (optional, will use empty list)\n");
+        }
+    }
+
+    /* 5. Validate regex-patterns.txt (optional; the language list replaces it) */
+    snprintf(filepath, sizeof(filepath), "%s/%s", SHARED_CONFIG_DIR, REGEX_PATTERNS_FILENAME);
+    if (lists && lists->lists[CONFIG_LIST_REGEX_PATTERNS].present) {
+        if (check_unified_list(lists, CONFIG_LIST_REGEX_PATTERNS, MAX_REGEX_PATTERNS, LINE_BUFFER_LARGE, 1,
+                               "patterns", verbose) != 0) {
+            failed = 1;
+        }
+    } else {
+        if (verbose) printf("Checking %s...\n", filepath);
+
+        if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
+            result = validate_file_exists(resolved_path);
+            if (result.code == VALIDATE_OK) {
+                result = validate_regex_patterns(resolved_path);
+                if (result.code != VALIDATE_OK) {
+                    print_validation_error(&result);
+                    failed = 1;
+                } else if (verbose) {
+                    printf("  VALID (%zu patterns)\n", result.actual_value);
+                }
+            }
+        } else if (verbose) {
+            printf("  WARNING: Not found (optional, will use empty list)\n");
+        }
+    }
+
+    /* 6. Validate symbol_limits.txt (optional) */
+    snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, SYMBOL_LIMITS_FILENAME);
     if (verbose) printf("Checking %s...\n", filepath);
 
+    int min_length = MIN_SYMBOL_LENGTH;
+    int max_length = SYMBOL_MAX_LENGTH - 1;
     if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
-        result = validate_file_exists(resolved_path);
-        if (result.code == VALIDATE_OK) {
-            result = validate_word_list_file(resolved_path, MAX_FILTER_WORDS, WORD_MAX_LENGTH, 1);
-            if (result.code != VALIDATE_OK) {
-                print_validation_error(&result);
-                failed = 1;
-            } else if (verbose) {
-                printf("  VALID (%zu directories)\n", result.actual_value);
-            }
+        result = validate_symbol_limits_file(resolved_path, &min_length, &max_length);
+        if (result.code != VALIDATE_OK) {
+            print_validation_error(&result);
+            failed = 1;
+        } else if (verbose) {
+            printf("  VALID (min_length %d, max_length %d)\n", min_length, max_length);
         }
     } else if (verbose) {
-        printf("  WARNING: Not found (optional, will use empty list)\n");
+        printf("  Not found (optional, using defaults: min_length %d, max_length %d)\n",
+               min_length, max_length);
     }
 
-    /* 5. Validate regex-patterns.txt (optional) */
-    snprintf(filepath, sizeof(filepath), "%s/%s", SHARED_CONFIG_DIR, REGEX_PATTERNS_FILENAME);
+    /* 7. Validate identifier_split.txt (optional) */
+    snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, IDENTIFIER_SPLIT_FILENAME);
     if (verbose) printf("Checking %s...\n", filepath);
 
     if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
-        result = validate_file_exists(resolved_path);
-        if (result.code == VALIDATE_OK) {
-            result = validate_regex_patterns(resolved_path);
-            if (result.code != VALIDATE_OK) {
-                print_validation_error(&result);
-                failed = 1;
-            } else if (verbose) {
-                printf("  VALID (%zu patterns)\n", result.actual_value);
-            }
+        int split_rules = 0;
+        result = validate_identifier_split_file(resolved_path, &split_rules);
+        if (result.code != VALIDATE_OK) {
+            print_validation_error(&result);
+            failed = 1;
+        } else if (verbose) {
+            char names[32] = "";
+            if (split_rules & SPLIT_CAMEL) strcat(names, " camel");
+            if (split_rules & SPLIT_SNAKE) strcat(names, " snake");
+            if (split_rules & SPLIT_DIGITS) strcat(names, " digits");
+            printf("  VALID (%s)\n", split_rules ? names + 1 : "none");
+        }
+    } else if (verbose) {
+        printf("  Not found (optional, using defaults: camel snake)\n");
+    }
+
+    /* 8. Validate extractors.txt (optional) */
+    snprintf(filepath, sizeof(filepath), "%s/%s", lang_data_dir, EXTRACTORS_FILENAME);
+    if (verbose) printf("Checking %s...\n", filepath);
+
+    if (resolve_data_file(filepath, resolved_path, sizeof(resolved_path)) == 0) {
+        result = validate_custom_extractors_file(resolved_path);
+        if (result.code != VALIDATE_OK) {
+            print_validation_error(&result);
+            failed = 1;
+        } else if (verbose) {
+            printf("  VALID (%zu extractors)\n", result.actual_value);
         }
     } else if (verbose) {
-        printf("  WARNING: Not found (optional, will use empty list)\n");
+        printf("  Not found (optional, no custom extractors)\n");
+    }
+
+    /* 9. Validate .sourceminderignore in each index root (optional) */
+    for (int i = 0; i < root_count; i++) {
+        size_t root_len = strlen(roots[i]);
+        snprintf(filepath, sizeof(filepath), "%s%s%s", roots[i],
+                 (root_len > 0 && roots[i][root_len - 1] == '/') ? "" : "/", IGNORE_RULES_FILENAME);
+        result = validate_file_exists(filepath);
+        if (result.code != VALIDATE_OK) {
+            continue;  /* Most projects have none; not worth a warning */
+        }
+        if (verbose) printf("Checking %s...\n", filepath);
+
+        result = validate_ignore_rules_file(filepath);
+        if (result.code != VALIDATE_OK) {
+            print_validation_error(&result);
+            failed = 1;
+        } else if (verbose) {
+            printf("  VALID (%zu patterns)\n", result.actual_value);
+        }
     }
 
     /* --- System Constraints --- */
 
-    /* 6. Validate buffer sizes are sane */
+    /* 10. Validate buffer sizes are sane */
     if (verbose) printf("\nChecking compile-time constants...\n");
 
     /* These checks are redundant with _Static_assert but provide runtime feedback */
@@ -363,6 +907,8 @@ int preflight_validation(const char *lang_data_dir, int verbose) {
                SYMBOL_MAX_LENGTH, MIN_SYMBOL_LENGTH);
     }
 
+    unified_config_free(&unified);
+
     /* --- Final Report --- */
 
     if (verbose) {
@@ -26,6 +26,7 @@
 
 #include <stdio.h>
 #include "constants.h"
+#include "unified_config.h"
 
 /* Validation result codes */
 #define VALIDATE_OK 0
@@ -35,6 +36,7 @@
 #define VALIDATE_LINE_TOO_LONG 4
 #define VALIDATE_EMPTY_FILE 5
 #define VALIDATE_BUFFER_TOO_SMALL 6
+#define VALIDATE_INVALID_PATTERN 7
 
 /* Validation result structure */
 typedef struct {
@@ -60,25 +62,66 @@ ValidationResult validate_word_list_file(const char *filepath, size_t max_words,
 /* Validation for file extensions */
 ValidationResult validate_file_extensions(const char *filepath);
 
-/* Validation for regex patterns */
+/* Validation for regex patterns: every pattern must compile
+ * Prints an error for each invalid pattern; actual_value is the number of
+ * valid patterns */
 ValidationResult validate_regex_patterns(const char *filepath);
 
+/* Validation for extractors.txt: every line must be KIND[:GROUP] REGEX with
+ * a regex that compiles and has the group
+ * Prints an error for each invalid line; actual_value is the number of
+ * valid extractors */
+ValidationResult validate_custom_extractors_file(const char *filepath);
+
+/* Validation for .sourceminderignore: every pattern must parse
+ * Prints an error for each invalid pattern; actual_value is the number of
+ * valid patterns */
+ValidationResult validate_ignore_rules_file(const char *filepath);
+
+/* Validation for symbol_limits.txt: every line must be a known limit and
+ * min_length may not exceed max_length. On success *min_length and
+ * *max_length hold the effective values (callers pass in the defaults) */
+ValidationResult validate_symbol_limits_file(const char *filepath, int *min_length, int *max_length);
+
+/* Validation for identifier_split.txt: every line must be a known split rule.
+ * On success *rules holds the SPLIT_* flags the file selects */
+ValidationResult validate_identifier_split_file(const char *filepath, int *rules);
+
+/* Validation for a word list of sourceminder.toml, with the same limits as
+ * validate_word_list_file(); lines are those of the TOML file */
+ValidationResult validate_unified_list(const UnifiedConfig *config, ConfigListId id, size_t max_words,
+                                       size_t max_word_length, int allow_empty);
+
+/* Validation for the regex_patterns list of sourceminder.toml: every
+ * pattern must compile (errors are printed as for validate_regex_patterns) */
+ValidationResult validate_unified_patterns(const UnifiedConfig *config);
+
 /* Print validation error (detailed, user-friendly) */
 void print_validation_error(const ValidationResult *result);
 
 /* Preflight validation: check ALL configuration before proceeding
  *
  * Validates all configuration files in lang_data_dir:
- * - stopwords.txt (required)
+ * - sourceminder.toml (optional; its lists are checked instead of the .txt
+ *   files they replace, and verbose output names the format in use)
+ * - stopwords.txt (required, shared; a language stopwords.txt is optional)
  * - keywords.txt (required)
  * - file-extensions.txt (required)
  * - ignore_files.txt (optional)
  * - regex-patterns.txt (optional)
+ * - symbol_limits.txt (optional)
+ * - identifier_split.txt (optional)
+ * - extractors.txt (optional)
+ * - .sourceminderignore in each of roots (optional; roots may be NULL)
+ * - extensions and extensions_add, the --extensions and --extensions-add
+ *   lists of the run (NULL if not given); verbose output shows the
+ *   extensions the run indexes
  *
  * Also validates compile-time constants are sane.
  *
  * Returns 0 on success, -1 if any validation fails.
  */
-int preflight_validation(const char *lang_data_dir, int verbose);
+int preflight_validation(const char *lang_data_dir, char *const *roots, int root_count,
+                         const char *extensions, const char *extensions_add, int verbose);
 
 #endif /* VALIDATION_H */
@@ -0,0 +1,17 @@
+package compat
+
+import "io"
+
+type Reader = io.Reader
+
+type Celsius float64
+
+type Temp = Celsius
+
+type Bytes = []byte
+
+type Handler = func(w io.Writer) error
+
+func Convert() {
+	type local = int
+}
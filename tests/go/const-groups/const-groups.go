@@ -0,0 +1,24 @@
+package config
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+)
+
+const (
+	MaxRetries = 3
+	ratio      = 1.5
+	Enabled    = true
+)
+
+const Version = 2
+
+var (
+	count, total int
+	Limit        = -1
+)
+
+var single Level
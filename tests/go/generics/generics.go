@@ -0,0 +1,16 @@
+package generic
+
+type Number interface {
+	~int | ~float64
+}
+
+func Sum[T Number](values []T) T
+
+func Map[K comparable, V any](input map[K]V) []V
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+type Set[T comparable] = map[T]struct{}
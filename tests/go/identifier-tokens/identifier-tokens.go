@@ -0,0 +1,12 @@
+package tokens
+
+type HTTPServer struct {
+	readTimeout int
+	max_conns   int
+}
+
+func utf8Decode(raw_bytes []byte) string
+
+func ParseHTTP2Frame(frameID int) error
+
+var base64URLEncoding, snake_case_name int
@@ -0,0 +1,35 @@
+package shapes
+
+import "io"
+
+type Shape interface {
+	Area()
+	Perimeter()
+}
+
+type Named interface {
+	Shape
+	Name()
+}
+
+type Rect struct{}
+
+func (r Rect) Area() {}
+
+func (r Rect) Perimeter() {}
+
+type Square struct {
+	Rect
+}
+
+func (s *Square) Name() {}
+
+type Circle struct{}
+
+func (c *Circle) Area() {}
+
+type Buffer struct {
+	io.Reader
+}
+
+func (b Buffer) Area() {}
@@ -0,0 +1,10 @@
+package shapes
+
+type Reader interface {
+	Read() error
+}
+
+type ReadCloser interface {
+	Reader
+	Close() error
+}
@@ -0,0 +1,17 @@
+package store
+
+type Cache struct {
+	Size int
+}
+
+func (c *Cache) Put(key string, value []byte) error
+
+func (c Cache) Len() int
+
+func (Cache) Name() string
+
+type List[T any] struct {
+	items []T
+}
+
+func (l *List[T]) Push(item T)
@@ -0,0 +1,7 @@
+package search
+
+type ReadCloser interface {
+	Close() error
+}
+
+func readcloser() {}
@@ -0,0 +1,11 @@
+package codec
+
+func Encode(name string, data []byte) error
+
+func Join(sep string, parts ...string) string
+
+func Split(text, sep string) (head, tail string, err error)
+
+func Decode(data []byte) (int, error)
+
+func Reset()
@@ -0,0 +1,10 @@
+package model
+
+type User struct {
+	ID        int    `json:"id" db:"user_id"`
+	Name      string `json:"name,omitempty"`
+	Password  string `json:"-"`
+	Email     string "json:\"email\" db:\"email\""
+	AvatarURL string `json:"avatar" db:avatar`
+	Note      string
+}
@@ -0,0 +1,11 @@
+package pipes
+
+type Pipe = chan (<-chan int)
+
+type Sink = chan<- chan int
+
+type Hook = func (w interface{ Write() ; Flush() }, n int) ( int , error )
+
+type Drain = func() <-chan int
+
+func Connect(pipe Pipe, hook func (s Sink) ( bool , error )) (chan (<-chan int), error)
@@ -0,0 +1,18 @@
+function parse(text) {
+    return text;
+}
+
+function helper(value) {
+    return value;
+}
+
+exports.loader = {
+    load(path) {
+        return path;
+    },
+    reset: function (force) {
+        return force;
+    },
+};
+
+module.exports.parse = parse;
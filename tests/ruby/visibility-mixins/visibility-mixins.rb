@@ -0,0 +1,41 @@
+module Auditable
+  def audit_log
+  end
+end
+
+class Account
+  include Auditable
+  extend Comparable
+
+  attr_reader :balance
+  attr_writer :owner
+  attr_accessor :nickname
+
+  def self.open_account
+  end
+
+  def deposit
+  end
+
+  private
+
+  def recalculate
+  end
+
+  protected
+
+  def ledger
+  end
+
+  public
+
+  def summary
+  end
+
+  def reset
+  end
+  private :reset
+
+  private def purge
+  end
+end
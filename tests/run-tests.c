@@ -9,13 +9,35 @@
 //   tests/{language}/{test-name}/
 //     {test-name}.{ext}            # Input fixture
 //     expected.qi.output           # Expected qi output
+//     expected.implements.output   # Optional: expected `index-{lang} implements` output
+//     search.args                  # Optional: `index-{lang} search` arguments, one run per line
+//     expected.search.output       # Expected output of those runs (with search.args)
+//     query.args                   # Optional: further qi arguments, one run per line
+//     expected.query.output        # Expected output of those runs (with query.args)
+//
+//   Instead of {test-name}.{ext}, the fixture may be an archive of sources,
+//   {test-name}.zip, .tar, .tar.gz or .tgz, indexed without extracting it,
+//   or a directory {test-name}/, indexed like a project (.sourceminderignore
+//   included).
 //
 // HOW IT WORKS:
 //   For each test:
 //     1. Index the fixture file: index-{lang} fixture.ext --db-file /tmp/test-{pid}.db
-//     2. Query the database: qi % -v --db-file /tmp/test-{pid}.db
+//     2. Query the database: qi % -v --db-file /tmp/test-{pid}.db -f fixture.ext
+//        (archives and directories: every file, without -f)
 //     3. Compare actual output to expected.qi.output
-//     4. Report pass/fail
+//     4. With expected.implements.output, also compare the output of
+//        index-{lang} implements --db-file /tmp/test-{pid}.db
+//     5. With search.args, run index-{lang} search ARGS --db-file /tmp/test-{pid}.db
+//        for each line and compare the outputs, each after a "$ search ARGS"
+//        line, to expected.search.output
+//     6. With query.args, likewise run qi ARGS --db-file /tmp/test-{pid}.db for
+//        each line and compare the outputs, each after a "$ qi ARGS" line, to
+//        expected.query.output
+//     7. Report pass/fail
+//
+//   Expected outputs are for a build with every language enabled
+//   (./configure --enable-all): qi -v shows the columns of all of them.
 //
 // EXIT CODES:
 //   0 - All tests passed
@@ -44,12 +66,18 @@ typedef struct {
 static const Language languages[] = {
     {"c", "c", "./index-c"},
     {"typescript", "ts", "./index-ts"},
+    {"javascript", "js", "./index-javascript"},
     {"php", "php", "./index-php"},
     {"go", "go", "./index-go"},
     {"python", "py", "./index-python"},
+    {"rust", "rs", "./index-rust"},
+    {"ruby", "rb", "./index-ruby"},
     {NULL, NULL, NULL}
 };
 
+/* Archive fixtures, tried in order when {test-name}.{ext} is missing */
+static const char *const archive_extensions[] = {"zip", "tar", "tar.gz", "tgz", NULL};
+
 static int update_mode = 0;
 static int passed = 0;
 static int failed = 0;
@@ -111,6 +139,69 @@ static int copy_file(const char *src, const char *dst) {
     return system(cmd);
 }
 
+// Compare actual output with the expected file, or replace it in update mode
+// Returns: 0 if they match (or were updated), 1 after reporting the failure
+static int check_output(const char *expected_path, const char *actual_path) {
+    if (update_mode) {
+        copy_file(actual_path, expected_path);
+        return 0;
+    }
+    if (!file_exists(expected_path)) {
+        printf("FAIL (expected output not found: %s)\n", expected_path);
+        printf("     Run with --update to create it\n");
+        return 1;
+    }
+    if (files_differ(expected_path, actual_path)) {
+        printf("FAIL (output differs: %s)\n", expected_path);
+        show_diff(expected_path, actual_path);
+        return 1;
+    }
+    return 0;
+}
+
+// Run "program ARGS --db-file db_path" once per line of args_path, writing
+// each line as "$ label ARGS" and then the output of that run to output_file
+// Returns: 0 on success, -1 if a file can't be read or written
+static int run_arg_lines(const char *args_path, const char *label, const char *program,
+                         const char *db_path, const char *output_file) {
+    FILE *args = fopen(args_path, "r");
+    if (!args) {
+        return -1;
+    }
+    FILE *out = fopen(output_file, "w");
+    if (!out) {
+        fclose(args);
+        return -1;
+    }
+    fclose(out);
+
+    char line[MAX_PATH];
+    int result = 0;
+    while (result == 0 && fgets(line, sizeof(line), args)) {
+        line[strcspn(line, "\n")] = '\0';
+        if (line[0] == '\0') {
+            continue;
+        }
+        out = fopen(output_file, "a");
+        if (!out) {
+            result = -1;
+            break;
+        }
+        fprintf(out, "$ %s %s\n", label, line);
+        fclose(out);
+
+        // Exit status not checked: a run without matches is output too
+        char cmd[MAX_CMD];
+        int n = snprintf(cmd, sizeof(cmd), "%s %s --db-file %s >> %s 2>&1",
+                         program, line, db_path, output_file);
+        if (n >= (int)sizeof(cmd) || system(cmd) == -1) {
+            result = -1;
+        }
+    }
+    fclose(args);
+    return result;
+}
+
 // Run a single test
 static void run_test(const char *lang_name, const char *test_name, const Language *lang) {
     char fixture_path[MAX_PATH];
@@ -145,8 +236,30 @@ static void run_test(const char *lang_name, const char *test_name, const Languag
     printf("  %s/%s ... ", lang_name, test_name);
     fflush(stdout);
 
-    // Check fixture exists
+    // Check fixture exists, as a source file, an archive or a directory
+    const char *archive_ext = NULL;
+    int is_tree = 0;
     if (!file_exists(fixture_path)) {
+        for (int i = 0; archive_extensions[i] != NULL; i++) {
+            char archive_path[MAX_PATH];
+            snprintf(archive_path, sizeof(archive_path), "tests/%s/%s/%s.%s",
+                     lang_name, test_name, test_name, archive_extensions[i]);
+            if (file_exists(archive_path)) {
+                archive_ext = archive_extensions[i];
+                memcpy(fixture_path, archive_path, sizeof(fixture_path));
+                break;
+            }
+        }
+    }
+    if (!file_exists(fixture_path)) {
+        char tree_path[MAX_PATH];
+        snprintf(tree_path, sizeof(tree_path), "tests/%s/%s/%s", lang_name, test_name, test_name);
+        if (dir_exists(tree_path)) {
+            is_tree = 1;
+            memcpy(fixture_path, tree_path, sizeof(fixture_path));
+        }
+    }
+    if (!is_tree && !file_exists(fixture_path)) {
         printf("SKIP (fixture not found: %s)\n", fixture_path);
         failed++;
         return;
@@ -172,9 +285,14 @@ static void run_test(const char *lang_name, const char *test_name, const Languag
         return;
     }
 
-    // Step 2: Query the database
-    n = snprintf(cmd, sizeof(cmd), "./qi %% -v --db-file %s -f %s.%s",
-                 db_path, test_name, lang->extension);
+    // Step 2: Query the database (an archive's entries and a directory's
+    // files have their own names)
+    if (archive_ext || is_tree) {
+        n = snprintf(cmd, sizeof(cmd), "./qi %% -v --db-file %s", db_path);
+    } else {
+        n = snprintf(cmd, sizeof(cmd), "./qi %% -v --db-file %s -f %s.%s",
+                     db_path, test_name, lang->extension);
+    }
     if (n >= (int)sizeof(cmd)) {
         printf("FAIL (command too long)\n");
         failed++;
@@ -191,25 +309,64 @@ static void run_test(const char *lang_name, const char *test_name, const Languag
     }
 
     // Step 3: Compare or update
-    if (update_mode) {
-        copy_file(actual_path, expected_path);
-        printf("UPDATED\n");
-        passed++;
-    } else {
-        if (!file_exists(expected_path)) {
-            printf("FAIL (expected output not found: %s)\n", expected_path);
-            printf("     Run with --update to create it\n");
-            failed++;
-        } else if (files_differ(expected_path, actual_path)) {
-            printf("FAIL (output differs)\n");
-            show_diff(expected_path, actual_path);
-            failed++;
+    int test_failed = check_output(expected_path, actual_path);
+
+    // Step 4: Interface satisfaction, for tests that record it
+    char implements_path[MAX_PATH];
+    n = snprintf(implements_path, sizeof(implements_path), "tests/%s/%s/expected.implements.output",
+                 lang_name, test_name);
+    if (!test_failed && n < (int)sizeof(implements_path) && file_exists(implements_path)) {
+        snprintf(cmd, sizeof(cmd), "%s implements --db-file %s", lang->indexer, db_path);
+        if (run_command(cmd, actual_path) != 0) {
+            printf("FAIL (implements failed)\n");
+            test_failed = 1;
         } else {
-            printf("PASS\n");
-            passed++;
+            test_failed = check_output(implements_path, actual_path);
         }
     }
 
+    // Step 5: Ranked search, for tests that list queries
+    char search_args_path[MAX_PATH];
+    char search_path[MAX_PATH];
+    n = snprintf(search_args_path, sizeof(search_args_path), "tests/%s/%s/search.args",
+                 lang_name, test_name);
+    int m = snprintf(search_path, sizeof(search_path), "tests/%s/%s/expected.search.output",
+                     lang_name, test_name);
+    if (!test_failed && n < (int)sizeof(search_args_path) && m < (int)sizeof(search_path) &&
+        file_exists(search_args_path)) {
+        snprintf(cmd, sizeof(cmd), "%s search", lang->indexer);
+        if (run_arg_lines(search_args_path, "search", cmd, db_path, actual_path) != 0) {
+            printf("FAIL (search failed)\n");
+            test_failed = 1;
+        } else {
+            test_failed = check_output(search_path, actual_path);
+        }
+    }
+
+    // Step 6: Further queries, such as filters the full listing doesn't show
+    char query_args_path[MAX_PATH];
+    char query_path[MAX_PATH];
+    n = snprintf(query_args_path, sizeof(query_args_path), "tests/%s/%s/query.args",
+                 lang_name, test_name);
+    m = snprintf(query_path, sizeof(query_path), "tests/%s/%s/expected.query.output",
+                 lang_name, test_name);
+    if (!test_failed && n < (int)sizeof(query_args_path) && m < (int)sizeof(query_path) &&
+        file_exists(query_args_path)) {
+        if (run_arg_lines(query_args_path, "qi", "./qi", db_path, actual_path) != 0) {
+            printf("FAIL (qi failed)\n");
+            test_failed = 1;
+        } else {
+            test_failed = check_output(query_path, actual_path);
+        }
+    }
+
+    if (test_failed) {
+        failed++;
+    } else {
+        printf(update_mode ? "UPDATED\n" : "PASS\n");
+        passed++;
+    }
+
     // Cleanup
     unlink(db_path);
     unlink(actual_path);
@@ -0,0 +1,19 @@
+pub struct Point {
+    pub width: i32,
+    height: i32,
+}
+
+pub enum Shape {
+    Circle,
+    Square,
+}
+
+pub trait Area {
+    fn area(&self) -> f64;
+}
+
+impl Area for Shape {
+    fn area(&self) -> f64 {
+        1.0
+    }
+}
@@ -8,11 +8,13 @@
 #include <sys/stat.h>
 #include <tree_sitter/api.h>
 #include "../shared/comment_utils.h"
+#include "../shared/source_file.h"
 #include "../shared/file_opener.h"
 #include "../shared/string_utils.h"
 #include "../shared/parse_result.h"
 #include "../shared/debug.h"
 #include "../shared/file_utils.h"
+#include "../shared/parse_errors.h"
 
 /* External TypeScript language function */
 extern const TSLanguage *tree_sitter_typescript(void);
@@ -20,6 +22,9 @@ extern const TSLanguage *tree_sitter_typescript(void);
 /* Global debug flag */
 static int g_debug = 0;
 
+/* Grammar of the file being parsed; queries are compiled against it */
+static _Thread_local const TSLanguage *g_language = NULL;
+
 /* Symbol lookup table for fast node type comparisons */
 static struct {
     TSSymbol identifier;
@@ -89,6 +94,7 @@ static struct {
     TSSymbol function_declaration;
     TSSymbol interface_declaration;
     TSSymbol type_alias_declaration;
+    TSSymbol internal_module;
     TSSymbol lexical_declaration;
     /* Import/Export types */
     TSSymbol import_clause;
@@ -113,6 +119,7 @@ static struct {
     TSSymbol pair;
     TSSymbol template_substitution;
     TSSymbol class_body;
+    TSSymbol statement_block;
 } ts_symbols;
 
 /* Removed: Now using safe_extract_node_text() from shared/string_utils.h */
@@ -200,6 +207,7 @@ static void init_ts_symbols(const TSLanguage *language) {
     ts_symbols.function_declaration = ts_language_symbol_for_name(language, "function_declaration", 20, true);
     ts_symbols.interface_declaration = ts_language_symbol_for_name(language, "interface_declaration", 21, true);
     ts_symbols.type_alias_declaration = ts_language_symbol_for_name(language, "type_alias_declaration", 22, true);
+    ts_symbols.internal_module = ts_language_symbol_for_name(language, "internal_module", 15, true);
     ts_symbols.lexical_declaration = ts_language_symbol_for_name(language, "lexical_declaration", 19, true);
 
     /* Import/Export types */
@@ -226,6 +234,7 @@ static void init_ts_symbols(const TSLanguage *language) {
     ts_symbols.pair = ts_language_symbol_for_name(language, "pair", 4, true);
     ts_symbols.template_substitution = ts_language_symbol_for_name(language, "template_substitution", 21, true);
     ts_symbols.class_body = ts_language_symbol_for_name(language, "class_body", 10, true);
+    ts_symbols.statement_block = ts_language_symbol_for_name(language, "statement_block", 15, true);
 }
 
 static void visit_node(TSNode node, const char *source_code, const char *directory,
@@ -253,12 +262,14 @@ typedef struct {
 static void extract_type_from_annotation(TSNode type_annotation_node, const char *source_code,
                                          char *type_buffer, size_t type_size, const char *filename);
 
-/* Global query cache - compiled once, reused for all files */
-static CachedQuery import_query = {NULL, NULL};
-static CachedQuery export_clause_query = {NULL, NULL};
-static CachedQuery export_decl_query = {NULL, NULL};
-static CachedQuery export_var_query = {NULL, NULL};
-static CachedQuery param_query = {NULL, NULL};
+/* Query cache - compiled once per thread, reused for all files parsed on it.
+ * Per thread because the indexer's workers parse concurrently, each with its
+ * own parser; parser_free() releases the calling thread's queries. */
+static _Thread_local CachedQuery import_query = {NULL, NULL};
+static _Thread_local CachedQuery export_clause_query = {NULL, NULL};
+static _Thread_local CachedQuery export_decl_query = {NULL, NULL};
+static _Thread_local CachedQuery export_var_query = {NULL, NULL};
+static _Thread_local CachedQuery param_query = {NULL, NULL};
 
 /* Compile and cache a query */
 static TSQuery* compile_query(const TSLanguage *language, CachedQuery *cache, const char *query_string) {
@@ -665,6 +676,155 @@ static void visit_expression(
     }
 }
 
+/* Helper: ExtColumns.exported value for a top-level declaration
+ * Exported when part of an export statement (export class Foo, export const x);
+ * names exported separately with export { Foo } are marked by mark_exported_names() */
+static const char *get_exported_declaration(TSNode node) {
+    for (TSNode parent = ts_node_parent(node); !ts_node_is_null(parent); parent = ts_node_parent(parent)) {
+        TSSymbol parent_sym = ts_node_symbol(parent);
+        if (parent_sym == ts_symbols.export_statement) {
+            return "1";
+        }
+        if (parent_sym == ts_symbols.statement_block || parent_sym == ts_symbols.class_body) {
+            return "0";
+        }
+    }
+    return "0";
+}
+
+/* Helper: ExtColumns.exported value for a class or interface member
+ * Members are part of the API unless private/protected, an ES2019 #private
+ * name, or named with the _internal convention */
+static const char *get_exported_member(const char *scope, const char *symbol) {
+    if (strcmp(scope, "private") == 0 || strcmp(scope, "protected") == 0 ||
+        symbol[0] == '#' || symbol[0] == '_') {
+        return "0";
+    }
+    return "1";
+}
+
+/* Mark declarations named in export { A, B } clauses as exported
+ * Only top-level kinds without an access modifier are considered */
+static void mark_exported_names(ParseResult *result) {
+    for (int i = 0; i < result->count; i++) {
+        if (result->entries[i].context != CONTEXT_EXPORT) {
+            continue;
+        }
+        const char *name = result->entries[i].full_symbol;
+        for (int j = 0; j < result->count; j++) {
+            IndexEntry *entry = &result->entries[j];
+            if (entry->is_exported || !entry->is_definition || entry->scope[0] != '\0' ||
+                strcmp(entry->full_symbol, name) != 0) {
+                continue;
+            }
+            if (entry->context == CONTEXT_CLASS || entry->context == CONTEXT_INTERFACE ||
+                entry->context == CONTEXT_TYPE || entry->context == CONTEXT_FUNCTION ||
+                entry->context == CONTEXT_VARIABLE || entry->context == CONTEXT_ENUM) {
+                entry->is_exported = 1;
+            }
+        }
+    }
+}
+
+/* Helper: check for module.exports (a member expression on the module object) */
+static int is_module_exports(TSNode node, const char *source_code, const char *filename) {
+    if (ts_node_symbol(node) != ts_symbols.member_expression) {
+        return 0;
+    }
+    TSNode object_node = ts_node_child_by_field_name(node, "object", 6);
+    TSNode property_node = ts_node_child_by_field_name(node, "property", 8);
+    if (ts_node_is_null(object_node) || ts_node_is_null(property_node) ||
+        ts_node_symbol(object_node) != ts_symbols.identifier) {
+        return 0;
+    }
+
+    char text[SYMBOL_MAX_LENGTH];
+    safe_extract_node_text(source_code, object_node, text, sizeof(text), filename);
+    if (strcmp(text, "module") != 0) {
+        return 0;
+    }
+    safe_extract_node_text(source_code, property_node, text, sizeof(text), filename);
+    return strcmp(text, "exports") == 0;
+}
+
+/* Helper: check whether an assignment target is a CommonJS export
+ * module.exports returns 1 with an empty name; exports.foo and
+ * module.exports.foo return 1 with name set to "foo" */
+static int get_commonjs_export(TSNode left, const char *source_code, char *name, size_t name_size,
+                               const char *filename) {
+    name[0] = '\0';
+    if (is_module_exports(left, source_code, filename)) {
+        return 1;
+    }
+    if (ts_node_symbol(left) != ts_symbols.member_expression) {
+        return 0;
+    }
+
+    TSNode object_node = ts_node_child_by_field_name(left, "object", 6);
+    TSNode property_node = ts_node_child_by_field_name(left, "property", 8);
+    if (ts_node_is_null(object_node) || ts_node_is_null(property_node)) {
+        return 0;
+    }
+
+    int exports_object = 0;
+    if (ts_node_symbol(object_node) == ts_symbols.identifier) {
+        char text[SYMBOL_MAX_LENGTH];
+        safe_extract_node_text(source_code, object_node, text, sizeof(text), filename);
+        exports_object = strcmp(text, "exports") == 0;
+    } else {
+        exports_object = is_module_exports(object_node, source_code, filename);
+    }
+    if (!exports_object) {
+        return 0;
+    }
+
+    safe_extract_node_text(source_code, property_node, name, name_size, filename);
+    return 1;
+}
+
+/* Helper: name an object literal is assigned to, used as the parent of its methods
+ * (const api = {...} -> "api", key: {...} -> "key", module.exports = {...} -> "exports")
+ * Returns the ExtColumns.exported value for the object's methods */
+static const char *get_object_owner(TSNode object, const char *source_code, char *owner, size_t owner_size,
+                                    const char *filename) {
+    owner[0] = '\0';
+    TSNode holder = ts_node_parent(object);
+    if (ts_node_is_null(holder)) {
+        return "0";
+    }
+
+    TSSymbol holder_sym = ts_node_symbol(holder);
+    if (holder_sym == ts_symbols.variable_declarator) {
+        TSNode name_node = ts_node_child_by_field_name(holder, "name", 4);
+        if (!ts_node_is_null(name_node) && ts_node_symbol(name_node) == ts_symbols.identifier) {
+            safe_extract_node_text(source_code, name_node, owner, owner_size, filename);
+        }
+        return get_exported_declaration(holder);
+    }
+    if (holder_sym == ts_symbols.pair) {
+        TSNode key_node = ts_node_child_by_field_name(holder, "key", 3);
+        if (!ts_node_is_null(key_node) && strcmp(ts_node_type(key_node), "property_identifier") == 0) {
+            safe_extract_node_text(source_code, key_node, owner, owner_size, filename);
+        }
+        return "0";
+    }
+    if (holder_sym == ts_symbols.assignment_expression) {
+        TSNode left_node = ts_node_child_by_field_name(holder, "left", 4);
+        if (ts_node_is_null(left_node)) {
+            return "0";
+        }
+        char export_name[SYMBOL_MAX_LENGTH];
+        if (get_commonjs_export(left_node, source_code, export_name, sizeof(export_name), filename)) {
+            snprintf(owner, owner_size, "%s", export_name[0] ? export_name : "exports");
+            return "1";
+        }
+        if (ts_node_symbol(left_node) == ts_symbols.identifier) {
+            safe_extract_node_text(source_code, left_node, owner, owner_size, filename);
+        }
+    }
+    return "0";
+}
+
 /* Extract access modifier (public, private, protected) from method or property */
 static void extract_access_modifier(TSNode node, const char *source_code, char *scope_buf, size_t buf_size, const char *filename) {
     /* Check first child for accessibility_modifier */
@@ -1051,9 +1211,28 @@ static void extract_type_from_annotation(TSNode type_annotation_node, const char
     }
 }
 
+/* Record the module of an import or re-export ("./utils", "react"): the
+ * source string, quotes removed */
+static void add_module_import(TSNode node, const char *source_code, const char *filename,
+                              ParseResult *result, int line) {
+    TSNode source_node = ts_node_child_by_field_name(node, "source", 6);
+    if (ts_node_is_null(source_node)) {
+        return;
+    }
+    char module[IMPORT_PATH_MAX_LENGTH];
+    safe_extract_node_text(source_code, source_node, module, sizeof(module), filename);
+    size_t len = strlen(module);
+    if (len >= 2 && (module[0] == '"' || module[0] == '\'') && module[len - 1] == module[0]) {
+        module[len - 1] = '\0';
+        add_import(result, module + 1, line);
+    }
+}
+
 static void handle_import_statement(TSNode node, const char *source_code, const char *directory,
                                     const char *filename, ParseResult *result, SymbolFilter *filter,
                                     int line) {
+    add_module_import(node, source_code, filename, result, line);
+
     /* Query-based approach - replaces 4 nested loops with declarative pattern matching */
     const char *query_string =
         "(import_statement"
@@ -1069,7 +1248,7 @@ static void handle_import_statement(TSNode node, const char *source_code, const
         "        !name"
         "        (identifier) @importdirect))))";
 
-    TSQuery *query = compile_query(tree_sitter_typescript(), &import_query, query_string);
+    TSQuery *query = compile_query(g_language, &import_query, query_string);
     if (!query) return;
 
     TSQueryCursor *cursor = ts_query_cursor_new();
@@ -1139,6 +1318,9 @@ static void handle_import_statement(TSNode node, const char *source_code, const
 static void handle_export_statement(TSNode node, const char *source_code, const char *directory,
                                     const char *filename, ParseResult *result, SymbolFilter *filter,
                                     int line) {
+    /* export { A } from "./a" depends on ./a too */
+    add_module_import(node, source_code, filename, result, line);
+
     /* Query-based approach - replaces 3 nested code paths with declarative patterns */
     char symbol[SYMBOL_MAX_LENGTH];
 
@@ -1155,7 +1337,7 @@ static void handle_export_statement(TSNode node, const char *source_code, const
         "      !alias"
         "      name: (identifier) @exportname)))";
 
-    TSQuery *clause_query = compile_query(tree_sitter_typescript(), &export_clause_query, clause_query_str);
+    TSQuery *clause_query = compile_query(g_language, &export_clause_query, clause_query_str);
     if (clause_query) {
         TSQueryCursor *cursor = ts_query_cursor_new();
         ts_query_cursor_exec(cursor, clause_query, node);
@@ -1165,7 +1347,7 @@ static void handle_export_statement(TSNode node, const char *source_code, const
             for (uint16_t i = 0; i < match.capture_count; i++) {
                 get_capture_text(source_code, match.captures[i].node, symbol, sizeof(symbol), filename);
                 if (filter_should_index(filter, symbol)) {
-                    add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+                    add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, &(ExtColumns){.exported = "1"});
                 }
             }
         }
@@ -1183,7 +1365,7 @@ static void handle_export_statement(TSNode node, const char *source_code, const
         "\n"
         "(export_statement (type_alias_declaration name: (type_identifier) @exportdecl))";
 
-    TSQuery *decl_query = compile_query(tree_sitter_typescript(), &export_decl_query, decl_query_str);
+    TSQuery *decl_query = compile_query(g_language, &export_decl_query, decl_query_str);
     if (decl_query) {
         TSQueryCursor *cursor = ts_query_cursor_new();
         ts_query_cursor_exec(cursor, decl_query, node);
@@ -1193,7 +1375,7 @@ static void handle_export_statement(TSNode node, const char *source_code, const
             for (uint16_t i = 0; i < match.capture_count; i++) {
                 get_capture_text(source_code, match.captures[i].node, symbol, sizeof(symbol), filename);
                 if (filter_should_index(filter, symbol)) {
-                    add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+                    add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, &(ExtColumns){.exported = "1"});
                 }
             }
         }
@@ -1207,7 +1389,7 @@ static void handle_export_statement(TSNode node, const char *source_code, const
         "    (variable_declarator"
         "      name: (identifier) @exportvar)))";
 
-    TSQuery *var_query = compile_query(tree_sitter_typescript(), &export_var_query, var_query_str);
+    TSQuery *var_query = compile_query(g_language, &export_var_query, var_query_str);
     if (var_query) {
         TSQueryCursor *cursor = ts_query_cursor_new();
         ts_query_cursor_exec(cursor, var_query, node);
@@ -1217,7 +1399,7 @@ static void handle_export_statement(TSNode node, const char *source_code, const
             for (uint16_t i = 0; i < match.capture_count; i++) {
                 get_capture_text(source_code, match.captures[i].node, symbol, sizeof(symbol), filename);
                 if (filter_should_index(filter, symbol)) {
-                    add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, NO_EXTENSIBLE_COLUMNS);
+                    add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, &(ExtColumns){.exported = "1"});
                 }
             }
         }
@@ -1422,14 +1604,16 @@ static void handle_class_declaration(TSNode node, const char *source_code, const
     const char *class_modifiers[] = {"abstract", NULL};
     extract_modifiers(node, modifier, sizeof(modifier), class_modifiers);
 
+    symbol[0] = '\0';
     TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
     if (!ts_node_is_null(name_node)) {
         safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
         if (filter_should_index(filter, symbol)) {
             add_entry(result, symbol, line, CONTEXT_CLASS, directory, filename, NULL,
-                &(ExtColumns){.modifier = modifier, .definition = "1"});
+                &(ExtColumns){.modifier = modifier, .definition = "1", .exported = get_exported_declaration(node)});
         }
     }
+    push_scope(result, symbol);
 
     /* Process type_parameters for generic classes like class Box<T> */
     TSNode type_params = ts_node_child_by_field_name(node, "type_parameters", 15);
@@ -1442,40 +1626,70 @@ static void handle_class_declaration(TSNode node, const char *source_code, const
     if (!ts_node_is_null(body_node)) {
         process_children(body_node, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
 }
 
 static void handle_interface_declaration(TSNode node, const char *source_code, const char *directory,
                                          const char *filename, ParseResult *result, SymbolFilter *filter,
                                          int line) {
-    char symbol[SYMBOL_MAX_LENGTH];
+    char symbol[SYMBOL_MAX_LENGTH] = "";
     TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
     if (!ts_node_is_null(name_node)) {
         safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
         if (filter_should_index(filter, symbol)) {
-            add_entry(result, symbol, line, CONTEXT_INTERFACE, directory, filename, NULL, &(ExtColumns){.definition = "1"});
+            add_entry(result, symbol, line, CONTEXT_INTERFACE, directory, filename, NULL,
+                &(ExtColumns){.definition = "1", .exported = get_exported_declaration(node)});
         }
     }
 
     /* Process interface body to index property signatures and methods */
     TSNode body_node = ts_node_child_by_field_name(node, "body", 4);
     if (!ts_node_is_null(body_node)) {
+        push_scope(result, symbol);
         process_children(body_node, source_code, directory, filename, result, filter);
+        pop_scope(result);
     }
 }
 
 static void handle_type_alias(TSNode node, const char *source_code, const char *directory,
                               const char *filename, ParseResult *result, SymbolFilter *filter,
                               int line) {
-    char symbol[SYMBOL_MAX_LENGTH];
+    char symbol[SYMBOL_MAX_LENGTH] = "";
     TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
     if (!ts_node_is_null(name_node)) {
         safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
         if (filter_should_index(filter, symbol)) {
-            add_entry(result, symbol, line, CONTEXT_TYPE, directory, filename, NULL, &(ExtColumns){.definition = "1"});
+            add_entry(result, symbol, line, CONTEXT_TYPE, directory, filename, NULL,
+                &(ExtColumns){.definition = "1", .exported = get_exported_declaration(node)});
         }
     }
     /* Process the type alias value (e.g., object_type, union_type) */
+    push_scope(result, symbol);
     process_children(node, source_code, directory, filename, result, filter);
+    pop_scope(result);
+}
+
+/* namespace A.B { ... } (TypeScript only): the name as written, with the
+ * body scoped under it */
+static void handle_namespace_declaration(TSNode node, const char *source_code, const char *directory,
+                                         const char *filename, ParseResult *result, SymbolFilter *filter,
+                                         int line) {
+    char symbol[SYMBOL_MAX_LENGTH] = "";
+    TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
+    if (!ts_node_is_null(name_node)) {
+        safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
+        if (filter_should_index(filter, symbol)) {
+            add_entry(result, symbol, line, CONTEXT_NAMESPACE, directory, filename, NULL,
+                &(ExtColumns){.definition = "1", .exported = get_exported_declaration(node)});
+        }
+    }
+
+    TSNode body_node = ts_node_child_by_field_name(node, "body", 4);
+    if (!ts_node_is_null(body_node)) {
+        push_scope(result, symbol);
+        process_children(body_node, source_code, directory, filename, result, filter);
+        pop_scope(result);
+    }
 }
 
 static void handle_type_parameter(TSNode node, const char *source_code, const char *directory,
@@ -1551,6 +1765,7 @@ static void handle_function_declaration(TSNode node, const char *source_code, co
     const char *function_modifiers[] = {"async", NULL};
     extract_modifiers(node, modifier, sizeof(modifier), function_modifiers);
 
+    symbol[0] = '\0';
     TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
     if (!ts_node_is_null(name_node)) {
         safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
@@ -1567,9 +1782,11 @@ static void handle_function_declaration(TSNode node, const char *source_code, co
             format_source_location(node, location, sizeof(location));
 
             add_entry(result, symbol, line, CONTEXT_FUNCTION, directory, filename, location,
-                &(ExtColumns){.modifier = modifier, .type = type_str[0] ? type_str : NULL, .definition = "1"});
+                &(ExtColumns){.modifier = modifier, .type = type_str[0] ? type_str : NULL, .definition = "1",
+                              .exported = get_exported_declaration(node)});
         }
     }
+    push_scope(result, symbol);
 
     /* Process type_parameters for generic functions like function foo<T>() */
     TSNode type_params = ts_node_child_by_field_name(node, "type_parameters", 15);
@@ -1586,6 +1803,7 @@ static void handle_function_declaration(TSNode node, const char *source_code, co
     if (!ts_node_is_null(body_node)) {
         process_children(body_node, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
 }
 
 static void handle_method_definition(TSNode node, const char *source_code, const char *directory,
@@ -1606,6 +1824,7 @@ static void handle_method_definition(TSNode node, const char *source_code, const
     const char *method_modifiers[] = {"static", "async", "readonly", "abstract", NULL};
     extract_modifiers(node, modifier, sizeof(modifier), method_modifiers);
 
+    symbol[0] = '\0';
     TSNode name_node = ts_node_child_by_field_name(node, "name", 4);
     if (!ts_node_is_null(name_node)) {
         safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
@@ -1630,10 +1849,22 @@ static void handle_method_definition(TSNode node, const char *source_code, const
             /* Extract source location for full method definition */
             format_source_location(node, location, sizeof(location));
 
+            /* Object literal methods ({ get() {} }) belong to the object's owner */
+            char owner[SYMBOL_MAX_LENGTH] = "";
+            const char *exported = get_exported_member(scope, symbol);
+            TSNode container = ts_node_parent(node);
+            if (!ts_node_is_null(container) && ts_node_symbol(container) == ts_symbols.object &&
+                strcmp(get_object_owner(container, source_code, owner, sizeof(owner), filename), "1") != 0) {
+                exported = "0";
+            }
+
             add_entry(result, symbol, line, CONTEXT_FUNCTION, directory, filename, location,
-                &(ExtColumns){.scope = scope, .modifier = modifier, .type = type_str[0] ? type_str : NULL, .definition = "1"});
+                &(ExtColumns){.parent = owner[0] ? owner : NULL, .scope = scope, .modifier = modifier,
+                              .type = type_str[0] ? type_str : NULL, .definition = "1", .exported = exported});
         }
     }
+    push_scope(result, symbol);
+
     /* Extract method parameters */
     TSNode params_node = ts_node_child_by_field_name(node, "parameters", 10);
     extract_parameters(params_node, source_code, directory, filename, result, filter, 1);
@@ -1643,6 +1874,7 @@ static void handle_method_definition(TSNode node, const char *source_code, const
     if (!ts_node_is_null(body_node)) {
         process_children(body_node, source_code, directory, filename, result, filter);
     }
+    pop_scope(result);
 }
 
 static void handle_variable_declaration(TSNode node, const char *source_code, const char *directory,
@@ -1679,7 +1911,8 @@ static void handle_variable_declaration(TSNode node, const char *source_code, co
                         format_source_location(node, location, sizeof(location));
 
                         add_entry(result, symbol, line, CONTEXT_VARIABLE, directory, filename, location,
-                            &(ExtColumns){.type = type_str[0] ? type_str : NULL, .definition = "1"});
+                            &(ExtColumns){.type = type_str[0] ? type_str : NULL, .definition = "1",
+                                          .exported = get_exported_declaration(node)});
                     }
                 }
             }
@@ -1727,7 +1960,8 @@ static void handle_property_signature(TSNode node, const char *source_code, cons
 
         if (filter_should_index(filter, symbol)) {
             add_entry(result, symbol, line, CONTEXT_PROPERTY, directory, filename, NULL,
-                &(ExtColumns){.scope = scope, .modifier = modifier, .type = type_str[0] ? type_str : NULL});
+                &(ExtColumns){.scope = scope, .modifier = modifier, .type = type_str[0] ? type_str : NULL,
+                              .exported = get_exported_member(scope, symbol)});
         }
     }
 
@@ -1860,6 +2094,73 @@ static void handle_call_expression(TSNode node, const char *source_code, const c
     process_children(node, source_code, directory, filename, result, filter);
 }
 
+/* Add an export entry for a name exported through module.exports/exports */
+static void add_commonjs_export_name(const char *symbol, const char *directory, const char *filename,
+                                     ParseResult *result, SymbolFilter *filter, int line) {
+    if (symbol[0] && filter_should_index(filter, symbol)) {
+        add_entry(result, symbol, line, CONTEXT_EXPORT, directory, filename, NULL, &(ExtColumns){.exported = "1"});
+    }
+}
+
+/* Index the names a CommonJS export assignment exports
+ * exports.foo = bar exports foo (and the local bar); module.exports = { a, b: c }
+ * exports a, b and c; module.exports = bar or class Bar {} exports bar/Bar.
+ * Same-named top-level declarations are marked by mark_exported_names() */
+static void handle_commonjs_export(TSNode node, const char *export_name, const char *source_code,
+                                   const char *directory, const char *filename, ParseResult *result,
+                                   SymbolFilter *filter, int line) {
+    char symbol[SYMBOL_MAX_LENGTH];
+
+    add_commonjs_export_name(export_name, directory, filename, result, filter, line);
+
+    TSNode right_node = ts_node_child_by_field_name(node, "right", 5);
+    if (ts_node_is_null(right_node)) {
+        return;
+    }
+
+    TSSymbol right_sym = ts_node_symbol(right_node);
+    if (right_sym == ts_symbols.identifier) {
+        safe_extract_node_text(source_code, right_node, symbol, sizeof(symbol), filename);
+        if (strcmp(symbol, export_name) != 0) {
+            add_commonjs_export_name(symbol, directory, filename, result, filter, line);
+        }
+    } else if (right_sym == ts_symbols.object && !export_name[0]) {
+        uint32_t child_count = ts_node_named_child_count(right_node);
+        for (uint32_t i = 0; i < child_count; i++) {
+            TSNode child = ts_node_named_child(right_node, i);
+            TSSymbol child_sym = ts_node_symbol(child);
+            if (child_sym == ts_symbols.shorthand_property_identifier) {
+                safe_extract_node_text(source_code, child, symbol, sizeof(symbol), filename);
+                add_commonjs_export_name(symbol, directory, filename, result, filter, (int)ts_node_start_point(child).row + 1);
+            } else if (child_sym == ts_symbols.pair) {
+                int child_line = (int)ts_node_start_point(child).row + 1;
+                symbol[0] = '\0';
+                TSNode key_node = ts_node_child_by_field_name(child, "key", 3);
+                if (!ts_node_is_null(key_node) && strcmp(ts_node_type(key_node), "property_identifier") == 0) {
+                    safe_extract_node_text(source_code, key_node, symbol, sizeof(symbol), filename);
+                    add_commonjs_export_name(symbol, directory, filename, result, filter, child_line);
+                }
+                char key[SYMBOL_MAX_LENGTH];
+                snprintf(key, sizeof(key), "%s", symbol);
+                TSNode value_node = ts_node_child_by_field_name(child, "value", 5);
+                if (!ts_node_is_null(value_node) && ts_node_symbol(value_node) == ts_symbols.identifier) {
+                    safe_extract_node_text(source_code, value_node, symbol, sizeof(symbol), filename);
+                    if (strcmp(symbol, key) != 0) {
+                        add_commonjs_export_name(symbol, directory, filename, result, filter, child_line);
+                    }
+                }
+            }
+        }
+    } else if (!export_name[0]) {
+        /* module.exports = class Foo {} / function foo() {} */
+        TSNode name_node = ts_node_child_by_field_name(right_node, "name", 4);
+        if (!ts_node_is_null(name_node)) {
+            safe_extract_node_text(source_code, name_node, symbol, sizeof(symbol), filename);
+            add_commonjs_export_name(symbol, directory, filename, result, filter, line);
+        }
+    }
+}
+
 static void handle_assignment_expression(TSNode node, const char *source_code, const char *directory,
                                          const char *filename, ParseResult *result, SymbolFilter *filter,
                                          int line) {
@@ -1881,6 +2182,13 @@ static void handle_assignment_expression(TSNode node, const char *source_code, c
         return;
     }
 
+    /* CommonJS exports: module.exports = ..., exports.foo = ... */
+    char export_name[SYMBOL_MAX_LENGTH];
+    int is_export = get_commonjs_export(left_node, source_code, export_name, sizeof(export_name), filename);
+    if (is_export) {
+        handle_commonjs_export(node, export_name, source_code, directory, filename, result, filter, line);
+    }
+
     /* Extract and index all levels of the member expression chain */
     /* For this.config.timeout, we want to index:
      *   - "timeout" with parent="config"
@@ -1934,8 +2242,10 @@ static void handle_assignment_expression(TSNode node, const char *source_code, c
                 fprintf(stderr, "[DEBUG] handle_assignment_expression: INDEXING LHS '%s' as PROP at line %d parent='%s'\n",
                         symbol, line, parent[0] ? parent : "(none)");
             }
+            /* exports.foo = ... defines the exported foo */
+            int exported = is_export && export_name[0] && current.id == left_node.id;
             add_entry(result, symbol, line, CONTEXT_PROPERTY, directory, filename, NULL,
-                &(ExtColumns){.parent = parent[0] ? parent : NULL, .definition = "1"});
+                &(ExtColumns){.parent = parent[0] ? parent : NULL, .definition = "1", .exported = exported ? "1" : NULL});
         }
 
         /* Move up the chain */
@@ -1997,6 +2307,24 @@ static void handle_pair(TSNode node, const char *source_code, const char *direct
         if (key_type && strcmp(key_type, "property_identifier") == 0) {
             safe_extract_node_text(source_code, key_node, symbol, sizeof(symbol), filename);
 
+            /* A function value makes this an object method: { get: function () {} } */
+            TSNode value_node = ts_node_child_by_field_name(node, "value", 5);
+            if (!ts_node_is_null(value_node) &&
+                (ts_node_symbol(value_node) == ts_symbols.function_expression ||
+                 ts_node_symbol(value_node) == ts_symbols.arrow_function)) {
+                if (filter_should_index(filter, symbol)) {
+                    char owner[SYMBOL_MAX_LENGTH];
+                    char location[128];
+                    const char *exported = get_object_owner(ts_node_parent(node), source_code, owner, sizeof(owner), filename);
+                    format_source_location(node, location, sizeof(location));
+                    add_entry(result, symbol, line, CONTEXT_FUNCTION, directory, filename, location,
+                        &(ExtColumns){.parent = owner[0] ? owner : NULL, .definition = "1",
+                                      .exported = strcmp(exported, "1") == 0 ? get_exported_member("", symbol) : "0"});
+                }
+                visit_node(value_node, source_code, directory, filename, result, filter);
+                return;
+            }
+
             if (filter_should_index(filter, symbol)) {
                 add_entry(result, symbol, line, CONTEXT_PROPERTY, directory, filename, NULL,
                     &(ExtColumns){.definition = "0"});
@@ -2119,6 +2447,10 @@ static void visit_node(TSNode node, const char *source_code, const char *directo
         handle_type_alias(node, source_code, directory, filename, result, filter, line);
         return;
     }
+    if (node_sym == ts_symbols.internal_module) {
+        handle_namespace_declaration(node, source_code, directory, filename, result, filter, line);
+        return;
+    }
     if (node_sym == ts_symbols.type_parameter) {
         handle_type_parameter(node, source_code, directory, filename, result, filter, line);
         return;
@@ -2203,45 +2535,32 @@ static void visit_node(TSNode node, const char *source_code, const char *directo
 
 int parser_init(TypeScriptParser *parser, SymbolFilter *filter) {
     parser->filter = filter;
+    parser->debug = 0;
+    parser->language = tree_sitter_typescript;
     return 0;
 }
 
-int parser_parse_file(TypeScriptParser *parser, const char *filepath, const char *project_root, ParseResult *result) {
-    if (g_debug) fprintf(stderr, "[DEBUG] parser_parse_file: Starting to parse %s (g_debug=%d)\n", filepath, g_debug);
-
-    FILE *fp = safe_fopen(filepath, "rb", 0);  /* binary mode for accurate byte count */
-    if (!fp) {
-        fprintf(stderr, "Cannot open file: %s\n", filepath);
+int parser_init_javascript(TypeScriptParser *parser, SymbolFilter *filter,
+                           const TSLanguage *(*language)(void)) {
+    if (parser_init(parser, filter) != 0) {
         return -1;
     }
+    parser->language = language;
+    return 0;
+}
 
-    /* Get file size using fstat */
-    int fd = fileno(fp);
-    struct stat st;
-    if (fstat(fd, &st) != 0) {
-        fclose(fp);
-        return -1;
-    }
-    size_t file_size = (size_t)st.st_size;
-
-    char *source_code = malloc(file_size + 1);
-    if (!source_code) {
-        fclose(fp);
-        return -1;
-    }
+int parser_parse_file(TypeScriptParser *parser, const char *filepath, const char *project_root, ParseResult *result) {
+    if (g_debug) fprintf(stderr, "[DEBUG] parser_parse_file: Starting to parse %s (g_debug=%d)\n", filepath, g_debug);
 
-    size_t bytes_read = fread(source_code, 1, file_size, fp);
-    if (bytes_read != file_size) {
-        fprintf(stderr, "Error reading file: %s (expected %zu bytes, got %zu)\n",
-                filepath, file_size, bytes_read);
-        free(source_code);
-        fclose(fp);
+    SourceFile source;
+    if (source_file_open(&source, filepath) != 0) {
         return -1;
     }
-    source_code[bytes_read] = '\0';
-    fclose(fp);
+    const char *source_code = source.text;
+    size_t bytes_read = source.length;
 
     result->count = 0;
+    result->import_count = 0;
 
     char directory[DIRECTORY_MAX_LENGTH];
     char filename[FILENAME_MAX_LENGTH];
@@ -2259,34 +2578,43 @@ int parser_parse_file(TypeScriptParser *parser, const char *filepath, const char
 
     /* Parse with tree-sitter */
     TSParser *ts_parser = ts_parser_new();
-    const TSLanguage *ts_language = tree_sitter_typescript();
+    const TSLanguage *ts_language = parser->language();
     if (!ts_parser_set_language(ts_parser, ts_language)) {
-        fprintf(stderr, "ERROR: Failed to set TypeScript language\n");
-        free(source_code);
+        parse_error_set("failed to load the tree-sitter grammar");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
 
     /* Initialize symbol lookup table (only happens once) */
     init_ts_symbols(ts_language);
+    g_language = ts_language;
 
-    TSTree *tree = ts_parser_parse_string(ts_parser, NULL, source_code, (uint32_t)file_size);
+    TSTree *tree = ts_parser_parse(ts_parser, NULL, source_file_input(&source));
     if (!tree) {
-        fprintf(stderr, "ERROR: Failed to parse file: %s\n", filepath);
-        free(source_code);
+        parse_error_set("tree-sitter could not parse the file");
+        source_file_close(&source);
         ts_parser_delete(ts_parser);
         return -1;
     }
 
+    /* Freed by parse_file_guarded() if the file is abandoned */
+    parse_guard_hold_tree(tree, ts_parser);
+
     TSNode root_node = ts_tree_root_node(tree);
 
     /* Visit all nodes */
     visit_node(root_node, source_code, directory, filename, result, parser->filter);
 
+    mark_exported_names(result);
+    if (attach_doc_comments(result, source_code, bytes_read, DOC_COMMENT_SLASH) != 0) {
+        fprintf(stderr, "Warning: out of memory reading doc comments of %s\n", filepath);
+    }
+
     /* Cleanup */
     ts_tree_delete(tree);
     ts_parser_delete(ts_parser);
-    free(source_code);
+    source_file_close(&source);
 
     return 0;
 }
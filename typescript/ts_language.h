@@ -5,16 +5,24 @@
 #include "../shared/filter.h"
 #include "../shared/constants.h"
 #include "../shared/parse_result.h"
+#include <tree_sitter/api.h>
 
 typedef struct {
     SymbolFilter *filter;
     int debug;
+    const TSLanguage *(*language)(void);  /* Grammar files are parsed with */
 } TypeScriptParser;
 
-/* Initialize parser with a filter */
+/* Initialize parser with a filter (TypeScript grammar) */
 int parser_init(TypeScriptParser *parser, SymbolFilter *filter);
 
-/* Parse a TypeScript file and extract symbols */
+/* Initialize parser for JavaScript (index-javascript)
+ * Uses the TSX grammar, which parses plain JavaScript and JSX into the same
+ * nodes as TypeScript, so every handler applies unchanged */
+int parser_init_javascript(TypeScriptParser *parser, SymbolFilter *filter,
+                           const TSLanguage *(*language)(void));
+
+/* Parse a TypeScript (or JavaScript) file and extract symbols */
 int parser_parse_file(TypeScriptParser *parser, const char *filepath, const char *project_root, ParseResult *result);
 
 /* Set debug mode */